@@ -0,0 +1,379 @@
+// Package aof appends the write commands to a file, like the append only
+// file of Redis, so they can be replayed when the server starts.
+package aof
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/replication"
+)
+
+var ErrInProgress = errors.New("rewrite already in progress")
+
+// Policy is when the file is synced to the disk, like appendfsync.
+type Policy string
+
+const (
+	// Always syncs after every command, before it is replied to.
+	Always Policy = "always"
+	// EverySec syncs once a second, losing at most a second of writes.
+	EverySec Policy = "everysec"
+	// No leaves syncing to the operating system.
+	No Policy = "no"
+)
+
+// Status describes the file, like the aof fields of INFO persistence.
+type Status struct {
+	Size int64
+	// BaseSize is the size after the last rewrite, or when it was opened.
+	BaseSize          int64
+	RewriteInProgress bool
+	// RewriteStarted is when the rewrite in progress started.
+	RewriteStarted time.Time
+	// LastRewriteError is the error of the last rewrite, nil when it
+	// succeeded.
+	LastRewriteError    error
+	LastRewriteDuration time.Duration
+	Rewrites            int64
+	// LastWriteError is the error of the last append, nil when it succeeded.
+	LastWriteError error
+}
+
+// File is an append only file. While it is rewritten, the appended commands
+// are also kept in memory, to follow the rewritten commands.
+type File struct {
+	path string
+
+	mutex  sync.Mutex
+	file   *os.File
+	policy Policy
+	dirty  bool
+	closed bool
+	buffer bytes.Buffer
+	status Status
+
+	done chan struct{}
+}
+
+// Open appends to the file at the path, creating it if needed.
+func Open(path string, policy Policy) (*File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open AOF: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("could not read AOF size: %w", err)
+	}
+
+	f := New(path, policy)
+	f.file = file
+	f.status.Size = info.Size()
+	f.status.BaseSize = info.Size()
+
+	return f, nil
+}
+
+// New returns a file that is only created by its first rewrite. Until then,
+// appending outside of a rewrite does nothing, as the commands are part of
+// the keys the rewrite writes.
+func New(path string, policy Policy) *File {
+	f := &File{
+		path:   path,
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+
+	go f.syncEverySecond()
+
+	return f
+}
+
+// Path is where the file is written.
+func (f *File) Path() string {
+	return f.path
+}
+
+// SetPolicy changes when the file is synced.
+func (f *File) SetPolicy(policy Policy) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.policy = policy
+}
+
+// Append writes a command, syncing it with the always policy.
+func (f *File) Append(tokens ...string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return fmt.Errorf("could not append to AOF: %w", os.ErrClosed)
+	}
+
+	data := replication.Encode(tokens...)
+
+	if f.status.RewriteInProgress {
+		f.buffer.Write(data)
+	}
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.write(data)
+	f.status.LastWriteError = err
+
+	return err
+}
+
+func (f *File) write(data []byte) error {
+	written, err := f.file.Write(data)
+	if err != nil {
+		// a partial command would not be replayed, or worse, merged with
+		// the next one
+		if written > 0 {
+			_ = f.file.Truncate(f.status.Size)
+		}
+
+		return fmt.Errorf("could not write to AOF: %w", err)
+	}
+
+	f.status.Size += int64(written)
+
+	if f.policy != Always {
+		f.dirty = true
+
+		return nil
+	}
+
+	err = f.file.Sync()
+	if err != nil {
+		return fmt.Errorf("could not sync AOF: %w", err)
+	}
+
+	return nil
+}
+
+func (f *File) syncEverySecond() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+			f.mutex.Lock()
+			if f.policy == EverySec {
+				_ = f.sync()
+			}
+			f.mutex.Unlock()
+		}
+	}
+}
+
+func (f *File) sync() error {
+	if !f.dirty || f.file == nil {
+		return nil
+	}
+
+	err := f.file.Sync()
+	if err != nil {
+		f.status.LastWriteError = err
+
+		return fmt.Errorf("could not sync AOF: %w", err)
+	}
+
+	f.dirty = false
+
+	return nil
+}
+
+// Status returns the status of the file and its rewrites.
+func (f *File) Status() Status {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.status
+}
+
+// NeedsRewrite is true when the file grew by the percentage of its base
+// size, and is at least the minimum size, like auto-aof-rewrite-percentage.
+// A percentage of zero never needs a rewrite.
+func (f *File) NeedsRewrite(percentage, minSize int64) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if percentage <= 0 || f.file == nil || f.status.RewriteInProgress {
+		return false
+	}
+
+	growth := f.status.Size - f.status.BaseSize
+
+	return f.status.Size >= minSize && growth*100 >= f.status.BaseSize*percentage
+}
+
+// StartRewrite keeps the commands appended from now on, for FinishRewrite.
+// The commands rewriting the file must recreate the keys as they are when
+// it starts.
+func (f *File) StartRewrite() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.status.RewriteInProgress {
+		return ErrInProgress
+	}
+
+	f.status.RewriteInProgress = true
+	f.status.RewriteStarted = time.Now()
+	f.buffer.Reset()
+
+	return nil
+}
+
+// AbortRewrite ends the rewrite in progress with an error, keeping the
+// file as it is.
+func (f *File) AbortRewrite(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.endRewrite(err)
+}
+
+func (f *File) endRewrite(err error) {
+	f.status.RewriteInProgress = false
+	f.status.LastRewriteError = err
+	f.status.LastRewriteDuration = time.Since(f.status.RewriteStarted)
+	f.buffer = bytes.Buffer{}
+}
+
+// FinishRewrite replaces the file with the commands of write, followed by
+// the commands appended since the rewrite started. The commands are written
+// to a temporary file, which replaces the file once it is complete.
+func (f *File) FinishRewrite(write func(writer io.Writer) error) error {
+	temporary := fmt.Sprintf("%s.temp-%d-%d", f.path, os.Getpid(), time.Now().UnixNano())
+
+	file, err := f.writeBase(temporary, write)
+	if err != nil {
+		_ = os.Remove(temporary)
+
+		f.AbortRewrite(err)
+
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	err = f.replace(file, temporary)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(temporary)
+	}
+
+	f.endRewrite(err)
+
+	return err
+}
+
+func (f *File) writeBase(path string, write func(writer io.Writer) error) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not create rewritten AOF: %w", err)
+	}
+
+	buffered := bufio.NewWriter(file)
+
+	err = write(buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+
+	if err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("could not rewrite AOF: %w", err)
+	}
+
+	return file, nil
+}
+
+func (f *File) replace(file *os.File, temporary string) error {
+	if f.closed {
+		return fmt.Errorf("could not replace AOF: %w", os.ErrClosed)
+	}
+
+	_, err := file.Write(f.buffer.Bytes())
+	if err != nil {
+		return fmt.Errorf("could not write the commands appended while rewriting: %w", err)
+	}
+
+	err = file.Sync()
+	if err != nil {
+		return fmt.Errorf("could not sync rewritten AOF: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("could not read rewritten AOF size: %w", err)
+	}
+
+	err = os.Rename(temporary, f.path)
+	if err != nil {
+		return fmt.Errorf("could not replace AOF: %w", err)
+	}
+
+	if f.file != nil {
+		_ = f.file.Close()
+	}
+
+	f.file = file
+	f.dirty = false
+	f.status.Size = info.Size()
+	f.status.BaseSize = info.Size()
+	f.status.Rewrites++
+
+	return nil
+}
+
+// Close syncs the file, stopping the rewrite in progress from replacing it.
+func (f *File) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return nil
+	}
+
+	f.closed = true
+	close(f.done)
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.sync()
+	if err != nil {
+		_ = f.file.Close()
+
+		return err
+	}
+
+	err = f.file.Close()
+	if err != nil {
+		return fmt.Errorf("could not close AOF: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package aof_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAOF(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AOF Suite")
+}
@@ -0,0 +1,245 @@
+package aof_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jtarchie/sqlettuce/aof"
+	"github.com/jtarchie/sqlettuce/rdb"
+	"github.com/jtarchie/sqlettuce/replication"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AOF", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "appendonly.aof")
+	})
+
+	replay := func() ([][]string, aof.Replayed) {
+		var commands [][]string
+
+		replayed, err := aof.Replay(path, func(tokens []string) error {
+			commands = append(commands, tokens)
+
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		return commands, replayed
+	}
+
+	open := func(policy aof.Policy) *aof.File {
+		file, err := aof.Open(path, policy)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(file.Close)
+
+		return file
+	}
+
+	It("replays the appended commands", func() {
+		for _, policy := range []aof.Policy{aof.Always, aof.EverySec, aof.No} {
+			Expect(os.RemoveAll(path)).To(Succeed())
+
+			file := open(policy)
+			Expect(file.Append("SET", "key", "line\r\nbreak")).To(Succeed())
+			Expect(file.Append("RPUSH", "list", "a", "")).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			commands, replayed := replay()
+			Expect(commands).To(Equal([][]string{
+				{"SET", "key", "line\r\nbreak"},
+				{"RPUSH", "list", "a", ""},
+			}))
+			Expect(replayed.Commands).To(BeEquivalentTo(2))
+			Expect(replayed.Truncated).To(BeFalse())
+			Expect(file.Status().Size).To(Equal(replayed.Size))
+		}
+	})
+
+	It("appends to an existing file", func() {
+		file := open(aof.No)
+		Expect(file.Append("SET", "a", "1")).To(Succeed())
+		Expect(file.Close()).To(Succeed())
+
+		file = open(aof.No)
+		Expect(file.Status().BaseSize).To(Equal(file.Status().Size))
+		Expect(file.Append("SET", "b", "2")).To(Succeed())
+		Expect(file.Close()).To(Succeed())
+
+		commands, _ := replay()
+		Expect(commands).To(HaveLen(2))
+	})
+
+	It("stops at a command that was not completely appended", func() {
+		complete := replication.Encode("SET", "a", "1")
+		partial := replication.Encode("SET", "b", "2")
+
+		for length := 1; length < len(partial); length++ {
+			contents := append(append([]byte{}, complete...), partial[:length]...)
+			Expect(os.WriteFile(path, contents, 0o600)).To(Succeed())
+
+			commands, replayed := replay()
+			Expect(commands).To(Equal([][]string{{"SET", "a", "1"}}), "length %d", length)
+			Expect(replayed.Truncated).To(BeTrue())
+			Expect(replayed.Size).To(BeEquivalentTo(len(complete)))
+		}
+	})
+
+	It("errors on a file that is not made of commands", func() {
+		for _, contents := range []string{
+			"SET a 1\r\n",
+			"*1\r\n+OK\r\n",
+			"*1\r\n$-1\r\n",
+			"*1\r\n$2\r\nabc\r\n",
+			"*1\n$1\na\n",
+		} {
+			Expect(os.WriteFile(path, []byte(contents), 0o600)).To(Succeed())
+
+			_, err := aof.Replay(path, func([]string) error { return nil })
+			Expect(err).To(MatchError(aof.ErrInvalidFile), "contents %q", contents)
+		}
+	})
+
+	It("stops replaying when a command fails", func() {
+		file := open(aof.No)
+		Expect(file.Append("SET", "a", "1")).To(Succeed())
+		Expect(file.Append("UNKNOWN")).To(Succeed())
+
+		failed := errors.New("unknown command")
+
+		replayed, err := aof.Replay(path, func(tokens []string) error {
+			if tokens[0] == "UNKNOWN" {
+				return failed
+			}
+
+			return nil
+		})
+		Expect(err).To(MatchError(failed))
+		Expect(err.Error()).To(ContainSubstring("offset " + strconv.FormatInt(replayed.Size, 10)))
+		Expect(replayed.Commands).To(BeEquivalentTo(1))
+	})
+
+	When("rewriting", func() {
+		It("follows the rewritten commands with the ones appended meanwhile", func() {
+			file := open(aof.EverySec)
+			Expect(file.Append("SET", "a", "1")).To(Succeed())
+			Expect(file.Append("SET", "a", "2")).To(Succeed())
+
+			Expect(file.StartRewrite()).To(Succeed())
+			Expect(file.StartRewrite()).To(MatchError(aof.ErrInProgress))
+			Expect(file.Append("SET", "b", "3")).To(Succeed())
+
+			err := file.FinishRewrite(func(writer io.Writer) error {
+				Expect(file.Append("SET", "c", "4")).To(Succeed())
+
+				_, err := writer.Write(replication.Encode("SET", "a", "2"))
+
+				return err
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(file.Append("SET", "d", "5")).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			commands, _ := replay()
+			Expect(commands).To(Equal([][]string{
+				{"SET", "a", "2"},
+				{"SET", "b", "3"},
+				{"SET", "c", "4"},
+				{"SET", "d", "5"},
+			}))
+
+			status := file.Status()
+			Expect(status.RewriteInProgress).To(BeFalse())
+			Expect(status.Rewrites).To(BeEquivalentTo(1))
+			Expect(status.LastRewriteError).NotTo(HaveOccurred())
+
+			matches, err := filepath.Glob(path + ".temp-*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matches).To(BeEmpty())
+		})
+
+		It("keeps the file when the rewrite fails", func() {
+			file := open(aof.No)
+			Expect(file.Append("SET", "a", "1")).To(Succeed())
+			Expect(file.StartRewrite()).To(Succeed())
+
+			failed := errors.New("failed")
+			err := file.FinishRewrite(func(io.Writer) error { return failed })
+			Expect(err).To(MatchError(failed))
+			Expect(file.Status().LastRewriteError).To(MatchError(failed))
+
+			Expect(file.Append("SET", "b", "2")).To(Succeed())
+
+			commands, _ := replay()
+			Expect(commands).To(HaveLen(2))
+
+			matches, err := filepath.Glob(path + ".temp-*")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matches).To(BeEmpty())
+		})
+
+		It("creates a new file with its first rewrite", func() {
+			file := aof.New(path, aof.Always)
+			DeferCleanup(file.Close)
+
+			Expect(file.Append("SET", "a", "1")).To(Succeed())
+			Expect(path).NotTo(BeAnExistingFile())
+
+			Expect(file.StartRewrite()).To(Succeed())
+			Expect(file.Append("SET", "b", "2")).To(Succeed())
+			Expect(file.FinishRewrite(func(io.Writer) error { return nil })).To(Succeed())
+			Expect(file.Append("SET", "c", "3")).To(Succeed())
+
+			commands, _ := replay()
+			Expect(commands).To(Equal([][]string{{"SET", "b", "2"}, {"SET", "c", "3"}}))
+		})
+
+		It("needs a rewrite once the file grew enough", func() {
+			file := open(aof.No)
+			Expect(file.Append("SET", "a", "1")).To(Succeed())
+			Expect(file.Append("SET", "a", "1")).To(Succeed())
+			Expect(file.StartRewrite()).To(Succeed())
+			Expect(file.FinishRewrite(func(writer io.Writer) error {
+				_, err := writer.Write(replication.Encode("SET", "a", "1"))
+
+				return err
+			})).To(Succeed())
+
+			base := file.Status().BaseSize
+			Expect(file.NeedsRewrite(100, 0)).To(BeFalse())
+
+			Expect(file.Append("SET", "a", "1")).To(Succeed())
+			Expect(file.Status().Size).To(Equal(2 * base))
+			Expect(file.NeedsRewrite(100, 0)).To(BeTrue())
+			Expect(file.NeedsRewrite(100, 3*base)).To(BeFalse())
+			Expect(file.NeedsRewrite(0, 0)).To(BeFalse())
+		})
+
+		It("writes the commands creating keys", func() {
+			elements := make([]string, 65)
+			for index := range elements {
+				elements[index] = strconv.Itoa(index)
+			}
+
+			var buffer bytes.Buffer
+
+			Expect(aof.WriteEntry(&buffer, &rdb.Entry{Key: "string", Type: rdb.String, Value: "value"})).To(Succeed())
+			Expect(aof.WriteEntry(&buffer, &rdb.Entry{Key: "list", Type: rdb.List, Elements: elements})).To(Succeed())
+
+			err := aof.WriteEntry(&buffer, &rdb.Entry{Key: "hash", Type: rdb.Hash})
+			Expect(err).To(MatchError(errors.ErrUnsupported))
+
+			Expect(buffer.String()).To(Equal(string(replication.Encode("SET", "string", "value")) +
+				string(replication.Encode(append([]string{"RPUSH", "list"}, elements[:64]...)...)) +
+				string(replication.Encode("RPUSH", "list", "64"))))
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package aof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidFile = errors.New("invalid AOF")
+
+// Replayed is what replaying a file read.
+type Replayed struct {
+	Commands int64
+	// Size is where the last complete command ends.
+	Size int64
+	// Truncated is true when the file ends in the middle of a command, like
+	// when the server stopped while appending it. The command is not
+	// replayed, the file should be truncated to the size before appending.
+	Truncated bool
+}
+
+// Replay calls apply with every command of the file, in order.
+func Replay(path string, apply func(tokens []string) error) (Replayed, error) {
+	var replayed Replayed
+
+	file, err := os.Open(path)
+	if err != nil {
+		return replayed, fmt.Errorf("could not open AOF: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	for {
+		tokens, read, err := readCommand(reader)
+		if errors.Is(err, io.EOF) && read == 0 {
+			return replayed, nil
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			replayed.Truncated = true
+
+			return replayed, nil
+		}
+
+		if err != nil {
+			return replayed, fmt.Errorf("could not read command at offset %d: %w", replayed.Size, err)
+		}
+
+		err = apply(tokens)
+		if err != nil {
+			return replayed, fmt.Errorf("could not replay command at offset %d: %w", replayed.Size, err)
+		}
+
+		replayed.Commands++
+		replayed.Size += read
+	}
+}
+
+// readCommand reads a command, returning the number of bytes it read, even
+// when it could not read all of it.
+func readCommand(reader *bufio.Reader) ([]string, int64, error) {
+	count, read, err := readLength(reader, '*')
+	if err != nil {
+		return nil, read, err
+	}
+
+	tokens := make([]string, 0, min(count, 1024))
+
+	for range count {
+		length, line, err := readLength(reader, '$')
+		read += line
+
+		if err != nil {
+			return nil, read, err
+		}
+
+		token := make([]byte, length+2)
+
+		bulk, err := io.ReadFull(reader, token)
+		read += int64(bulk)
+
+		if err != nil {
+			return nil, read, err //nolint:wrapcheck
+		}
+
+		if token[length] != '\r' || token[length+1] != '\n' {
+			return nil, read, fmt.Errorf("%w: bulk string of %d bytes is not terminated", ErrInvalidFile, length)
+		}
+
+		tokens = append(tokens, string(token[:length]))
+	}
+
+	return tokens, read, nil
+}
+
+// readLength reads a line with the prefix and a length, like `*3` or `$5`.
+func readLength(reader *bufio.Reader, prefix byte) (int64, int64, error) {
+	line, err := reader.ReadString('\n')
+	read := int64(len(line))
+
+	if err != nil {
+		return 0, read, err //nolint:wrapcheck
+	}
+
+	if !strings.HasSuffix(line, "\r\n") || line[0] != prefix {
+		return 0, read, fmt.Errorf("%w: expected %q, got %q", ErrInvalidFile, prefix, line)
+	}
+
+	length, err := strconv.ParseInt(line[1:len(line)-2], 10, 64)
+	if err != nil || length < 0 || length > maxLength {
+		return 0, read, fmt.Errorf("%w: invalid length %q", ErrInvalidFile, line)
+	}
+
+	return length, read, nil
+}
+
+// maxLength is the largest array or bulk string, like the largest string of
+// the server.
+const maxLength = 512 * 1024 * 1024
@@ -0,0 +1,39 @@
+package aof
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jtarchie/sqlettuce/rdb"
+	"github.com/jtarchie/sqlettuce/replication"
+)
+
+// itemsPerCommand is the most elements of a list pushed by a single
+// command, like Redis, so replaying doesn't build huge commands.
+const itemsPerCommand = 64
+
+// WriteEntry writes the commands creating a key, for rewriting the file.
+func WriteEntry(writer io.Writer, entry *rdb.Entry) error {
+	switch entry.Type {
+	case rdb.String:
+		_, err := writer.Write(replication.Encode("SET", entry.Key, entry.Value))
+		if err != nil {
+			return fmt.Errorf("could not write %q: %w", entry.Key, err)
+		}
+	case rdb.List:
+		for start := 0; start < len(entry.Elements); start += itemsPerCommand {
+			end := min(start+itemsPerCommand, len(entry.Elements))
+			tokens := append([]string{"RPUSH", entry.Key}, entry.Elements[start:end]...)
+
+			_, err := writer.Write(replication.Encode(tokens...))
+			if err != nil {
+				return fmt.Errorf("could not write %q: %w", entry.Key, err)
+			}
+		}
+	default:
+		return fmt.Errorf("could not write %s: %w", entry.Type, errors.ErrUnsupported)
+	}
+
+	return nil
+}
@@ -2,23 +2,108 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/alecthomas/kong"
+	"github.com/jtarchie/sqlettuce/config"
 	"github.com/jtarchie/sqlettuce/db"
 	"github.com/jtarchie/sqlettuce/handler"
+	"github.com/jtarchie/sqlettuce/metrics"
+	"github.com/jtarchie/sqlettuce/router"
+	"github.com/jtarchie/sqlettuce/sentinel"
 	"github.com/jtarchie/sqlettuce/tcp"
 )
 
+// Commands are the subcommands, running the server without one.
+type Commands struct {
+	Server  CLI            `cmd:"" default:"withargs" help:"run the server"`
+	Import  ImportCommand  `cmd:""                    help:"import the keys of a Redis RDB file"`
+	Export  ExportCommand  `cmd:""                    help:"export the keys to a Redis RDB file"`
+	Restore RestoreCommand `cmd:""                    help:"restore the keys as of a time, from a snapshot and the change journal"`
+}
+
 type CLI struct {
+	Config kong.ConfigFlag `help:"path to a redis.conf style config file, flags override its values" placeholder:"PATH"`
+
 	Port     uint   `default:"6379"             help:"port to listen on"`
-	Filename string `default:"sqlite://test.db" help:"filename to store database"`
+	Filename string `default:"sqlite://test.db" help:"DSN of the database, with the sqlite, memory or postgres scheme"`
 	Workers  uint   `default:"100"              help:"number of workers to run"`
+
+	MetricsAddr string `help:"address to serve prometheus metrics on, disabled when empty"`
+
+	Appendonly          bool `help:"log the write commands to an append only file, replayed on start" name:"appendonly"`
+	SqliteChangeJournal bool `help:"record every change of the keys in a journal, for the restore subcommand"`
+
+	ClusterEnabled bool   `help:"answer as a single node cluster owning every hash slot"`
+	ReplicaOf      string `help:"replicate the primary at a host and port, like \"localhost 6379\"" name:"replicaof" placeholder:"HOST PORT"`
+
+	Sentinel                bool          `help:"run as a sentinel, monitoring primaries and failing over to their replicas"`
+	SentinelMonitor         []string      `help:"primary monitored by the sentinel, like \"mymaster 127.0.0.1 6379 2\"" placeholder:"NAME HOST PORT QUORUM" sep:"none"`
+	SentinelDownAfter       time.Duration `default:"30s"                                                                                                                  help:"time a primary must be unreachable to be considered down"`
+	SentinelFailoverTimeout time.Duration `default:"3m"                                                                                                                   help:"time before a failed failover is retried"`
+
+	SlowlogLogSlowerThan    int64 `default:"10000" help:"microseconds a command must take to be in the slowlog, negative disables"`
+	SlowlogMaxLen           int   `default:"128"   help:"number of commands kept in the slowlog"`
+	LatencyMonitorThreshold int64 `default:"0"     help:"milliseconds an event must take to be monitored, zero disables"`
+
+	GroupCommitSize  int   `default:"128" help:"most writes committed in a single transaction, zero or one disables grouping"`
+	GroupCommitDelay int64 `default:"0"   help:"microseconds a write waits for others to be committed with"`
+}
+
+// parameters are the flags reported by CONFIG GET. They can't be changed
+// while the server is running.
+func (c *CLI) parameters() []config.Parameter {
+	return []config.Parameter{
+		{Name: "port", Type: config.Int(0, math.MaxUint16), Default: "6379", Immutable: true},
+		{Name: "filename", Default: "sqlite://test.db", Immutable: true},
+		{Name: "workers", Type: config.Int(1, math.MaxInt32), Default: "100", Immutable: true},
+		{Name: "metrics-addr", Default: "", Immutable: true},
+	}
 }
 
 func (c *CLI) Run() error {
 	ctx := context.TODO()
 
-	client, err := db.NewClient(c.Filename)
+	registry := config.NewRegistry()
+
+	err := registry.Register(c.parameters()...)
+	if err != nil {
+		return fmt.Errorf("could not register config: %w", err)
+	}
+
+	var directives []config.Directive
+
+	if c.Config != "" {
+		directives, err = config.ParseFile(string(c.Config))
+		if err != nil {
+			return fmt.Errorf("could not read config: %w", err)
+		}
+
+		registry.SetFile(string(c.Config))
+	}
+
+	filename := c.Filename
+	if c.Sentinel {
+		// sentinels keep no data
+		filename = "memory://"
+	}
+
+	// the DSN seeds the defaults of the sqlite-* settings of the handler
+	err = registry.Load("filename", c.Filename)
+	if err != nil {
+		return fmt.Errorf("could not load config: %w", err)
+	}
+
+	client, err := db.NewClient(filename)
 	if err != nil {
 		return fmt.Errorf("could not start db client: %w", err)
 	}
@@ -29,10 +114,199 @@ func (c *CLI) Run() error {
 		return fmt.Errorf("could not create server: %w", err)
 	}
 
-	err = server.Listen(ctx, handler.New(client))
+	var middleware []router.Middleware
+
+	if c.MetricsAddr != "" {
+		observer, err := metrics.New(ctx, client, server)
+		if err != nil {
+			return fmt.Errorf("could not create metrics: %w", err)
+		}
+
+		listener, err := net.Listen("tcp", c.MetricsAddr)
+		if err != nil {
+			return fmt.Errorf("could not listen for metrics: %w", err)
+		}
+
+		go func() {
+			err := observer.Listen(ctx, listener)
+			if err != nil {
+				slog.Error("metrics server errored", slog.String("error", err.Error()))
+			}
+		}()
+
+		middleware = append(middleware, observer.Middleware)
+	}
+
+	commands, err := handler.New(client, server, registry, middleware...)
+	if err != nil {
+		return fmt.Errorf("could not create handler: %w", err)
+	}
+
+	// flags were already resolved from the config file by kong,
+	// so they are loaded last to take precedence
+	for _, directive := range directives {
+		if directive.Name == "sentinel" {
+			continue
+		}
+
+		err = registry.Load(directive.Name, directive.Value())
+		if errors.Is(err, config.ErrUnknown) {
+			slog.Warn("ignoring unsupported config directive", slog.String("name", directive.Name))
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not load config file: %w", err)
+		}
+	}
+
+	err = registry.Load(
+		"port", strconv.FormatUint(uint64(c.Port), 10),
+		"workers", strconv.FormatUint(uint64(c.Workers), 10),
+		"metrics-addr", c.MetricsAddr,
+		"slowlog-log-slower-than", strconv.FormatInt(c.SlowlogLogSlowerThan, 10),
+		"slowlog-max-len", strconv.Itoa(c.SlowlogMaxLen),
+		"latency-monitor-threshold", strconv.FormatInt(c.LatencyMonitorThreshold, 10),
+		"group-commit-size", strconv.Itoa(c.GroupCommitSize),
+		"group-commit-delay", strconv.FormatInt(c.GroupCommitDelay, 10),
+		"appendonly", yesNo(c.Appendonly),
+		"sqlite-change-journal", yesNo(c.SqliteChangeJournal),
+		"cluster-enabled", yesNo(c.ClusterEnabled),
+		"replicaof", c.ReplicaOf,
+	)
+	if err != nil {
+		return fmt.Errorf("could not load config: %w", err)
+	}
+
+	err = commands.LoadAppendOnly(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load AOF: %w", err)
+	}
+
+	if c.Sentinel {
+		configs, err := c.sentinelConfigs(directives)
+		if err != nil {
+			return fmt.Errorf("could not configure sentinel: %w", err)
+		}
+
+		monitor, err := commands.Sentinel(configs...)
+		if err != nil {
+			return fmt.Errorf("could not create sentinel: %w", err)
+		}
+
+		go monitor.Run(ctx)
+	}
+
+	err = server.Listen(ctx, commands)
 	if err != nil {
 		return fmt.Errorf("could not listen for server: %w", err)
 	}
 
 	return nil
 }
+
+// sentinelConfigs are the primaries monitored in sentinel mode, from the
+// flags and the `sentinel` directives of the config file.
+func (c *CLI) sentinelConfigs(directives []config.Directive) ([]sentinel.Config, error) {
+	var configs []sentinel.Config
+
+	monitor := func(value string) error {
+		config, err := sentinel.ParseMonitor(value)
+		if err != nil {
+			return fmt.Errorf("could not parse monitor: %w", err)
+		}
+
+		config.DownAfter = c.SentinelDownAfter
+		config.FailoverTimeout = c.SentinelFailoverTimeout
+		configs = append(configs, config)
+
+		return nil
+	}
+
+	for _, value := range c.SentinelMonitor {
+		err := monitor(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// options of the config file apply to the monitors before them
+	for _, directive := range directives {
+		if directive.Name != "sentinel" || len(directive.Args) < 2 {
+			continue
+		}
+
+		option, args := strings.ToLower(directive.Args[0]), directive.Args[1:]
+		if option == "monitor" {
+			err := monitor(strings.Join(args, " "))
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if len(args) != 2 {
+			slog.Warn("ignoring unsupported sentinel directive", slog.String("option", option))
+
+			continue
+		}
+
+		index := slices.IndexFunc(configs, func(config sentinel.Config) bool {
+			return config.Name == args[0]
+		})
+		if index < 0 {
+			return nil, fmt.Errorf("could not set %s: %w: %q", option, sentinel.ErrNoSuchMaster, args[0])
+		}
+
+		err := configs[index].SetOption(option, args[1])
+		if errors.Is(err, sentinel.ErrUnknownOption) {
+			slog.Warn("ignoring unsupported sentinel directive", slog.String("option", option))
+
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not set %s: %w", option, err)
+		}
+	}
+
+	return configs, nil
+}
+
+// configResolver resolves flags from a redis.conf style config file,
+// using the directives named like the flags.
+func configResolver(reader io.Reader) (kong.Resolver, error) {
+	directives, err := config.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config: %w", err)
+	}
+
+	values := map[string]string{}
+	for _, directive := range directives {
+		values[directive.Name] = directive.Value()
+	}
+
+	return kong.ResolverFunc(func(_ *kong.Context, _ *kong.Path, flag *kong.Flag) (interface{}, error) {
+		value, ok := values[flag.Name]
+		if !ok {
+			return nil, nil //nolint:nilnil
+		}
+
+		// redis.conf uses yes and no for booleans
+		if flag.IsBool() {
+			return value == "yes" || value == "true", nil
+		}
+
+		return value, nil
+	}), nil
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+
+	return "no"
+}
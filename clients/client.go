@@ -0,0 +1,350 @@
+package clients
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Client struct {
+	ID        uint64
+	Addr      string
+	LocalAddr string
+	CreatedAt time.Time
+
+	mutex           sync.RWMutex
+	name            string
+	libName         string
+	libVersion      string
+	db              int
+	lastCommand     string
+	lastInteraction time.Time
+	noEvict         bool
+	monitor         bool
+	replica         bool
+	primary         bool
+	listeningPort   int
+	protocol        int
+	subscriptions   int
+	tracking        bool
+	trackingBCast   bool
+	redirect        uint64
+	writer          *Writer
+	closer          io.Closer
+	queue           queue
+}
+
+func (c *Client) Name() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.name
+}
+
+func (c *Client) SetName(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.name = name
+}
+
+func (c *Client) SetLibName(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.libName = name
+}
+
+func (c *Client) SetLibVersion(version string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.libVersion = version
+}
+
+func (c *Client) SetNoEvict(enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.noEvict = enabled
+}
+
+// Monitor reports whether the client is receiving MONITOR output.
+func (c *Client) Monitor() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.monitor
+}
+
+// SetMonitor marks the client as receiving MONITOR output.
+func (c *Client) SetMonitor(enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.monitor = enabled
+}
+
+// Replica reports whether the client is a replica receiving the
+// replication stream.
+func (c *Client) Replica() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.replica
+}
+
+func (c *Client) SetReplica(enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.replica = enabled
+}
+
+// Primary reports whether the client applies the replication stream of
+// the primary this server replicates.
+func (c *Client) Primary() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.primary
+}
+
+func (c *Client) SetPrimary(enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.primary = enabled
+}
+
+// ListeningPort is the port a replica announced with REPLCONF.
+func (c *Client) ListeningPort() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.listeningPort
+}
+
+func (c *Client) SetListeningPort(port int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.listeningPort = port
+}
+
+// Protocol returns the RESP version negotiated with HELLO.
+func (c *Client) Protocol() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.protocol
+}
+
+func (c *Client) SetProtocol(protocol int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.protocol = protocol
+}
+
+// Type is the class of the client matched by the TYPE filter: `master` for
+// the link to the primary, `replica`, `pubsub` while subscribed, or `normal`.
+func (c *Client) Type() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	switch {
+	case c.primary:
+		return "master"
+	case c.replica:
+		return "replica"
+	case c.subscriptions > 0:
+		return "pubsub"
+	default:
+		return "normal"
+	}
+}
+
+func (c *Client) Subscriptions() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.subscriptions
+}
+
+func (c *Client) SetSubscriptions(count int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.subscriptions = count
+}
+
+// SetTracking records the CLIENT TRACKING state reported by CLIENT LIST.
+func (c *Client) SetTracking(enabled, bcast bool, redirect uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.tracking = enabled
+	c.trackingBCast = bcast
+	c.redirect = redirect
+}
+
+func (c *Client) DB() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.db
+}
+
+// Touch records the command currently being executed by the client.
+func (c *Client) Touch(command string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.lastCommand = command
+	c.lastInteraction = time.Now()
+}
+
+func (c *Client) Age() time.Duration {
+	return time.Since(c.CreatedAt)
+}
+
+func (c *Client) Idle() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return time.Since(c.lastInteraction)
+}
+
+// Push sends a message to the client outside of the request/reply cycle.
+func (c *Client) Push(message []byte) error {
+	if c.writer == nil {
+		return nil
+	}
+
+	err := c.writer.Push(message)
+	if err != nil {
+		return fmt.Errorf("could not push to client %d: %w", c.ID, err)
+	}
+
+	return nil
+}
+
+// PushFrom sends a message followed by the contents of the reader, like a
+// snapshot, outside of the request/reply cycle.
+func (c *Client) PushFrom(message []byte, reader io.Reader) error {
+	if c.writer == nil {
+		return nil
+	}
+
+	err := c.writer.PushFrom(message, reader)
+	if err != nil {
+		return fmt.Errorf("could not push to client %d: %w", c.ID, err)
+	}
+
+	return nil
+}
+
+// Queue pushes a message to the client in the background, in order with the
+// other queued messages. A client too slow to keep up is disconnected, as it
+// would miss messages otherwise.
+func (c *Client) Queue(message []byte) error {
+	if c.writer == nil {
+		return nil
+	}
+
+	if c.queue.push(message, c.writer.Push) {
+		return nil
+	}
+
+	c.queue.stop()
+
+	err := c.Close()
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("could not queue to client %d: %w", c.ID, ErrQueueFull)
+}
+
+func (c *Client) Close() error {
+	if c.closer == nil {
+		return nil
+	}
+
+	err := c.closer.Close()
+	if err != nil {
+		return fmt.Errorf("could not close client %d: %w", c.ID, err)
+	}
+
+	return nil
+}
+
+// String returns the client in the format of CLIENT LIST and CLIENT INFO.
+func (c *Client) String() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var flags strings.Builder
+
+	if c.monitor {
+		flags.WriteString("O")
+	}
+
+	if c.replica {
+		flags.WriteString("S")
+	}
+
+	if c.primary {
+		flags.WriteString("M")
+	}
+
+	if c.subscriptions > 0 {
+		flags.WriteString("P")
+	}
+
+	if c.tracking {
+		flags.WriteString("t")
+	}
+
+	if c.trackingBCast {
+		flags.WriteString("B")
+	}
+
+	if c.noEvict {
+		flags.WriteString("e")
+	}
+
+	if flags.Len() == 0 {
+		flags.WriteString("N")
+	}
+
+	redirect := int64(-1)
+	if c.tracking {
+		redirect = int64(c.redirect)
+	}
+
+	fields := []string{
+		fmt.Sprintf("id=%d", c.ID),
+		"addr=" + c.Addr,
+		"laddr=" + c.LocalAddr,
+		"name=" + c.name,
+		fmt.Sprintf("age=%d", int64(time.Since(c.CreatedAt).Seconds())),
+		fmt.Sprintf("idle=%d", int64(time.Since(c.lastInteraction).Seconds())),
+		"flags=" + flags.String(),
+		fmt.Sprintf("db=%d", c.db),
+		fmt.Sprintf("sub=%d", c.subscriptions),
+		"psub=0",
+		"multi=-1",
+		"cmd=" + c.lastCommand,
+		"user=default",
+		fmt.Sprintf("redir=%d", redirect),
+		fmt.Sprintf("resp=%d", c.protocol),
+		"lib-name=" + c.libName,
+		"lib-ver=" + c.libVersion,
+	}
+
+	return strings.Join(fields, " ")
+}
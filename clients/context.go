@@ -0,0 +1,16 @@
+package clients
+
+import "context"
+
+type contextKey struct{}
+
+func WithClient(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, contextKey{}, client)
+}
+
+// FromContext returns the client that is executing the current command.
+func FromContext(ctx context.Context) (*Client, bool) {
+	client, ok := ctx.Value(contextKey{}).(*Client)
+
+	return client, ok
+}
@@ -0,0 +1,142 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidFilter = errors.New("syntax error")
+
+// Filter selects clients for CLIENT LIST and CLIENT KILL.
+// Zero values match every client.
+type Filter struct {
+	IDs       []uint64
+	Addr      string
+	LocalAddr string
+	Type      string
+	User      string
+	MaxAge    time.Duration
+	Skip      *Client
+}
+
+func (f Filter) Matches(client *Client) bool {
+	if f.Skip != nil && f.Skip.ID == client.ID {
+		return false
+	}
+
+	if len(f.IDs) > 0 {
+		found := false
+
+		for _, id := range f.IDs {
+			if id == client.ID {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if f.Addr != "" && f.Addr != client.Addr {
+		return false
+	}
+
+	if f.LocalAddr != "" && f.LocalAddr != client.LocalAddr {
+		return false
+	}
+
+	if f.Type != "" && f.Type != client.Type() {
+		return false
+	}
+
+	// every client is authenticated as the default user
+	if f.User != "" && f.User != "default" {
+		return false
+	}
+
+	if f.MaxAge > 0 && client.Age() < f.MaxAge {
+		return false
+	}
+
+	return true
+}
+
+// ParseType validates the TYPE of CLIENT LIST and CLIENT KILL, `slave` is
+// the old name of `replica`.
+func ParseType(value string) (string, error) {
+	switch clientType := strings.ToLower(value); clientType {
+	case "normal", "master", "replica", "pubsub":
+		return clientType, nil
+	case "slave":
+		return "replica", nil
+	default:
+		return "", fmt.Errorf("unknown client type %q: %w", value, ErrInvalidFilter)
+	}
+}
+
+// ParseFilter parses the `<FILTER> <value>` pairs of CLIENT KILL.
+// The current client is skipped unless `SKIPME no` is given.
+//
+//nolint:cyclop
+func ParseFilter(args []string, current *Client) (Filter, error) {
+	filter := Filter{
+		Skip: current,
+	}
+
+	if len(args)%2 != 0 {
+		return filter, ErrInvalidFilter
+	}
+
+	for index := 0; index < len(args); index += 2 {
+		value := args[index+1]
+
+		switch strings.ToUpper(args[index]) {
+		case "ID":
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || id == 0 {
+				return filter, fmt.Errorf("client-id should be greater than 0: %w", ErrInvalidFilter)
+			}
+
+			filter.IDs = append(filter.IDs, id)
+		case "ADDR":
+			filter.Addr = value
+		case "LADDR":
+			filter.LocalAddr = value
+		case "TYPE":
+			clientType, err := ParseType(value)
+			if err != nil {
+				return filter, err
+			}
+
+			filter.Type = clientType
+		case "USER":
+			filter.User = value
+		case "MAXAGE":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("could not parse max age: %w", ErrInvalidFilter)
+			}
+
+			filter.MaxAge = time.Duration(seconds) * time.Second
+		case "SKIPME":
+			switch strings.ToLower(value) {
+			case "yes":
+				filter.Skip = current
+			case "no":
+				filter.Skip = nil
+			default:
+				return filter, ErrInvalidFilter
+			}
+		default:
+			return filter, ErrInvalidFilter
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,79 @@
+package clients
+
+import (
+	"errors"
+	"sync"
+)
+
+// queueSize is the number of messages queued for a client before it is
+// disconnected.
+const queueSize = 1024
+
+var ErrQueueFull = errors.New("too many queued messages")
+
+// queue delivers the messages of other connections, like invalidations, in
+// the background, so they never wait on a slow client. The goroutine
+// delivering them is started with the first message.
+type queue struct {
+	mutex    sync.Mutex
+	messages chan []byte
+	done     chan struct{}
+	stopped  bool
+}
+
+// push queues the message without blocking, it reports false when the
+// queue is full.
+func (q *queue) push(message []byte, deliver func([]byte) error) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.stopped {
+		return true
+	}
+
+	if q.messages == nil {
+		q.messages = make(chan []byte, queueSize)
+		q.done = make(chan struct{})
+
+		go q.run(q.messages, q.done, deliver)
+	}
+
+	select {
+	case q.messages <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *queue) run(messages chan []byte, done chan struct{}, deliver func([]byte) error) {
+	for {
+		select {
+		case <-done:
+			return
+		case message := <-messages:
+			err := deliver(message)
+			if err != nil {
+				q.stop()
+
+				return
+			}
+		}
+	}
+}
+
+// stop drops the queued messages, and the ones pushed after.
+func (q *queue) stop() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.stopped {
+		return
+	}
+
+	q.stopped = true
+
+	if q.done != nil {
+		close(q.done)
+	}
+}
@@ -0,0 +1,200 @@
+package clients
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+type PauseMode int
+
+const (
+	PauseNone PauseMode = iota
+	PauseWrite
+	PauseAll
+)
+
+type Registry struct {
+	mutex   sync.RWMutex
+	clients map[uint64]*Client
+	nextID  uint64
+
+	pauseMode  PauseMode
+	pauseUntil time.Time
+	unpaused   chan struct{}
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		clients: map[uint64]*Client{},
+	}
+}
+
+// Register tracks a new connection. The writer delivers pushed messages and
+// the closer is used to terminate the connection when the client is killed.
+func (r *Registry) Register(addr, localAddr string, writer *Writer, closer io.Closer) *Client {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.nextID++
+
+	now := time.Now()
+	client := &Client{
+		ID:              r.nextID,
+		Addr:            addr,
+		LocalAddr:       localAddr,
+		CreatedAt:       now,
+		lastInteraction: now,
+		protocol:        2,
+		writer:          writer,
+		closer:          closer,
+	}
+
+	r.clients[client.ID] = client
+
+	return client
+}
+
+func (r *Registry) Unregister(client *Client) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.clients, client.ID)
+	client.queue.stop()
+}
+
+func (r *Registry) Get(id uint64) (*Client, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	client, ok := r.clients[id]
+
+	return client, ok
+}
+
+func (r *Registry) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.clients)
+}
+
+// List returns the clients matching the filter, ordered by ID.
+func (r *Registry) List(filter Filter) []*Client {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	clients := make([]*Client, 0, len(r.clients))
+
+	for _, client := range r.clients {
+		if filter.Matches(client) {
+			clients = append(clients, client)
+		}
+	}
+
+	slices.SortFunc(clients, func(a, b *Client) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return clients
+}
+
+// Kill closes the connections of all clients matching the filter and
+// returns the number of clients that were killed.
+func (r *Registry) Kill(filter Filter) int {
+	clients := r.List(filter)
+
+	for _, client := range clients {
+		err := client.Close()
+		if err != nil {
+			slog.Error("could not kill client",
+				slog.Uint64("id", client.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+
+	return len(clients)
+}
+
+// Pause suspends clients for the timeout. When the mode is PauseWrite, only
+// write commands are suspended.
+func (r *Registry) Pause(mode PauseMode, timeout time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	until := time.Now().Add(timeout)
+
+	// an active pause can only be extended, like redis does
+	if r.pauseMode != PauseNone && until.Before(r.pauseUntil) {
+		until = r.pauseUntil
+	}
+
+	if r.pauseMode < mode {
+		r.pauseMode = mode
+	}
+
+	if r.unpaused == nil {
+		r.unpaused = make(chan struct{})
+	}
+
+	r.pauseUntil = until
+}
+
+func (r *Registry) Unpause() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.unpause()
+}
+
+func (r *Registry) unpause() {
+	r.pauseMode = PauseNone
+	r.pauseUntil = time.Time{}
+
+	if r.unpaused != nil {
+		close(r.unpaused)
+		r.unpaused = nil
+	}
+}
+
+// WaitUnpaused blocks while a pause applies to the command. It returns early
+// when the context is cancelled.
+func (r *Registry) WaitUnpaused(ctx context.Context, isWrite bool) error {
+	for {
+		r.mutex.Lock()
+
+		if r.pauseMode == PauseNone || (r.pauseMode == PauseWrite && !isWrite) {
+			r.mutex.Unlock()
+
+			return nil
+		}
+
+		remaining := time.Until(r.pauseUntil)
+		if remaining <= 0 {
+			r.unpause()
+			r.mutex.Unlock()
+
+			return nil
+		}
+
+		unpaused := r.unpaused
+		r.mutex.Unlock()
+
+		timer := time.NewTimer(remaining)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err() //nolint:wrapcheck
+		case <-unpaused:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package clients_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type closer struct {
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+
+	return nil
+}
+
+var _ = Describe("Registry", func() {
+	var registry *clients.Registry
+
+	BeforeEach(func() {
+		registry = clients.NewRegistry()
+	})
+
+	It("assigns incrementing IDs", func() {
+		first := registry.Register("127.0.0.1:1000", "127.0.0.1:6379", nil, nil)
+		second := registry.Register("127.0.0.1:1001", "127.0.0.1:6379", nil, nil)
+
+		Expect(first.ID).To(BeEquivalentTo(1))
+		Expect(second.ID).To(BeEquivalentTo(2))
+		Expect(registry.Len()).To(Equal(2))
+
+		registry.Unregister(first)
+		Expect(registry.Len()).To(Equal(1))
+
+		_, found := registry.Get(first.ID)
+		Expect(found).To(BeFalse())
+
+		client, found := registry.Get(second.ID)
+		Expect(found).To(BeTrue())
+		Expect(client).To(Equal(second))
+	})
+
+	It("formats clients like CLIENT LIST", func() {
+		client := registry.Register("127.0.0.1:1000", "127.0.0.1:6379", nil, nil)
+		client.SetName("worker")
+		client.SetLibName("go-redis")
+		client.SetLibVersion("9.5.1")
+		client.Touch("client|info")
+
+		Expect(client.String()).To(And(
+			HavePrefix("id=1 addr=127.0.0.1:1000 laddr=127.0.0.1:6379 name=worker "),
+			ContainSubstring(" flags=N db=0 "),
+			ContainSubstring(" cmd=client|info "),
+			ContainSubstring(" redir=-1 resp=2 "),
+			HaveSuffix(" lib-name=go-redis lib-ver=9.5.1"),
+		))
+
+		client.SetProtocol(3)
+		client.SetTracking(true, true, 5)
+		Expect(client.String()).To(And(
+			ContainSubstring(" flags=tB "),
+			ContainSubstring(" redir=5 resp=3 "),
+		))
+	})
+
+	It("lists clients by filter", func() {
+		first := registry.Register("127.0.0.1:1000", "127.0.0.1:6379", nil, nil)
+		second := registry.Register("127.0.0.1:1001", "127.0.0.1:6379", nil, nil)
+
+		Expect(registry.List(clients.Filter{})).To(Equal([]*clients.Client{first, second}))
+		Expect(registry.List(clients.Filter{IDs: []uint64{second.ID}})).To(Equal([]*clients.Client{second}))
+		Expect(registry.List(clients.Filter{Addr: "127.0.0.1:1000"})).To(Equal([]*clients.Client{first}))
+		Expect(registry.List(clients.Filter{Type: "pubsub"})).To(BeEmpty())
+		Expect(registry.List(clients.Filter{Skip: first})).To(Equal([]*clients.Client{second}))
+	})
+
+	It("lists clients by type", func() {
+		normal := registry.Register("127.0.0.1:1000", "127.0.0.1:6379", nil, nil)
+		subscriber := registry.Register("127.0.0.1:1001", "127.0.0.1:6379", nil, nil)
+		subscriber.SetSubscriptions(1)
+		replica := registry.Register("127.0.0.1:1002", "127.0.0.1:6379", nil, nil)
+		replica.SetReplica(true)
+		primary := registry.Register("127.0.0.1:1003", "127.0.0.1:6379", nil, nil)
+		primary.SetPrimary(true)
+
+		Expect(registry.List(clients.Filter{Type: "normal"})).To(Equal([]*clients.Client{normal}))
+		Expect(registry.List(clients.Filter{Type: "pubsub"})).To(Equal([]*clients.Client{subscriber}))
+		Expect(registry.List(clients.Filter{Type: "replica"})).To(Equal([]*clients.Client{replica}))
+		Expect(registry.List(clients.Filter{Type: "master"})).To(Equal([]*clients.Client{primary}))
+
+		filter, err := clients.ParseFilter([]string{"TYPE", "SLAVE"}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry.List(filter)).To(Equal([]*clients.Client{replica}))
+	})
+
+	It("kills clients by filter", func() {
+		firstCloser, secondCloser := &closer{}, &closer{}
+		first := registry.Register("127.0.0.1:1000", "127.0.0.1:6379", nil, firstCloser)
+		_ = registry.Register("127.0.0.1:1001", "127.0.0.1:6379", nil, secondCloser)
+
+		filter, err := clients.ParseFilter([]string{"LADDR", "127.0.0.1:6379"}, first)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(registry.Kill(filter)).To(Equal(1))
+		Expect(firstCloser.closed).To(BeFalse())
+		Expect(secondCloser.closed).To(BeTrue())
+
+		filter, err = clients.ParseFilter([]string{"ID", "1", "SKIPME", "no"}, first)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(registry.Kill(filter)).To(Equal(1))
+		Expect(firstCloser.closed).To(BeTrue())
+	})
+
+	It("rejects invalid filters", func() {
+		_, err := clients.ParseFilter([]string{"ID"}, nil)
+		Expect(err).To(MatchError(clients.ErrInvalidFilter))
+
+		_, err = clients.ParseFilter([]string{"ID", "zero"}, nil)
+		Expect(err).To(MatchError(clients.ErrInvalidFilter))
+
+		_, err = clients.ParseFilter([]string{"TYPE", "unknown"}, nil)
+		Expect(err).To(MatchError(clients.ErrInvalidFilter))
+
+		_, err = clients.ParseFilter([]string{"UNKNOWN", "value"}, nil)
+		Expect(err).To(MatchError(clients.ErrInvalidFilter))
+	})
+
+	When("paused", func() {
+		It("only blocks writes with PauseWrite", func() {
+			registry.Pause(clients.PauseWrite, time.Hour)
+
+			err := registry.WaitUnpaused(context.TODO(), false)
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+			defer cancel()
+
+			err = registry.WaitUnpaused(ctx, true)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("resumes after the timeout", func() {
+			registry.Pause(clients.PauseAll, 10*time.Millisecond)
+
+			started := time.Now()
+			err := registry.WaitUnpaused(context.TODO(), false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(time.Since(started)).To(BeNumerically(">=", 10*time.Millisecond))
+		})
+
+		It("resumes when unpaused", func() {
+			registry.Pause(clients.PauseAll, time.Hour)
+
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				registry.Unpause()
+			}()
+
+			err := registry.WaitUnpaused(context.TODO(), true)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Writer", func() {
+	It("buffers replies until flushed", func() {
+		conn := &bytes.Buffer{}
+		writer := clients.NewWriter(conn)
+
+		_, err := writer.Write([]byte("+OK\r\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.String()).To(BeEmpty())
+
+		err = writer.Push([]byte(">1\r\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.String()).To(Equal(">1\r\n"))
+
+		err = writer.Flush()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.String()).To(Equal(">1\r\n+OK\r\n"))
+	})
+
+	It("pushes the contents of a reader", func() {
+		conn := &bytes.Buffer{}
+		writer := clients.NewWriter(conn)
+
+		err := writer.PushFrom([]byte("$5\r\n"), bytes.NewBufferString("Hello"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.String()).To(Equal("$5\r\nHello"))
+	})
+})
+
+var _ = Describe("Client", func() {
+	It("queues pushed messages without waiting on the connection", func() {
+		reader, conn := io.Pipe()
+		DeferCleanup(reader.Close)
+
+		connection := &closer{}
+		registry := clients.NewRegistry()
+		client := registry.Register("127.0.0.1:1000", "127.0.0.1:6379", clients.NewWriter(conn), connection)
+
+		err := client.Queue([]byte(">1\r\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		received := make([]byte, 4)
+		_, err = io.ReadFull(reader, received)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(received)).To(Equal(">1\r\n"))
+
+		// nothing reads the connection anymore, the messages pile up
+		for {
+			err = client.Queue([]byte(">1\r\n"))
+			if err != nil {
+				break
+			}
+		}
+
+		Expect(err).To(MatchError(clients.ErrQueueFull))
+		Expect(connection.closed).To(BeTrue())
+
+		registry.Unregister(client)
+	})
+})
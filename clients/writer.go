@@ -0,0 +1,86 @@
+package clients
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Writer buffers the replies for a connection. Messages pushed from other
+// connections, such as invalidations, are serialized with the replies so
+// they are never interleaved.
+type Writer struct {
+	mutex  sync.Mutex
+	conn   io.Writer
+	buffer bytes.Buffer
+}
+
+func NewWriter(conn io.Writer) *Writer {
+	return &Writer{
+		conn: conn,
+	}
+}
+
+// Write buffers the reply until Flush is called. It must only be called by
+// the goroutine handling the connection.
+func (w *Writer) Write(p []byte) (int, error) {
+	//nolint:wrapcheck
+	return w.buffer.Write(p)
+}
+
+// Discard drops the replies buffered since the last flush, like the partial
+// reply of a command that failed.
+func (w *Writer) Discard() {
+	w.buffer.Reset()
+}
+
+func (w *Writer) Flush() error {
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	_, err := w.conn.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+
+	if err != nil {
+		return fmt.Errorf("could not flush: %w", err)
+	}
+
+	return nil
+}
+
+// Push writes the message to the connection immediately.
+func (w *Writer) Push(message []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	_, err := w.conn.Write(message)
+	if err != nil {
+		return fmt.Errorf("could not push: %w", err)
+	}
+
+	return nil
+}
+
+// PushFrom writes the message, followed by the contents of the reader, to
+// the connection immediately. The contents are copied, not held in memory.
+func (w *Writer) PushFrom(message []byte, reader io.Reader) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	_, err := w.conn.Write(message)
+	if err != nil {
+		return fmt.Errorf("could not push: %w", err)
+	}
+
+	_, err = io.Copy(w.conn, reader)
+	if err != nil {
+		return fmt.Errorf("could not push: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+// Package cluster computes the hash slots of keys, like a redis cluster.
+package cluster
+
+import "strings"
+
+// Slots is the number of hash slots in a cluster.
+const Slots = 16384
+
+// Slot returns the hash slot of the key. When the key has a non-empty
+// `{hashtag}`, only the hashtag is hashed, so related keys share a slot.
+func Slot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	return int(CRC16(key)) % Slots
+}
+
+// SameSlot reports if all the keys hash to the same slot.
+func SameSlot(keys ...string) bool {
+	for _, key := range keys[min(1, len(keys)):] {
+		if Slot(key) != Slot(keys[0]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CRC16 is the CCITT (XMODEM) checksum used for hash slots.
+func CRC16(value string) uint16 {
+	var crc uint16
+
+	for index := range len(value) {
+		crc ^= uint16(value[index]) << 8
+
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
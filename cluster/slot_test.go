@@ -0,0 +1,33 @@
+package cluster_test
+
+import (
+	"github.com/jtarchie/sqlettuce/cluster"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Slot", func() {
+	It("computes the CRC16 checksum", func() {
+		Expect(cluster.CRC16("123456789")).To(BeEquivalentTo(0x31C3))
+	})
+
+	It("computes the slot of a key", func() {
+		Expect(cluster.Slot("foo")).To(Equal(12182))
+		Expect(cluster.Slot("")).To(Equal(0))
+	})
+
+	It("only hashes the hashtag", func() {
+		Expect(cluster.Slot("{user1000}.following")).To(Equal(cluster.Slot("user1000")))
+		Expect(cluster.Slot("{user1000}.followers")).To(Equal(cluster.Slot("user1000")))
+		Expect(cluster.Slot("foo{}{bar}")).To(Equal(int(cluster.CRC16("foo{}{bar}")) % cluster.Slots))
+		Expect(cluster.Slot("foo{}{bar}")).NotTo(Equal(cluster.Slot("bar")))
+		Expect(cluster.Slot("foo{{bar}}zap")).To(Equal(cluster.Slot("{bar")))
+		Expect(cluster.Slot("foo{bar}{zap}")).To(Equal(cluster.Slot("bar")))
+	})
+
+	It("checks if keys share a slot", func() {
+		Expect(cluster.SameSlot()).To(BeTrue())
+		Expect(cluster.SameSlot("{a}1", "{a}2")).To(BeTrue())
+		Expect(cluster.SameSlot("a", "b")).To(BeFalse())
+	})
+})
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const maxIncludeDepth = 16
+
+var (
+	ErrUnbalancedQuotes = errors.New("unbalanced quotes in configuration line")
+	ErrIncludeDepth     = errors.New("too many nested includes")
+	ErrMissingArguments = errors.New("wrong number of arguments")
+)
+
+// Directive is a line of a config file, like `maxmemory 100mb`.
+type Directive struct {
+	Name string
+	Args []string
+}
+
+// Value joins the arguments, so `save 900 1` has the value `900 1`.
+func (d Directive) Value() string {
+	return strings.Join(d.Args, " ")
+}
+
+// Parse reads directives in the syntax of redis.conf. Included files are
+// expanded in place, relative paths are relative to the working directory
+// like redis does.
+func Parse(reader io.Reader) ([]Directive, error) {
+	return parse(reader, 0)
+}
+
+// ParseFile reads the directives of the file at the path.
+func ParseFile(path string) ([]Directive, error) {
+	return parseFile(path, 0)
+}
+
+func parseFile(path string, depth int) ([]Directive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open config file: %w", err)
+	}
+	defer file.Close()
+
+	directives, err := parse(file, depth)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %q: %w", path, err)
+	}
+
+	return directives, nil
+}
+
+func parse(reader io.Reader, depth int) ([]Directive, error) {
+	if depth > maxIncludeDepth {
+		return nil, ErrIncludeDepth
+	}
+
+	var directives []Directive
+
+	scanner := bufio.NewScanner(reader)
+	number := 0
+
+	for scanner.Scan() {
+		number++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args, err := SplitArgs(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+
+		name := strings.ToLower(args[0])
+		if name != "include" {
+			directives = append(directives, Directive{Name: name, Args: args[1:]})
+
+			continue
+		}
+
+		if len(args) != 2 {
+			return nil, fmt.Errorf("line %d: include: %w", number, ErrMissingArguments)
+		}
+
+		included, err := parseFile(args[1], depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+
+		directives = append(directives, included...)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("could not scan config: %w", err)
+	}
+
+	return directives, nil
+}
+
+// SplitArgs splits a line into arguments like redis does. Arguments are
+// separated by spaces and can be quoted. Double quotes support the escapes
+// \n, \r, \t, \b, \a, \\, \" and \xHH, single quotes only support \'.
+//
+//nolint:cyclop,funlen
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+
+	index := 0
+
+	for {
+		for index < len(line) && isSpace(line[index]) {
+			index++
+		}
+
+		if index >= len(line) {
+			return args, nil
+		}
+
+		var (
+			current      strings.Builder
+			doubleQuoted bool
+			singleQuoted bool
+			done         bool
+		)
+
+		for !done {
+			if index >= len(line) {
+				if doubleQuoted || singleQuoted {
+					return nil, ErrUnbalancedQuotes
+				}
+
+				break
+			}
+
+			char := line[index]
+
+			switch {
+			case doubleQuoted:
+				switch {
+				case char == '\\' && index+3 < len(line) && line[index+1] == 'x' &&
+					isHex(line[index+2]) && isHex(line[index+3]):
+					value, _ := strconv.ParseUint(line[index+2:index+4], 16, 8)
+					current.WriteByte(byte(value))
+					index += 3
+				case char == '\\' && index+1 < len(line):
+					index++
+					current.WriteByte(unescape(line[index]))
+				case char == '"':
+					if index+1 < len(line) && !isSpace(line[index+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+
+					done = true
+				default:
+					current.WriteByte(char)
+				}
+			case singleQuoted:
+				switch {
+				case char == '\\' && index+1 < len(line) && line[index+1] == '\'':
+					index++
+					current.WriteByte('\'')
+				case char == '\'':
+					if index+1 < len(line) && !isSpace(line[index+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+
+					done = true
+				default:
+					current.WriteByte(char)
+				}
+			default:
+				switch {
+				case isSpace(char):
+					done = true
+				case char == '"':
+					doubleQuoted = true
+				case char == '\'':
+					singleQuoted = true
+				default:
+					current.WriteByte(char)
+				}
+			}
+
+			index++
+		}
+
+		args = append(args, current.String())
+	}
+}
+
+func unescape(char byte) byte {
+	switch char {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return char
+	}
+}
+
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\r'
+}
+
+func isHex(char byte) bool {
+	return ('0' <= char && char <= '9') || ('a' <= char && char <= 'f') || ('A' <= char && char <= 'F')
+}
@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jtarchie/sqlettuce/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parse", func() {
+	DescribeTable("splitting arguments",
+		func(line string, expected []string) {
+			args, err := config.SplitArgs(line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(args).To(Equal(expected))
+		},
+		Entry("plain", "maxmemory 100mb", []string{"maxmemory", "100mb"}),
+		Entry("extra spaces", "  save   900 1  ", []string{"save", "900", "1"}),
+		Entry("double quotes", `requirepass "a b\n\"c\""`, []string{"requirepass", "a b\n\"c\""}),
+		Entry("hex escapes", `name "\x41\x62"`, []string{"name", "Ab"}),
+		Entry("single quotes", `name 'it\'s'`, []string{"name", "it's"}),
+		Entry("empty quotes", `save ""`, []string{"save", ""}),
+	)
+
+	It("errors on unbalanced quotes", func() {
+		_, err := config.SplitArgs(`name "value`)
+		Expect(err).To(MatchError(config.ErrUnbalancedQuotes))
+
+		_, err = config.SplitArgs(`name "value"more`)
+		Expect(err).To(MatchError(config.ErrUnbalancedQuotes))
+	})
+
+	It("reads directives and skips comments", func() {
+		directives, err := config.Parse(strings.NewReader(
+			"# comment\n\nPORT 1234\nsave 900 1\n  # indented comment\n",
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(directives).To(Equal([]config.Directive{
+			{Name: "port", Args: []string{"1234"}},
+			{Name: "save", Args: []string{"900", "1"}},
+		}))
+		Expect(directives[1].Value()).To(Equal("900 1"))
+	})
+
+	It("expands included files in place", func() {
+		dir := GinkgoT().TempDir()
+		included := filepath.Join(dir, "included.conf")
+
+		err := os.WriteFile(included, []byte("maxmemory 1mb\n"), 0o600)
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(dir, "redis.conf")
+
+		err = os.WriteFile(path, []byte("port 1\ninclude "+included+"\ntimeout 5\n"), 0o600)
+		Expect(err).NotTo(HaveOccurred())
+
+		directives, err := config.ParseFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(directives).To(Equal([]config.Directive{
+			{Name: "port", Args: []string{"1"}},
+			{Name: "maxmemory", Args: []string{"1mb"}},
+			{Name: "timeout", Args: []string{"5"}},
+		}))
+	})
+
+	It("errors on recursive includes", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "redis.conf")
+
+		err := os.WriteFile(path, []byte("include "+path+"\n"), 0o600)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = config.ParseFile(path)
+		Expect(err).To(MatchError(config.ErrIncludeDepth))
+	})
+})
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jtarchie/sqlettuce/glob"
+)
+
+var (
+	ErrUnknown   = errors.New("unknown option")
+	ErrImmutable = errors.New("can't set immutable config")
+	ErrDuplicate = errors.New("duplicate parameter")
+	ErrNoFile    = errors.New("the server is running without a config file")
+)
+
+// Parameter is a setting of the server.
+type Parameter struct {
+	Name    string
+	Type    Type
+	Default string
+	// Immutable parameters can only be loaded on start, not with CONFIG SET.
+	Immutable bool
+	// Apply is called with the canonical value whenever it changes.
+	Apply func(value string) error
+}
+
+type entry struct {
+	Parameter
+	value string
+}
+
+// Registry holds the parameters of the server and their current values.
+type Registry struct {
+	mutex      sync.RWMutex
+	parameters map[string]*entry
+	file       string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		parameters: map[string]*entry{},
+	}
+}
+
+// Register adds parameters with their default values. Apply is not called
+// for the defaults, they are expected to be in effect already.
+func (r *Registry) Register(parameters ...Parameter) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, parameter := range parameters {
+		name := strings.ToLower(parameter.Name)
+
+		if _, ok := r.parameters[name]; ok {
+			return fmt.Errorf("could not register %q: %w", name, ErrDuplicate)
+		}
+
+		if parameter.Type == nil {
+			parameter.Type = String()
+		}
+
+		value, err := parameter.Type.Normalize(parameter.Default)
+		if err != nil {
+			return fmt.Errorf("could not register %q default: %w", name, err)
+		}
+
+		parameter.Name = name
+		parameter.Default = value
+		r.parameters[name] = &entry{Parameter: parameter, value: value}
+	}
+
+	return nil
+}
+
+// Get returns the parameters matching any of the glob patterns, sorted by
+// name.
+func (r *Registry) Get(patterns ...string) [][2]string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var values [][2]string
+
+	for name, entry := range r.parameters {
+		for _, pattern := range patterns {
+			if glob.Match(strings.ToLower(pattern), name) {
+				values = append(values, [2]string{name, entry.value})
+
+				break
+			}
+		}
+	}
+
+	slices.SortFunc(values, func(a, b [2]string) int {
+		return strings.Compare(a[0], b[0])
+	})
+
+	return values
+}
+
+// Value returns the current value of the parameter.
+func (r *Registry) Value(name string) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entry, ok := r.parameters[strings.ToLower(name)]
+	if !ok {
+		return "", false
+	}
+
+	return entry.value, true
+}
+
+// Int returns the current value of an integer parameter, or zero.
+func (r *Registry) Int(name string) int64 {
+	value, _ := r.Value(name)
+	number, _ := strconv.ParseInt(value, 10, 64)
+
+	return number
+}
+
+// Bool returns the current value of a boolean parameter.
+func (r *Registry) Bool(name string) bool {
+	value, _ := r.Value(name)
+
+	return value == "yes"
+}
+
+// Set changes the parameters from name and value pairs, like CONFIG SET.
+// All values are validated before any are applied. When applying a value
+// fails, the values already applied are reverted.
+func (r *Registry) Set(pairs ...string) error {
+	return r.set(false, pairs...)
+}
+
+// Load changes the parameters like Set, but allows immutable parameters.
+// It is used for the values the server is started with.
+func (r *Registry) Load(pairs ...string) error {
+	return r.set(true, pairs...)
+}
+
+func (r *Registry) set(loading bool, pairs ...string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	type change struct {
+		entry    *entry
+		value    string
+		previous string
+	}
+
+	changes := make([]change, 0, len(pairs)/2)
+
+	for index := 0; index+1 < len(pairs); index += 2 {
+		name := strings.ToLower(pairs[index])
+
+		entry, ok := r.parameters[name]
+		if !ok {
+			return &Error{Name: name, Err: ErrUnknown}
+		}
+
+		if entry.Immutable && !loading {
+			return &Error{Name: name, Err: ErrImmutable}
+		}
+
+		value, err := entry.Type.Normalize(pairs[index+1])
+		if err != nil {
+			return &Error{Name: name, Err: err}
+		}
+
+		changes = append(changes, change{entry: entry, value: value, previous: entry.value})
+	}
+
+	for index, change := range changes {
+		err := apply(change.entry, change.value)
+		if err != nil {
+			for revert := index - 1; revert >= 0; revert-- {
+				_ = apply(changes[revert].entry, changes[revert].previous)
+			}
+
+			return &Error{Name: change.entry.Name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func apply(entry *entry, value string) error {
+	if entry.Apply != nil {
+		err := entry.Apply(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	entry.value = value
+
+	return nil
+}
+
+// Error is a parameter that could not be set.
+type Error struct {
+	Name string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
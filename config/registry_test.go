@@ -0,0 +1,155 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/jtarchie/sqlettuce/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var (
+		registry *config.Registry
+		applied  []string
+	)
+
+	BeforeEach(func() {
+		applied = nil
+		registry = config.NewRegistry()
+
+		err := registry.Register(
+			config.Parameter{Name: "port", Type: config.Int(0, 65535), Default: "6379", Immutable: true},
+			config.Parameter{
+				Name:    "slowlog-max-len",
+				Type:    config.Int(0, 1000),
+				Default: "128",
+				Apply: func(value string) error {
+					applied = append(applied, value)
+
+					return nil
+				},
+			},
+			config.Parameter{Name: "appendonly", Type: config.Bool(), Default: "no"},
+			config.Parameter{Name: "maxmemory", Type: config.Memory(), Default: "0"},
+			config.Parameter{Name: "maxmemory-policy", Type: config.Enum("noeviction", "allkeys-lru"), Default: "noeviction"},
+			config.Parameter{
+				Name:    "failing",
+				Default: "",
+				Apply: func(_ string) error {
+					return errors.New("could not apply")
+				},
+			},
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects duplicate parameters", func() {
+		err := registry.Register(config.Parameter{Name: "PORT"})
+		Expect(err).To(MatchError(config.ErrDuplicate))
+	})
+
+	It("gets parameters matching glob patterns", func() {
+		Expect(registry.Get("maxmemory*")).To(Equal([][2]string{
+			{"maxmemory", "0"},
+			{"maxmemory-policy", "noeviction"},
+		}))
+		Expect(registry.Get("PORT", "appendonly")).To(Equal([][2]string{
+			{"appendonly", "no"},
+			{"port", "6379"},
+		}))
+		Expect(registry.Get("unknown")).To(BeEmpty())
+	})
+
+	It("sets and applies canonical values", func() {
+		err := registry.Set("slowlog-max-len", "10", "maxmemory", "1mb", "appendonly", "YES")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(applied).To(Equal([]string{"10"}))
+		Expect(registry.Int("slowlog-max-len")).To(BeEquivalentTo(10))
+		Expect(registry.Int("maxmemory")).To(BeEquivalentTo(1024 * 1024))
+		Expect(registry.Bool("appendonly")).To(BeTrue())
+	})
+
+	It("validates every value before applying", func() {
+		err := registry.Set("slowlog-max-len", "10", "appendonly", "maybe")
+		Expect(err).To(MatchError(config.ErrNotBool))
+
+		var configErr *config.Error
+		Expect(errors.As(err, &configErr)).To(BeTrue())
+		Expect(configErr.Name).To(Equal("appendonly"))
+
+		Expect(applied).To(BeEmpty())
+		Expect(registry.Int("slowlog-max-len")).To(BeEquivalentTo(128))
+
+		Expect(registry.Set("slowlog-max-len", "abc")).To(MatchError(config.ErrNotInteger))
+		Expect(registry.Set("slowlog-max-len", "1001")).To(MatchError(config.ErrOutOfRange))
+		Expect(registry.Set("maxmemory-policy", "random")).To(MatchError(config.ErrNotEnum))
+		Expect(registry.Set("unknown", "value")).To(MatchError(config.ErrUnknown))
+	})
+
+	It("reverts applied values when applying fails", func() {
+		err := registry.Set("slowlog-max-len", "10", "failing", "value")
+		Expect(err).To(HaveOccurred())
+
+		Expect(applied).To(Equal([]string{"10", "128"}))
+		Expect(registry.Int("slowlog-max-len")).To(BeEquivalentTo(128))
+	})
+
+	It("only loads immutable parameters", func() {
+		Expect(registry.Set("port", "1234")).To(MatchError(config.ErrImmutable))
+
+		err := registry.Load("port", "1234")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry.Int("port")).To(BeEquivalentTo(1234))
+	})
+
+	When("rewriting", func() {
+		It("requires a config file", func() {
+			Expect(registry.Rewrite()).To(MatchError(config.ErrNoFile))
+		})
+
+		It("replaces directives and keeps the rest of the file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "redis.conf")
+
+			err := os.WriteFile(path, []byte(
+				"# a comment\n"+
+					"slowlog-max-len 5\n"+
+					"unknown-directive value\n"+
+					"appendonly yes\n",
+			), 0o600)
+			Expect(err).NotTo(HaveOccurred())
+
+			registry.SetFile(path)
+
+			err = registry.Set("slowlog-max-len", "10", "maxmemory-policy", "allkeys-lru")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = registry.Load("port", "1234")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = registry.Rewrite()
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(
+				"# a comment\n" +
+					"slowlog-max-len 10\n" +
+					"unknown-directive value\n" +
+					"# Generated by CONFIG REWRITE\n" +
+					"maxmemory-policy allkeys-lru\n" +
+					"port 1234\n",
+			))
+		})
+	})
+
+	It("quotes values", func() {
+		Expect(config.Quote("value")).To(Equal("value"))
+		Expect(config.Quote("")).To(Equal(`""`))
+		Expect(config.Quote("900 1")).To(Equal(`"900 1"`))
+		Expect(config.Quote("a \"b\"\n")).To(Equal(`"a \"b\"\n"`))
+	})
+})
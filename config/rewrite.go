@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const rewriteSignature = "# Generated by CONFIG REWRITE"
+
+// SetFile sets the config file that Rewrite persists to.
+func (r *Registry) SetFile(path string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.file = path
+}
+
+func (r *Registry) File() string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.file
+}
+
+// Rewrite persists the parameters that differ from their defaults to the
+// config file, like CONFIG REWRITE. Comments and unknown directives in the
+// file are kept, directives of parameters are replaced in place.
+func (r *Registry) Rewrite() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.file == "" {
+		return ErrNoFile
+	}
+
+	contents, err := os.ReadFile(r.file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	var (
+		output  bytes.Buffer
+		written = map[string]bool{}
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			if line != rewriteSignature {
+				output.WriteString(line + "\n")
+			}
+
+			continue
+		}
+
+		name := strings.ToLower(fields[0])
+
+		entry, ok := r.parameters[name]
+		if !ok {
+			output.WriteString(line + "\n")
+
+			continue
+		}
+
+		if written[name] || entry.value == entry.Default {
+			continue
+		}
+
+		output.WriteString(name + " " + Quote(entry.value) + "\n")
+		written[name] = true
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("could not scan config file: %w", err)
+	}
+
+	names := make([]string, 0, len(r.parameters))
+	for name, entry := range r.parameters {
+		if !written[name] && entry.value != entry.Default {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	if len(names) > 0 {
+		output.WriteString(rewriteSignature + "\n")
+	}
+
+	for _, name := range names {
+		output.WriteString(name + " " + Quote(r.parameters[name].value) + "\n")
+	}
+
+	return writeFile(r.file, output.Bytes())
+}
+
+// writeFile replaces the file atomically, so a crash never leaves a
+// partially written config.
+func writeFile(path string, contents []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("could not create config file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	_, err = temp.Write(contents)
+	if err != nil {
+		_ = temp.Close()
+
+		return fmt.Errorf("could not write config file: %w", err)
+	}
+
+	err = temp.Close()
+	if err != nil {
+		return fmt.Errorf("could not close config file: %w", err)
+	}
+
+	err = os.Rename(temp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("could not replace config file: %w", err)
+	}
+
+	return nil
+}
+
+// Quote returns the value as a single argument of a config file.
+func Quote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n\"'\\#") {
+		return value
+	}
+
+	var builder strings.Builder
+
+	builder.WriteByte('"')
+
+	for index := 0; index < len(value); index++ {
+		switch char := value[index]; char {
+		case '"', '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(char)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		default:
+			builder.WriteByte(char)
+		}
+	}
+
+	builder.WriteByte('"')
+
+	return builder.String()
+}
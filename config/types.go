@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotInteger = errors.New("argument couldn't be parsed into an integer")
+	ErrOutOfRange = errors.New("argument must be between the minimum and maximum")
+	ErrNotBool    = errors.New("argument must be 'yes' or 'no'")
+	ErrNotEnum    = errors.New("argument(s) must be one of the following")
+	ErrNotMemory  = errors.New("argument must be a memory value")
+)
+
+// Type validates a value and returns it in its canonical form,
+// which is the form reported by CONFIG GET.
+type Type interface {
+	Normalize(value string) (string, error)
+}
+
+type stringType struct{}
+
+func (stringType) Normalize(value string) (string, error) {
+	return value, nil
+}
+
+// String accepts any value.
+func String() Type {
+	return stringType{}
+}
+
+type boolType struct{}
+
+func (boolType) Normalize(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "yes":
+		return "yes", nil
+	case "no":
+		return "no", nil
+	default:
+		return "", ErrNotBool
+	}
+}
+
+// Bool accepts `yes` or `no`.
+func Bool() Type {
+	return boolType{}
+}
+
+type intType struct {
+	min, max int64
+}
+
+func (i intType) Normalize(value string) (string, error) {
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", ErrNotInteger
+	}
+
+	if number < i.min || number > i.max {
+		return "", fmt.Errorf("%w (%d and %d)", ErrOutOfRange, i.min, i.max)
+	}
+
+	return strconv.FormatInt(number, 10), nil
+}
+
+// Int accepts integers within the inclusive range.
+func Int(minimum, maximum int64) Type {
+	return intType{min: minimum, max: maximum}
+}
+
+type enumType struct {
+	values []string
+}
+
+func (e enumType) Normalize(value string) (string, error) {
+	value = strings.ToLower(value)
+
+	if !slices.Contains(e.values, value) {
+		return "", fmt.Errorf("%w: %s", ErrNotEnum, strings.Join(e.values, ", "))
+	}
+
+	return value, nil
+}
+
+// Enum accepts one of the values, case insensitively.
+func Enum(values ...string) Type {
+	lowered := make([]string, 0, len(values))
+	for _, value := range values {
+		lowered = append(lowered, strings.ToLower(value))
+	}
+
+	return enumType{values: lowered}
+}
+
+type memoryType struct{}
+
+var memoryUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"kb", 1024},
+	{"mb", 1024 * 1024},
+	{"gb", 1024 * 1024 * 1024},
+	{"k", 1000},
+	{"m", 1000 * 1000},
+	{"g", 1000 * 1000 * 1000},
+	{"b", 1},
+}
+
+func (memoryType) Normalize(value string) (string, error) {
+	lowered := strings.ToLower(value)
+	multiplier := int64(1)
+
+	for _, unit := range memoryUnits {
+		if strings.HasSuffix(lowered, unit.suffix) {
+			lowered = strings.TrimSuffix(lowered, unit.suffix)
+			multiplier = unit.multiplier
+
+			break
+		}
+	}
+
+	number, err := strconv.ParseInt(lowered, 10, 64)
+	if err != nil || number < 0 {
+		return "", ErrNotMemory
+	}
+
+	return strconv.FormatInt(number*multiplier, 10), nil
+}
+
+// Memory accepts a number of bytes with an optional unit like `100mb`.
+// The canonical form is the number of bytes.
+func Memory() Type {
+	return memoryType{}
+}
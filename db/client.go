@@ -1,63 +1,107 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
+	"sync/atomic"
 
+	"github.com/jtarchie/sqlettuce/db/drivers"
+	"github.com/jtarchie/sqlettuce/db/drivers/memory"
+	"github.com/jtarchie/sqlettuce/db/drivers/postgres"
 	"github.com/jtarchie/sqlettuce/db/drivers/sqlite"
 )
 
 type Client struct {
-	db *sql.DB
+	driver drivers.Driver
+	group  *group
 
-	readers sqlite.Reader
-	writers sqlite.Writer
-	batcher sqlite.Batcher
+	maintenance *maintenance
+
+	observers          []Observer
+	statementObservers []StatementObserver
+
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	retries atomic.Uint64
 }
 
+// Observer is called after keys have been modified.
+// A nil slice of names means that every key was removed.
+type Observer func(ctx context.Context, names []string)
+
 var ErrDriverNotFound = errors.New("could not find driver")
 
+// NewClient opens the database of the DSN, with the driver of its scheme:
+// `sqlite://`, `memory://` or `postgres://`.
 func NewClient(dsn string) (*Client, error) {
 	uri, err := url.Parse(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse DSN (%q): %w", dsn, err)
 	}
 
+	client := &Client{}
+
 	switch uri.Scheme {
 	case "sqlite":
-		driver, err := sqlite.New(uri.Host)
+		driver, err := sqlite.New(dsn)
 		if err != nil {
 			return nil, fmt.Errorf("could not setup sqlite: %w", err)
 		}
 
-		return &Client{
-			db:      driver.DB,
-			readers: driver.Readers,
-			writers: driver.Writers,
-			batcher: driver.Batcher,
-		}, nil
+		driver.Measure = client.measure
+		client.driver = driver
+	case "memory":
+		client.driver = memory.New()
+	case "postgres", "postgresql":
+		driver, err := postgres.New(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("could not setup postgres: %w", err)
+		}
+
+		client.driver = driver
 	default:
 		return nil, fmt.Errorf("could not find a driver for %q: %w", uri.Scheme, ErrDriverNotFound)
 	}
+
+	if grouper, ok := client.driver.(drivers.Grouper); ok {
+		client.group = newGroup(grouper)
+	}
+
+	if maintainer, ok := client.driver.(drivers.Maintainer); ok {
+		journal, _ := client.driver.(drivers.Journaler)
+		client.maintenance = newMaintenance(maintainer, journal, client.driver.Stats)
+	}
+
+	return client, nil
 }
 
 func (c *Client) Close() error {
-	err := c.readers.Close()
-	if err != nil {
-		return fmt.Errorf("could not close readers: %w", err)
+	if c.maintenance != nil {
+		c.maintenance.close()
 	}
 
-	err = c.writers.Close()
-	if err != nil {
-		return fmt.Errorf("could not close writers: %w", err)
+	if c.group != nil {
+		c.group.close()
 	}
 
-	err = c.db.Close()
+	err := c.driver.Close()
 	if err != nil {
-		return fmt.Errorf("could not close db: %w", err)
+		return fmt.Errorf("could not close driver: %w", err)
 	}
 
 	return nil
 }
+
+// Observe registers an observer for every write.
+// It is not safe to call concurrently with writes.
+func (c *Client) Observe(observer Observer) {
+	c.observers = append(c.observers, observer)
+}
+
+func (c *Client) changed(ctx context.Context, names []string) {
+	for _, observer := range c.observers {
+		observer(ctx, names)
+	}
+}
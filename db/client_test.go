@@ -0,0 +1,104 @@
+package db_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	It("fails on unknown drivers", func() {
+		_, err := db.NewClient("unknown://")
+		Expect(err).To(MatchError(db.ErrDriverNotFound))
+	})
+
+	forEachDriver(func(newClient func() *db.Client) {
+		var client *db.Client
+
+		BeforeEach(func() {
+			client = newClient()
+		})
+
+		When("Observe", func() {
+			It("is notified of every written key", func() {
+				var changes [][]string
+
+				client.Observe(func(_ context.Context, names []string) {
+					changes = append(changes, names)
+				})
+
+				err := client.Set(context.TODO(), "key1", "value")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = client.MSet(context.TODO(), "key2", "value", "key3", "value")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = client.AddInt(context.TODO(), "key4", 1)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = client.ListRightPushUpsert(context.TODO(), "key5", "value")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = client.Get(context.TODO(), "key1")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = client.FlushAll(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(changes).To(Equal([][]string{
+					{"key1"},
+					{"key2", "key3"},
+					{"key4"},
+					{"key5"},
+					nil,
+				}))
+			})
+		})
+
+		When("ObserveStatements", func() {
+			It("times every operation", func() {
+				var operations []string
+
+				client.ObserveStatements(func(operation string, _ time.Duration) {
+					operations = append(operations, operation)
+				})
+
+				_, _, err := client.Get(context.TODO(), "key1")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = client.Keys(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(operations).To(Equal([]string{"Get", "Keys"}))
+			})
+		})
+	})
+
+	When("ObserveStatements with sqlite", func() {
+		It("times every operation, with the commits", func() {
+			client, err := db.NewClient("sqlite://:memory:")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(client.Close)
+
+			var operations []string
+
+			client.ObserveStatements(func(operation string, _ time.Duration) {
+				operations = append(operations, operation)
+			})
+
+			err = client.MSet(context.TODO(), "key1", "value", "key2", "value")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.ListRightPush(context.TODO(), "key3", "value")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = client.Get(context.TODO(), "key1")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(operations).To(Equal([]string{"Commit", "MSet", "Commit", "ListRightPush", "Get"}))
+		})
+	})
+})
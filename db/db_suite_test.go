@@ -1,8 +1,12 @@
 package db_test
 
 import (
+	"context"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/jtarchie/sqlettuce/db"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -11,3 +15,36 @@ func TestDb(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Db Suite")
 }
+
+// dsns are the drivers every behavior of the client is verified against.
+// PostgreSQL needs a database, given with SQLETTUCE_POSTGRES_DSN.
+func dsns() []string {
+	dsns := []string{"sqlite://:memory:", "memory://"}
+
+	if dsn := os.Getenv("SQLETTUCE_POSTGRES_DSN"); dsn != "" {
+		dsns = append(dsns, dsn)
+	}
+
+	return dsns
+}
+
+// forEachDriver declares the specs for the client of every driver.
+func forEachDriver(specs func(newClient func() *db.Client)) {
+	for _, dsn := range dsns() {
+		scheme, _, _ := strings.Cut(dsn, ":")
+
+		Context(scheme, func() {
+			specs(func() *db.Client {
+				client, err := db.NewClient(dsn)
+				Expect(err).NotTo(HaveOccurred())
+				DeferCleanup(client.Close)
+
+				// a database server keeps the keys of the previous specs
+				err = client.FlushAll(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+
+				return client
+			})
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Package drivers defines the storage behind the database client.
+// A driver, like SQLite, stores every key with its value as a string, with
+// lists encoded as JSON arrays.
+package drivers
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("record was not found")
+	ErrNotArray   = errors.New("not an array")
+	ErrNotInteger = errors.New("not an integer")
+	ErrNotFloat   = errors.New("not a float")
+
+	// ErrBusy is returned when the storage is locked by another writer,
+	// the operation can be retried.
+	ErrBusy = errors.New("storage is busy")
+
+	// ErrJournalGap is returned when the changes following a database were
+	// removed from the journal.
+	ErrJournalGap = errors.New("the journal does not follow the database")
+)
+
+// Driver stores the keys. Every operation must be atomic.
+type Driver interface {
+	// Set creates or replaces a key.
+	Set(ctx context.Context, name, value string) error
+	// MSet sets every pair of names and values at once.
+	MSet(ctx context.Context, args ...string) error
+	Get(ctx context.Context, name string) (string, bool, error)
+	// MGet returns the value of every key found.
+	MGet(ctx context.Context, names ...string) (map[string]string, error)
+	// Delete returns the values of the keys removed.
+	Delete(ctx context.Context, names ...string) ([]string, error)
+	// Append returns the length of the value once appended.
+	Append(ctx context.Context, name, value string) (int64, error)
+	// Substr returns the part of a value, like SQLite's SUBSTR.
+	Substr(ctx context.Context, name string, start, end int64) (string, error)
+	AddInt(ctx context.Context, name string, value int64) (int64, error)
+	AddFloat(ctx context.Context, name string, value float64) (float64, error)
+	FlushAll(ctx context.Context) error
+	// Keys returns the name of every key, sorted.
+	Keys(ctx context.Context) ([]string, error)
+
+	ListInsert(ctx context.Context, name string, offset int64, pivot, value string) (int64, bool, error)
+	ListRange(ctx context.Context, name string, start, end int64) ([]string, error)
+	ListLength(ctx context.Context, name string) (int64, error)
+	// ListRightPush pushes to an existing list, returning its new length.
+	ListRightPush(ctx context.Context, name string, values ...string) (int64, error)
+	// ListRightPushUpsert pushes to a list, creating it when missing. A value
+	// that is not a list is left as is, with a length of zero.
+	ListRightPushUpsert(ctx context.Context, name string, values ...string) (int64, bool, error)
+	ListSet(ctx context.Context, name string, index int64, value string) (bool, error)
+
+	Stats(ctx context.Context) (*Stats, error)
+	Close() error
+}
+
+// Snapshotter is a driver that can copy its keys to a SQLite file, and
+// replace them with the keys of one.
+type Snapshotter interface {
+	Snapshot(ctx context.Context, path string) error
+	Restore(ctx context.Context, path string) error
+}
+
+// Journaler is a driver that records every change of the keys in a journal,
+// with the time it was made, so the keys can be recovered as of a time.
+type Journaler interface {
+	// SetJournal starts or stops recording the changes.
+	SetJournal(ctx context.Context, enabled bool) error
+	JournalStatus(ctx context.Context) (*JournalStatus, error)
+	// TrimJournal removes the changes made before a time, returning how
+	// many were removed.
+	TrimJournal(ctx context.Context, before time.Time) (int64, error)
+	// Recover applies the changes of the journal of another SQLite file
+	// that follow the last change of this journal, up to a time. It returns
+	// how many changes were applied.
+	Recover(ctx context.Context, path string, to time.Time) (int64, error)
+}
+
+// JournalStatus describes the changes kept in a journal.
+type JournalStatus struct {
+	Enabled bool
+	Entries int64
+	// First and Last are the oldest and latest changes, zero without any.
+	First JournalPosition
+	Last  JournalPosition
+}
+
+// JournalPosition is a change of the journal. Changes are numbered in the
+// order they were made, from one.
+type JournalPosition struct {
+	ID int64
+	At time.Time
+}
+
+// Operation is a modification of the keys, run with the driver given.
+type Operation func(ctx context.Context, driver Driver) error
+
+// Grouper is a driver that can commit the operations of many callers in a
+// single transaction. An operation that fails is rolled back alone, its
+// error is returned at its index. An error of the transaction applies to
+// every operation.
+type Grouper interface {
+	Group(ctx context.Context, operations ...Operation) ([]error, error)
+}
+
+// Maintainer is a driver with maintenance tasks, run in the background.
+type Maintainer interface {
+	// Checkpoint copies the write ahead log to the database, with a mode
+	// of SQLite: PASSIVE, FULL, RESTART or TRUNCATE.
+	Checkpoint(ctx context.Context, mode string) (*Checkpoint, error)
+	// Vacuum returns up to a number of free pages to the file system.
+	Vacuum(ctx context.Context, pages int64) error
+	// Optimize updates the statistics of the query planner, all of them
+	// when analyze is true.
+	Optimize(ctx context.Context, analyze bool) error
+	// EnableAutoVacuum rebuilds a database whose free pages can't be
+	// vacuumed, reporting whether it was rebuilt.
+	EnableAutoVacuum(ctx context.Context) (bool, error)
+}
+
+// Checkpoint is the result of a checkpoint, in frames of the write ahead log.
+type Checkpoint struct {
+	// Busy is true when readers or writers prevented it from completing.
+	Busy         bool
+	Log          int64
+	Checkpointed int64
+}
+
+// KeyIterator is a driver that reads the names of its keys one at a time,
+// so they can be read without loading every one of them.
+type KeyIterator interface {
+	// EachKey calls fn with the name of every key, sorted, until it
+	// returns false.
+	EachKey(ctx context.Context, fn func(name string) bool) error
+}
+
+// Sizer is a driver that reads the size of its database without counting its
+// keys, so it can be checked before every write.
+type Sizer interface {
+	Size(ctx context.Context) (int64, error)
+}
+
+// Pragmas is a driver configured with SQLite PRAGMAs.
+type Pragmas interface {
+	SetPragma(ctx context.Context, name, value string) error
+}
+
+// Stats describe the storage. Drivers leave out what does not apply to them,
+// like the pages of a SQLite file.
+type Stats struct {
+	Keys          int64
+	PageCount     int64
+	PageSize      int64
+	FreelistCount int64
+	Filename      string
+	WALSize       int64
+}
+
+// DatabaseSize is the size of the database in bytes, including free pages.
+func (s *Stats) DatabaseSize() int64 {
+	return s.PageCount * s.PageSize
+}
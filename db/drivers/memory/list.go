@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+// list returns the elements of a list, with false when it does not exist.
+func (d *Driver) list(name string) ([]string, bool, error) {
+	value, ok := d.keys[name]
+	if !ok {
+		return nil, false, nil
+	}
+
+	list, err := drivers.DecodeList(value)
+	if err != nil {
+		return nil, true, err //nolint:wrapcheck
+	}
+
+	return list, true, nil
+}
+
+func (d *Driver) ListInsert(_ context.Context, name string, offset int64, pivot, value string) (int64, bool, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	list, ok, err := d.list(name)
+	if !ok || err != nil {
+		return 0, false, err
+	}
+
+	list = drivers.ListInsert(list, offset, pivot, value)
+	d.keys[name] = drivers.EncodeList(list)
+
+	return int64(len(list)), true, nil
+}
+
+func (d *Driver) ListRange(_ context.Context, name string, start, end int64) ([]string, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	list, _, err := d.list(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return drivers.ListRange(list, start, end), nil
+}
+
+func (d *Driver) ListLength(_ context.Context, name string) (int64, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	list, _, err := d.list(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(list)), nil
+}
+
+func (d *Driver) ListRightPush(_ context.Context, name string, values ...string) (int64, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	list, ok, err := d.list(name)
+	if !ok || err != nil {
+		return 0, err
+	}
+
+	list = append(list, values...)
+	d.keys[name] = drivers.EncodeList(list)
+
+	return int64(len(list)), nil
+}
+
+func (d *Driver) ListRightPushUpsert(_ context.Context, name string, values ...string) (int64, bool, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	list, _, err := d.list(name)
+	if err != nil {
+		return 0, true, nil
+	}
+
+	list = append(list, values...)
+	d.keys[name] = drivers.EncodeList(list)
+
+	return int64(len(list)), true, nil
+}
+
+func (d *Driver) ListSet(_ context.Context, name string, index int64, value string) (bool, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	list, ok, err := d.list(name)
+	if !ok || err != nil {
+		return false, err
+	}
+
+	d.keys[name] = drivers.EncodeList(drivers.ListSet(list, index, value))
+
+	return true, nil
+}
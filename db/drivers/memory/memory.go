@@ -0,0 +1,174 @@
+// Package memory is a driver keeping the keys in a map, they are lost on
+// close. It is meant for tests and servers without data, like sentinels.
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+type Driver struct {
+	mutex sync.RWMutex
+	keys  map[string]string
+}
+
+var _ drivers.Driver = &Driver{}
+
+func New() *Driver {
+	return &Driver{
+		keys: map[string]string{},
+	}
+}
+
+func (d *Driver) Set(_ context.Context, name, value string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.keys[name] = value
+
+	return nil
+}
+
+func (d *Driver) MSet(_ context.Context, args ...string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for index := 0; index < len(args); index += 2 {
+		d.keys[args[index]] = args[index+1]
+	}
+
+	return nil
+}
+
+func (d *Driver) Get(_ context.Context, name string) (string, bool, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	value, ok := d.keys[name]
+
+	return value, ok, nil
+}
+
+func (d *Driver) MGet(_ context.Context, names ...string) (map[string]string, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	values := map[string]string{}
+
+	for _, name := range names {
+		if value, ok := d.keys[name]; ok {
+			values[name] = value
+		}
+	}
+
+	return values, nil
+}
+
+func (d *Driver) Delete(_ context.Context, names ...string) ([]string, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var values []string
+
+	for _, name := range names {
+		if value, ok := d.keys[name]; ok {
+			values = append(values, value)
+			delete(d.keys, name)
+		}
+	}
+
+	return values, nil
+}
+
+func (d *Driver) Append(_ context.Context, name, value string) (int64, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.keys[name] += value
+
+	return int64(len([]rune(d.keys[name]))), nil
+}
+
+func (d *Driver) Substr(_ context.Context, name string, start, end int64) (string, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return drivers.Substr(d.keys[name], start, end), nil
+}
+
+func (d *Driver) AddInt(_ context.Context, name string, value int64) (int64, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	current, ok := d.keys[name]
+	if !ok {
+		current = "0"
+	}
+
+	updated, sum, err := drivers.AddInt(current, value)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	d.keys[name] = updated
+
+	return sum, nil
+}
+
+func (d *Driver) AddFloat(_ context.Context, name string, value float64) (float64, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	current, ok := d.keys[name]
+	if !ok {
+		current = "0"
+	}
+
+	updated, sum, err := drivers.AddFloat(current, value)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	d.keys[name] = updated
+
+	return sum, nil
+}
+
+func (d *Driver) FlushAll(_ context.Context) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	clear(d.keys)
+
+	return nil
+}
+
+func (d *Driver) Keys(_ context.Context) ([]string, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	names := make([]string, 0, len(d.keys))
+	for name := range d.keys {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names, nil
+}
+
+func (d *Driver) Stats(_ context.Context) (*drivers.Stats, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return &drivers.Stats{
+		Keys: int64(len(d.keys)),
+	}, nil
+}
+
+func (d *Driver) Close() error {
+	return nil
+}
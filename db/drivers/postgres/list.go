@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+// list returns the elements of a list, with false when it does not exist.
+func (d *Driver) list(ctx context.Context, name string) ([]string, bool, error) {
+	value, found, err := d.Get(ctx, name)
+	if !found || err != nil {
+		return nil, false, err
+	}
+
+	list, err := drivers.DecodeList(value)
+	if err != nil {
+		return nil, true, err //nolint:wrapcheck
+	}
+
+	return list, true, nil
+}
+
+// modifyList changes the elements of an existing list.
+func (d *Driver) modifyList(ctx context.Context, name string, fn func(list []string) []string) (int64, bool, error) {
+	var (
+		length int64
+		exists bool
+	)
+
+	err := d.modify(ctx, name, func(value string, found bool) (string, bool, error) {
+		if !found {
+			return "", false, nil
+		}
+
+		list, err := drivers.DecodeList(value)
+		if err != nil {
+			return "", false, err //nolint:wrapcheck
+		}
+
+		list = fn(list)
+		length, exists = int64(len(list)), true
+
+		return drivers.EncodeList(list), true, nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return length, exists, nil
+}
+
+func (d *Driver) ListInsert(ctx context.Context, name string, offset int64, pivot, value string) (int64, bool, error) {
+	length, found, err := d.modifyList(ctx, name, func(list []string) []string {
+		return drivers.ListInsert(list, offset, pivot, value)
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("could not execute ListInsert: %w", err)
+	}
+
+	return length, found, nil
+}
+
+func (d *Driver) ListRange(ctx context.Context, name string, start, end int64) ([]string, error) {
+	list, _, err := d.list(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return drivers.ListRange(list, start, end), nil
+}
+
+func (d *Driver) ListLength(ctx context.Context, name string) (int64, error) {
+	list, _, err := d.list(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(list)), nil
+}
+
+func (d *Driver) ListRightPush(ctx context.Context, name string, values ...string) (int64, error) {
+	length, _, err := d.modifyList(ctx, name, func(list []string) []string {
+		return append(list, values...)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not execute ListRightPush: %w", err)
+	}
+
+	return length, nil
+}
+
+func (d *Driver) ListRightPushUpsert(ctx context.Context, name string, values ...string) (int64, bool, error) {
+	var length int64
+
+	err := d.modify(ctx, name, func(value string, found bool) (string, bool, error) {
+		var list []string
+
+		if found {
+			var err error
+
+			list, err = drivers.DecodeList(value)
+			if err != nil {
+				return "", false, nil
+			}
+		}
+
+		list = append(list, values...)
+		length = int64(len(list))
+
+		return drivers.EncodeList(list), true, nil
+	})
+	if err != nil {
+		return 0, true, fmt.Errorf("could not execute ListRightPushUpsert: %w", err)
+	}
+
+	return length, true, nil
+}
+
+func (d *Driver) ListSet(ctx context.Context, name string, index int64, value string) (bool, error) {
+	_, found, err := d.modifyList(ctx, name, func(list []string) []string {
+		return drivers.ListSet(list, index, value)
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not execute ListSet: %w", err)
+	}
+
+	return found, nil
+}
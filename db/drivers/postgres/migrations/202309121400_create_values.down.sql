@@ -0,0 +1 @@
+DROP TABLE keys;
\ No newline at end of file
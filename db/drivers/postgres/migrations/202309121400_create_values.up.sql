@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS keys (
+  name BYTEA NOT NULL PRIMARY KEY,
+  value BYTEA NOT NULL
+);
@@ -0,0 +1,246 @@
+// Package postgres is a driver storing the keys in a PostgreSQL table.
+// Values are changed in transactions, with the semantics of the SQLite
+// driver shared through the drivers package.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+	"github.com/lib/pq"
+)
+
+type Driver struct {
+	db *sql.DB
+}
+
+var _ drivers.Driver = &Driver{}
+
+func (d *Driver) Set(ctx context.Context, name, value string) error {
+	_, err := d.db.ExecContext(ctx, setQuery, []byte(name), []byte(value))
+	if err != nil {
+		return fmt.Errorf("could not execute Set: %w", busy(err))
+	}
+
+	return nil
+}
+
+func (d *Driver) MSet(ctx context.Context, args ...string) error {
+	err := d.transaction(ctx, func(transaction *sql.Tx) error {
+		for index := 0; index < len(args); index += 2 {
+			_, err := transaction.ExecContext(ctx, setQuery, []byte(args[index]), []byte(args[index+1]))
+			if err != nil {
+				return fmt.Errorf("could not execute Set: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not execute MSet: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Driver) Get(ctx context.Context, name string) (string, bool, error) {
+	var value []byte
+
+	err := d.db.QueryRowContext(ctx, getQuery, []byte(name)).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("could not execute Get: %w", err)
+	}
+
+	return string(value), true, nil
+}
+
+func (d *Driver) MGet(ctx context.Context, names ...string) (map[string]string, error) {
+	rows, err := d.db.QueryContext(ctx, mgetQuery, byteaArray(names))
+	if err != nil {
+		return nil, fmt.Errorf("could not execute MGet: %w", err)
+	}
+	defer rows.Close()
+
+	values := map[string]string{}
+
+	for rows.Next() {
+		var name, value []byte
+
+		err = rows.Scan(&name, &value)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan MGet: %w", err)
+		}
+
+		values[string(name)] = string(value)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("could not execute MGet: %w", rows.Err())
+	}
+
+	return values, nil
+}
+
+func (d *Driver) Delete(ctx context.Context, names ...string) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, deleteQuery, byteaArray(names))
+	if err != nil {
+		return nil, fmt.Errorf("could not execute Delete: %w", busy(err))
+	}
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var value []byte
+
+		err = rows.Scan(&value)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan Delete: %w", err)
+		}
+
+		values = append(values, string(value))
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("could not execute Delete: %w", busy(rows.Err()))
+	}
+
+	return values, nil
+}
+
+func (d *Driver) Append(ctx context.Context, name, value string) (int64, error) {
+	var length int64
+
+	err := d.modify(ctx, name, func(current string, _ bool) (string, bool, error) {
+		current += value
+		length = int64(len([]rune(current)))
+
+		return current, true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not execute Append: %w", err)
+	}
+
+	return length, nil
+}
+
+func (d *Driver) Substr(ctx context.Context, name string, start, end int64) (string, error) {
+	value, _, err := d.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	return drivers.Substr(value, start, end), nil
+}
+
+func (d *Driver) AddInt(ctx context.Context, name string, value int64) (int64, error) {
+	var sum int64
+
+	err := d.modify(ctx, name, func(current string, found bool) (string, bool, error) {
+		if !found {
+			current = "0"
+		}
+
+		updated, result, err := drivers.AddInt(current, value)
+		sum = result
+
+		return updated, true, err //nolint:wrapcheck
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not execute AddInt: %w", err)
+	}
+
+	return sum, nil
+}
+
+func (d *Driver) AddFloat(ctx context.Context, name string, value float64) (float64, error) {
+	var sum float64
+
+	err := d.modify(ctx, name, func(current string, found bool) (string, bool, error) {
+		if !found {
+			current = "0"
+		}
+
+		updated, result, err := drivers.AddFloat(current, value)
+		sum = result
+
+		return updated, true, err //nolint:wrapcheck
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not execute AddFloat: %w", err)
+	}
+
+	return sum, nil
+}
+
+func (d *Driver) FlushAll(ctx context.Context) error {
+	_, err := d.db.ExecContext(ctx, flushAllQuery)
+	if err != nil {
+		return fmt.Errorf("could not execute FlushAll: %w", busy(err))
+	}
+
+	return nil
+}
+
+func (d *Driver) Keys(ctx context.Context) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, keysQuery)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute Keys: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name []byte
+
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan Keys: %w", err)
+		}
+
+		names = append(names, string(name))
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("could not execute Keys: %w", rows.Err())
+	}
+
+	return names, nil
+}
+
+func (d *Driver) Stats(ctx context.Context) (*drivers.Stats, error) {
+	stats := &drivers.Stats{}
+
+	err := d.db.QueryRowContext(ctx, countKeysQuery).Scan(&stats.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("could not count keys: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (d *Driver) Close() error {
+	err := d.db.Close()
+	if err != nil {
+		return fmt.Errorf("could not close db: %w", err)
+	}
+
+	return nil
+}
+
+func byteaArray(names []string) pq.ByteaArray {
+	array := make(pq.ByteaArray, 0, len(names))
+	for _, name := range names {
+		array = append(array, []byte(name))
+	}
+
+	return array
+}
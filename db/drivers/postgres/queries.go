@@ -0,0 +1,21 @@
+package postgres
+
+// Names and values are BYTEA, as redis values can be any bytes.
+const (
+	setQuery = `
+		INSERT INTO keys (name, value)
+		VALUES ($1, $2) ON CONFLICT (name) DO
+		UPDATE
+		SET value = excluded.value`
+	insertQuery = `
+		INSERT INTO keys (name, value)
+		VALUES ($1, $2) ON CONFLICT (name) DO NOTHING`
+	getQuery       = `SELECT value FROM keys WHERE name = $1`
+	getForUpdate   = `SELECT value FROM keys WHERE name = $1 FOR UPDATE`
+	updateQuery    = `UPDATE keys SET value = $2 WHERE name = $1`
+	mgetQuery      = `SELECT name, value FROM keys WHERE name = ANY($1)`
+	deleteQuery    = `DELETE FROM keys WHERE name = ANY($1) RETURNING value`
+	flushAllQuery  = `DELETE FROM keys`
+	keysQuery      = `SELECT name FROM keys ORDER BY name`
+	countKeysQuery = `SELECT COUNT(*) FROM keys`
+)
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	// load the postgres driver.
+	_ "github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// New connects to the database of a `postgres://` DSN, creating the tables.
+func New(dsn string) (*Driver, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("could not open db: %w", err)
+	}
+
+	migrationsFS, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("could not wrap migrations: %w", err)
+	}
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("could not wrap driver: %w", err)
+	}
+
+	migrator, err := migrate.NewWithInstance(
+		"iofs", migrationsFS,
+		"postgres", driver,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not setup migrations: %w", err)
+	}
+
+	err = migrator.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return nil, fmt.Errorf("could not migrate up: %w", err)
+	}
+
+	return &Driver{db: db}, nil
+}
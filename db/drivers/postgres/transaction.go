@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+	"github.com/lib/pq"
+)
+
+// transaction runs the function in a transaction, which is committed when
+// no error is returned.
+func (d *Driver) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	transaction, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	//nolint:errcheck
+	defer transaction.Rollback()
+
+	err = fn(transaction)
+	if err != nil {
+		return busy(err)
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return fmt.Errorf("could not commit transaction: %w", busy(err))
+	}
+
+	return nil
+}
+
+// errInserted is returned when a missing key was created by another
+// transaction, so its value must be read again.
+var errInserted = errors.New("key was inserted")
+
+// modify changes the value of a key, which is locked until it is stored.
+// The function returns if the value should be stored.
+func (d *Driver) modify(
+	ctx context.Context,
+	name string,
+	fn func(value string, found bool) (string, bool, error),
+) error {
+	for {
+		err := d.transaction(ctx, func(transaction *sql.Tx) error {
+			var value []byte
+
+			err := transaction.QueryRowContext(ctx, getForUpdate, []byte(name)).Scan(&value)
+
+			found := !errors.Is(err, sql.ErrNoRows)
+			if found && err != nil {
+				return fmt.Errorf("could not lock key: %w", err)
+			}
+
+			updated, store, err := fn(string(value), found)
+			if err != nil || !store {
+				return err
+			}
+
+			if found {
+				_, err = transaction.ExecContext(ctx, updateQuery, []byte(name), []byte(updated))
+				if err != nil {
+					return fmt.Errorf("could not update key: %w", err)
+				}
+
+				return nil
+			}
+
+			result, err := transaction.ExecContext(ctx, insertQuery, []byte(name), []byte(updated))
+			if err != nil {
+				return fmt.Errorf("could not insert key: %w", err)
+			}
+
+			if inserted, _ := result.RowsAffected(); inserted == 0 {
+				return errInserted
+			}
+
+			return nil
+		})
+		if !errors.Is(err, errInserted) {
+			return err
+		}
+	}
+}
+
+// busy marks the errors of transactions that can be retried, like deadlocks.
+func busy(err error) error {
+	var pqErr *pq.Error
+
+	if errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "40P01") {
+		return fmt.Errorf("%w: %w", drivers.ErrBusy, err)
+	}
+
+	return err
+}
@@ -4,7 +4,16 @@
 
 package batch
 
-import ()
+import (
+	"database/sql"
+)
+
+type Journal struct {
+	ID    int64
+	At    int64
+	Name  sql.NullString
+	Value sql.NullString
+}
 
 type Key struct {
 	Name  string
@@ -0,0 +1,220 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingFilename = errors.New("missing filename")
+	ErrInvalidOption   = errors.New("invalid option")
+)
+
+// Pragma is a setting of the connection, run when the database is opened.
+type Pragma struct {
+	Name  string
+	Value string
+}
+
+// Config is the database of a `sqlite://` DSN.
+type Config struct {
+	// Filename is a path, or `:memory:`.
+	Filename string
+	// Params are passed to SQLite, like `cache=shared` and `mode=memory`.
+	Params url.Values
+	// Pragmas are run in order once the database is opened.
+	Pragmas []Pragma
+}
+
+// DefaultPragmas are run when the DSN does not set them.
+// https://www.sqlite.org/compile.html
+// https://phiresky.github.io/blog/2020/sqlite-performance-tuning/
+func DefaultPragmas() []Pragma {
+	return []Pragma{
+		{Name: "busy_timeout", Value: "5000"},
+		{Name: "journal_mode", Value: "WAL"},
+		{Name: "synchronous", Value: "NORMAL"},
+	}
+}
+
+// options are the settings of the DSN that are PRAGMAs. The driver style
+// names, like `_journal_mode`, are accepted, too, the plain name wins when
+// both are given.
+var options = map[string]func(value string) (string, error){
+	"busy_timeout": milliseconds,
+	"journal_mode": oneOf("DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"),
+	"synchronous":  oneOf("OFF", "NORMAL", "FULL", "EXTRA", "0", "1", "2", "3"),
+	"mmap_size":    bytes,
+}
+
+// ParseDSN translates a DSN, like `sqlite:///var/lib/sqlettuce/data.db`.
+// The host and path are a relative path, unless the host is empty.
+func ParseDSN(dsn string) (*Config, error) {
+	uri, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse DSN (%q): %w", dsn, err)
+	}
+
+	config := &Config{
+		Filename: uri.Host + uri.Path,
+		Params:   url.Values{},
+		Pragmas:  DefaultPragmas(),
+	}
+
+	// like `sqlite:test.db`
+	if uri.Opaque != "" {
+		config.Filename = uri.Opaque
+	}
+
+	if config.Filename == "" {
+		return nil, fmt.Errorf("could not parse DSN (%q): %w", dsn, ErrMissingFilename)
+	}
+
+	query := uri.Query()
+
+	for _, name := range optionOrder(query) {
+		values := query[name]
+
+		option, ok := options[strings.TrimPrefix(name, "_")]
+		if !ok {
+			config.Params[name] = values
+
+			continue
+		}
+
+		value, err := option(values[len(values)-1])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %q: %w", name, err)
+		}
+
+		config.setPragma(strings.TrimPrefix(name, "_"), value)
+	}
+
+	return config, nil
+}
+
+// optionOrder sorts the names of the query, so the options are set in the
+// same order on every run. A driver style name comes before its plain name,
+// which wins when both are given.
+func optionOrder(query url.Values) []string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		trimmedA, trimmedB := strings.TrimPrefix(a, "_"), strings.TrimPrefix(b, "_")
+		if trimmedA != trimmedB {
+			return strings.Compare(trimmedA, trimmedB)
+		}
+
+		// the driver style name, the longer one, first
+		return len(b) - len(a)
+	})
+
+	return names
+}
+
+func (c *Config) setPragma(name, value string) {
+	index := slices.IndexFunc(c.Pragmas, func(pragma Pragma) bool {
+		return pragma.Name == name
+	})
+	if index < 0 {
+		c.Pragmas = append(c.Pragmas, Pragma{Name: name, Value: value})
+
+		return
+	}
+
+	c.Pragmas[index].Value = value
+}
+
+// DriverDSN is the URI filename given to the SQLite driver.
+// https://www.sqlite.org/uri.html
+func (c *Config) DriverDSN() string {
+	// the filename is decoded, as a URI
+	dsn := "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(c.Filename)
+	if len(c.Params) > 0 {
+		dsn += "?" + c.Params.Encode()
+	}
+
+	return dsn
+}
+
+// Memory is true when the database only exists in memory, it cannot be
+// opened read only by other connections.
+func (c *Config) Memory() bool {
+	return c.Filename == ":memory:" || c.Params.Get("mode") == "memory"
+}
+
+// ReaderDSN is the URI filename of the read only connections. Unlike the
+// writer, where they run once, their PRAGMAs are set by the driver on every
+// connection of the pool.
+func (c *Config) ReaderDSN() string {
+	reader := &Config{
+		Filename: c.Filename,
+		Params:   url.Values{},
+	}
+
+	for name, values := range c.Params {
+		reader.Params[name] = values
+	}
+
+	reader.Params.Set("mode", "ro")
+
+	for name, values := range readerParams(c.Pragma("busy_timeout")) {
+		reader.Params[name] = values
+	}
+
+	return reader.DriverDSN()
+}
+
+// Pragma is the value of a PRAGMA, empty when it is not set.
+func (c *Config) Pragma(name string) string {
+	for _, pragma := range c.Pragmas {
+		if pragma.Name == name {
+			return pragma.Value
+		}
+	}
+
+	return ""
+}
+
+func oneOf(allowed ...string) func(string) (string, error) {
+	return func(value string) (string, error) {
+		value = strings.ToUpper(value)
+		if !slices.Contains(allowed, value) {
+			return "", fmt.Errorf("%w: %q is not one of %s", ErrInvalidOption, value, strings.Join(allowed, ", "))
+		}
+
+		return value, nil
+	}
+}
+
+// milliseconds accepts a number of milliseconds, or a duration like `5s`.
+func milliseconds(value string) (string, error) {
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err == nil && number >= 0 {
+		return strconv.FormatInt(number, 10), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return "", fmt.Errorf("%w: %q is not a duration", ErrInvalidOption, value)
+	}
+
+	return strconv.FormatInt(duration.Milliseconds(), 10), nil
+}
+
+func bytes(value string) (string, error) {
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number < 0 {
+		return "", fmt.Errorf("%w: %q is not a size", ErrInvalidOption, value)
+	}
+
+	return strconv.FormatInt(number, 10), nil
+}
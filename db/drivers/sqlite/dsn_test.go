@@ -0,0 +1,131 @@
+package sqlite_test
+
+import (
+	"net/url"
+	"path/filepath"
+
+	"github.com/jtarchie/sqlettuce/db/drivers/sqlite"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DSN", func() {
+	DescribeTable("filenames",
+		func(dsn, filename, driverDSN string) {
+			config, err := sqlite.ParseDSN(dsn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Filename).To(Equal(filename))
+			Expect(config.DriverDSN()).To(Equal(driverDSN))
+		},
+		Entry("relative", "sqlite://test.db", "test.db", "file:test.db"),
+		Entry("relative path", "sqlite://data/test.db", "data/test.db", "file:data/test.db"),
+		Entry("absolute path", "sqlite:///var/lib/sqlettuce/data.db", "/var/lib/sqlettuce/data.db", "file:/var/lib/sqlettuce/data.db"),
+		Entry("opaque", "sqlite:test.db", "test.db", "file:test.db"),
+		Entry("in memory", "sqlite://:memory:", ":memory:", "file::memory:"),
+		Entry("driver options", "sqlite://:memory:?cache=shared&mode=memory", ":memory:", "file::memory:?cache=shared&mode=memory"),
+		Entry("escaped", "sqlite:///tmp/a%3Fb.db", "/tmp/a?b.db", "file:/tmp/a%3fb.db"),
+	)
+
+	It("maps options to PRAGMAs", func() {
+		config, err := sqlite.ParseDSN("sqlite://test.db?journal_mode=delete&_synchronous=full&busy_timeout=2s&mmap_size=1024")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Params).To(BeEmpty())
+		Expect(config.Pragmas).To(Equal([]sqlite.Pragma{
+			{Name: "busy_timeout", Value: "2000"},
+			{Name: "journal_mode", Value: "DELETE"},
+			{Name: "synchronous", Value: "FULL"},
+			{Name: "mmap_size", Value: "1024"},
+		}))
+	})
+
+	It("prefers the plain names over the driver style ones", func() {
+		for _, dsn := range []string{
+			"sqlite://test.db?_journal_mode=delete&journal_mode=truncate&_mmap_size=1&mmap_size=2&_busy_timeout=1",
+			"sqlite://test.db?journal_mode=truncate&_journal_mode=delete&mmap_size=2&_mmap_size=1&_busy_timeout=1",
+		} {
+			config, err := sqlite.ParseDSN(dsn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Pragmas).To(Equal([]sqlite.Pragma{
+				{Name: "busy_timeout", Value: "1"},
+				{Name: "journal_mode", Value: "TRUNCATE"},
+				{Name: "synchronous", Value: "NORMAL"},
+				{Name: "mmap_size", Value: "2"},
+			}))
+		}
+	})
+
+	It("defaults the PRAGMAs", func() {
+		config, err := sqlite.ParseDSN("sqlite://test.db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Params).To(Equal(url.Values{}))
+		Expect(config.Pragmas).To(Equal([]sqlite.Pragma{
+			{Name: "busy_timeout", Value: "5000"},
+			{Name: "journal_mode", Value: "WAL"},
+			{Name: "synchronous", Value: "NORMAL"},
+		}))
+	})
+
+	It("opens the readers read only", func() {
+		config, err := sqlite.ParseDSN("sqlite://test.db?cache=private&busy_timeout=2s")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Memory()).To(BeFalse())
+
+		uri, err := url.Parse(config.ReaderDSN())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uri.Opaque).To(Equal("test.db"))
+		Expect(uri.Query().Get("mode")).To(Equal("ro"))
+		Expect(uri.Query().Get("cache")).To(Equal("private"))
+		Expect(uri.RawQuery).To(ContainSubstring("2000"))
+		Expect(config.DriverDSN()).To(Equal("file:test.db?cache=private"))
+	})
+
+	DescribeTable("databases in memory",
+		func(dsn string, memory bool) {
+			config, err := sqlite.ParseDSN(dsn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Memory()).To(Equal(memory))
+		},
+		Entry("memory filename", "sqlite://:memory:", true),
+		Entry("memory mode", "sqlite://test?mode=memory&cache=shared", true),
+		Entry("file", "sqlite://test.db", false),
+	)
+
+	DescribeTable("invalid DSNs",
+		func(dsn string, expected error) {
+			_, err := sqlite.ParseDSN(dsn)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("without a filename", "sqlite://", sqlite.ErrMissingFilename),
+		Entry("unknown journal mode", "sqlite://test.db?journal_mode=fast", sqlite.ErrInvalidOption),
+		Entry("negative busy timeout", "sqlite://test.db?busy_timeout=-1", sqlite.ErrInvalidOption),
+		Entry("invalid mmap size", "sqlite://test.db?mmap_size=large", sqlite.ErrInvalidOption),
+	)
+
+	It("opens the database with the PRAGMAs", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "data.db")
+
+		driver, err := sqlite.New("sqlite://" + path + "?journal_mode=truncate&busy_timeout=1000")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(driver.Close)
+
+		Expect(path).To(BeAnExistingFile())
+
+		var journalMode string
+
+		err = driver.DB.QueryRow("PRAGMA journal_mode").Scan(&journalMode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(journalMode).To(Equal("truncate"))
+
+		var busyTimeout int
+
+		err = driver.DB.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(busyTimeout).To(Equal(1000))
+
+		var autoVacuum int
+
+		err = driver.DB.QueryRow("PRAGMA auto_vacuum").Scan(&autoVacuum)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(autoVacuum).To(Equal(2), "incremental")
+	})
+})
@@ -0,0 +1,287 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+// journalNow is the time of a change, in milliseconds.
+const journalNow = "CAST(unixepoch('subsec') * 1000 AS INTEGER)"
+
+// journalTriggers record every change of the keys in the journal, whatever
+// the statement making it. A key that was removed has a NULL value, and a
+// change without a name removes every key.
+var journalTriggers = map[string]string{
+	"journal_insert": `CREATE TRIGGER IF NOT EXISTS journal_insert AFTER INSERT ON keys BEGIN
+		INSERT INTO journal (at, name, value) VALUES (` + journalNow + `, new.name, new.value);
+	END`,
+	"journal_update": `CREATE TRIGGER IF NOT EXISTS journal_update AFTER UPDATE ON keys BEGIN
+		INSERT INTO journal (at, name, value) VALUES (` + journalNow + `, new.name, new.value);
+	END`,
+	"journal_delete": `CREATE TRIGGER IF NOT EXISTS journal_delete AFTER DELETE ON keys BEGIN
+		INSERT INTO journal (at, name, value) VALUES (` + journalNow + `, old.name, NULL);
+	END`,
+}
+
+// SetJournal creates or drops the triggers recording the changes. The
+// triggers are part of the file, so the journal stays enabled when the file
+// is opened again, or copied by a snapshot.
+func (d *Driver) SetJournal(ctx context.Context, enabled bool) error {
+	err := d.transaction(ctx, func(transaction *sql.Tx) error {
+		triggers, err := countJournalTriggers(ctx, transaction)
+		if err != nil {
+			return err
+		}
+
+		if !enabled {
+			return dropJournalTriggers(ctx, transaction)
+		}
+
+		if triggers == len(journalTriggers) {
+			return nil
+		}
+
+		// the changes made while the journal was disabled are unknown, so
+		// it starts over from the keys
+		_, err = transaction.ExecContext(ctx, `
+			INSERT INTO journal (at, name, value) VALUES (`+journalNow+`, NULL, NULL);
+			INSERT INTO journal (at, name, value) SELECT `+journalNow+`, name, value FROM keys ORDER BY name;
+		`)
+		if err != nil {
+			return fmt.Errorf("could not record keys: %w", err)
+		}
+
+		for name, trigger := range journalTriggers {
+			_, err = transaction.ExecContext(ctx, trigger)
+			if err != nil {
+				return fmt.Errorf("could not create trigger %s: %w", name, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not set journal: %w", err)
+	}
+
+	return nil
+}
+
+func countJournalTriggers(ctx context.Context, transaction *sql.Tx) (int, error) {
+	var triggers int
+
+	err := transaction.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'trigger' AND tbl_name = 'keys' AND name LIKE 'journal\_%' ESCAPE '\'
+	`).Scan(&triggers)
+	if err != nil {
+		return 0, fmt.Errorf("could not read triggers: %w", err)
+	}
+
+	return triggers, nil
+}
+
+func dropJournalTriggers(ctx context.Context, transaction *sql.Tx) error {
+	for name := range journalTriggers {
+		_, err := transaction.ExecContext(ctx, "DROP TRIGGER IF EXISTS "+name)
+		if err != nil {
+			return fmt.Errorf("could not drop trigger %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// JournalStatus counts the changes from their numbers, as they follow each
+// other without gaps.
+func (d *Driver) JournalStatus(ctx context.Context) (*drivers.JournalStatus, error) {
+	var (
+		status          drivers.JournalStatus
+		triggers        int
+		first, last     int64
+		firstAt, lastAt int64
+	)
+
+	err := d.DB.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND tbl_name = 'keys' AND name LIKE 'journal\_%' ESCAPE '\'),
+			COALESCE(MIN(id), 0), COALESCE(MAX(id), 0),
+			COALESCE((SELECT at FROM journal ORDER BY id LIMIT 1), 0),
+			COALESCE((SELECT at FROM journal ORDER BY id DESC LIMIT 1), 0)
+		FROM journal
+	`).Scan(&triggers, &first, &last, &firstAt, &lastAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not read journal: %w", err)
+	}
+
+	status.Enabled = triggers == len(journalTriggers)
+
+	if last > 0 {
+		status.Entries = last - first + 1
+		status.First = drivers.JournalPosition{ID: first, At: time.UnixMilli(firstAt)}
+		status.Last = drivers.JournalPosition{ID: last, At: time.UnixMilli(lastAt)}
+	}
+
+	return &status, nil
+}
+
+// TrimJournal removes the oldest changes, by their time.
+func (d *Driver) TrimJournal(ctx context.Context, before time.Time) (int64, error) {
+	result, err := d.DB.ExecContext(ctx, "DELETE FROM journal WHERE at < ?", before.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("could not trim journal: %w", err)
+	}
+
+	trimmed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not count trimmed changes: %w", err)
+	}
+
+	return trimmed, nil
+}
+
+// latestChanges is the last change of every key between two changes,
+// excluded, of the source journal.
+const latestChanges = `
+	SELECT name, value FROM (
+		SELECT name, value, ROW_NUMBER() OVER (PARTITION BY name ORDER BY id DESC) AS latest
+		FROM source.journal
+		WHERE id > ? AND id < ? AND name IS NOT NULL
+	)
+	WHERE latest = 1
+`
+
+// Recover only applies the last change of every key, after the last change
+// removing every key. The changes are copied to the journal, with their
+// numbers, so it follows the journal of the other file. The triggers are
+// dropped, so applying the changes does not record them again.
+func (d *Driver) Recover(ctx context.Context, path string, to time.Time) (int64, error) {
+	// attached databases are per connection
+	conn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not get connection: %w", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "ATTACH DATABASE ? AS source", path)
+	if err != nil {
+		return 0, fmt.Errorf("could not attach journal: %w", err)
+	}
+
+	//nolint:contextcheck
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "DETACH DATABASE source")
+	}()
+
+	transaction, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() { _ = transaction.Rollback() }()
+
+	var (
+		position int64
+		next     sql.NullInt64
+		last     int64
+		end      int64
+		reset    sql.NullInt64
+	)
+
+	err = transaction.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM main.journal").Scan(&position)
+	if err != nil {
+		return 0, fmt.Errorf("could not read journal: %w", err)
+	}
+
+	err = transaction.QueryRowContext(ctx, "SELECT MIN(id) FROM source.journal WHERE id > ?", position).Scan(&next)
+	if err != nil {
+		return 0, fmt.Errorf("could not read journal to recover: %w", err)
+	}
+
+	// the numbers of the changes are kept once they are removed
+	err = transaction.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(seq), 0) FROM source.sqlite_sequence WHERE name = 'journal'",
+	).Scan(&last)
+	if err != nil {
+		return 0, fmt.Errorf("could not read journal to recover: %w", err)
+	}
+
+	if last > position && (!next.Valid || next.Int64 != position+1) {
+		return 0, fmt.Errorf("could not recover after change %d: %w", position, drivers.ErrJournalGap)
+	}
+
+	// the first change after the time, which is not applied
+	err = transaction.QueryRowContext(ctx, `
+		SELECT COALESCE(MIN(id), (SELECT COALESCE(MAX(id), 0) + 1 FROM source.journal))
+		FROM source.journal WHERE id > ? AND at > ?
+	`, position, to.UnixMilli()).Scan(&end)
+	if err != nil {
+		return 0, fmt.Errorf("could not read journal to recover: %w", err)
+	}
+
+	err = dropJournalTriggers(ctx, transaction)
+	if err != nil {
+		return 0, err
+	}
+
+	if end <= position+1 {
+		return 0, commit(transaction)
+	}
+
+	err = transaction.QueryRowContext(ctx,
+		"SELECT MAX(id) FROM source.journal WHERE id > ? AND id < ? AND name IS NULL", position, end,
+	).Scan(&reset)
+	if err != nil {
+		return 0, fmt.Errorf("could not read journal to recover: %w", err)
+	}
+
+	start := position
+
+	if reset.Valid {
+		start = reset.Int64
+
+		_, err = transaction.ExecContext(ctx, "DELETE FROM main.keys")
+		if err != nil {
+			return 0, fmt.Errorf("could not delete keys: %w", err)
+		}
+	}
+
+	_, err = transaction.ExecContext(ctx,
+		"DELETE FROM main.keys WHERE name IN (SELECT name FROM ("+latestChanges+") WHERE value IS NULL)",
+		start, end,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("could not delete keys: %w", err)
+	}
+
+	_, err = transaction.ExecContext(ctx, `
+		INSERT INTO main.keys (name, value)
+		SELECT name, value FROM (`+latestChanges+`) WHERE value IS NOT NULL
+		ON CONFLICT(name) DO UPDATE SET value = excluded.value
+	`, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("could not set keys: %w", err)
+	}
+
+	_, err = transaction.ExecContext(ctx, `
+		INSERT INTO main.journal (id, at, name, value)
+		SELECT id, at, name, value FROM source.journal WHERE id > ? AND id < ? ORDER BY id
+	`, position, end)
+	if err != nil {
+		return 0, fmt.Errorf("could not copy journal: %w", err)
+	}
+
+	return end - position - 1, commit(transaction)
+}
+
+func commit(transaction *sql.Tx) error {
+	err := transaction.Commit()
+	if err != nil {
+		return fmt.Errorf("could not commit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+func (d *Driver) FlushAll(ctx context.Context) error {
+	err := d.Writers.FlushAll(ctx)
+	if err != nil {
+		return fmt.Errorf("could not execute FlushAll: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Driver) Keys(ctx context.Context) ([]string, error) {
+	var names []string
+
+	err := d.EachKey(ctx, func(name string) bool {
+		names = append(names, name)
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func (d *Driver) EachKey(ctx context.Context, fn func(name string) bool) error {
+	rows, err := d.ReadersDB.QueryContext(ctx, "SELECT name FROM keys ORDER BY name")
+	if err != nil {
+		return fmt.Errorf("could not execute Keys: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+		if err != nil {
+			return fmt.Errorf("could not scan Keys: %w", err)
+		}
+
+		if !fn(name) {
+			break
+		}
+	}
+
+	if rows.Err() != nil {
+		return fmt.Errorf("could not execute Keys: %w", rows.Err())
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/writers"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+func (d *Driver) ListInsert(
+	ctx context.Context,
+	name string,
+	offset int64,
+	pivot, value string,
+) (int64, bool, error) {
+	row := d.execer().QueryRowContext(ctx, `
+	-- name: ListIndex :one
+	 UPDATE keys
+	 SET value = json_array_insert(
+	 		value,
+			?4,
+			?2,
+			?3
+		)
+	 WHERE name = ?1
+	 RETURNING json_array_length(value);
+	`, name, value, offset, pivot)
+	if row.Err() != nil {
+		return 0, false, fmt.Errorf("could not execute ListInsert: %w", row.Err())
+	}
+
+	var newOffset int64
+
+	err := row.Scan(&newOffset)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("could not scan ListInsert: %w", err)
+	}
+
+	return newOffset, true, nil
+}
+
+func (d *Driver) ListRange(ctx context.Context, name string, start, end int64) ([]string, error) {
+	rows, err := d.ReadersDB.QueryContext(ctx, `
+	-- name: ListRange :many
+		SELECT json_each.value
+		FROM keys,
+			json_each(keys.value)
+		WHERE keys.name = ?1
+		AND json_each.key >= IIF(?2 >=0, ?2, json_array_length(keys.value) + ?2)
+		AND json_each.key <= IIF(?3 >=0, ?3, json_array_length(keys.value) + ?3);
+	`, name, start, end)
+	if isMalformedJSON(err) {
+		return nil, drivers.ErrNotArray
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not execute ListRange: %w", err)
+	}
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var value string
+
+		_ = rows.Scan(&value)
+		values = append(values, value)
+	}
+
+	if isMalformedJSON(rows.Err()) {
+		return nil, drivers.ErrNotArray
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("could not execute ListRange: %w", rows.Err())
+	}
+
+	return values, nil
+}
+
+func (d *Driver) ListLength(ctx context.Context, name string) (int64, error) {
+	length, err := d.Readers.ListLength(ctx, name)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not execute ListLength: %w", err)
+	}
+
+	if value, ok := length.(int64); ok {
+		return value, nil
+	}
+
+	return 0, drivers.ErrNotFound
+}
+
+func (d *Driver) ListRightPush(ctx context.Context, name string, values ...string) (int64, error) {
+	var result writers.ListRightPushRow
+
+	err := d.transaction(ctx, func(transaction *sql.Tx) error {
+		queries := d.Writers.WithTx(transaction)
+
+		for _, value := range values {
+			var err error
+
+			result, err = queries.ListRightPush(ctx, &writers.ListRightPushParams{
+				Name:  name,
+				Value: value,
+			})
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+
+	if isMalformedJSON(err) {
+		return 0, drivers.ErrNotArray
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not execute ListRightPush: %w", err)
+	}
+
+	return result.Column2, nil
+}
+
+func (d *Driver) ListRightPushUpsert(ctx context.Context, name string, values ...string) (int64, bool, error) {
+	var result writers.ListRightPushUpsertRow
+
+	err := d.transaction(ctx, func(transaction *sql.Tx) error {
+		queries := d.Writers.WithTx(transaction)
+
+		for _, value := range values {
+			var err error
+
+			result, err = queries.ListRightPushUpsert(ctx, &writers.ListRightPushUpsertParams{
+				Name:  name,
+				Value: value,
+			})
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+		}
+
+		return nil
+	})
+
+	if isMalformedJSON(err) {
+		return 0, true, nil
+	}
+
+	if err != nil {
+		return 0, true, fmt.Errorf("could not execute ListRightPushUpsert: %w", err)
+	}
+
+	return result.Column2, result.Column1, nil
+}
+
+func (d *Driver) ListSet(ctx context.Context, name string, index int64, value string) (bool, error) {
+	valid, err := d.Writers.ListSet(ctx, &writers.ListSetParams{
+		Name:  name,
+		Index: index,
+		Value: value,
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not execute ListSet: %w", err)
+	}
+
+	if value, ok := valid.(int64); ok {
+		return value == 1, nil
+	}
+
+	return false, nil
+}
+
+// isMalformedJSON reports if the value of a key is not a list.
+func isMalformedJSON(err error) bool {
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && sqliteErr.Error() == "malformed JSON"
+}
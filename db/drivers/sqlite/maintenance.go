@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+var checkpointMode = oneOf("PASSIVE", "FULL", "RESTART", "TRUNCATE")
+
+// Checkpoint runs on the writer connection, so it never waits on a write.
+// The log is only checkpointed here, as automatic checkpoints are disabled.
+// https://www.sqlite.org/pragma.html#pragma_wal_checkpoint
+func (d *Driver) Checkpoint(ctx context.Context, mode string) (*drivers.Checkpoint, error) {
+	mode, err := checkpointMode(mode)
+	if err != nil {
+		return nil, fmt.Errorf("could not checkpoint: %w", err)
+	}
+
+	defer d.Measure("Checkpoint", time.Now())
+
+	var (
+		busy       int64
+		checkpoint drivers.Checkpoint
+	)
+
+	err = d.DB.QueryRowContext(ctx, "PRAGMA wal_checkpoint("+mode+")").Scan(
+		&busy, &checkpoint.Log, &checkpoint.Checkpointed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not checkpoint: %w", err)
+	}
+
+	checkpoint.Busy = busy != 0
+
+	return &checkpoint, nil
+}
+
+// Vacuum frees pages with auto_vacuum set to INCREMENTAL, which is done
+// when the database is opened. A page is freed by every step of the
+// statement, so its rows are read until the end.
+func (d *Driver) Vacuum(ctx context.Context, pages int64) error {
+	rows, err := d.DB.QueryContext(ctx, fmt.Sprintf("PRAGMA incremental_vacuum(%d)", pages))
+	if err != nil {
+		return fmt.Errorf("could not vacuum: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+
+	if rows.Err() != nil {
+		return fmt.Errorf("could not vacuum: %w", rows.Err())
+	}
+
+	return nil
+}
+
+// EnableAutoVacuum rebuilds a database created without auto_vacuum with
+// VACUUM. Writes wait until it completes, and it needs as much free disk
+// space as the database, so it is only run when asked.
+func (d *Driver) EnableAutoVacuum(ctx context.Context) (bool, error) {
+	incremental, err := autoVacuumEnabled(ctx, d.DB)
+	if err != nil || incremental {
+		return false, err
+	}
+
+	defer d.Measure("EnableAutoVacuum", time.Now())
+
+	_, err = d.DB.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL; VACUUM;")
+	if err != nil {
+		return false, fmt.Errorf("could not vacuum: %w", err)
+	}
+
+	return true, nil
+}
+
+// Optimize runs PRAGMA optimize, which only analyzes the tables that changed
+// a lot. ANALYZE is run on every table when asked.
+// https://www.sqlite.org/lang_analyze.html
+func (d *Driver) Optimize(ctx context.Context, analyze bool) error {
+	statement := "PRAGMA optimize"
+	if analyze {
+		statement = "ANALYZE"
+	}
+
+	_, err := d.DB.ExecContext(ctx, statement)
+	if err != nil {
+		return fmt.Errorf("could not execute %s: %w", statement, err)
+	}
+
+	return nil
+}
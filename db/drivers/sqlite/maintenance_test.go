@@ -0,0 +1,48 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/jtarchie/sqlettuce/db/drivers/sqlite"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Maintenance", func() {
+	It("only rebuilds a database without auto vacuum when asked", func() {
+		dsn := "sqlite://" + filepath.Join(GinkgoT().TempDir(), "data.db")
+
+		autoVacuum := func(driver *sqlite.Driver) int {
+			var mode int
+
+			err := driver.DB.QueryRow("PRAGMA auto_vacuum").Scan(&mode)
+			Expect(err).NotTo(HaveOccurred())
+
+			return mode
+		}
+
+		// a database created by an older version
+		driver, err := sqlite.New(dsn)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = driver.DB.Exec("PRAGMA auto_vacuum = NONE; VACUUM;")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(driver.Close()).To(Succeed())
+
+		driver, err = sqlite.New(dsn)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(driver.Close)
+
+		Expect(autoVacuum(driver)).To(Equal(0), "none")
+
+		rebuilt, err := driver.EnableAutoVacuum(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rebuilt).To(BeTrue())
+		Expect(autoVacuum(driver)).To(Equal(2), "incremental")
+
+		rebuilt, err = driver.EnableAutoVacuum(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rebuilt).To(BeFalse())
+	})
+})
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS journal_insert;
+DROP TRIGGER IF EXISTS journal_update;
+DROP TRIGGER IF EXISTS journal_delete;
+DROP TABLE journal;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS journal (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  at INTEGER NOT NULL,
+  name TEXT,
+  value TEXT
+);
+CREATE INDEX IF NOT EXISTS journal_at ON journal (at);
@@ -0,0 +1,18 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetPragma changes a setting of the writer connection, the read only
+// connections keep the settings of the DSN. The name and value are not
+// escaped, they must come from a validated configuration.
+func (d *Driver) SetPragma(ctx context.Context, name, value string) error {
+	_, err := d.DB.ExecContext(ctx, fmt.Sprintf("PRAGMA %s = %s", name, value))
+	if err != nil {
+		return fmt.Errorf("could not set PRAGMA %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/jtarchie/sqlettuce/db/drivers/sqlite"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Readers", func() {
+	var driver *sqlite.Driver
+
+	BeforeEach(func() {
+		var err error
+
+		driver, err = sqlite.New("sqlite://" + filepath.Join(GinkgoT().TempDir(), "data.db"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(driver.Close)
+	})
+
+	It("uses a pool of connections beside the writer", func() {
+		Expect(driver.ReadersDB).NotTo(BeIdenticalTo(driver.DB))
+		Expect(driver.DB.Stats().MaxOpenConnections).To(Equal(1))
+		Expect(driver.ReadersDB.Stats().MaxOpenConnections).To(BeNumerically(">=", 1))
+	})
+
+	It("does not allow writes on the readers", func() {
+		_, err := driver.ReadersDB.Exec("INSERT INTO keys (name, value) VALUES ('name', 'value')")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reads while a write is in progress", func() {
+		ctx := context.Background()
+
+		err := driver.Set(ctx, "name", "before")
+		Expect(err).NotTo(HaveOccurred())
+
+		transaction, err := driver.DB.Begin()
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(transaction.Rollback)
+
+		_, err = transaction.Exec("UPDATE keys SET value = 'during' WHERE name = 'name'")
+		Expect(err).NotTo(HaveOccurred())
+
+		value, found, err := driver.Get(ctx, "name")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal("before"))
+
+		values, err := driver.MGet(ctx, "name")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]string{"name": "before"}))
+	})
+
+	It("reads the writes once they return", func() {
+		ctx := context.Background()
+
+		var group sync.WaitGroup
+
+		for client := range 8 {
+			group.Add(1)
+
+			go func() {
+				defer GinkgoRecover()
+				defer group.Done()
+
+				name := fmt.Sprintf("client-%d", client)
+
+				for index := range 50 {
+					value := fmt.Sprintf("value-%d", index)
+
+					err := driver.Set(ctx, name, value)
+					Expect(err).NotTo(HaveOccurred())
+
+					found, ok, err := driver.Get(ctx, name)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(ok).To(BeTrue())
+					Expect(found).To(Equal(value))
+
+					_, _, err = driver.ListRightPushUpsert(ctx, name+"-list", value)
+					Expect(err).NotTo(HaveOccurred())
+
+					length, err := driver.ListLength(ctx, name+"-list")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(length).To(BeEquivalentTo(index + 1))
+				}
+			}()
+		}
+
+		group.Wait()
+	})
+
+	It("reads with the writer for databases in memory", func() {
+		driver, err := sqlite.New("sqlite://:memory:")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(driver.Close)
+
+		Expect(driver.ReadersDB).To(BeIdenticalTo(driver.DB))
+	})
+})
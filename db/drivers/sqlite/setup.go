@@ -1,10 +1,15 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"errors"
 	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
@@ -17,29 +22,40 @@ import (
 //go:embed migrations/*.sql
 var migrations embed.FS
 
+// New opens the database of a `sqlite://` DSN, see ParseDSN.
 func New(dsn string) (*Driver, error) {
-	writerDB, err := sql.Open(driverName, dsn)
+	config, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse DSN: %w", err)
+	}
+
+	writerDB, err := sql.Open(driverName, config.DriverDSN())
 	if err != nil {
 		return nil, fmt.Errorf("could not open writer db: %w", err)
 	}
 
 	writerDB.SetMaxOpenConns(1)
 
-	// https://www.sqlite.org/compile.html
-	// https://phiresky.github.io/blog/2020/sqlite-performance-tuning/
-	_, err = writerDB.Exec(`
-		PRAGMA busy_timeout = 5000;
-		PRAGMA journal_mode = WAL;
-		PRAGMA synchronous = NORMAL;
+	var pragmas strings.Builder
+
+	for _, pragma := range config.Pragmas {
+		fmt.Fprintf(&pragmas, "PRAGMA %s = %s;\n", pragma.Name, pragma.Value)
+	}
+
+	// checkpoints and vacuums are run by the maintenance of the client
+	_, err = writerDB.Exec(`PRAGMA auto_vacuum = INCREMENTAL;` + pragmas.String() + `
 		PRAGMA wal_autocheckpoint = 0;
 		PRAGMA temp_store = memory;
-		-- PRAGMA mmap_size = 30000000000;
-		PRAGMA incremental_vacuum;
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("could not setup PRAGMA: %w", err)
 	}
 
+	err = warnAutoVacuum(writerDB, config.Filename)
+	if err != nil {
+		return nil, err
+	}
+
 	migrationsFS, err := iofs.New(migrations, "migrations")
 	if err != nil {
 		return nil, fmt.Errorf("could not wrap migrations: %w", err)
@@ -63,10 +79,79 @@ func New(dsn string) (*Driver, error) {
 		return nil, fmt.Errorf("could not migrate up: %w", err)
 	}
 
+	readersDB, err := openReaders(config, writerDB)
+	if err != nil {
+		return nil, fmt.Errorf("could not open readers db: %w", err)
+	}
+
 	return &Driver{
-		DB:      writerDB,
-		Readers: &Readers{readers.New(writerDB)},
-		Writers: &Writers{writers.New(writerDB)},
-		Batcher: &Batches{batch.New(writerDB)},
+		DB:          writerDB,
+		ReadersDB:   readersDB,
+		Readers:     &Readers{readers.New(readersDB)},
+		Writers:     &Writers{writers.New(writerDB)},
+		Batcher:     &Batches{batch.New(writerDB)},
+		ReadBatcher: &Batches{batch.New(readersDB)},
+		Measure:     func(string, time.Time) {},
 	}, nil
 }
+
+// openReaders opens a pool of read only connections, one per CPU. With WAL,
+// they read the last commit without waiting on the writer. Every write is
+// committed before replying, so a client reads its own writes.
+// A database in memory is not shared with other connections, the writer
+// reads it.
+func openReaders(config *Config, writerDB *sql.DB) (*sql.DB, error) {
+	if config.Memory() {
+		return writerDB, nil
+	}
+
+	readersDB, err := sql.Open(driverName, config.ReaderDSN())
+	if err != nil {
+		return nil, fmt.Errorf("could not open: %w", err)
+	}
+
+	readersDB.SetMaxOpenConns(runtime.NumCPU())
+	readersDB.SetMaxIdleConns(runtime.NumCPU())
+
+	err = readersDB.Ping()
+	if err != nil {
+		_ = readersDB.Close()
+
+		return nil, fmt.Errorf("could not connect: %w", err)
+	}
+
+	return readersDB, nil
+}
+
+// warnAutoVacuum reports a database created without auto_vacuum, by an
+// older version. New databases have it, as it is set before the first table
+// is created. It is only enabled by DEBUG VACUUM, see EnableAutoVacuum, as
+// the database is rebuilt.
+// https://www.sqlite.org/pragma.html#pragma_auto_vacuum
+func warnAutoVacuum(writerDB *sql.DB, filename string) error {
+	incremental, err := autoVacuumEnabled(context.Background(), writerDB)
+	if err != nil {
+		return err
+	}
+
+	if !incremental {
+		slog.Warn("the database was created without auto_vacuum, free pages are kept until DEBUG VACUUM",
+			slog.String("filename", filename),
+		)
+	}
+
+	return nil
+}
+
+func autoVacuumEnabled(ctx context.Context, db *sql.DB) (bool, error) {
+	var mode int
+
+	err := db.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&mode)
+	if err != nil {
+		return false, fmt.Errorf("could not read auto vacuum: %w", err)
+	}
+
+	const incremental = 2
+
+	return mode == incremental, nil
+}
@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// Snapshot writes a consistent copy of the database to a new SQLite file.
+// The file must not exist, or be empty.
+func (d *Driver) Snapshot(ctx context.Context, path string) error {
+	_, err := d.DB.ExecContext(ctx, "VACUUM INTO ?", path)
+	if err != nil {
+		return fmt.Errorf("could not execute Snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Restore replaces every key with the keys of a snapshot file.
+func (d *Driver) Restore(ctx context.Context, path string) error {
+	// attached databases are per connection
+	conn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get connection: %w", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "ATTACH DATABASE ? AS snapshot", path)
+	if err != nil {
+		return fmt.Errorf("could not attach snapshot: %w", err)
+	}
+
+	//nolint:contextcheck
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "DETACH DATABASE snapshot")
+	}()
+
+	transaction, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer func() { _ = transaction.Rollback() }()
+
+	_, err = transaction.ExecContext(ctx, "DELETE FROM main.keys")
+	if err != nil {
+		return fmt.Errorf("could not delete keys: %w", err)
+	}
+
+	_, err = transaction.ExecContext(ctx, "INSERT INTO main.keys (name, value) SELECT name, value FROM snapshot.keys")
+	if err != nil {
+		return fmt.Errorf("could not copy keys: %w", err)
+	}
+
+	err = transaction.Commit()
+	if err != nil {
+		return fmt.Errorf("could not commit: %w", err)
+	}
+
+	return nil
+}
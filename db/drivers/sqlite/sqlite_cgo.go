@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 
 	sqlite3 "github.com/mattn/go-sqlite3"
 )
@@ -27,6 +28,14 @@ func init() {
 	})
 }
 
+// readerParams sets the PRAGMAs of the read only connections.
+func readerParams(busyTimeout string) url.Values {
+	return url.Values{
+		"_busy_timeout": []string{busyTimeout},
+		"_query_only":   []string{"true"},
+	}
+}
+
 var ErrNotArray = errors.New("not an array")
 
 func jsonArrayInsert(array string, pivot, value string, offset int) (string, error) {
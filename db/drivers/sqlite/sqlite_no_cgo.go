@@ -4,7 +4,19 @@
 package sqlite
 
 import (
+	"net/url"
+
 	_ "modernc.org/sqlite"
 )
 
 const driverName = "sqlite"
+
+// readerParams sets the PRAGMAs of the read only connections.
+func readerParams(busyTimeout string) url.Values {
+	return url.Values{
+		"_pragma": []string{
+			"busy_timeout(" + busyTimeout + ")",
+			"query_only(1)",
+		},
+	}
+}
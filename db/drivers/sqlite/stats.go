@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+func (d *Driver) Stats(ctx context.Context) (*drivers.Stats, error) {
+	stats := &drivers.Stats{}
+
+	for pragma, value := range map[string]*int64{
+		"page_count":     &stats.PageCount,
+		"page_size":      &stats.PageSize,
+		"freelist_count": &stats.FreelistCount,
+	} {
+		err := d.DB.QueryRowContext(ctx, "PRAGMA "+pragma).Scan(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not read PRAGMA %s: %w", pragma, err)
+		}
+	}
+
+	err := d.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM keys").Scan(&stats.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("could not count keys: %w", err)
+	}
+
+	var (
+		seq  int64
+		name string
+	)
+
+	err = d.DB.QueryRowContext(ctx, "PRAGMA database_list").Scan(&seq, &name, &stats.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read database list: %w", err)
+	}
+
+	// in memory databases do not have a filename or a WAL
+	if stats.Filename != "" {
+		info, err := os.Stat(stats.Filename + "-wal")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("could not read WAL size: %w", err)
+		}
+
+		if err == nil {
+			stats.WALSize = info.Size()
+		}
+	}
+
+	return stats, nil
+}
+
+// Size is the size of the database in bytes, including free pages.
+func (d *Driver) Size(ctx context.Context) (int64, error) {
+	var pageCount, pageSize int64
+
+	err := d.DB.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount)
+	if err != nil {
+		return 0, fmt.Errorf("could not read PRAGMA page_count: %w", err)
+	}
+
+	err = d.DB.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize)
+	if err != nil {
+		return 0, fmt.Errorf("could not read PRAGMA page_size: %w", err)
+	}
+
+	return pageCount * pageSize, nil
+}
@@ -0,0 +1,143 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/readers"
+	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/writers"
+)
+
+func (d *Driver) Set(ctx context.Context, name, value string) error {
+	err := d.Writers.Set(ctx, &writers.SetParams{
+		Name:  name,
+		Value: value,
+	})
+	if err != nil {
+		return fmt.Errorf("could not execute Set: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Driver) MSet(ctx context.Context, args ...string) error {
+	return d.transaction(ctx, func(transaction *sql.Tx) error {
+		queries := d.Writers.WithTx(transaction)
+		params := &writers.SetParams{}
+
+		for index := 0; index < len(args); index += 2 {
+			params.Name = args[index]
+			params.Value = args[index+1]
+
+			err := queries.Set(ctx, params)
+			if err != nil {
+				return fmt.Errorf("could not execute Set: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *Driver) Get(ctx context.Context, name string) (string, bool, error) {
+	value, err := d.Readers.Get(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("could not execute Get: %w", err)
+	}
+
+	return value, true, nil
+}
+
+func (d *Driver) MGet(ctx context.Context, names ...string) (map[string]string, error) {
+	results, err := d.ReadBatcher.Get(ctx, names)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("could not execute Get: %w", err)
+	}
+
+	values := make(map[string]string, len(results))
+	for _, result := range results {
+		values[result.Name] = result.Value
+	}
+
+	return values, nil
+}
+
+func (d *Driver) Delete(ctx context.Context, names ...string) ([]string, error) {
+	values, err := d.Batcher.Delete(ctx, names)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("could not execute Delete: %w", err)
+	}
+
+	return values, nil
+}
+
+func (d *Driver) Append(ctx context.Context, name, value string) (int64, error) {
+	length, err := d.Writers.AppendValue(ctx, &writers.AppendValueParams{
+		Name:  name,
+		Value: value,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not execute AppendValue: %w", err)
+	}
+
+	return length.Int64, nil
+}
+
+func (d *Driver) Substr(ctx context.Context, name string, start, end int64) (string, error) {
+	value, err := d.Readers.Substr(ctx, &readers.SubstrParams{
+		Name:  name,
+		Start: start,
+		End:   end,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("could not execute Substr: %w", err)
+	}
+
+	return value, nil
+}
+
+func (d *Driver) AddInt(ctx context.Context, name string, value int64) (int64, error) {
+	intValue, err := d.Writers.AddInt(ctx, &writers.AddIntParams{
+		Name:  name,
+		Value: strconv.FormatInt(value, 10),
+	})
+	// the value is only updated when it is a number
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, drivers.ErrNotInteger
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not execute AddInt: %w", err)
+	}
+
+	return intValue, nil
+}
+
+func (d *Driver) AddFloat(ctx context.Context, name string, value float64) (float64, error) {
+	newValue, err := d.Writers.AddFloat(ctx, &writers.AddFloatParams{
+		Name:  name,
+		Value: strconv.FormatFloat(value, 'f', 17, 64),
+	})
+	// the value is only updated when it is a number
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, drivers.ErrNotFloat
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not execute AddFloat: %w", err)
+	}
+
+	return newValue, nil
+}
@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+// transaction runs the function in a transaction, which is committed when
+// no error is returned. Busy errors are returned as drivers.ErrBusy.
+// Within a group, the function runs in the transaction of the group.
+func (d *Driver) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if d.tx != nil {
+		return fn(d.tx)
+	}
+
+	err := d.runTransaction(ctx, fn)
+	if err != nil && isBusy(err) {
+		return fmt.Errorf("%w: %w", drivers.ErrBusy, err)
+	}
+
+	return err
+}
+
+func (d *Driver) runTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	transaction, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	//nolint:errcheck
+	defer transaction.Rollback()
+
+	err = fn(transaction)
+	if err != nil {
+		return err
+	}
+
+	committed := time.Now()
+	err = transaction.Commit()
+	d.Measure("Commit", committed)
+
+	if err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Group runs the operations in a single transaction, committed once. Each
+// operation runs in a savepoint, so one failing does not undo the others.
+func (d *Driver) Group(ctx context.Context, operations ...drivers.Operation) ([]error, error) {
+	// alone, an operation does not need a savepoint
+	if len(operations) == 1 {
+		err := d.transaction(ctx, func(transaction *sql.Tx) error {
+			return operations[0](ctx, d.withTx(transaction))
+		})
+
+		return []error{err}, nil
+	}
+
+	errs := make([]error, len(operations))
+
+	err := d.transaction(ctx, func(transaction *sql.Tx) error {
+		grouped := d.withTx(transaction)
+
+		for index, operation := range operations {
+			_, err := transaction.ExecContext(ctx, "SAVEPOINT operation")
+			if err != nil {
+				return fmt.Errorf("could not create savepoint: %w", err)
+			}
+
+			errs[index] = operation(ctx, grouped)
+			if errs[index] != nil {
+				_, err = transaction.ExecContext(ctx, "ROLLBACK TO operation")
+				if err != nil {
+					return fmt.Errorf("could not rollback savepoint: %w", err)
+				}
+			}
+
+			_, err = transaction.ExecContext(ctx, "RELEASE operation")
+			if err != nil {
+				return fmt.Errorf("could not release savepoint: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return errs, nil
+}
+
+// withTx is the driver running every query in the transaction.
+func (d *Driver) withTx(transaction *sql.Tx) *Driver {
+	return &Driver{
+		DB:          d.DB,
+		ReadersDB:   d.ReadersDB,
+		Readers:     d.Readers.WithTx(transaction),
+		Writers:     d.Writers.WithTx(transaction),
+		Batcher:     d.Batcher.WithTx(transaction),
+		ReadBatcher: d.Batcher.WithTx(transaction),
+		Measure:     d.Measure,
+		tx:          transaction,
+	}
+}
+
+// execer runs the queries that are not generated, it is a *sql.DB or *sql.Tx.
+type execer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// execer is the transaction of the group, when there is one.
+func (d *Driver) execer() execer {
+	if d.tx != nil {
+		return d.tx
+	}
+
+	return d.DB
+}
+
+// isBusy matches the busy errors of both the cgo and pure go drivers.
+func isBusy(err error) bool {
+	message := err.Error()
+
+	return strings.Contains(message, "database is locked") ||
+		strings.Contains(message, "database table is locked") ||
+		strings.Contains(message, "SQLITE_BUSY")
+}
@@ -3,7 +3,10 @@ package sqlite
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
+	"github.com/jtarchie/sqlettuce/db/drivers"
 	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/batch"
 	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/readers"
 	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/writers"
@@ -60,9 +63,56 @@ func (q *Batches) WithTx(tx *sql.Tx) Batcher {
 }
 
 type Driver struct {
+	// DB is the single connection writing to the database.
 	DB *sql.DB
+	// ReadersDB is the pool of read only connections, it is DB for
+	// databases in memory.
+	ReadersDB *sql.DB
 
 	Readers Reader
 	Writers Writer
 	Batcher Batcher
+	// ReadBatcher runs the batch queries that only read, on ReadersDB.
+	ReadBatcher Batcher
+
+	// Measure is called with the duration of the operations of the
+	// driver, like commits.
+	Measure func(operation string, started time.Time)
+
+	// tx is the transaction of a group, see Group.
+	tx *sql.Tx
+}
+
+var _ interface {
+	drivers.Driver
+	drivers.Snapshotter
+	drivers.Pragmas
+	drivers.Grouper
+	drivers.Maintainer
+} = &Driver{}
+
+func (d *Driver) Close() error {
+	err := d.Readers.Close()
+	if err != nil {
+		return fmt.Errorf("could not close readers: %w", err)
+	}
+
+	err = d.Writers.Close()
+	if err != nil {
+		return fmt.Errorf("could not close writers: %w", err)
+	}
+
+	if d.ReadersDB != d.DB {
+		err = d.ReadersDB.Close()
+		if err != nil {
+			return fmt.Errorf("could not close readers db: %w", err)
+		}
+	}
+
+	err = d.DB.Close()
+	if err != nil {
+		return fmt.Errorf("could not close db: %w", err)
+	}
+
+	return nil
 }
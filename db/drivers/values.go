@@ -0,0 +1,195 @@
+package drivers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The functions below change values like the queries of the SQLite driver,
+// so drivers storing plain strings behave the same.
+
+// Substr returns the part of a value between the start and the end, counted
+// in characters. Like the SQLite driver, negative indexes count from the end.
+func Substr(value string, start, end int64) string {
+	chars := []rune(value)
+
+	from := start + 1
+	if start < 0 {
+		from = start
+	}
+
+	length := start + end + 1
+	if end < 0 {
+		length = int64(len(chars)) - end
+	}
+
+	return substr(chars, from, length)
+}
+
+// substr is SQLite's SUBSTR(X, Y, Z), with a one based Y.
+func substr(chars []rune, from, length int64) string {
+	size := int64(len(chars))
+
+	negative := length < 0
+	if negative {
+		length = -length
+	}
+
+	switch {
+	case from < 0:
+		from += size
+		if from < 0 {
+			length = max(length+from, 0)
+			from = 0
+		}
+	case from > 0:
+		from--
+	case length > 0:
+		length--
+	}
+
+	if negative {
+		from -= length
+		if from < 0 {
+			length += from
+			from = 0
+		}
+	}
+
+	if from >= size {
+		return ""
+	}
+
+	return string(chars[from:min(from+length, size)])
+}
+
+// AddInt adds to a value that is an integer, returning the new value.
+func AddInt(value string, increment int64) (string, int64, error) {
+	current, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || strconv.FormatInt(current, 10) != value {
+		return "", 0, ErrNotInteger
+	}
+
+	sum := current + increment
+	if (increment > 0 && sum < current) || (increment < 0 && sum > current) {
+		return "", 0, ErrNotInteger
+	}
+
+	return strconv.FormatInt(sum, 10), sum, nil
+}
+
+// AddFloat adds to a value that is a number, returning the new value.
+func AddFloat(value string, increment float64) (string, float64, error) {
+	current, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsInf(current, 0) || math.IsNaN(current) {
+		return "", 0, ErrNotFloat
+	}
+
+	sum := current + increment
+
+	return FormatFloat(sum), sum, nil
+}
+
+// FormatFloat is the shortest representation of a number.
+func FormatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// DecodeList returns the elements of a list, stored as a JSON array.
+func DecodeList(value string) ([]string, error) {
+	var elements []json.RawMessage
+
+	if !utf8.ValidString(value) || json.Unmarshal([]byte(value), &elements) != nil || elements == nil {
+		return nil, ErrNotArray
+	}
+
+	list := make([]string, 0, len(elements))
+
+	for _, element := range elements {
+		var text string
+
+		// like JSON_EACH, only strings are unquoted
+		if json.Unmarshal(element, &text) != nil {
+			text = string(element)
+		}
+
+		list = append(list, text)
+	}
+
+	return list, nil
+}
+
+// EncodeList stores the elements of a list as a JSON array.
+func EncodeList(list []string) string {
+	if list == nil {
+		list = []string{}
+	}
+
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+
+	// strings are always encoded
+	_ = encoder.Encode(list)
+
+	return string(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")))
+}
+
+// ListRange returns the elements between the start and the end, inclusive.
+// Negative indexes count from the end.
+func ListRange(list []string, start, end int64) []string {
+	size := int64(len(list))
+
+	if start < 0 {
+		start += size
+	}
+
+	if end < 0 {
+		end += size
+	}
+
+	start = max(start, 0)
+	end = min(end, size-1)
+
+	if start > end {
+		return nil
+	}
+
+	return list[start : end+1]
+}
+
+// ListInsert inserts a value before the first pivot, or after it when the
+// offset is positive. The list is unchanged without a pivot.
+func ListInsert(list []string, offset int64, pivot, value string) []string {
+	for index, element := range list {
+		if element != pivot {
+			continue
+		}
+
+		if offset >= 0 {
+			index++
+		}
+
+		return append(list[:index], append([]string{value}, list[index:]...)...)
+	}
+
+	return list
+}
+
+// ListSet replaces the element at an index. Negative indexes count from the
+// end. The list is unchanged when the index is out of range.
+func ListSet(list []string, index int64, value string) []string {
+	if index < 0 {
+		index += int64(len(list))
+	}
+
+	if index >= 0 && index < int64(len(list)) {
+		list[index] = value
+	}
+
+	return list
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+	"github.com/jtarchie/sqlettuce/rdb"
+)
+
+// ErrKeyExists is returned when restoring a key that exists, without
+// replacing it.
+var ErrKeyExists = errors.New("key already exists")
+
+// Entries returns the keys found, with their type, to be serialized like
+// Redis. As lists are stored as JSON arrays, values holding an array are
+// lists.
+func (c *Client) Entries(ctx context.Context, names ...string) ([]*rdb.Entry, error) {
+	defer c.measure("Entries", time.Now())
+
+	values, err := c.driver.MGet(ctx, names...)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute Entries: %w", err)
+	}
+
+	entries := make([]*rdb.Entry, 0, len(values))
+
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+
+		entries = append(entries, entry(name, value))
+	}
+
+	return entries, nil
+}
+
+func entry(name, value string) *rdb.Entry {
+	if strings.HasPrefix(value, "[") {
+		list, err := drivers.DecodeList(value)
+		if err == nil && len(list) > 0 {
+			return &rdb.Entry{Key: name, Type: rdb.List, Elements: list}
+		}
+	}
+
+	return &rdb.Entry{Key: name, Type: rdb.String, Value: value}
+}
+
+// RestoreEntry creates a key from an entry, replacing it when asked. Only
+// strings and lists can be stored, and keys don't expire.
+func (c *Client) RestoreEntry(ctx context.Context, entry *rdb.Entry, replace bool) error {
+	defer c.measure("RestoreEntry", time.Now())
+
+	var value string
+
+	switch entry.Type {
+	case rdb.String:
+		value = entry.Value
+	case rdb.List:
+		value = drivers.EncodeList(entry.Elements)
+	default:
+		return fmt.Errorf("could not restore %s: %w", entry.Type, errors.ErrUnsupported)
+	}
+
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		if !replace {
+			_, found, err := driver.Get(ctx, entry.Key)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			if found {
+				return ErrKeyExists
+			}
+		}
+
+		return driver.Set(ctx, entry.Key, value)
+	})
+	if errors.Is(err, ErrKeyExists) {
+		return ErrKeyExists
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not execute RestoreEntry: %w", err)
+	}
+
+	c.changed(ctx, []string{entry.Key})
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/rdb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Entries", func() {
+	forEachDriver(func(newClient func() *db.Client) {
+		var client *db.Client
+
+		BeforeEach(func() {
+			client = newClient()
+		})
+
+		It("returns the keys found with their type", func() {
+			err := client.MSet(context.TODO(), "string", "value", "array", "[1,")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = client.ListRightPushUpsert(context.TODO(), "list", "a", "b")
+			Expect(err).NotTo(HaveOccurred())
+
+			entries, err := client.Entries(context.TODO(), "string", "missing", "list", "array")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(Equal([]*rdb.Entry{
+				{Key: "string", Type: rdb.String, Value: "value"},
+				{Key: "list", Type: rdb.List, Elements: []string{"a", "b"}},
+				{Key: "array", Type: rdb.String, Value: "[1,"},
+			}))
+		})
+
+		It("restores strings and lists", func() {
+			err := client.RestoreEntry(context.TODO(), &rdb.Entry{Key: "key", Type: rdb.String, Value: "value"}, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = client.RestoreEntry(context.TODO(), &rdb.Entry{Key: "key", Type: rdb.List, Elements: []string{"a"}}, false)
+			Expect(err).To(MatchError(db.ErrKeyExists))
+
+			err = client.RestoreEntry(context.TODO(), &rdb.Entry{Key: "key", Type: rdb.List, Elements: []string{"a"}}, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			values, err := client.ListRange(context.TODO(), "key", 0, -1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(Equal([]string{"a"}))
+
+			err = client.RestoreEntry(context.TODO(), &rdb.Entry{Key: "hash", Type: rdb.Hash}, true)
+			Expect(errors.Is(err, errors.ErrUnsupported)).To(BeTrue())
+		})
+	})
+})
@@ -2,20 +2,36 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
+	"time"
 
-	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/writers"
+	"github.com/jtarchie/sqlettuce/db/drivers"
 )
 
+var ErrNotFloat = drivers.ErrNotFloat
+
 func (c *Client) AddFloat(ctx context.Context, name string, value float64) (float64, error) {
-	newValue, err := c.writers.AddFloat(ctx, &writers.AddFloatParams{
-		Name:  name,
-		Value: strconv.FormatFloat(value, 'f', 17, 64),
+	defer c.measure("AddFloat", time.Now())
+
+	var newValue float64
+
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		var err error
+
+		newValue, err = driver.AddFloat(ctx, name, value)
+
+		return err //nolint:wrapcheck
 	})
+	if errors.Is(err, ErrNotFloat) {
+		return 0, ErrNotFloat
+	}
+
 	if err != nil {
 		return 0, fmt.Errorf("could not ADDFLOAT: %w", err)
 	}
 
+	c.changed(ctx, []string{name})
+
 	return newValue, nil
 }
@@ -3,13 +3,22 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
 )
 
 func (c *Client) FlushAll(ctx context.Context) error {
-	err := c.writers.FlushAll(ctx)
+	defer c.measure("FlushAll", time.Now())
+
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		return driver.FlushAll(ctx)
+	})
 	if err != nil {
 		return fmt.Errorf("could not flush all: %w", err)
 	}
 
+	c.changed(ctx, nil)
+
 	return nil
 }
@@ -9,31 +9,26 @@ import (
 )
 
 var _ = Describe("Flush", func() {
-	var client *db.Client
+	forEachDriver(func(newClient func() *db.Client) {
+		var client *db.Client
 
-	BeforeEach(func() {
-		var err error
-
-		client, err = db.NewClient("sqlite://:memory:?cache=shared&mode=memory")
-		Expect(err).NotTo(HaveOccurred())
-	})
-
-	AfterEach(func() {
-		client.Close()
-	})
+		BeforeEach(func() {
+			client = newClient()
+		})
 
-	When("FlushAll", func() {
-		It("resets all values", func() {
-			err := client.Set(context.TODO(), "key", "value")
-			Expect(err).NotTo(HaveOccurred())
+		When("FlushAll", func() {
+			It("resets all values", func() {
+				err := client.Set(context.TODO(), "key", "value")
+				Expect(err).NotTo(HaveOccurred())
 
-			err = client.FlushAll(context.TODO())
-			Expect(err).NotTo(HaveOccurred())
+				err = client.FlushAll(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
 
-			value, found, err := client.Get(context.TODO(), "key")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeFalse())
-			Expect(value).To(Equal(""))
+				value, found, err := client.Get(context.TODO(), "key")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeFalse())
+				Expect(value).To(Equal(""))
+			})
 		})
 	})
 })
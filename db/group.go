@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+const (
+	// DefaultGroupCommitSize is the most writes committed together.
+	DefaultGroupCommitSize = 128
+	// DefaultGroupCommitDelay does not wait for writes, those queued while
+	// the previous group was committing are committed together.
+	DefaultGroupCommitDelay = 0
+)
+
+var ErrClosed = errors.New("client is closed")
+
+// group queues the writes of every connection, to run them in a single
+// transaction. Each caller is answered once the transaction is committed.
+type group struct {
+	driver drivers.Grouper
+	writes chan *groupedWrite
+
+	size  atomic.Int64
+	delay atomic.Int64
+
+	// commits and committed count the transactions and their writes
+	commits   atomic.Uint64
+	committed atomic.Uint64
+
+	done    chan struct{}
+	stopped chan struct{}
+	closing sync.Once
+}
+
+type groupedWrite struct {
+	ctx       context.Context //nolint:containedctx
+	operation drivers.Operation
+	result    chan error
+}
+
+func newGroup(driver drivers.Grouper) *group {
+	group := &group{
+		driver:  driver,
+		writes:  make(chan *groupedWrite),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	group.size.Store(DefaultGroupCommitSize)
+	group.delay.Store(DefaultGroupCommitDelay)
+
+	go group.run()
+
+	return group
+}
+
+// enabled is false when every write is its own transaction.
+func (g *group) enabled() bool {
+	return g.size.Load() > 1
+}
+
+// write queues the operation, it returns once the group is committed.
+func (g *group) write(ctx context.Context, operation drivers.Operation) error {
+	write := &groupedWrite{
+		ctx:       ctx,
+		operation: operation,
+		result:    make(chan error, 1),
+	}
+
+	select {
+	case g.writes <- write:
+	case <-ctx.Done():
+		return fmt.Errorf("could not queue write: %w", ctx.Err())
+	case <-g.done:
+		return ErrClosed
+	}
+
+	// a queued write is always answered, it might be committed
+	return <-write.result
+}
+
+func (g *group) run() {
+	defer close(g.stopped)
+
+	for {
+		select {
+		case write := <-g.writes:
+			g.commit(g.collect(write))
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// collect queues writes until the group is full, or the delay is over.
+// Without a delay, only the writes already waiting are added.
+func (g *group) collect(first *groupedWrite) []*groupedWrite {
+	writes := []*groupedWrite{first}
+	size := int(g.size.Load())
+
+	delay := time.Duration(g.delay.Load())
+	if delay <= 0 {
+		for len(writes) < size {
+			select {
+			case write := <-g.writes:
+				writes = append(writes, write)
+			default:
+				return writes
+			}
+		}
+
+		return writes
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for len(writes) < size {
+		select {
+		case write := <-g.writes:
+			writes = append(writes, write)
+		case <-timer.C:
+			return writes
+		}
+	}
+
+	return writes
+}
+
+func (g *group) commit(writes []*groupedWrite) {
+	operations := make([]drivers.Operation, len(writes))
+
+	for index, write := range writes {
+		operations[index] = func(ctx context.Context, driver drivers.Driver) error {
+			// the caller is gone, its write is not run
+			err := write.ctx.Err()
+			if err != nil {
+				return fmt.Errorf("could not write: %w", err)
+			}
+
+			return write.operation(ctx, driver)
+		}
+	}
+
+	errs, err := g.driver.Group(context.Background(), operations...)
+	if err == nil {
+		g.commits.Add(1)
+		g.committed.Add(uint64(len(writes)))
+	}
+
+	for index, write := range writes {
+		if err != nil {
+			write.result <- fmt.Errorf("could not commit group: %w", err)
+
+			continue
+		}
+
+		write.result <- errs[index]
+	}
+}
+
+func (g *group) close() {
+	g.closing.Do(func() {
+		close(g.done)
+	})
+
+	<-g.stopped
+}
+
+// write runs a modification of the keys. With a driver that supports it,
+// the writes of concurrent callers are committed together, see
+// SetGroupCommitSize.
+func (c *Client) write(ctx context.Context, operation drivers.Operation) error {
+	if c.group == nil || !c.group.enabled() {
+		return operation(ctx, c.driver)
+	}
+
+	return c.group.write(ctx, operation)
+}
+
+// SetGroupCommitSize changes how many writes are committed together, at
+// most. A size of zero or one commits every write on its own.
+func (c *Client) SetGroupCommitSize(size int) {
+	if c.group == nil {
+		return
+	}
+
+	c.group.size.Store(int64(max(size, 1)))
+}
+
+// SetGroupCommitDelay changes how long the first write of a group waits for
+// others, adding to its latency.
+func (c *Client) SetGroupCommitDelay(delay time.Duration) {
+	if c.group == nil {
+		return
+	}
+
+	c.group.delay.Store(int64(max(delay, 0)))
+}
@@ -0,0 +1,195 @@
+package db_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Group commit", func() {
+	var (
+		client  *db.Client
+		commits atomic.Int64
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		client, err = db.NewClient("sqlite://" + filepath.Join(GinkgoT().TempDir(), "data.db"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(client.Close)
+
+		commits.Store(0)
+		client.ObserveStatements(func(operation string, _ time.Duration) {
+			if operation == "Commit" {
+				commits.Add(1)
+			}
+		})
+	})
+
+	// concurrently runs the writes, returning their errors in order.
+	concurrently := func(writes ...func() error) []error {
+		errs := make([]error, len(writes))
+
+		var group sync.WaitGroup
+
+		for index, write := range writes {
+			group.Add(1)
+
+			go func() {
+				defer group.Done()
+
+				errs[index] = write()
+			}()
+		}
+
+		group.Wait()
+
+		return errs
+	}
+
+	It("commits concurrent writes together", func() {
+		client.SetGroupCommitDelay(50 * time.Millisecond)
+
+		writes := make([]func() error, 10)
+		for index := range writes {
+			writes[index] = func() error {
+				return client.Set(context.Background(), fmt.Sprintf("key%d", index), "value")
+			}
+		}
+
+		errs := concurrently(writes...)
+		Expect(errs).To(HaveEach(BeNil()))
+		Expect(commits.Load()).To(BeEquivalentTo(1))
+
+		stats, err := client.Stats(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats.GroupCommits).To(BeEquivalentTo(1))
+		Expect(stats.GroupCommitWrites).To(BeEquivalentTo(10))
+
+		names, err := client.Keys(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(HaveLen(10))
+	})
+
+	It("rolls back a failing write alone", func() {
+		err := client.Set(context.Background(), "text", "value")
+		Expect(err).NotTo(HaveOccurred())
+
+		commits.Store(0)
+		client.SetGroupCommitDelay(50 * time.Millisecond)
+
+		errs := concurrently(
+			func() error { return client.Set(context.Background(), "key1", "value") },
+			func() error {
+				_, err := client.AddInt(context.Background(), "text", 1)
+
+				return err
+			},
+			func() error {
+				_, _, err := client.ListRightPushUpsert(context.Background(), "list", "a", "b")
+
+				return err
+			},
+		)
+		Expect(errs[0]).NotTo(HaveOccurred())
+		Expect(errs[1]).To(MatchError(db.ErrNotInteger))
+		Expect(errs[2]).NotTo(HaveOccurred())
+		Expect(commits.Load()).To(BeEquivalentTo(1))
+
+		values, err := client.MGet(context.Background(), "key1", "text")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal([]string{"value", "value"}))
+
+		elements, err := client.ListRange(context.Background(), "list", 0, -1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(elements).To(Equal([]string{"a", "b"}))
+	})
+
+	It("commits every write on its own with a size of one", func() {
+		client.SetGroupCommitSize(1)
+		client.SetGroupCommitDelay(50 * time.Millisecond)
+
+		errs := concurrently(
+			func() error { return client.Set(context.Background(), "key1", "value") },
+			func() error { return client.Set(context.Background(), "key2", "value") },
+		)
+		Expect(errs).To(HaveEach(BeNil()))
+		Expect(commits.Load()).To(BeZero())
+	})
+
+	It("does not run the writes of canceled callers", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := client.Set(ctx, "key", "value")
+		Expect(err).To(MatchError(context.Canceled))
+
+		_, found, err := client.Get(context.Background(), "key")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not accept writes once closed", func() {
+		err := client.Close()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.Set(context.Background(), "key", "value")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// benchmarkSet writes from many connections at once. Every commit is synced
+// with `synchronous=full`, which is when grouping them pays off.
+//
+//	go test ./db -run none -bench Set
+func benchmarkSet(b *testing.B, size int, delay time.Duration) {
+	b.Helper()
+
+	client, err := db.NewClient("sqlite://" + filepath.Join(b.TempDir(), "data.db") + "?synchronous=full")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer client.Close()
+
+	client.SetGroupCommitSize(size)
+	client.SetGroupCommitDelay(delay)
+
+	var counter atomic.Int64
+
+	b.SetParallelism(16)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			name := fmt.Sprintf("key%d", counter.Add(1)%1000)
+
+			err := client.Set(context.Background(), name, "value")
+			if err != nil {
+				b.Error(err)
+
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkSetWithoutGroupCommit(b *testing.B) {
+	benchmarkSet(b, 1, 0)
+}
+
+func BenchmarkSetWithGroupCommit(b *testing.B) {
+	benchmarkSet(b, db.DefaultGroupCommitSize, 0)
+}
+
+func BenchmarkSetWithGroupCommitDelay(b *testing.B) {
+	benchmarkSet(b, db.DefaultGroupCommitSize, 100*time.Microsecond)
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+type Stats struct {
+	drivers.Stats
+
+	Hits    uint64
+	Misses  uint64
+	Retries uint64
+
+	// GroupCommits is the number of transactions committing grouped writes,
+	// GroupCommitWrites the number of writes they committed.
+	GroupCommits      uint64
+	GroupCommitWrites uint64
+}
+
+func (c *Client) Stats(ctx context.Context) (*Stats, error) {
+	stats, err := c.driver.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read stats: %w", err)
+	}
+
+	result := &Stats{
+		Stats:   *stats,
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Retries: c.retries.Load(),
+	}
+
+	if c.group != nil {
+		result.GroupCommits = c.group.commits.Load()
+		result.GroupCommitWrites = c.group.committed.Load()
+	}
+
+	return result, nil
+}
+
+// ResetStats resets the counters reported by Stats, like CONFIG RESETSTAT.
+func (c *Client) ResetStats() {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.retries.Store(0)
+
+	if c.group != nil {
+		c.group.commits.Store(0)
+		c.group.committed.Store(0)
+	}
+}
+
+// Size is the size of the database in bytes, like the DatabaseSize of Stats.
+// Drivers that can't report it cheaply have their stats read instead.
+func (c *Client) Size(ctx context.Context) (int64, error) {
+	if sizer, ok := c.driver.(drivers.Sizer); ok {
+		size, err := sizer.Size(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("could not read size: %w", err)
+		}
+
+		return size, nil
+	}
+
+	stats, err := c.driver.Stats(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not read stats: %w", err)
+	}
+
+	return stats.DatabaseSize(), nil
+}
@@ -0,0 +1,57 @@
+package db_test
+
+import (
+	"context"
+
+	"github.com/jtarchie/sqlettuce/db"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Info", func() {
+	forEachDriver(func(newClient func() *db.Client) {
+		When("Stats", func() {
+			It("reports the keyspace", func() {
+				client := newClient()
+
+				err := client.MSet(context.TODO(), "key1", "value", "key2", "value")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = client.Get(context.TODO(), "key1")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = client.MGet(context.TODO(), "key2", "missing")
+				Expect(err).NotTo(HaveOccurred())
+
+				stats, err := client.Stats(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stats.Keys).To(BeEquivalentTo(2))
+				Expect(stats.Hits).To(BeEquivalentTo(2))
+				Expect(stats.Misses).To(BeEquivalentTo(1))
+			})
+		})
+	})
+
+	When("Stats with sqlite", func() {
+		It("reports the keyspace and database", func() {
+			client, err := db.NewClient("sqlite://:memory:")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(client.Close)
+
+			err = client.MSet(context.TODO(), "key1", "value", "key2", "value")
+			Expect(err).NotTo(HaveOccurred())
+
+			stats, err := client.Stats(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats.Keys).To(BeEquivalentTo(2))
+			Expect(stats.PageCount).To(BeNumerically(">", 0))
+			Expect(stats.PageSize).To(BeNumerically(">", 0))
+			Expect(stats.DatabaseSize()).To(Equal(stats.PageCount * stats.PageSize))
+			Expect(stats.WALSize).To(BeEquivalentTo(0))
+
+			size, err := client.Size(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(Equal(stats.DatabaseSize()))
+		})
+	})
+})
@@ -2,20 +2,36 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
+	"time"
 
-	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/writers"
+	"github.com/jtarchie/sqlettuce/db/drivers"
 )
 
+var ErrNotInteger = drivers.ErrNotInteger
+
 func (c *Client) AddInt(ctx context.Context, name string, value int64) (int64, error) {
-	intValue, err := c.writers.AddInt(ctx, &writers.AddIntParams{
-		Name:  name,
-		Value: strconv.FormatInt(value, 10),
+	defer c.measure("AddInt", time.Now())
+
+	var intValue int64
+
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		var err error
+
+		intValue, err = driver.AddInt(ctx, name, value)
+
+		return err //nolint:wrapcheck
 	})
+	if errors.Is(err, ErrNotInteger) {
+		return 0, ErrNotInteger
+	}
+
 	if err != nil {
 		return 0, fmt.Errorf("could not ADDINT: %w", err)
 	}
 
+	c.changed(ctx, []string{name})
+
 	return intValue, nil
 }
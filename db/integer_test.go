@@ -9,49 +9,44 @@ import (
 )
 
 var _ = Describe("Integer", func() {
-	var client *db.Client
+	forEachDriver(func(newClient func() *db.Client) {
+		var client *db.Client
 
-	BeforeEach(func() {
-		var err error
-
-		client, err = db.NewClient("sqlite://:memory:?cache=shared&mode=memory")
-		Expect(err).NotTo(HaveOccurred())
-	})
-
-	AfterEach(func() {
-		client.Close()
-	})
-
-	It("can increment and decrement a number", func() {
-		err := client.Set(context.Background(), "key", "10")
-		Expect(err).NotTo(HaveOccurred())
+		BeforeEach(func() {
+			client = newClient()
+		})
 
-		intValue, err := client.AddInt(context.Background(), "key", 1)
-		Expect(err).NotTo(HaveOccurred())
-		Expect(intValue).To(BeEquivalentTo(11))
+		It("can increment and decrement a number", func() {
+			err := client.Set(context.Background(), "key", "10")
+			Expect(err).NotTo(HaveOccurred())
 
-		value, found, err := client.Get(context.Background(), "key")
-		Expect(err).NotTo(HaveOccurred())
-		Expect(found).To(BeTrue())
-		Expect(value).To(Equal("11"))
+			intValue, err := client.AddInt(context.Background(), "key", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(intValue).To(BeEquivalentTo(11))
 
-		intValue, err = client.AddInt(context.Background(), "key", -1)
-		Expect(err).NotTo(HaveOccurred())
-		Expect(intValue).To(BeEquivalentTo(10))
+			value, found, err := client.Get(context.Background(), "key")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("11"))
 
-		value, found, err = client.Get(context.Background(), "key")
-		Expect(err).NotTo(HaveOccurred())
-		Expect(found).To(BeTrue())
-		Expect(value).To(Equal("10"))
-	})
+			intValue, err = client.AddInt(context.Background(), "key", -1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(intValue).To(BeEquivalentTo(10))
 
-	When("the value is non integer string", func() {
-		It("returns an error", func() {
-			err := client.Set(context.Background(), "key", "value")
+			value, found, err = client.Get(context.Background(), "key")
 			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("10"))
+		})
+
+		When("the value is non integer string", func() {
+			It("returns an error", func() {
+				err := client.Set(context.Background(), "key", "value")
+				Expect(err).NotTo(HaveOccurred())
 
-			_, err = client.AddInt(context.Background(), "key", 1)
-			Expect(err).To(HaveOccurred())
+				_, err = client.AddInt(context.Background(), "key", 1)
+				Expect(err).To(MatchError(db.ErrNotInteger))
+			})
 		})
 	})
 })
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+// DefaultJournalRetention is how long the changes of the journal are kept.
+const DefaultJournalRetention = 7 * 24 * time.Hour
+
+// Journals is true when the driver can record the changes of the keys.
+func (c *Client) Journals() bool {
+	_, ok := c.driver.(drivers.Journaler)
+
+	return ok
+}
+
+// SetJournal starts or stops recording every change of the keys, with the
+// time it was made, for point in time recovery. The journal starts with
+// every key when it is enabled.
+func (c *Client) SetJournal(ctx context.Context, enabled bool) error {
+	driver, ok := c.driver.(drivers.Journaler)
+	if !ok {
+		if !enabled {
+			return nil
+		}
+
+		return fmt.Errorf("could not enable journal: %w", errors.ErrUnsupported)
+	}
+
+	err := driver.SetJournal(ctx, enabled)
+	if err != nil {
+		return fmt.Errorf("could not set journal: %w", err)
+	}
+
+	return nil
+}
+
+// JournalStatus describes the changes kept in the journal.
+func (c *Client) JournalStatus(ctx context.Context) (*drivers.JournalStatus, error) {
+	driver, ok := c.driver.(drivers.Journaler)
+	if !ok {
+		return nil, fmt.Errorf("could not read journal: %w", errors.ErrUnsupported)
+	}
+
+	status, err := driver.JournalStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read journal: %w", err)
+	}
+
+	return status, nil
+}
+
+// SetJournalRetention changes how long the changes of the journal are kept,
+// they are removed by the maintenance. Zero keeps them forever.
+func (c *Client) SetJournalRetention(retention time.Duration) {
+	if c.maintenance == nil {
+		return
+	}
+
+	c.maintenance.journalRetention.Store(int64(retention))
+}
+
+// Recover applies the changes of the journal of the source that follow the
+// last change of the journal of the client, up to a time. The source must
+// be a SQLite file. The journal of the client is disabled, as the changes
+// are copied to it. It returns the number of changes applied.
+func (c *Client) Recover(ctx context.Context, source *Client, to time.Time) (int64, error) {
+	defer c.measure("Recover", time.Now())
+
+	driver, ok := c.driver.(drivers.Journaler)
+	if !ok {
+		return 0, fmt.Errorf("could not execute Recover: %w", errors.ErrUnsupported)
+	}
+
+	stats, err := source.Stats(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not read source: %w", err)
+	}
+
+	if stats.Filename == "" {
+		return 0, fmt.Errorf("could not execute Recover from a database in memory: %w", errors.ErrUnsupported)
+	}
+
+	applied, err := driver.Recover(ctx, stats.Filename, to)
+	if err != nil {
+		return 0, fmt.Errorf("could not execute Recover: %w", err)
+	}
+
+	c.changed(ctx, nil)
+
+	return applied, nil
+}
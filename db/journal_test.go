@@ -0,0 +1,193 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/db/drivers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Journal", func() {
+	var (
+		dir    string
+		client *db.Client
+	)
+
+	open := func(name string) *db.Client {
+		client, err := db.NewClient("sqlite://" + filepath.Join(dir, name))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(client.Close)
+
+		return client
+	}
+
+	keys := func(client *db.Client) map[string]string {
+		names, err := client.Keys(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		values, err := client.MGet(context.Background(), names...)
+		Expect(err).NotTo(HaveOccurred())
+
+		keys := map[string]string{}
+		for index, name := range names {
+			keys[name] = values[index]
+		}
+
+		return keys
+	}
+
+	// later waits for the next millisecond, so changes before and after it
+	// are told apart
+	later := func() time.Time {
+		time.Sleep(5 * time.Millisecond)
+		now := time.Now()
+		time.Sleep(5 * time.Millisecond)
+
+		return now
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		client = open("data.db")
+	})
+
+	It("records the changes once enabled, starting with the keys", func() {
+		ctx := context.Background()
+
+		Expect(client.Set(ctx, "before", "1")).To(Succeed())
+
+		status, err := client.JournalStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Enabled).To(BeFalse())
+		Expect(status.Entries).To(BeZero())
+
+		Expect(client.SetJournal(ctx, true)).To(Succeed())
+		Expect(client.SetJournal(ctx, true)).To(Succeed())
+		Expect(client.Set(ctx, "after", "2")).To(Succeed())
+		_, _, err = client.Delete(ctx, "before")
+		Expect(err).NotTo(HaveOccurred())
+
+		status, err = client.JournalStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Enabled).To(BeTrue())
+		// the removal of every key, the key, then the two changes
+		Expect(status.Entries).To(BeEquivalentTo(4))
+		Expect(status.First.ID).To(BeEquivalentTo(1))
+		Expect(status.Last.ID).To(BeEquivalentTo(4))
+		Expect(status.Last.At).To(BeTemporally("~", time.Now(), time.Second))
+
+		Expect(client.SetJournal(ctx, false)).To(Succeed())
+		Expect(client.Set(ctx, "ignored", "3")).To(Succeed())
+
+		status, err = client.JournalStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Enabled).To(BeFalse())
+		Expect(status.Entries).To(BeEquivalentTo(4))
+	})
+
+	It("recovers the keys as of a time", func() {
+		ctx := context.Background()
+
+		Expect(client.Set(ctx, "kept", "1")).To(Succeed())
+		Expect(client.SetJournal(ctx, true)).To(Succeed())
+		Expect(client.MSet(ctx, "a", "1", "b", "2")).To(Succeed())
+		_, _, err := client.ListRightPushUpsert(ctx, "list", "x")
+		Expect(err).NotTo(HaveOccurred())
+
+		first := later()
+
+		Expect(client.Set(ctx, "a", "3")).To(Succeed())
+		_, _, err = client.Delete(ctx, "b")
+		Expect(err).NotTo(HaveOccurred())
+
+		second := later()
+
+		Expect(client.FlushAll(ctx)).To(Succeed())
+		Expect(client.Set(ctx, "c", "4")).To(Succeed())
+
+		recovered := open("recovered.db")
+
+		applied, err := recovered.Recover(ctx, client, first)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applied).To(BeEquivalentTo(5))
+		Expect(keys(recovered)).To(Equal(map[string]string{
+			"kept": "1", "a": "1", "b": "2", "list": `["x"]`,
+		}))
+
+		// the journal follows the source, so recovery continues from it
+		_, err = recovered.Recover(ctx, client, second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys(recovered)).To(Equal(map[string]string{
+			"kept": "1", "a": "3", "list": `["x"]`,
+		}))
+
+		_, err = recovered.Recover(ctx, client, time.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys(recovered)).To(Equal(map[string]string{"c": "4"}))
+
+		status, err := recovered.JournalStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Enabled).To(BeFalse())
+
+		source, err := client.JournalStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Last).To(Equal(source.Last))
+	})
+
+	It("recovers from a snapshot", func() {
+		ctx := context.Background()
+		path := filepath.Join(dir, "snapshot.db")
+
+		Expect(client.SetJournal(ctx, true)).To(Succeed())
+		Expect(client.Set(ctx, "a", "1")).To(Succeed())
+		Expect(client.Snapshot(ctx, path)).To(Succeed())
+		Expect(client.Set(ctx, "a", "2")).To(Succeed())
+
+		// the changes before the snapshot are not needed anymore
+		_, _, err := client.Delete(ctx, "a")
+		Expect(err).NotTo(HaveOccurred())
+
+		snapshot := open("snapshot.db")
+
+		applied, err := snapshot.Recover(ctx, client, time.Now())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applied).To(BeEquivalentTo(2))
+		Expect(keys(snapshot)).To(BeEmpty())
+	})
+
+	It("does not recover when changes were trimmed", func() {
+		ctx := context.Background()
+
+		Expect(client.SetJournal(ctx, true)).To(Succeed())
+		Expect(client.Set(ctx, "a", "1")).To(Succeed())
+		Expect(client.Set(ctx, "a", "2")).To(Succeed())
+
+		client.SetJournalRetention(time.Millisecond)
+		later()
+		Expect(client.Maintain(ctx)).To(Succeed())
+
+		status, _ := client.MaintenanceStatus()
+		Expect(status.JournalTrimmed).To(BeEquivalentTo(3))
+		Expect(status.LastJournalTrim).NotTo(BeZero())
+
+		recovered := open("recovered.db")
+
+		_, err := recovered.Recover(ctx, client, time.Now())
+		Expect(errors.Is(err, drivers.ErrJournalGap)).To(BeTrue())
+	})
+
+	It("can't be enabled without SQLite", func() {
+		client, err := db.NewClient("memory://")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(client.Close)
+
+		Expect(client.Journals()).To(BeFalse())
+		Expect(client.SetJournal(context.Background(), false)).To(Succeed())
+		Expect(client.SetJournal(context.Background(), true)).To(MatchError(errors.ErrUnsupported))
+	})
+})
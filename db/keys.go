@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+// Keys returns the name of every key, sorted.
+func (c *Client) Keys(ctx context.Context) ([]string, error) {
+	defer c.measure("Keys", time.Now())
+
+	names, err := c.driver.Keys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not KEYS: %w", err)
+	}
+
+	return names, nil
+}
+
+// EachKey calls fn with the name of every key, sorted, until it returns
+// false. Drivers that can't iterate their keys have all of them read first.
+func (c *Client) EachKey(ctx context.Context, fn func(name string) bool) error {
+	defer c.measure("EachKey", time.Now())
+
+	if iterator, ok := c.driver.(drivers.KeyIterator); ok {
+		err := iterator.EachKey(ctx, fn)
+		if err != nil {
+			return fmt.Errorf("could not iterate keys: %w", err)
+		}
+
+		return nil
+	}
+
+	names, err := c.driver.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("could not KEYS: %w", err)
+	}
+
+	for _, name := range names {
+		if !fn(name) {
+			break
+		}
+	}
+
+	return nil
+}
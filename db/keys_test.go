@@ -0,0 +1,47 @@
+package db_test
+
+import (
+	"context"
+
+	"github.com/jtarchie/sqlettuce/db"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Keys", func() {
+	forEachDriver(func(newClient func() *db.Client) {
+		var client *db.Client
+
+		BeforeEach(func() {
+			client = newClient()
+		})
+
+		It("returns the sorted names", func() {
+			names, err := client.Keys(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(BeEmpty())
+
+			err = client.MSet(context.TODO(), "b", "1", "a", "2")
+			Expect(err).NotTo(HaveOccurred())
+
+			names, err = client.Keys(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(Equal([]string{"a", "b"}))
+		})
+
+		It("iterates the sorted names until told to stop", func() {
+			err := client.MSet(context.TODO(), "c", "1", "b", "2", "a", "3")
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+
+			err = client.EachKey(context.TODO(), func(name string) bool {
+				names = append(names, name)
+
+				return len(names) < 2
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(Equal([]string{"a", "b"}))
+		})
+	})
+})
@@ -2,17 +2,16 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
-	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/writers"
-	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/jtarchie/sqlettuce/db/drivers"
 )
 
 var (
-	ErrNotFound = errors.New("record was not found")
-	ErrNotArray = errors.New("not an array")
+	ErrNotFound = drivers.ErrNotFound
+	ErrNotArray = drivers.ErrNotArray
 )
 
 func (c *Client) ListInsert(
@@ -21,180 +20,145 @@ func (c *Client) ListInsert(
 	offset int64,
 	pivot, value string,
 ) (int64, bool, error) {
-	row := c.db.QueryRowContext(ctx, `
-	-- name: ListIndex :one
-	 UPDATE keys
-	 SET value = json_array_insert(
-	 		value,
-			?4,
-			?2,
-			?3
-		)
-	 WHERE name = ?1
-	 RETURNING json_array_length(value);
-	`, name, value, offset, pivot)
-	if row.Err() != nil {
-		return 0, false, fmt.Errorf("could not execute ListInsert: %w", row.Err())
-	}
+	defer c.measure("ListInsert", time.Now())
 
-	var newOffset int64
+	var (
+		length int64
+		found  bool
+	)
 
-	err := row.Scan(&newOffset)
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		var err error
 
-	if errors.Is(err, sql.ErrNoRows) {
-		return 0, false, nil
-	}
+		length, found, err = driver.ListInsert(ctx, name, offset, pivot, value)
 
+		return err //nolint:wrapcheck
+	})
 	if err != nil {
-		return 0, false, fmt.Errorf("could not scan ListInsert: %w", err)
+		return 0, false, fmt.Errorf("could not execute ListInsert: %w", err)
 	}
 
-	return newOffset, true, nil
-}
-
-func (c *Client) ListRange(ctx context.Context, name string, start, end int64) ([]string, error) {
-	rows, err := c.db.QueryContext(ctx, `
-	-- name: ListRange :many
-		SELECT json_each.value
-		FROM keys,
-			json_each(keys.value)
-		WHERE keys.name = ?1
-		AND json_each.key >= IIF(?2 >=0, ?2, json_array_length(keys.value) + ?2)
-		AND json_each.key <= IIF(?3 >=0, ?3, json_array_length(keys.value) + ?3);
-	`, name, start, end)
-	if err != nil {
-		return nil, fmt.Errorf("could not execute ListRange: %w", err)
+	if !found {
+		return 0, false, nil
 	}
-	defer rows.Close()
 
-	var values []string
+	c.changed(ctx, []string{name})
 
-	for rows.Next() {
-		var value string
+	return length, true, nil
+}
 
-		_ = rows.Scan(&value)
-		values = append(values, value)
+func (c *Client) ListRange(ctx context.Context, name string, start, end int64) ([]string, error) {
+	defer c.measure("ListRange", time.Now())
+
+	values, err := c.driver.ListRange(ctx, name, start, end)
+	if errors.Is(err, ErrNotArray) {
+		return nil, ErrNotArray
 	}
 
-	if rows.Err() != nil {
-		return nil, fmt.Errorf("could not execute ListRange: %w", rows.Err())
+	if err != nil {
+		return nil, fmt.Errorf("could not execute ListRange: %w", err)
 	}
 
 	return values, nil
 }
 
 func (c *Client) ListLength(ctx context.Context, name string) (int64, error) {
-	length, err := c.readers.ListLength(ctx, name)
+	defer c.measure("ListLength", time.Now())
 
-	if errors.Is(err, sql.ErrNoRows) {
-		return 0, nil
+	length, err := c.driver.ListLength(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		return 0, ErrNotFound
 	}
 
 	if err != nil {
-		return 0, fmt.Errorf("could not execute ListSet: %w", err)
-	}
-
-	if value, ok := length.(int64); ok {
-		return value, nil
+		return 0, fmt.Errorf("could not execute ListLength: %w", err)
 	}
 
-	return 0, ErrNotFound
+	return length, nil
 }
 
 func (c *Client) ListRightPush(ctx context.Context, name string, values ...string) (int64, error) {
-	transaction, err := c.db.Begin()
-	if err != nil {
-		return 0, fmt.Errorf("could not start ListRightPush: %w", err)
-	}
-	//nolint:errcheck
-	defer transaction.Rollback()
-
-	var result writers.ListRightPushRow
+	defer c.measure("ListRightPush", time.Now())
 
-	queries := c.writers.WithTx(transaction)
-
-	for _, value := range values {
-		result, err = queries.ListRightPush(ctx, &writers.ListRightPushParams{
-			Name:  name,
-			Value: value,
-		})
+	var length int64
 
-		if errors.Is(err, sql.ErrNoRows) {
-			return 0, nil
-		}
+	err := c.retry(ctx, func() error {
+		return c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+			var err error
 
-		//nolint:errorlint
-		if err, ok := err.(sqlite3.Error); ok && err.Error() == "malformed JSON" {
-			return 0, ErrNotArray
-		}
+			length, err = driver.ListRightPush(ctx, name, values...)
 
-		if err != nil {
-			return 0, fmt.Errorf("could not execute ListRightPush: %w", err)
-		}
+			return err //nolint:wrapcheck
+		})
+	})
+	if errors.Is(err, ErrNotArray) {
+		return 0, ErrNotArray
 	}
 
-	err = transaction.Commit()
 	if err != nil {
-		return 0, fmt.Errorf("could not ListRightPush: %w", err)
+		return 0, fmt.Errorf("could not execute ListRightPush: %w", err)
+	}
+
+	if length == 0 {
+		return 0, nil
 	}
 
-	return result.Column2, nil
+	c.changed(ctx, []string{name})
+
+	return length, nil
 }
 
 func (c *Client) ListRightPushUpsert(ctx context.Context, name string, values ...string) (int64, bool, error) {
-	transaction, err := c.db.Begin()
-	if err != nil {
-		return 0, false, fmt.Errorf("could not start ListRightPushUpsert: %w", err)
-	}
-	//nolint:errcheck
-	defer transaction.Rollback()
-
-	var result writers.ListRightPushUpsertRow
+	defer c.measure("ListRightPushUpsert", time.Now())
 
-	queries := c.writers.WithTx(transaction)
+	var (
+		length int64
+		valid  bool
+	)
 
-	for _, value := range values {
-		result, err = queries.ListRightPushUpsert(ctx, &writers.ListRightPushUpsertParams{
-			Name:  name,
-			Value: value,
-		})
+	err := c.retry(ctx, func() error {
+		return c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+			var err error
 
-		//nolint:errorlint
-		if err, ok := err.(sqlite3.Error); ok && err.Error() == "malformed JSON" {
-			return 0, true, nil
-		}
+			length, valid, err = driver.ListRightPushUpsert(ctx, name, values...)
 
-		if err != nil {
-			return 0, true, fmt.Errorf("could not execute ListRightPushUpsert: %w", err)
-		}
+			return err //nolint:wrapcheck
+		})
+	})
+	if err != nil {
+		return 0, true, fmt.Errorf("could not execute ListRightPushUpsert: %w", err)
 	}
 
-	err = transaction.Commit()
-	if err != nil {
-		return 0, false, fmt.Errorf("could not ListRightPushUpsert: %w", err)
+	if length == 0 {
+		return 0, valid, nil
 	}
 
-	return result.Column2, result.Column1, nil
+	c.changed(ctx, []string{name})
+
+	return length, valid, nil
 }
 
 func (c *Client) ListSet(ctx context.Context, name string, index int64, value string) (bool, error) {
-	valid, err := c.writers.ListSet(ctx, &writers.ListSetParams{
-		Name:  name,
-		Index: index,
-		Value: value,
-	})
+	defer c.measure("ListSet", time.Now())
 
-	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
-	}
+	var found bool
 
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		var err error
+
+		found, err = driver.ListSet(ctx, name, index, value)
+
+		return err //nolint:wrapcheck
+	})
 	if err != nil {
 		return false, fmt.Errorf("could not execute ListSet: %w", err)
 	}
 
-	if value, ok := valid.(int64); ok {
-		return value == 1, nil
+	if !found {
+		return false, nil
 	}
 
-	return false, nil
+	c.changed(ctx, []string{name})
+
+	return true, nil
 }
@@ -0,0 +1,307 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+const (
+	// DefaultCheckpointSize is the size of the write ahead log that is
+	// checkpointed, without waiting on readers.
+	DefaultCheckpointSize = 4 * 1024 * 1024
+	// DefaultTruncateSize is the size of the write ahead log that is
+	// checkpointed and truncated, waiting on readers.
+	DefaultTruncateSize = 64 * 1024 * 1024
+
+	maintenanceInterval = time.Second
+	optimizeInterval    = time.Hour
+	journalTrimInterval = time.Minute
+	vacuumPages         = 1000
+)
+
+// MaintenanceStatus describes the last runs of the maintenance tasks.
+type MaintenanceStatus struct {
+	Checkpoints    int64
+	LastCheckpoint time.Time
+	// LastCheckpointMode is empty before the first checkpoint.
+	LastCheckpointMode   string
+	LastCheckpointResult drivers.Checkpoint
+	Vacuums              int64
+	LastVacuum           time.Time
+	LastOptimize         time.Time
+	LastAnalyze          time.Time
+	LastJournalTrim      time.Time
+	// JournalTrimmed is the number of changes removed from the journal.
+	JournalTrimmed int64
+	// LastError is the error of the last task, or nil when it succeeded.
+	LastError error
+}
+
+// maintenance runs the tasks of a driver in the background: it checkpoints
+// the write ahead log when it grows, frees pages, keeps the statistics of
+// the query planner up to date, and removes the old changes of the journal.
+type maintenance struct {
+	driver drivers.Maintainer
+	// journal is nil when the driver has none.
+	journal drivers.Journaler
+	stats   func(ctx context.Context) (*drivers.Stats, error)
+
+	checkpointSize   atomic.Int64
+	truncateSize     atomic.Int64
+	journalRetention atomic.Int64
+
+	mutex  sync.Mutex
+	status MaintenanceStatus
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+func newMaintenance(
+	driver drivers.Maintainer,
+	journal drivers.Journaler,
+	stats func(ctx context.Context) (*drivers.Stats, error),
+) *maintenance {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	maintenance := &maintenance{
+		driver:  driver,
+		journal: journal,
+		stats:   stats,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+
+	maintenance.checkpointSize.Store(DefaultCheckpointSize)
+	maintenance.truncateSize.Store(DefaultTruncateSize)
+	maintenance.journalRetention.Store(int64(DefaultJournalRetention))
+
+	go maintenance.run(ctx)
+
+	return maintenance
+}
+
+func (m *maintenance) run(ctx context.Context) {
+	defer close(m.stopped)
+
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := m.maintain(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("could not maintain database", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// maintain runs the tasks that are due.
+func (m *maintenance) maintain(ctx context.Context) error {
+	stats, err := m.stats(ctx)
+	if err != nil {
+		return m.failed(fmt.Errorf("could not read stats: %w", err))
+	}
+
+	switch {
+	case stats.WALSize >= m.truncateSize.Load():
+		_, err = m.checkpoint(ctx, "TRUNCATE")
+	case stats.WALSize >= m.checkpointSize.Load():
+		_, err = m.checkpoint(ctx, "PASSIVE")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if stats.FreelistCount > 0 {
+		err = m.driver.Vacuum(ctx, vacuumPages)
+		if err != nil {
+			return m.failed(fmt.Errorf("could not vacuum: %w", err))
+		}
+
+		m.mutex.Lock()
+		m.status.Vacuums++
+		m.status.LastVacuum = time.Now()
+		m.mutex.Unlock()
+	}
+
+	m.mutex.Lock()
+	lastAnalyze, lastOptimize := m.status.LastAnalyze, m.status.LastOptimize
+	m.mutex.Unlock()
+
+	// every table is analyzed once, then only those that changed a lot
+	switch {
+	case lastAnalyze.IsZero():
+		err = m.driver.Optimize(ctx, true)
+		if err != nil {
+			return m.failed(fmt.Errorf("could not analyze: %w", err))
+		}
+
+		m.mutex.Lock()
+		m.status.LastAnalyze = time.Now()
+		m.status.LastOptimize = m.status.LastAnalyze
+		m.mutex.Unlock()
+	case time.Since(lastOptimize) >= optimizeInterval:
+		err = m.driver.Optimize(ctx, false)
+		if err != nil {
+			return m.failed(fmt.Errorf("could not optimize: %w", err))
+		}
+
+		m.mutex.Lock()
+		m.status.LastOptimize = time.Now()
+		m.mutex.Unlock()
+	}
+
+	err = m.trimJournal(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.failed(nil)
+}
+
+// trimJournal removes the changes older than the retention, at most once
+// every journalTrimInterval.
+func (m *maintenance) trimJournal(ctx context.Context) error {
+	retention := time.Duration(m.journalRetention.Load())
+	if m.journal == nil || retention <= 0 {
+		return nil
+	}
+
+	m.mutex.Lock()
+	lastTrim := m.status.LastJournalTrim
+	m.mutex.Unlock()
+
+	if time.Since(lastTrim) < journalTrimInterval {
+		return nil
+	}
+
+	trimmed, err := m.journal.TrimJournal(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return m.failed(fmt.Errorf("could not trim journal: %w", err))
+	}
+
+	m.mutex.Lock()
+	m.status.LastJournalTrim = time.Now()
+	m.status.JournalTrimmed += trimmed
+	m.mutex.Unlock()
+
+	return nil
+}
+
+func (m *maintenance) checkpoint(ctx context.Context, mode string) (*drivers.Checkpoint, error) {
+	checkpoint, err := m.driver.Checkpoint(ctx, mode)
+	if err != nil {
+		return nil, m.failed(fmt.Errorf("could not checkpoint: %w", err))
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.status.Checkpoints++
+	m.status.LastCheckpoint = time.Now()
+	m.status.LastCheckpointMode = mode
+	m.status.LastCheckpointResult = *checkpoint
+
+	return checkpoint, nil
+}
+
+// failed records the error of the last task, returning it.
+func (m *maintenance) failed(err error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.status.LastError = err
+
+	return err
+}
+
+func (m *maintenance) close() {
+	m.cancel()
+	<-m.stopped
+}
+
+// Checkpoint copies the write ahead log to the database, with a mode of
+// SQLite: PASSIVE, FULL, RESTART or TRUNCATE.
+func (c *Client) Checkpoint(ctx context.Context, mode string) (*drivers.Checkpoint, error) {
+	if c.maintenance == nil {
+		return nil, fmt.Errorf("could not checkpoint: %w", errors.ErrUnsupported)
+	}
+
+	checkpoint, err := c.maintenance.checkpoint(ctx, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+// Maintain runs the maintenance tasks that are due, without waiting for
+// the background schedule.
+func (c *Client) Maintain(ctx context.Context) error {
+	if c.maintenance == nil {
+		return fmt.Errorf("could not maintain: %w", errors.ErrUnsupported)
+	}
+
+	return c.maintenance.maintain(ctx)
+}
+
+// EnableAutoVacuum rebuilds a database created without auto_vacuum, so its
+// free pages are given back, reporting whether it was rebuilt.
+func (c *Client) EnableAutoVacuum(ctx context.Context) (bool, error) {
+	if c.maintenance == nil {
+		return false, fmt.Errorf("could not enable auto vacuum: %w", errors.ErrUnsupported)
+	}
+
+	rebuilt, err := c.maintenance.driver.EnableAutoVacuum(ctx)
+	if err != nil {
+		return false, fmt.Errorf("could not enable auto vacuum: %w", err)
+	}
+
+	return rebuilt, nil
+}
+
+// MaintenanceStatus returns the status of the maintenance tasks, false when
+// the driver has none.
+func (c *Client) MaintenanceStatus() (MaintenanceStatus, bool) {
+	if c.maintenance == nil {
+		return MaintenanceStatus{}, false
+	}
+
+	c.maintenance.mutex.Lock()
+	defer c.maintenance.mutex.Unlock()
+
+	return c.maintenance.status, true
+}
+
+// SetCheckpointSize changes the size of the write ahead log that is
+// checkpointed, without waiting on readers.
+func (c *Client) SetCheckpointSize(size int64) {
+	if c.maintenance == nil {
+		return
+	}
+
+	c.maintenance.checkpointSize.Store(size)
+}
+
+// SetTruncateSize changes the size of the write ahead log that is
+// checkpointed and truncated, waiting on readers.
+func (c *Client) SetTruncateSize(size int64) {
+	if c.maintenance == nil {
+		return
+	}
+
+	c.maintenance.truncateSize.Store(size)
+}
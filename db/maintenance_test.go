@@ -0,0 +1,129 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jtarchie/sqlettuce/db"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Maintenance", func() {
+	var client *db.Client
+
+	BeforeEach(func() {
+		var err error
+
+		client, err = db.NewClient("sqlite://" + filepath.Join(GinkgoT().TempDir(), "data.db"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(client.Close)
+	})
+
+	write := func(count int) {
+		args := make([]string, 0, count*2)
+		for index := range count {
+			args = append(args, fmt.Sprintf("key%d", index), strings.Repeat("value", 100))
+		}
+
+		err := client.MSet(context.Background(), args...)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	walSize := func() int64 {
+		stats, err := client.Stats(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		return stats.WALSize
+	}
+
+	It("checkpoints and truncates the write ahead log", func() {
+		write(100)
+		Expect(walSize()).To(BeNumerically(">", 0))
+
+		checkpoint, err := client.Checkpoint(context.Background(), "TRUNCATE")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoint.Busy).To(BeFalse())
+		Expect(walSize()).To(BeZero())
+
+		status, ok := client.MaintenanceStatus()
+		Expect(ok).To(BeTrue())
+		Expect(status.Checkpoints).To(BeEquivalentTo(1))
+		Expect(status.LastCheckpointMode).To(Equal("TRUNCATE"))
+	})
+
+	It("rejects unknown checkpoint modes", func() {
+		_, err := client.Checkpoint(context.Background(), "SOMETIMES")
+		Expect(err).To(HaveOccurred())
+
+		status, _ := client.MaintenanceStatus()
+		Expect(status.LastError).To(HaveOccurred())
+	})
+
+	It("checkpoints once the write ahead log is too large", func() {
+		write(100)
+
+		err := client.Maintain(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		status, _ := client.MaintenanceStatus()
+		Expect(status.Checkpoints).To(BeZero())
+		Expect(status.LastAnalyze).NotTo(BeZero())
+
+		client.SetCheckpointSize(1)
+
+		err = client.Maintain(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		status, _ = client.MaintenanceStatus()
+		Expect(status.Checkpoints).To(BeEquivalentTo(1))
+		Expect(status.LastCheckpointMode).To(Equal("PASSIVE"))
+		Expect(status.LastCheckpointResult.Checkpointed).To(Equal(status.LastCheckpointResult.Log))
+
+		client.SetTruncateSize(1)
+
+		err = client.Maintain(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		status, _ = client.MaintenanceStatus()
+		Expect(status.LastCheckpointMode).To(Equal("TRUNCATE"))
+		Expect(status.LastError).NotTo(HaveOccurred())
+		Expect(walSize()).To(BeZero())
+	})
+
+	It("frees the pages of removed keys", func() {
+		write(1000)
+
+		err := client.FlushAll(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		stats, err := client.Stats(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats.FreelistCount).To(BeNumerically(">", 0))
+
+		err = client.Maintain(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		stats, err = client.Stats(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats.FreelistCount).To(BeZero())
+
+		status, _ := client.MaintenanceStatus()
+		Expect(status.Vacuums).To(BeEquivalentTo(1))
+	})
+
+	It("is not supported by the memory driver", func() {
+		client, err := db.NewClient("memory://")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(client.Close)
+
+		_, ok := client.MaintenanceStatus()
+		Expect(ok).To(BeFalse())
+
+		_, err = client.Checkpoint(context.Background(), "PASSIVE")
+		Expect(errors.Is(err, errors.ErrUnsupported)).To(BeTrue())
+	})
+})
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+// SetPragma changes a setting of the database connection. The name and value
+// are not escaped, they must come from a validated configuration.
+func (c *Client) SetPragma(ctx context.Context, name, value string) error {
+	defer c.measure("SetPragma", time.Now())
+
+	driver, ok := c.driver.(drivers.Pragmas)
+	if !ok {
+		return fmt.Errorf("could not set PRAGMA %s: %w", name, errors.ErrUnsupported)
+	}
+
+	err := driver.SetPragma(ctx, name, value)
+	if err != nil {
+		return fmt.Errorf("could not set PRAGMA %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+// Snapshots is true when the driver can write and restore snapshots.
+func (c *Client) Snapshots() bool {
+	_, ok := c.driver.(drivers.Snapshotter)
+
+	return ok
+}
+
+// Snapshot writes a consistent copy of the database to a new SQLite file.
+// The file must not exist, or be empty.
+func (c *Client) Snapshot(ctx context.Context, path string) error {
+	defer c.measure("Snapshot", time.Now())
+
+	driver, ok := c.driver.(drivers.Snapshotter)
+	if !ok {
+		return fmt.Errorf("could not execute Snapshot: %w", errors.ErrUnsupported)
+	}
+
+	err := driver.Snapshot(ctx, path)
+	if err != nil {
+		return fmt.Errorf("could not execute Snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Restore replaces every key with the keys of a snapshot file.
+func (c *Client) Restore(ctx context.Context, path string) error {
+	defer c.measure("Restore", time.Now())
+
+	driver, ok := c.driver.(drivers.Snapshotter)
+	if !ok {
+		return fmt.Errorf("could not execute Restore: %w", errors.ErrUnsupported)
+	}
+
+	err := driver.Restore(ctx, path)
+	if err != nil {
+		return fmt.Errorf("could not execute Restore: %w", err)
+	}
+
+	c.changed(ctx, nil)
+
+	return nil
+}
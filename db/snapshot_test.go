@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/jtarchie/sqlettuce/db"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot", func() {
+	var client *db.Client
+
+	BeforeEach(func() {
+		var err error
+
+		client, err = db.NewClient("sqlite://:memory:?cache=shared&mode=memory")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		client.Close()
+	})
+
+	It("restores the keys of a snapshot", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "snapshot.db")
+
+		err := client.MSet(context.TODO(), "key1", "value1", "key2", "value2")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.Snapshot(context.TODO(), path)
+		Expect(err).NotTo(HaveOccurred())
+
+		other, err := db.NewClient("sqlite://:memory:")
+		Expect(err).NotTo(HaveOccurred())
+		defer other.Close()
+
+		err = other.Set(context.TODO(), "key3", "value3")
+		Expect(err).NotTo(HaveOccurred())
+
+		var changes [][]string
+
+		other.Observe(func(_ context.Context, names []string) {
+			changes = append(changes, names)
+		})
+
+		err = other.Restore(context.TODO(), path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal([][]string{nil}))
+
+		names, err := other.Keys(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"key1", "key2"}))
+
+		value, found, err := other.Get(context.TODO(), "key1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal("value1"))
+	})
+})
@@ -2,131 +2,144 @@ package db
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
-	"slices"
+	"time"
 
-	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/readers"
-	"github.com/jtarchie/sqlettuce/db/drivers/sqlite/writers"
+	"github.com/jtarchie/sqlettuce/db/drivers"
 )
 
 func (c *Client) Set(ctx context.Context, name, value string) error {
-	err := c.writers.Set(ctx, &writers.SetParams{
-		Name:  name,
-		Value: value,
+	defer c.measure("Set", time.Now())
+
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		return driver.Set(ctx, name, value)
 	})
 	if err != nil {
 		return fmt.Errorf("could not SET: %w", err)
 	}
 
+	c.changed(ctx, []string{name})
+
 	return nil
 }
 
 func (c *Client) MSet(ctx context.Context, args ...string) error {
-	transaction, err := c.db.Begin()
-	if err != nil {
-		return fmt.Errorf("could not start MSET: %w", err)
-	}
-	//nolint:errcheck
-	defer transaction.Rollback()
-
-	queries := c.writers.WithTx(transaction)
-	params := &writers.SetParams{}
+	defer c.measure("MSet", time.Now())
 
+	names := make([]string, 0, len(args)/2)
 	for index := 0; index < len(args); index += 2 {
-		params.Name = args[index]
-		params.Value = args[index+1]
-
-		err := queries.Set(ctx, params)
-		if err != nil {
-			return fmt.Errorf("could not set MSET: %w", err)
-		}
+		names = append(names, args[index])
 	}
 
-	err = transaction.Commit()
+	err := c.retry(ctx, func() error {
+		return c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+			return driver.MSet(ctx, args...)
+		})
+	})
 	if err != nil {
 		return fmt.Errorf("could not MSET: %w", err)
 	}
 
+	c.changed(ctx, names)
+
 	return nil
 }
 
 func (c *Client) Get(ctx context.Context, name string) (string, bool, error) {
-	value, err := c.readers.Get(ctx, name)
-
-	if errors.Is(err, sql.ErrNoRows) {
-		return "", false, nil
-	}
+	defer c.measure("Get", time.Now())
 
+	value, found, err := c.driver.Get(ctx, name)
 	if err != nil {
 		return "", false, fmt.Errorf("could not GET: %w", err)
 	}
 
+	if !found {
+		c.misses.Add(1)
+
+		return "", false, nil
+	}
+
+	c.hits.Add(1)
+
 	return value, true, nil
 }
 
 func (c *Client) MGet(ctx context.Context, names ...string) ([]string, error) {
-	results, err := c.batcher.Get(ctx, names)
-
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
-	}
+	defer c.measure("MGet", time.Now())
 
+	results, err := c.driver.MGet(ctx, names...)
 	if err != nil {
 		return nil, fmt.Errorf("could not MGET: %w", err)
 	}
 
 	values := make([]string, len(names))
 
-	for _, result := range results {
-		index := slices.Index(names, result.Name)
+	for index, name := range names {
+		value, ok := results[name]
+		if !ok {
+			c.misses.Add(1)
 
-		if index >= 0 {
-			values[index] = result.Value
+			continue
 		}
+
+		c.hits.Add(1)
+
+		values[index] = value
 	}
 
 	return values, nil
 }
 
 func (c *Client) Delete(ctx context.Context, names ...string) ([]string, bool, error) {
-	values, err := c.batcher.Delete(ctx, names)
+	defer c.measure("Delete", time.Now())
 
-	if errors.Is(err, sql.ErrNoRows) || len(values) == 0 {
-		return nil, false, nil
-	}
+	var values []string
+
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		var err error
 
+		values, err = driver.Delete(ctx, names...)
+
+		return err //nolint:wrapcheck
+	})
 	if err != nil {
 		return nil, false, fmt.Errorf("could not DELETE: %w", err)
 	}
 
+	if len(values) == 0 {
+		return nil, false, nil
+	}
+
+	c.changed(ctx, names)
+
 	return values, true, nil
 }
 
 func (c *Client) Append(ctx context.Context, name, value string) (int64, error) {
-	length, err := c.writers.AppendValue(ctx, &writers.AppendValueParams{
-		Name:  name,
-		Value: value,
+	defer c.measure("Append", time.Now())
+
+	var length int64
+
+	err := c.write(ctx, func(ctx context.Context, driver drivers.Driver) error {
+		var err error
+
+		length, err = driver.Append(ctx, name, value)
+
+		return err //nolint:wrapcheck
 	})
 	if err != nil {
 		return 0, fmt.Errorf("could not APPEND: %w", err)
 	}
 
-	return length.Int64, nil
+	c.changed(ctx, []string{name})
+
+	return length, nil
 }
 
 func (c *Client) Substr(ctx context.Context, name string, start, end int64) (string, error) {
-	value, err := c.readers.Substr(ctx, &readers.SubstrParams{
-		Name:  name,
-		Start: start,
-		End:   end,
-	})
-
-	if errors.Is(err, sql.ErrNoRows) {
-		return "", nil
-	}
+	defer c.measure("Substr", time.Now())
 
+	value, err := c.driver.Substr(ctx, name, start, end)
 	if err != nil {
 		return "", fmt.Errorf("could not SUBSTR: %w", err)
 	}
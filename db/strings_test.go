@@ -9,147 +9,163 @@ import (
 )
 
 var _ = Describe("Strings", func() {
-	var client *db.Client
+	forEachDriver(func(newClient func() *db.Client) {
+		var client *db.Client
 
-	BeforeEach(func() {
-		var err error
-
-		client, err = db.NewClient("sqlite://:memory:?cache=shared&mode=memory")
-		Expect(err).NotTo(HaveOccurred())
-	})
-
-	AfterEach(func() {
-		client.Close()
-	})
-
-	When("Set", func() {
-		It("sets a value", func() {
-			err := client.Set(context.TODO(), "key", "value")
-			Expect(err).NotTo(HaveOccurred())
-
-			value, found, err := client.Get(context.TODO(), "key")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeTrue())
-			Expect(value).To(Equal("value"))
+		BeforeEach(func() {
+			client = newClient()
 		})
-	})
 
-	When("MSet", func() {
-		It("can set multiple values", func() {
-			err := client.MSet(context.TODO(),
-				"key", "value",
-				"key1", "value1",
-			)
-			Expect(err).NotTo(HaveOccurred())
-
-			value, found, err := client.Get(context.TODO(), "key")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeTrue())
-			Expect(value).To(Equal("value"))
-
-			value, found, err = client.Get(context.TODO(), "key1")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeTrue())
-			Expect(value).To(Equal("value1"))
-		})
-	})
+		When("Set", func() {
+			It("sets a value", func() {
+				err := client.Set(context.TODO(), "key", "value")
+				Expect(err).NotTo(HaveOccurred())
 
-	When("Get", func() {
-		It("returns not found", func() {
-			value, found, err := client.Get(context.TODO(), "key")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeFalse())
-			Expect(value).To(Equal(""))
+				value, found, err := client.Get(context.TODO(), "key")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(value).To(Equal("value"))
+			})
 		})
-	})
 
-	When("MGet", func() {
-		It("returns values, nil if it does not exist", func() {
-			err := client.MSet(context.TODO(),
-				"key1", "value1",
-				"key2", "value2",
-			)
-			Expect(err).NotTo(HaveOccurred())
-
-			values, err := client.MGet(context.TODO(), "key1", "key3", "key2")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(values).To(HaveLen(3))
-			Expect(values).To(Equal([]string{
-				"value1", "", "value2",
-			}))
+		When("MSet", func() {
+			It("can set multiple values", func() {
+				err := client.MSet(context.TODO(),
+					"key", "value",
+					"key1", "value1",
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				value, found, err := client.Get(context.TODO(), "key")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(value).To(Equal("value"))
+
+				value, found, err = client.Get(context.TODO(), "key1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(value).To(Equal("value1"))
+			})
 		})
-	})
-
-	When("Delete", func() {
-		It("can delete a value", func() {
-			err := client.Set(context.TODO(), "key", "value")
-			Expect(err).NotTo(HaveOccurred())
-
-			values, found, err := client.Delete(context.TODO(), "key")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeTrue())
-			Expect(values).To(Equal([]string{"value"}))
 
-			value, found, err := client.Get(context.TODO(), "key")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeFalse())
-			Expect(value).To(Equal(""))
+		When("Get", func() {
+			It("returns not found", func() {
+				value, found, err := client.Get(context.TODO(), "key")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeFalse())
+				Expect(value).To(Equal(""))
+			})
 		})
 
-		It("does not fail on missing value", func() {
-			value, found, err := client.Delete(context.TODO(), "key")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeFalse())
-			Expect(value).To(BeEmpty())
+		When("MGet", func() {
+			It("returns values, nil if it does not exist", func() {
+				err := client.MSet(context.TODO(),
+					"key1", "value1",
+					"key2", "value2",
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				values, err := client.MGet(context.TODO(), "key1", "key3", "key2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(values).To(HaveLen(3))
+				Expect(values).To(Equal([]string{
+					"value1", "", "value2",
+				}))
+			})
 		})
-	})
 
-	When("Append", func() {
-		It("appends values to a string", func() {
-			length, err := client.Append(context.TODO(), "name", "Hello")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(length).To(BeEquivalentTo(5))
-
-			value, found, err := client.Get(context.TODO(), "name")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeTrue())
-			Expect(value).To(Equal("Hello"))
-
-			length, err = client.Append(context.TODO(), "name", " World")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(length).To(BeEquivalentTo(11))
-
-			value, found, err = client.Get(context.TODO(), "name")
-			Expect(err).NotTo(HaveOccurred())
-			Expect(found).To(BeTrue())
-			Expect(value).To(Equal("Hello World"))
+		When("Delete", func() {
+			It("can delete a value", func() {
+				err := client.Set(context.TODO(), "key", "value")
+				Expect(err).NotTo(HaveOccurred())
+
+				values, found, err := client.Delete(context.TODO(), "key")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(values).To(Equal([]string{"value"}))
+
+				value, found, err := client.Get(context.TODO(), "key")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeFalse())
+				Expect(value).To(Equal(""))
+			})
+
+			It("does not fail on missing value", func() {
+				value, found, err := client.Delete(context.TODO(), "key")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeFalse())
+				Expect(value).To(BeEmpty())
+			})
 		})
-	})
 
-	When("Substr", func() {
-		It("handles start and end, using negative indexes, too", func() {
-			err := client.Set(context.TODO(), "key", "This is a string")
-			Expect(err).NotTo(HaveOccurred())
-
-			value, err := client.Substr(context.TODO(), "key", 0, 3)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(value).To(Equal("This"))
-
-			value, err = client.Substr(context.TODO(), "key", -3, -1)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(value).To(Equal("ing"))
-
-			value, err = client.Substr(context.TODO(), "key", 0, -1)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(value).To(Equal("This is a string"))
-
-			value, err = client.Substr(context.TODO(), "key", 10, 100)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(value).To(Equal("string"))
+		When("Append", func() {
+			It("appends values to a string", func() {
+				length, err := client.Append(context.TODO(), "name", "Hello")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(length).To(BeEquivalentTo(5))
+
+				value, found, err := client.Get(context.TODO(), "name")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(value).To(Equal("Hello"))
+
+				length, err = client.Append(context.TODO(), "name", " World")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(length).To(BeEquivalentTo(11))
+
+				value, found, err = client.Get(context.TODO(), "name")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(value).To(Equal("Hello World"))
+			})
+		})
 
-			value, err = client.Substr(context.TODO(), "nokey", 0, 1)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(value).To(Equal(""))
+		When("Substr", func() {
+			It("handles start and end, using negative indexes, too", func() {
+				err := client.Set(context.TODO(), "key", "This is a string")
+				Expect(err).NotTo(HaveOccurred())
+
+				value, err := client.Substr(context.TODO(), "key", 0, 3)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal("This"))
+
+				value, err = client.Substr(context.TODO(), "key", -3, -1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal("ing"))
+
+				value, err = client.Substr(context.TODO(), "key", 0, -1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal("This is a string"))
+
+				value, err = client.Substr(context.TODO(), "key", 10, 100)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal("string"))
+
+				value, err = client.Substr(context.TODO(), "nokey", 0, 1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal(""))
+			})
+
+			It("counts characters", func() {
+				err := client.Set(context.TODO(), "key", "héllo wörld")
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, substr := range []struct {
+					start, end int64
+					expected   string
+				}{
+					{0, 3, "héll"},
+					{-5, -2, "wörld"},
+					{0, 0, "h"},
+					{-1, -1, "d"},
+					{-100, 2, ""},
+					{20, 30, ""},
+				} {
+					value, err := client.Substr(context.TODO(), "key", substr.start, substr.end)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(value).To(Equal(substr.expected))
+				}
+			})
 		})
 	})
 })
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db/drivers"
+)
+
+const (
+	maxTransactionRetries = 5
+	transactionRetryDelay = 10 * time.Millisecond
+)
+
+// retry runs an operation of the driver, again while the database is busy.
+func (c *Client) retry(ctx context.Context, fn func() error) error {
+	var err error
+
+	for attempt := 0; attempt <= maxTransactionRetries; attempt++ {
+		if attempt > 0 {
+			c.retries.Add(1)
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("could not retry transaction: %w", ctx.Err())
+			case <-time.After(time.Duration(attempt) * transactionRetryDelay):
+			}
+		}
+
+		err = fn()
+		if err == nil || !errors.Is(err, drivers.ErrBusy) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// StatementObserver is called with the duration of each operation executed
+// against the database.
+type StatementObserver func(operation string, duration time.Duration)
+
+// ObserveStatements registers an observer for every operation.
+// It is not safe to call concurrently with operations.
+func (c *Client) ObserveStatements(observer StatementObserver) {
+	c.statementObservers = append(c.statementObservers, observer)
+}
+
+func (c *Client) measure(operation string, started time.Time) {
+	duration := time.Since(started)
+
+	for _, observer := range c.statementObservers {
+		observer(operation, duration)
+	}
+}
@@ -0,0 +1,106 @@
+// Package glob implements the glob-style pattern matching used by redis for
+// commands like KEYS, PUBSUB CHANNELS and CONFIG GET.
+package glob
+
+// Match reports whether the string matches the pattern. It supports `*`,
+// `?`, character classes like `[a-z]` and `[^a]`, and `\` escapes.
+//
+//nolint:cyclop,gocognit
+func Match(pattern, value string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for index := 0; index <= len(value); index++ {
+				if Match(pattern[1:], value[index:]) {
+					return true
+				}
+			}
+
+			return false
+		case '?':
+			if len(value) == 0 {
+				return false
+			}
+
+			value = value[1:]
+		case '[':
+			if len(value) == 0 {
+				return false
+			}
+
+			pattern = pattern[1:]
+
+			negate := len(pattern) > 0 && pattern[0] == '^'
+			if negate {
+				pattern = pattern[1:]
+			}
+
+			matched := false
+
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					pattern = pattern[1:]
+
+					if pattern[0] == value[0] {
+						matched = true
+					}
+				case len(pattern) >= 3 && pattern[1] == '-':
+					start, end := pattern[0], pattern[2]
+					if start > end {
+						start, end = end, start
+					}
+
+					pattern = pattern[2:]
+
+					if start <= value[0] && value[0] <= end {
+						matched = true
+					}
+				case pattern[0] == value[0]:
+					matched = true
+				}
+
+				pattern = pattern[1:]
+			}
+
+			if negate {
+				matched = !matched
+			}
+
+			if !matched {
+				return false
+			}
+
+			value = value[1:]
+
+			// an unterminated class matches till the end of the pattern
+			if len(pattern) == 0 {
+				return len(value) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+
+			fallthrough
+		default:
+			if len(value) == 0 || pattern[0] != value[0] {
+				return false
+			}
+
+			value = value[1:]
+		}
+
+		pattern = pattern[1:]
+	}
+
+	return len(value) == 0
+}
@@ -0,0 +1,32 @@
+package glob_test
+
+import (
+	"github.com/jtarchie/sqlettuce/glob"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Match", func() {
+	DescribeTable("matching patterns",
+		func(pattern, value string, expected bool) {
+			Expect(glob.Match(pattern, value)).To(Equal(expected))
+		},
+		Entry("exact", "hello", "hello", true),
+		Entry("exact mismatch", "hello", "hell", false),
+		Entry("star", "h*o", "hello", true),
+		Entry("star empty", "h*", "h", true),
+		Entry("star across separators", "*", "a/b:c", true),
+		Entry("multiple stars", "*l*o", "hello", true),
+		Entry("question", "h?llo", "hallo", true),
+		Entry("question missing", "h?llo", "hllo", false),
+		Entry("class", "h[ae]llo", "hello", true),
+		Entry("class mismatch", "h[ae]llo", "hillo", false),
+		Entry("negated class", "h[^e]llo", "hallo", true),
+		Entry("negated class mismatch", "h[^e]llo", "hello", false),
+		Entry("range", "h[a-f]llo", "hello", true),
+		Entry("range mismatch", "h[a-d]llo", "hello", false),
+		Entry("escape", `h\*llo`, "h*llo", true),
+		Entry("escape mismatch", `h\*llo`, "hello", false),
+		Entry("config names", "max*", "maxmemory", true),
+	)
+})
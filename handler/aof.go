@@ -0,0 +1,372 @@
+//nolint:ireturn
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jtarchie/sqlettuce/aof"
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+var (
+	ErrNotAOFFilename = errors.New("appendfilename can't be a path, just a filename")
+	ErrAOFTruncated   = errors.New("the AOF ends with an incomplete command, aof-load-truncated is disabled")
+)
+
+const (
+	errAOFRewriteInProgress = router.Error("ERR Background append only file rewriting already in progress")
+	errAOFDisabled          = router.Error("ERR the AOF is disabled, enable it with CONFIG SET appendonly yes")
+)
+
+const (
+	defaultAOFFilename          = "appendonly.aof"
+	defaultAOFRewritePercentage = 100
+	defaultAOFRewriteMinSize    = 64 * 1024 * 1024
+
+	// rewriteBatchSize is the number of keys read at once when rewriting.
+	rewriteBatchSize = 1000
+)
+
+// appendOnlySettings are the config of the AOF. They are applied while the
+// config is locked, so they can't be read from it.
+type appendOnlySettings struct {
+	mutex         sync.Mutex
+	enabled       bool
+	loaded        bool
+	filename      string
+	policy        aof.Policy
+	loadTruncated bool
+	percentage    int64
+	minSize       int64
+}
+
+func (h *Handler) appendOnlyPath() string {
+	h.aofSettings.mutex.Lock()
+	defer h.aofSettings.mutex.Unlock()
+
+	return filepath.Join(h.saver.Dir(), h.aofSettings.filename)
+}
+
+// execute runs a write command of the keys, then propagates it to the
+// replicas and appends it to the AOF, once it succeeded.
+func (h *Handler) execute(keys []string, tokens []string, execute func() error) error {
+	return h.executeFunc(keys, func() ([]string, error) {
+		return tokens, execute()
+	})
+}
+
+// executeFunc is execute for a write that returns the command propagated and
+// appended, if any, like MIGRATE.
+func (h *Handler) executeFunc(keys []string, execute func() ([]string, error)) error {
+	err := h.primary.ExecuteFunc(keys, func() ([]string, error) {
+		tokens, err := execute()
+		if err != nil || len(tokens) == 0 {
+			return nil, err
+		}
+
+		file := h.appendOnly.Load()
+		if file == nil {
+			return tokens, nil
+		}
+
+		return tokens, file.Append(tokens...) //nolint:wrapcheck
+	})
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	h.autoRewriteAppendOnly()
+
+	return nil
+}
+
+// autoRewriteAppendOnly rewrites the AOF in the background once it grew
+// enough, like auto-aof-rewrite-percentage.
+func (h *Handler) autoRewriteAppendOnly() {
+	file := h.appendOnly.Load()
+	if file == nil {
+		return
+	}
+
+	h.aofSettings.mutex.Lock()
+	percentage, minSize := h.aofSettings.percentage, h.aofSettings.minSize
+	h.aofSettings.mutex.Unlock()
+
+	if !file.NeedsRewrite(percentage, minSize) {
+		return
+	}
+
+	go func() {
+		err := h.rewriteAppendOnly(context.Background(), file)
+		if err != nil && !errors.Is(err, aof.ErrInProgress) {
+			slog.Error("could not rewrite the AOF", slog.String("error", err.Error()))
+		}
+	}()
+}
+
+// LoadAppendOnly replays the AOF once the config is loaded, when it is
+// enabled. Like Redis, the AOF replaces the keys of the database. Without a
+// file, it is created from the keys.
+func (h *Handler) LoadAppendOnly(ctx context.Context) error {
+	h.aofSettings.mutex.Lock()
+	h.aofSettings.loaded = true
+	enabled, policy := h.aofSettings.enabled, h.aofSettings.policy
+	h.aofSettings.mutex.Unlock()
+
+	if !enabled {
+		return nil
+	}
+
+	path := h.appendOnlyPath()
+
+	_, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h.enableAppendOnly(ctx)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not read AOF: %w", err)
+	}
+
+	err = h.replayAppendOnly(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	file, err := aof.Open(path, policy)
+	if err != nil {
+		return fmt.Errorf("could not open AOF: %w", err)
+	}
+
+	h.appendOnly.Store(file)
+
+	return nil
+}
+
+// setAppendOnly enables or disables the AOF, once it was loaded.
+func (h *Handler) setAppendOnly(enabled bool) error {
+	h.aofSettings.mutex.Lock()
+	h.aofSettings.enabled = enabled
+	loaded := h.aofSettings.loaded
+	h.aofSettings.mutex.Unlock()
+
+	if !loaded {
+		return nil
+	}
+
+	if !enabled {
+		file := h.appendOnly.Swap(nil)
+		if file == nil {
+			return nil
+		}
+
+		err := file.Close()
+		if err != nil {
+			return fmt.Errorf("could not close AOF: %w", err)
+		}
+
+		return nil
+	}
+
+	if h.appendOnly.Load() != nil {
+		return nil
+	}
+
+	return h.enableAppendOnly(context.Background())
+}
+
+// enableAppendOnly creates the AOF from the keys, with a rewrite. The file
+// only replaces an existing one once the rewrite is complete.
+func (h *Handler) enableAppendOnly(ctx context.Context) error {
+	if !h.client.Snapshots() {
+		return fmt.Errorf("could not create AOF: %w", errors.ErrUnsupported)
+	}
+
+	h.aofSettings.mutex.Lock()
+	policy := h.aofSettings.policy
+	h.aofSettings.mutex.Unlock()
+
+	file := aof.New(h.appendOnlyPath(), policy)
+	h.appendOnly.Store(file)
+
+	err := h.rewriteAppendOnly(ctx, file)
+	if err != nil {
+		h.appendOnly.Store(nil)
+		_ = file.Close()
+
+		return fmt.Errorf("could not create AOF: %w", err)
+	}
+
+	return nil
+}
+
+// rewriteAppendOnly starts rewriting the AOF in the background, from a
+// snapshot of the keys. The snapshot is taken between two writes, so the
+// commands appended after it follow the keys in the rewritten file.
+func (h *Handler) rewriteAppendOnly(ctx context.Context, file *aof.File) error {
+	dir, err := os.MkdirTemp("", "sqlettuce-aof")
+	if err != nil {
+		return fmt.Errorf("could not create directory for snapshot: %w", err)
+	}
+
+	path := filepath.Join(dir, "snapshot.db")
+
+	err = h.primary.Pause(func() error {
+		err := file.StartRewrite()
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		err = h.client.Snapshot(ctx, path)
+		if err != nil {
+			file.AbortRewrite(err)
+
+			return fmt.Errorf("could not snapshot: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = os.RemoveAll(dir)
+
+		return err //nolint:wrapcheck
+	}
+
+	go func() {
+		defer os.RemoveAll(dir)
+
+		err := file.FinishRewrite(func(writer io.Writer) error {
+			return writeSnapshot(context.Background(), path, writer)
+		})
+		if err != nil {
+			slog.Error("could not rewrite the AOF", slog.String("error", err.Error()))
+
+			return
+		}
+
+		slog.Info("rewrote the AOF", slog.String("path", file.Path()))
+	}()
+
+	return nil
+}
+
+// writeSnapshot writes the commands creating the keys of a snapshot.
+func writeSnapshot(ctx context.Context, path string, writer io.Writer) error {
+	client, err := db.NewClient("sqlite://" + path)
+	if err != nil {
+		return fmt.Errorf("could not open snapshot: %w", err)
+	}
+	defer client.Close()
+
+	names, err := client.Keys(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read keys: %w", err)
+	}
+
+	for start := 0; start < len(names); start += rewriteBatchSize {
+		entries, err := client.Entries(ctx, names[start:min(start+rewriteBatchSize, len(names))]...)
+		if err != nil {
+			return fmt.Errorf("could not read keys: %w", err)
+		}
+
+		for _, entry := range entries {
+			err = aof.WriteEntry(writer, entry)
+			if err != nil {
+				return fmt.Errorf("could not write key: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// replayAppendOnly replaces the keys with the commands of the AOF, executed
+// as a client, like Redis. An incomplete command at the end of the file is
+// removed, unless aof-load-truncated is disabled.
+func (h *Handler) replayAppendOnly(ctx context.Context, path string) error {
+	current := h.clients.Register("", "", clients.NewWriter(io.Discard), nil)
+	defer h.clients.Unregister(current)
+
+	routes := h.NewRoutes(ctx, current)
+	writer := clients.NewWriter(io.Discard)
+
+	h.aofSettings.mutex.Lock()
+	loadTruncated := h.aofSettings.loadTruncated
+	h.aofSettings.mutex.Unlock()
+
+	//nolint:wrapcheck
+	return h.primary.Pause(func() error {
+		err := h.client.FlushAll(ctx)
+		if err != nil {
+			return fmt.Errorf("could not flush before replaying AOF: %w", err)
+		}
+
+		replayed, err := aof.Replay(path, func(tokens []string) error {
+			current.Touch(routes.Name(tokens))
+
+			callback, _ := routes.Lookup(tokens)
+
+			defer writer.Discard()
+
+			return callback(tokens, writer)
+		})
+		if err != nil {
+			return fmt.Errorf("could not replay AOF: %w", err)
+		}
+
+		if replayed.Truncated {
+			if !loadTruncated {
+				return ErrAOFTruncated
+			}
+
+			slog.Warn("removing the incomplete command at the end of the AOF", slog.Int64("size", replayed.Size))
+
+			err = os.Truncate(path, replayed.Size)
+			if err != nil {
+				return fmt.Errorf("could not truncate AOF: %w", err)
+			}
+		}
+
+		slog.Info("replayed the AOF", slog.String("path", path), slog.Int64("commands", replayed.Commands))
+
+		return nil
+	})
+}
+
+func bgrewriteaofRouter(ctx context.Context, h *Handler) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		file := h.appendOnly.Load()
+		if file == nil {
+			return errAOFDisabled
+		}
+
+		if !h.client.Snapshots() {
+			return router.Error("ERR snapshots are not supported by the storage driver")
+		}
+
+		err := h.rewriteAppendOnly(ctx, file)
+		if errors.Is(err, aof.ErrInProgress) {
+			return errAOFRewriteInProgress
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not start AOF rewrite: %w", err)
+		}
+
+		_, err = io.WriteString(conn, "+Background append only file rewriting started\r\n")
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
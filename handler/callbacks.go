@@ -3,6 +3,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -16,7 +17,7 @@ func strlenRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(1, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		value, _, err := client.Get(ctx, tokens[1])
 		if err != nil {
 			return fmt.Errorf("could not execute GET: %w", err)
@@ -35,19 +36,14 @@ func rpushRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(2, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		value, found, err := client.ListRightPushUpsert(ctx, tokens[1], tokens[2:]...)
 		if err != nil {
 			return fmt.Errorf("could not execute RPUSH: %w", err)
 		}
 
 		if !found {
-			err = writeError(conn, "Not an array value")
-			if err != nil {
-				return fmt.Errorf("could not send reply: %w", err)
-			}
-
-			return nil
+			return router.ErrWrongType
 		}
 
 		err = writeInt(conn, value)
@@ -63,11 +59,13 @@ func rpushXRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(2, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		value, err := client.ListRightPush(ctx, tokens[1], tokens[2:]...)
-		if err != nil {
-			_ = writeError(conn, "WRONGTYPE Operation against a key holding the wrong kind of value")
+		if errors.Is(err, db.ErrNotArray) {
+			return router.ErrWrongType
+		}
 
+		if err != nil {
 			return fmt.Errorf("could not execute RPUSHX: %w", err)
 		}
 
@@ -81,7 +79,7 @@ func rpushXRouter(
 }
 
 func echoRouter() router.Router {
-	return router.MinMaxTokensRouter(1, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		err := writeBulkString(conn, tokens[1])
 		if err != nil {
 			return fmt.Errorf("could not echo message: %w", err)
@@ -95,7 +93,7 @@ func getRangeRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(3, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		start, _ := strconv.Atoi(tokens[2])
 		end, _ := strconv.Atoi(tokens[3])
 
@@ -117,15 +115,17 @@ func incrByFloatRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(2, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		value, err := strconv.ParseFloat(tokens[2], 64)
 		if err != nil {
-			_ = writeError(conn, "Expected float value to increment")
-
-			return fmt.Errorf("could not parse float: %w", err)
+			return router.ErrNotFloat
 		}
 
 		value, err = client.AddFloat(ctx, tokens[1], value)
+		if errors.Is(err, db.ErrNotFloat) {
+			return router.ErrNotFloat
+		}
+
 		if err != nil {
 			return fmt.Errorf("could not execute INCRBYFLOAT: %w", err)
 		}
@@ -143,15 +143,17 @@ func decrByRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(2, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		incr, err := strconv.ParseInt(tokens[2], 10, 64)
 		if err != nil {
-			_ = writeError(conn, "Expected integer value to increment")
-
-			return fmt.Errorf("could not parse integer: %w", err)
+			return router.ErrNotInteger
 		}
 
 		value, err := client.AddInt(ctx, tokens[1], -incr)
+		if errors.Is(err, db.ErrNotInteger) {
+			return router.ErrNotInteger
+		}
+
 		if err != nil {
 			return fmt.Errorf("could not execute DECRBY: %w", err)
 		}
@@ -169,15 +171,17 @@ func incrByRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(2, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		incr, err := strconv.ParseInt(tokens[2], 10, 64)
 		if err != nil {
-			_ = writeError(conn, "Expected integer value to increment")
-
-			return fmt.Errorf("could not parse integer: %w", err)
+			return router.ErrNotInteger
 		}
 
 		value, err := client.AddInt(ctx, tokens[1], incr)
+		if errors.Is(err, db.ErrNotInteger) {
+			return router.ErrNotInteger
+		}
+
 		if err != nil {
 			return fmt.Errorf("could not execute INCRBY: %w", err)
 		}
@@ -195,8 +199,12 @@ func incrRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(1, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		value, err := client.AddInt(ctx, tokens[1], 1)
+		if errors.Is(err, db.ErrNotInteger) {
+			return router.ErrNotInteger
+		}
+
 		if err != nil {
 			return fmt.Errorf("could not execute INCR: %w", err)
 		}
@@ -214,11 +222,13 @@ func decrRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(1, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		value, err := client.AddInt(ctx, tokens[1], -1)
-		if err != nil {
-			_ = writeError(conn, "value is not an integer or out of range")
+		if errors.Is(err, db.ErrNotInteger) {
+			return router.ErrNotInteger
+		}
 
+		if err != nil {
 			return fmt.Errorf("could not execute DECR: %w", err)
 		}
 
@@ -235,14 +245,16 @@ func lrangeRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(3, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		start, _ := strconv.ParseInt(tokens[2], 10, 64)
 		end, _ := strconv.ParseInt(tokens[3], 10, 64)
 
 		values, err := client.ListRange(ctx, tokens[1], start, end)
-		if err != nil {
-			_ = writeError(conn, "value is not an array")
+		if errors.Is(err, db.ErrNotArray) {
+			return router.ErrWrongType
+		}
 
+		if err != nil {
 			return fmt.Errorf("could not execute LRANGE: %w", err)
 		}
 
@@ -268,7 +280,7 @@ func appendRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(2, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		value, err := client.Append(ctx, tokens[1], tokens[2])
 		if err != nil {
 			return fmt.Errorf("could not execute APPEND: %w", err)
@@ -287,7 +299,7 @@ func getDelRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(1, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		values, found, err := client.Delete(ctx, tokens[1])
 		if err != nil {
 			return fmt.Errorf("could not execute GET: %w", err)
@@ -315,7 +327,7 @@ func mgetRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(1, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		values, err := client.MGet(ctx, tokens[1:]...)
 		if err != nil {
 			return fmt.Errorf("could not execute GET: %w", err)
@@ -343,7 +355,7 @@ func getRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(1, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		value, found, err := client.Get(ctx, tokens[1])
 		if err != nil {
 			return fmt.Errorf("could not execute GET: %w", err)
@@ -371,12 +383,10 @@ func msetRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(2, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		if len(tokens[1:])%2 != 0 {
 			// require even number of tokens for key-value pairs
-			_ = writeError(conn, "Expected key-value pair, not enough tokens")
-
-			return fmt.Errorf("require even number of tokens: %w", ErrIncorrectTokens)
+			return router.WrongArity("mset")
 		}
 
 		err := client.MSet(ctx, tokens[1:]...)
@@ -397,7 +407,7 @@ func setRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(2, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		err := client.Set(ctx, tokens[1], tokens[2])
 		if err != nil {
 			return fmt.Errorf("could not execute SET: %w", err)
@@ -416,7 +426,7 @@ func delRouter(
 	ctx context.Context,
 	client *db.Client,
 ) router.Router {
-	return router.MinMaxTokensRouter(1, 0, func(tokens []string, conn io.Writer) error {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
 		values, _, err := client.Delete(ctx, tokens[1:]...)
 		if err != nil {
 			_ = writeInt(conn, int64(len(values)))
@@ -0,0 +1,552 @@
+//nolint:ireturn
+package handler
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/router"
+	"github.com/jtarchie/sqlettuce/tracking"
+)
+
+// Version is the redis version the server is compatible with.
+const Version = "7.2.0"
+
+func clientRouter(
+	registry *clients.Registry,
+	table *tracking.Table,
+	current *clients.Client,
+) router.Router {
+	return router.Command{
+		"CACHING":      clientCachingRouter(table, current),
+		"GETNAME":      clientGetNameRouter(current),
+		"GETREDIR":     clientGetRedirRouter(table, current),
+		"ID":           clientIDRouter(current),
+		"INFO":         clientInfoRouter(current),
+		"KILL":         clientKillRouter(registry, current),
+		"LIST":         clientListRouter(registry),
+		"NO-EVICT":     clientNoEvictRouter(current),
+		"PAUSE":        clientPauseRouter(registry),
+		"SETINFO":      clientSetInfoRouter(current),
+		"SETNAME":      clientSetNameRouter(current),
+		"TRACKING":     clientTrackingRouter(registry, table, current),
+		"TRACKINGINFO": clientTrackingInfoRouter(table, current),
+		"UNPAUSE":      clientUnpauseRouter(registry),
+	}
+}
+
+func helloRouter(h *Handler, current *clients.Client) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		args := tokens[1:]
+		protocol := current.Protocol()
+
+		if 0 < len(args) {
+			version, err := strconv.Atoi(args[0])
+			if err != nil {
+				return router.Error("ERR Protocol version is not an integer or out of range")
+			}
+
+			if version < 2 || 3 < version {
+				return router.Error("NOPROTO unsupported protocol version")
+			}
+
+			protocol = version
+			args = args[1:]
+		}
+
+		var name *string
+
+		for 0 < len(args) {
+			switch {
+			// authentication is not supported, so every user is accepted
+			case strings.EqualFold(args[0], "AUTH") && 3 <= len(args):
+				args = args[3:]
+			case strings.EqualFold(args[0], "SETNAME") && 2 <= len(args):
+				if !validClientValue(args[1]) {
+					return router.Error("ERR Client names cannot contain spaces, newlines or special characters.")
+				}
+
+				name = &args[1]
+				args = args[2:]
+			default:
+				return router.Error("ERR Syntax error in HELLO option '" + args[0] + "'")
+			}
+		}
+
+		if name != nil {
+			current.SetName(*name)
+		}
+
+		current.SetProtocol(protocol)
+
+		_ = writeMap(conn, protocol, 7)
+		_ = writeBulkString(conn, "server")
+		_ = writeBulkString(conn, "redis")
+		_ = writeBulkString(conn, "version")
+		_ = writeBulkString(conn, Version)
+		_ = writeBulkString(conn, "proto")
+		_ = writeInt(conn, int64(protocol))
+		_ = writeBulkString(conn, "id")
+		_ = writeInt(conn, int64(current.ID))
+		_ = writeBulkString(conn, "mode")
+		_ = writeBulkString(conn, h.mode())
+		_ = writeBulkString(conn, "role")
+		_ = writeBulkString(conn, h.helloRole())
+		_ = writeBulkString(conn, "modules")
+
+		err := writeArray(conn, 0)
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// helloRole is the role reported by HELLO, which names replicas unlike INFO.
+func (h *Handler) helloRole() string {
+	if h.replica.Load() != nil {
+		return "replica"
+	}
+
+	return "master"
+}
+
+func clientIDRouter(current *clients.Client) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		err := writeInt(conn, int64(current.ID))
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientInfoRouter(current *clients.Client) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		err := writeBulkString(conn, current.String()+"\n")
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientGetNameRouter(current *clients.Client) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		name := current.Name()
+		if name == "" {
+			_, err := io.WriteString(conn, router.NullResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}
+
+		err := writeBulkString(conn, name)
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientSetNameRouter(current *clients.Client) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		name := tokens[2]
+
+		if !validClientValue(name) {
+			return router.Error("ERR Client names cannot contain spaces, newlines or special characters.")
+		}
+
+		current.SetName(name)
+
+		_, err := io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientSetInfoRouter(current *clients.Client) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		attribute, value := strings.ToUpper(tokens[2]), tokens[3]
+
+		if !validClientValue(value) {
+			return router.Error("ERR " + attribute + " cannot contain spaces, newlines or special characters.")
+		}
+
+		switch attribute {
+		case "LIB-NAME":
+			current.SetLibName(value)
+		case "LIB-VER":
+			current.SetLibVersion(value)
+		default:
+			return router.Error("ERR Unrecognized option '" + tokens[2] + "'")
+		}
+
+		_, err := io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientListRouter(registry *clients.Registry) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		filter := clients.Filter{}
+		args := tokens[2:]
+
+		if 0 < len(args) {
+			switch strings.ToUpper(args[0]) {
+			case "TYPE":
+				if len(args) != 2 {
+					return router.ErrSyntax
+				}
+
+				clientType, err := clients.ParseType(args[1])
+				if err != nil {
+					return router.Error(fmt.Sprintf("ERR Unknown client type '%s'", args[1]))
+				}
+
+				filter.Type = clientType
+			case "ID":
+				for _, rawID := range args[1:] {
+					id, err := strconv.ParseUint(rawID, 10, 64)
+					if err != nil || id == 0 {
+						return router.Error("ERR Invalid client ID")
+					}
+
+					filter.IDs = append(filter.IDs, id)
+				}
+			default:
+				return router.ErrSyntax
+			}
+		}
+
+		var builder strings.Builder
+
+		for _, client := range registry.List(filter) {
+			builder.WriteString(client.String())
+			builder.WriteString("\n")
+		}
+
+		err := writeBulkString(conn, builder.String())
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientKillRouter(
+	registry *clients.Registry,
+	current *clients.Client,
+) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		args := tokens[2:]
+
+		// the old form only accepts an address and replies with OK
+		if len(args) == 1 {
+			killed := registry.Kill(clients.Filter{Addr: args[0]})
+			if killed == 0 {
+				return router.Error("ERR No such client")
+			}
+
+			_, err := io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}
+
+		filter, err := clients.ParseFilter(args, current)
+		if err != nil {
+			return router.Error("ERR " + err.Error())
+		}
+
+		err = writeInt(conn, int64(registry.Kill(filter)))
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientPauseRouter(registry *clients.Registry) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		if len(tokens) > 4 {
+			return router.ErrSyntax
+		}
+
+		timeout, err := strconv.ParseInt(tokens[2], 10, 64)
+		if err != nil || timeout < 0 {
+			return router.Error("ERR timeout is not an integer or out of range")
+		}
+
+		mode := clients.PauseAll
+
+		if len(tokens) == 4 {
+			switch strings.ToUpper(tokens[3]) {
+			case "WRITE":
+				mode = clients.PauseWrite
+			case "ALL":
+				mode = clients.PauseAll
+			default:
+				return router.ErrSyntax
+			}
+		}
+
+		registry.Pause(mode, time.Duration(timeout)*time.Millisecond)
+
+		_, err = io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientUnpauseRouter(registry *clients.Registry) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		registry.Unpause()
+
+		_, err := io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientNoEvictRouter(current *clients.Client) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		switch strings.ToUpper(tokens[2]) {
+		case "ON":
+			current.SetNoEvict(true)
+		case "OFF":
+			current.SetNoEvict(false)
+		default:
+			return router.ErrSyntax
+		}
+
+		_, err := io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+//nolint:cyclop
+func clientTrackingRouter(
+	registry *clients.Registry,
+	table *tracking.Table,
+	current *clients.Client,
+) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		switch strings.ToUpper(tokens[2]) {
+		case "ON":
+		case "OFF":
+			table.Disable(current.ID)
+			current.SetTracking(false, false, 0)
+
+			_, err := io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		default:
+			return router.ErrSyntax
+		}
+
+		options := tracking.Options{}
+		args := tokens[3:]
+
+		for 0 < len(args) {
+			switch strings.ToUpper(args[0]) {
+			case "REDIRECT":
+				if len(args) < 2 {
+					return router.ErrSyntax
+				}
+
+				id, err := strconv.ParseUint(args[1], 10, 64)
+				if err != nil {
+					return router.ErrNotInteger
+				}
+
+				if _, ok := registry.Get(id); !ok && id != current.ID {
+					return router.Error("ERR The client ID you want redirect to does not exist")
+				}
+
+				// redirecting to itself is the same as no redirect
+				if id != current.ID {
+					options.Redirect = id
+				}
+
+				args = args[2:]
+			case "PREFIX":
+				if len(args) < 2 {
+					return router.ErrSyntax
+				}
+
+				options.Prefixes = append(options.Prefixes, args[1])
+				args = args[2:]
+			case "BCAST":
+				options.BCast = true
+				args = args[1:]
+			case "OPTIN":
+				options.OptIn = true
+				args = args[1:]
+			case "OPTOUT":
+				options.OptOut = true
+				args = args[1:]
+			case "NOLOOP":
+				options.NoLoop = true
+				args = args[1:]
+			default:
+				return router.ErrSyntax
+			}
+		}
+
+		err := table.Enable(current.ID, options)
+		if err != nil {
+			return router.Error("ERR " + err.Error())
+		}
+
+		current.SetTracking(true, options.BCast, options.Redirect)
+
+		_, err = io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientTrackingInfoRouter(
+	table *tracking.Table,
+	current *clients.Client,
+) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		options, enabled := table.Options(current.ID)
+
+		flags := []string{"off"}
+		redirect := int64(-1)
+
+		if enabled {
+			flags = []string{"on"}
+			redirect = int64(options.Redirect)
+
+			for flag, set := range map[string]bool{
+				"bcast":  options.BCast,
+				"optin":  options.OptIn,
+				"optout": options.OptOut,
+				"noloop": options.NoLoop,
+			} {
+				if set {
+					flags = append(flags, flag)
+				}
+			}
+
+			slices.Sort(flags[1:])
+		}
+
+		_ = writeMap(conn, current.Protocol(), 3)
+		_ = writeBulkString(conn, "flags")
+		_ = writeBulkStrings(conn, flags)
+		_ = writeBulkString(conn, "redirect")
+		_ = writeInt(conn, redirect)
+		_ = writeBulkString(conn, "prefixes")
+
+		err := writeBulkStrings(conn, options.Prefixes)
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientGetRedirRouter(
+	table *tracking.Table,
+	current *clients.Client,
+) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		redirect := int64(-1)
+
+		if options, enabled := table.Options(current.ID); enabled {
+			redirect = int64(options.Redirect)
+		}
+
+		err := writeInt(conn, redirect)
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func clientCachingRouter(
+	table *tracking.Table,
+	current *clients.Client,
+) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		var enabled bool
+
+		switch strings.ToUpper(tokens[2]) {
+		case "YES":
+			enabled = true
+		case "NO":
+			enabled = false
+		default:
+			return router.ErrSyntax
+		}
+
+		err := table.SetCaching(current.ID, enabled)
+		if err != nil {
+			return router.Error("ERR " + err.Error())
+		}
+
+		_, err = io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// validClientValue follows redis, which only allows printable characters
+// without spaces for client names and library information.
+func validClientValue(value string) bool {
+	for _, char := range value {
+		if char < '!' || char > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,251 @@
+//nolint:ireturn
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/cluster"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+const (
+	// ErrCrossSlot is the error for a multi-key command, in cluster mode,
+	// with keys that hash to different slots.
+	ErrCrossSlot router.Error = "CROSSSLOT Keys in request don't hash to the same slot"
+
+	errClusterDisabled router.Error = "ERR This instance has cluster support disabled"
+	errInvalidSlot     router.Error = "ERR Invalid or out of range slot"
+)
+
+// clusterNode is how the server is announced to cluster clients.
+// In cluster mode, the server is a single primary owning every slot.
+type clusterNode struct {
+	id   string
+	ip   string
+	port int
+}
+
+func (h *Handler) clusterNode(current *clients.Client) clusterNode {
+	ip, _, err := net.SplitHostPort(current.LocalAddr)
+	if err != nil || ip == "" {
+		ip = "127.0.0.1"
+	}
+
+	return clusterNode{
+		id:   h.runID,
+		ip:   ip,
+		port: int(h.server.Port()),
+	}
+}
+
+// crossSlot reports if the keys of a command hash to different slots,
+// which is rejected in cluster mode.
+func (h *Handler) crossSlot(spec router.Spec, tokens []string) bool {
+	return h.cluster.Load() && !cluster.SameSlot(spec.Keys(tokens)...)
+}
+
+func crossSlotCallback(_ []string, _ io.Writer) error {
+	return ErrCrossSlot
+}
+
+//nolint:funlen,cyclop
+func clusterRouter(ctx context.Context, h *Handler, current *clients.Client) router.Router {
+	// the commands are only available in cluster mode
+	enabled := func(callback router.Callback) router.Router {
+		return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			if !h.cluster.Load() {
+				return errClusterDisabled
+			}
+
+			return callback(tokens, conn)
+		})
+	}
+
+	return router.Command{
+		"COUNTKEYSINSLOT": enabled(func(tokens []string, conn io.Writer) error {
+			slot, err := parseSlot(tokens[2])
+			if err != nil {
+				return err
+			}
+
+			keys, err := keysInSlot(ctx, h, slot, -1)
+			if err != nil {
+				return err
+			}
+
+			err = writeInt(conn, int64(len(keys)))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"GETKEYSINSLOT": enabled(func(tokens []string, conn io.Writer) error {
+			slot, err := parseSlot(tokens[2])
+			if err != nil {
+				return err
+			}
+
+			count, err := strconv.Atoi(tokens[3])
+			if err != nil || count < 0 {
+				return router.Error("ERR Invalid number of keys")
+			}
+
+			keys, err := keysInSlot(ctx, h, slot, count)
+			if err != nil {
+				return err
+			}
+
+			err = writeBulkStrings(conn, keys)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"INFO": enabled(func(_ []string, conn io.Writer) error {
+			info := strings.Join([]string{
+				"cluster_state:ok",
+				fmt.Sprintf("cluster_slots_assigned:%d", cluster.Slots),
+				fmt.Sprintf("cluster_slots_ok:%d", cluster.Slots),
+				"cluster_slots_pfail:0",
+				"cluster_slots_fail:0",
+				"cluster_known_nodes:1",
+				"cluster_size:1",
+				"cluster_current_epoch:1",
+				"cluster_my_epoch:1",
+				"cluster_stats_messages_sent:0",
+				"cluster_stats_messages_received:0",
+				"total_cluster_links_buffer_limit_exceeded:0",
+			}, "\r\n") + "\r\n"
+
+			err := writeBulkString(conn, info)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"KEYSLOT": enabled(func(tokens []string, conn io.Writer) error {
+			err := writeInt(conn, int64(cluster.Slot(tokens[2])))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"MYID": enabled(func(_ []string, conn io.Writer) error {
+			err := writeBulkString(conn, h.runID)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"NODES": enabled(func(_ []string, conn io.Writer) error {
+			node := h.clusterNode(current)
+
+			err := writeBulkString(conn, fmt.Sprintf(
+				"%s %s:%d@%d myself,master - 0 0 1 connected 0-%d\n",
+				node.id, node.ip, node.port, node.port+10000, cluster.Slots-1,
+			))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"SHARDS": enabled(func(_ []string, conn io.Writer) error {
+			node := h.clusterNode(current)
+			protocol := current.Protocol()
+
+			_ = writeArray(conn, 1)
+			_ = writeMap(conn, protocol, 2)
+			_ = writeBulkString(conn, "slots")
+			_ = writeArray(conn, 2)
+			_ = writeInt(conn, 0)
+			_ = writeInt(conn, cluster.Slots-1)
+			_ = writeBulkString(conn, "nodes")
+			_ = writeArray(conn, 1)
+			_ = writeMap(conn, protocol, 7)
+			_ = writeBulkString(conn, "id")
+			_ = writeBulkString(conn, node.id)
+			_ = writeBulkString(conn, "port")
+			_ = writeInt(conn, int64(node.port))
+			_ = writeBulkString(conn, "ip")
+			_ = writeBulkString(conn, node.ip)
+			_ = writeBulkString(conn, "endpoint")
+			_ = writeBulkString(conn, node.ip)
+			_ = writeBulkString(conn, "role")
+			_ = writeBulkString(conn, "master")
+			_ = writeBulkString(conn, "replication-offset")
+			_ = writeInt(conn, 0)
+			_ = writeBulkString(conn, "health")
+
+			err := writeBulkString(conn, "online")
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"SLOTS": enabled(func(_ []string, conn io.Writer) error {
+			node := h.clusterNode(current)
+
+			_ = writeArray(conn, 1)
+			_ = writeArray(conn, 3)
+			_ = writeInt(conn, 0)
+			_ = writeInt(conn, cluster.Slots-1)
+			_ = writeArray(conn, 4)
+			_ = writeBulkString(conn, node.ip)
+			_ = writeInt(conn, int64(node.port))
+			_ = writeBulkString(conn, node.id)
+
+			err := writeMap(conn, current.Protocol(), 0)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+	}
+}
+
+func parseSlot(value string) (int, error) {
+	slot, err := strconv.Atoi(value)
+	if err != nil || slot < 0 || slot >= cluster.Slots {
+		return 0, errInvalidSlot
+	}
+
+	return slot, nil
+}
+
+// keysInSlot returns up to count keys in the slot, all of them when the
+// count is negative. The keys are read one at a time, GETKEYSINSLOT stops
+// once it has count keys, while COUNTKEYSINSLOT hashes every key.
+func keysInSlot(ctx context.Context, h *Handler, slot int, count int) ([]string, error) {
+	keys := []string{}
+
+	if count == 0 {
+		return keys, nil
+	}
+
+	err := h.client.EachKey(ctx, func(name string) bool {
+		if cluster.Slot(name) == slot {
+			keys = append(keys, name)
+		}
+
+		return count < 0 || len(keys) < count
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read keys: %w", err)
+	}
+
+	return keys, nil
+}
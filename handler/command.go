@@ -0,0 +1,274 @@
+//nolint:ireturn
+package handler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/glob"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+// commandRouter reports the specs of the commands. It reads the commands when
+// called, so it includes itself once it is added to them.
+func commandRouter(commands router.Command, current *clients.Client) router.Command {
+	return router.Command{
+		"": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			specs := commands.Specs()
+
+			err := writeArray(conn, len(specs))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			for _, spec := range specs {
+				err = writeCommandInfo(conn, spec)
+				if err != nil {
+					return fmt.Errorf("could not write value: %w", err)
+				}
+			}
+
+			return nil
+		}),
+		"COUNT": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			err := writeInt(conn, int64(len(commands.Specs())))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"DOCS": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			specs := commands.Specs()
+
+			if names := tokens[2:]; len(names) > 0 {
+				specs = findSpecs(specs, names)
+			}
+
+			err := writeMap(conn, current.Protocol(), len(specs))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			for _, spec := range specs {
+				_ = writeBulkString(conn, spec.Name)
+
+				err = writeCommandDocs(conn, current.Protocol(), spec)
+				if err != nil {
+					return fmt.Errorf("could not write value: %w", err)
+				}
+			}
+
+			return nil
+		}),
+		"GETKEYS": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			args := tokens[2:]
+
+			spec, ok := commands.Spec(args)
+			if !ok {
+				return router.Error("ERR Invalid command specified")
+			}
+
+			if !spec.ValidArity(len(args)) {
+				return router.Error("ERR Invalid number of arguments specified for command")
+			}
+
+			keys := spec.Keys(args)
+			if len(keys) == 0 {
+				return router.Error("ERR The command has no key arguments")
+			}
+
+			err := writeBulkStrings(conn, keys)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"INFO": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			specs := commands.Specs()
+			names := tokens[2:]
+
+			if len(names) == 0 {
+				for _, spec := range specs {
+					names = append(names, spec.Name)
+				}
+			}
+
+			err := writeArray(conn, len(names))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			for _, name := range names {
+				found := findSpecs(specs, []string{name})
+				if len(found) == 0 {
+					_, err = io.WriteString(conn, "*-1\r\n")
+				} else {
+					err = writeCommandInfo(conn, found[0])
+				}
+
+				if err != nil {
+					return fmt.Errorf("could not write value: %w", err)
+				}
+			}
+
+			return nil
+		}),
+		"LIST": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			args := tokens[2:]
+			match := func(router.Spec) bool { return true }
+
+			switch {
+			case len(args) == 0:
+			case len(args) == 3 && strings.EqualFold(args[0], "FILTERBY"):
+				value := args[2]
+
+				switch strings.ToUpper(args[1]) {
+				case "MODULE":
+					match = func(router.Spec) bool { return false }
+				case "ACLCAT":
+					match = func(spec router.Spec) bool {
+						for _, category := range spec.Categories {
+							if strings.EqualFold(category, value) {
+								return true
+							}
+						}
+
+						return false
+					}
+				case "PATTERN":
+					match = func(spec router.Spec) bool { return glob.Match(strings.ToLower(value), spec.Name) }
+				default:
+					return router.ErrSyntax
+				}
+			default:
+				return router.ErrSyntax
+			}
+
+			var names []string
+
+			for _, spec := range flattenSpecs(commands.Specs()) {
+				if match(spec) {
+					names = append(names, spec.Name)
+				}
+			}
+
+			err := writeBulkStrings(conn, names)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+	}
+}
+
+// writeCommandInfo writes the reply of COMMAND INFO for a command.
+// Tips and key specs aren't supported, so they are always empty.
+func writeCommandInfo(conn io.Writer, spec router.Spec) error {
+	_ = writeArray(conn, 10)
+	_ = writeBulkString(conn, spec.Name)
+	_ = writeInt(conn, int64(spec.Arity))
+	_ = writeArray(conn, len(spec.Flags))
+
+	for _, flag := range spec.Flags {
+		_, _ = io.WriteString(conn, "+"+flag+"\r\n")
+	}
+
+	_ = writeInt(conn, int64(spec.FirstKey))
+	_ = writeInt(conn, int64(spec.LastKey))
+	_ = writeInt(conn, int64(spec.Step))
+	_ = writeArray(conn, len(spec.Categories))
+
+	for _, category := range spec.Categories {
+		_, _ = io.WriteString(conn, "+@"+category+"\r\n")
+	}
+
+	_ = writeArray(conn, 0)
+	_ = writeArray(conn, 0)
+
+	err := writeArray(conn, len(spec.Subcommands))
+	if err != nil {
+		return err
+	}
+
+	for _, subcommand := range spec.Subcommands {
+		err = writeCommandInfo(conn, subcommand)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCommandDocs writes the reply of COMMAND DOCS for a command.
+func writeCommandDocs(conn io.Writer, protocol int, spec router.Spec) error {
+	pairs := 3
+	if len(spec.Subcommands) > 0 {
+		pairs++
+	}
+
+	_ = writeMap(conn, protocol, pairs)
+	_ = writeBulkString(conn, "summary")
+	_ = writeBulkString(conn, spec.Summary)
+	_ = writeBulkString(conn, "since")
+	_ = writeBulkString(conn, spec.Since)
+	_ = writeBulkString(conn, "group")
+
+	err := writeBulkString(conn, spec.Group)
+	if err != nil || len(spec.Subcommands) == 0 {
+		return err
+	}
+
+	_ = writeBulkString(conn, "subcommands")
+
+	err = writeMap(conn, protocol, len(spec.Subcommands))
+	if err != nil {
+		return err
+	}
+
+	for _, subcommand := range spec.Subcommands {
+		_ = writeBulkString(conn, subcommand.Name)
+
+		err = writeCommandDocs(conn, protocol, subcommand)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findSpecs returns the specs with the names, including subcommands like
+// `client|list`, in the order of the names. Unknown names are skipped.
+func findSpecs(specs []router.Spec, names []string) []router.Spec {
+	flattened := flattenSpecs(specs)
+	found := make([]router.Spec, 0, len(names))
+
+	for _, name := range names {
+		for _, spec := range flattened {
+			if strings.EqualFold(spec.Name, name) {
+				found = append(found, spec)
+
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+func flattenSpecs(specs []router.Spec) []router.Spec {
+	flattened := make([]router.Spec, 0, len(specs))
+
+	for _, spec := range specs {
+		flattened = append(flattened, spec)
+		flattened = append(flattened, flattenSpecs(spec.Subcommands)...)
+	}
+
+	return flattened
+}
@@ -0,0 +1,509 @@
+//nolint:ireturn
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/aof"
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/config"
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/db/drivers/sqlite"
+	"github.com/jtarchie/sqlettuce/persistence"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+var (
+	ErrNotDirectory = errors.New("not a directory")
+	ErrNotFilename  = errors.New("dbfilename can't be a path, just a filename")
+	ErrNoEviction   = errors.New("not supported, keys are never evicted")
+)
+
+// registerConfig adds the parameters that can be changed while the server
+// is running. Keys are never evicted, maxmemory-policy only accepts
+// noeviction, see outOfMemory.
+//
+//nolint:funlen
+func (h *Handler) registerConfig(registry *config.Registry) error {
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("could not read working dir: %w", err)
+	}
+
+	pragma := func(name string) func(string) error {
+		return func(value string) error {
+			return h.client.SetPragma(context.Background(), name, value)
+		}
+	}
+
+	filename, _ := registry.Value("filename")
+	dsn := sqlitePragmas(filename)
+
+	err = registry.Register(
+		config.Parameter{
+			Name:    "slowlog-log-slower-than",
+			Type:    config.Int(-1, math.MaxInt64),
+			Default: strconv.FormatInt(defaultSlowlogThreshold.Microseconds(), 10),
+			Apply: func(value string) error {
+				microseconds, _ := strconv.ParseInt(value, 10, 64)
+				h.slowlog.SetThreshold(time.Duration(microseconds) * time.Microsecond)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "slowlog-max-len",
+			Type:    config.Int(0, math.MaxInt32),
+			Default: strconv.Itoa(defaultSlowlogMaxLen),
+			Apply: func(value string) error {
+				maxLen, _ := strconv.Atoi(value)
+				h.slowlog.SetMaxLen(maxLen)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "latency-monitor-threshold",
+			Type:    config.Int(0, math.MaxInt64),
+			Default: "0",
+			Apply: func(value string) error {
+				milliseconds, _ := strconv.ParseInt(value, 10, 64)
+				h.latency.SetThreshold(time.Duration(milliseconds) * time.Millisecond)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "timeout",
+			Type:    config.Int(0, math.MaxInt32),
+			Default: "0",
+			Apply: func(value string) error {
+				seconds, _ := strconv.ParseInt(value, 10, 64)
+				h.timeout.Store(int64(time.Duration(seconds) * time.Second))
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "maxmemory",
+			Type:    config.Memory(),
+			Default: "0",
+			Apply: func(value string) error {
+				bytes, _ := strconv.ParseInt(value, 10, 64)
+				h.maxMemory.Store(bytes)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name: "maxmemory-policy",
+			Type: config.Enum(
+				"volatile-lru", "allkeys-lru", "volatile-lfu", "allkeys-lfu",
+				"volatile-random", "allkeys-random", "volatile-ttl", "noeviction",
+			),
+			Default: "noeviction",
+			Apply: func(value string) error {
+				if value != "noeviction" {
+					return fmt.Errorf("maxmemory-policy is %w", ErrNoEviction)
+				}
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "save",
+			Default: "",
+			Apply: func(value string) error {
+				points, err := persistence.ParsePoints(value)
+				if err != nil {
+					return fmt.Errorf("could not parse save points: %w", err)
+				}
+
+				h.saver.SetPoints(points)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "dir",
+			Default: workingDir,
+			Apply: func(value string) error {
+				info, err := os.Stat(value)
+				if err != nil {
+					return fmt.Errorf("could not read dir: %w", err)
+				}
+
+				if !info.IsDir() {
+					return ErrNotDirectory
+				}
+
+				h.saver.SetDir(value)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "dbfilename",
+			Default: "dump.db",
+			Apply: func(value string) error {
+				if value == "" || filepath.Base(value) != value {
+					return ErrNotFilename
+				}
+
+				h.saver.SetFilename(value)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "save-retention",
+			Type:    config.Int(1, math.MaxInt16),
+			Default: "1",
+			Apply: func(value string) error {
+				retention, _ := strconv.Atoi(value)
+				h.saver.SetRetention(retention)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "appendonly",
+			Type:    config.Bool(),
+			Default: "no",
+			Apply: func(value string) error {
+				return h.setAppendOnly(value == "yes")
+			},
+		},
+		config.Parameter{
+			Name:      "appendfilename",
+			Default:   defaultAOFFilename,
+			Immutable: true,
+			Apply: func(value string) error {
+				if value == "" || filepath.Base(value) != value {
+					return ErrNotAOFFilename
+				}
+
+				h.aofSettings.mutex.Lock()
+				h.aofSettings.filename = value
+				h.aofSettings.mutex.Unlock()
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "appendfsync",
+			Type:    config.Enum(string(aof.Always), string(aof.EverySec), string(aof.No)),
+			Default: string(aof.EverySec),
+			Apply: func(value string) error {
+				h.aofSettings.mutex.Lock()
+				h.aofSettings.policy = aof.Policy(value)
+				h.aofSettings.mutex.Unlock()
+
+				if file := h.appendOnly.Load(); file != nil {
+					file.SetPolicy(aof.Policy(value))
+				}
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "aof-load-truncated",
+			Type:    config.Bool(),
+			Default: "yes",
+			Apply: func(value string) error {
+				h.aofSettings.mutex.Lock()
+				h.aofSettings.loadTruncated = value == "yes"
+				h.aofSettings.mutex.Unlock()
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "auto-aof-rewrite-percentage",
+			Type:    config.Int(0, math.MaxInt32),
+			Default: strconv.Itoa(defaultAOFRewritePercentage),
+			Apply: func(value string) error {
+				percentage, _ := strconv.ParseInt(value, 10, 64)
+
+				h.aofSettings.mutex.Lock()
+				h.aofSettings.percentage = percentage
+				h.aofSettings.mutex.Unlock()
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "auto-aof-rewrite-min-size",
+			Type:    config.Memory(),
+			Default: strconv.Itoa(defaultAOFRewriteMinSize),
+			Apply: func(value string) error {
+				size, _ := strconv.ParseInt(value, 10, 64)
+
+				h.aofSettings.mutex.Lock()
+				h.aofSettings.minSize = size
+				h.aofSettings.mutex.Unlock()
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:      "cluster-enabled",
+			Type:      config.Bool(),
+			Default:   "no",
+			Immutable: true,
+			Apply: func(value string) error {
+				h.cluster.Store(value == "yes")
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "replica-read-only",
+			Type:    config.Bool(),
+			Default: "yes",
+			Apply: func(value string) error {
+				h.replicaReadOnly.Store(value == "yes")
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "repl-backlog-size",
+			Type:    config.Memory(),
+			Default: strconv.Itoa(defaultBacklogSize),
+			Apply: func(value string) error {
+				size, _ := strconv.Atoi(value)
+				h.primary.Backlog().SetSize(size)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:      "replicaof",
+			Default:   "",
+			Immutable: true,
+			Apply: func(value string) error {
+				if value == "" {
+					h.replicaOf("", 0)
+
+					return nil
+				}
+
+				host, port, err := parseReplicaOf(value)
+				if err != nil {
+					return err
+				}
+
+				h.replicaOf(host, port)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "sqlite-busy-timeout",
+			Type:    config.Int(0, math.MaxInt32),
+			Default: dsn.Pragma("busy_timeout"),
+			Apply:   pragma("busy_timeout"),
+		},
+		config.Parameter{
+			Name:    "sqlite-synchronous",
+			Type:    config.Enum("off", "normal", "full", "extra"),
+			Default: synchronousLevel(dsn.Pragma("synchronous")),
+			Apply:   pragma("synchronous"),
+		},
+		config.Parameter{
+			Name:    "sqlite-cache-size",
+			Type:    config.Int(math.MinInt32, math.MaxInt32),
+			Default: "-2000",
+			Apply:   pragma("cache_size"),
+		},
+		config.Parameter{
+			Name:    "sqlite-checkpoint-size",
+			Type:    config.Memory(),
+			Default: strconv.Itoa(db.DefaultCheckpointSize),
+			Apply: func(value string) error {
+				size, _ := strconv.ParseInt(value, 10, 64)
+				h.client.SetCheckpointSize(size)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "sqlite-truncate-size",
+			Type:    config.Memory(),
+			Default: strconv.Itoa(db.DefaultTruncateSize),
+			Apply: func(value string) error {
+				size, _ := strconv.ParseInt(value, 10, 64)
+				h.client.SetTruncateSize(size)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "sqlite-change-journal",
+			Type:    config.Bool(),
+			Default: "no",
+			Apply: func(value string) error {
+				return h.client.SetJournal(context.Background(), value == "yes")
+			},
+		},
+		config.Parameter{
+			Name:    "sqlite-change-journal-retention",
+			Type:    config.Int(0, math.MaxInt32),
+			Default: strconv.Itoa(int(db.DefaultJournalRetention.Seconds())),
+			Apply: func(value string) error {
+				seconds, _ := strconv.ParseInt(value, 10, 64)
+				h.client.SetJournalRetention(time.Duration(seconds) * time.Second)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "group-commit-size",
+			Type:    config.Int(0, math.MaxInt32),
+			Default: strconv.Itoa(db.DefaultGroupCommitSize),
+			Apply: func(value string) error {
+				size, _ := strconv.Atoi(value)
+				h.client.SetGroupCommitSize(size)
+
+				return nil
+			},
+		},
+		config.Parameter{
+			Name:    "group-commit-delay",
+			Type:    config.Int(0, math.MaxInt32),
+			Default: strconv.Itoa(db.DefaultGroupCommitDelay),
+			Apply: func(value string) error {
+				microseconds, _ := strconv.ParseInt(value, 10, 64)
+				h.client.SetGroupCommitDelay(time.Duration(microseconds) * time.Microsecond)
+
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("could not register config: %w", err)
+	}
+
+	return nil
+}
+
+// sqlitePragmas are the PRAGMAs set by the DSN, which are the defaults of the
+// sqlite-* settings. Other drivers report the defaults of SQLite.
+func sqlitePragmas(filename string) *sqlite.Config {
+	if strings.HasPrefix(filename, "sqlite:") {
+		dsn, err := sqlite.ParseDSN(filename)
+		if err == nil {
+			return dsn
+		}
+	}
+
+	return &sqlite.Config{Pragmas: sqlite.DefaultPragmas()}
+}
+
+// synchronousLevel is the name of a synchronous PRAGMA, which can be a number.
+func synchronousLevel(value string) string {
+	levels := []string{"off", "normal", "full", "extra"}
+
+	level, err := strconv.Atoi(value)
+	if err == nil && level >= 0 && level < len(levels) {
+		return levels[level]
+	}
+
+	return strings.ToLower(value)
+}
+
+func configRouter(h *Handler, current *clients.Client) router.Router {
+	return router.Command{
+		"GET": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			values := h.config.Get(tokens[2:]...)
+
+			err := writeMap(conn, current.Protocol(), len(values))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			for _, value := range values {
+				_ = writeBulkString(conn, value[0])
+
+				err = writeBulkString(conn, value[1])
+				if err != nil {
+					return fmt.Errorf("could not write value: %w", err)
+				}
+			}
+
+			return nil
+		}),
+		"SET": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			if len(tokens)%2 != 0 {
+				return router.WrongArity("config|set")
+			}
+
+			err := h.config.Set(tokens[2:]...)
+			if err != nil {
+				return router.Error(configSetError(err))
+			}
+
+			_, err = io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}),
+		"RESETSTAT": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			h.stats.Reset()
+			h.client.ResetStats()
+
+			_, err := io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}),
+		"REWRITE": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			err := h.config.Rewrite()
+			if errors.Is(err, config.ErrNoFile) {
+				return router.Error("ERR The server is running without a config file")
+			}
+
+			if err != nil {
+				return router.Error("ERR Rewriting config file: " + err.Error())
+			}
+
+			_, err = io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}),
+	}
+}
+
+func configSetError(err error) string {
+	var configErr *config.Error
+	if !errors.As(err, &configErr) {
+		return "ERR CONFIG SET failed - " + err.Error()
+	}
+
+	if errors.Is(err, config.ErrUnknown) {
+		return fmt.Sprintf("ERR Unknown option or number of arguments for CONFIG SET - '%s'", configErr.Name)
+	}
+
+	return fmt.Sprintf(
+		"ERR CONFIG SET failed (possibly related to argument '%s') - %s",
+		configErr.Name,
+		configErr.Err,
+	)
+}
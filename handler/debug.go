@@ -0,0 +1,105 @@
+//nolint:ireturn
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+// debugRouter runs the maintenance of the database on demand.
+func debugRouter(ctx context.Context, client *db.Client) router.Router {
+	return router.Command{
+		// DEBUG CHECKPOINT [PASSIVE|FULL|RESTART|TRUNCATE] replies like
+		// PRAGMA wal_checkpoint, with the busy flag and the frames of the
+		// log and checkpointed.
+		"CHECKPOINT": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			if len(tokens) > 3 {
+				return router.ErrSyntax
+			}
+
+			mode := "PASSIVE"
+			if len(tokens) == 3 {
+				mode = strings.ToUpper(tokens[2])
+			}
+
+			switch mode {
+			case "PASSIVE", "FULL", "RESTART", "TRUNCATE":
+			default:
+				return router.ErrSyntax
+			}
+
+			checkpoint, err := client.Checkpoint(ctx, mode)
+			if errors.Is(err, errors.ErrUnsupported) {
+				return router.Error("ERR checkpoints are not supported by the storage driver")
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not checkpoint: %w", err)
+			}
+
+			busy := int64(0)
+			if checkpoint.Busy {
+				busy = 1
+			}
+
+			_ = writeArray(conn, 3)
+			_ = writeInt(conn, busy)
+			_ = writeInt(conn, checkpoint.Log)
+
+			err = writeInt(conn, checkpoint.Checkpointed)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		// DEBUG VACUUM rebuilds a database created without auto_vacuum,
+		// replying with 1 when it was rebuilt.
+		"VACUUM": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			rebuilt, err := client.EnableAutoVacuum(ctx)
+			if errors.Is(err, errors.ErrUnsupported) {
+				return router.Error("ERR vacuums are not supported by the storage driver")
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not vacuum: %w", err)
+			}
+
+			reply := int64(0)
+			if rebuilt {
+				reply = 1
+			}
+
+			err = writeInt(conn, reply)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		// DEBUG MAINTENANCE runs the maintenance tasks that are due.
+		"MAINTENANCE": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			err := client.Maintain(ctx)
+			if errors.Is(err, errors.ErrUnsupported) {
+				return router.Error("ERR maintenance is not supported by the storage driver")
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not maintain: %w", err)
+			}
+
+			_, err = io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}),
+	}
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/rdb"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+// dumpRouter serializes a key like Redis, so it can be restored by Redis.
+func dumpRouter(ctx context.Context, client *db.Client) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		entries, err := client.Entries(ctx, tokens[1])
+		if err != nil {
+			return fmt.Errorf("could not execute DUMP: %w", err)
+		}
+
+		if len(entries) == 0 {
+			_, err = io.WriteString(conn, router.NullResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}
+
+		payload, err := rdb.Dump(entries[0])
+		if err != nil {
+			return fmt.Errorf("could not execute DUMP: %w", err)
+		}
+
+		err = writeBulkString(conn, string(payload))
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// restoreRouter creates a key from the payload of DUMP, of Redis or
+// sqlettuce. Keys can't expire, so only a TTL in the past is honored, by not
+// creating the key. IDLETIME and FREQ are validated, then ignored.
+func restoreRouter(ctx context.Context, client *db.Client) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		ttl, err := strconv.ParseInt(tokens[2], 10, 64)
+		if err != nil {
+			return router.ErrNotInteger
+		}
+
+		var replace, absolute, idle, frequency bool
+
+		for index := 4; index < len(tokens); index++ {
+			option := strings.ToUpper(tokens[index])
+
+			switch {
+			case option == "REPLACE":
+				replace = true
+			case option == "ABSTTL":
+				absolute = true
+			case option == "IDLETIME" && index+1 < len(tokens) && !frequency:
+				index++
+				idle = true
+
+				seconds, err := strconv.ParseInt(tokens[index], 10, 64)
+				if err != nil {
+					return router.ErrNotInteger
+				}
+
+				if seconds < 0 {
+					return router.Error("ERR Invalid IDLETIME value, must be >= 0")
+				}
+			case option == "FREQ" && index+1 < len(tokens) && !idle:
+				index++
+				frequency = true
+
+				count, err := strconv.ParseInt(tokens[index], 10, 64)
+				if err != nil {
+					return router.ErrNotInteger
+				}
+
+				if count < 0 || count > 255 {
+					return router.Error("ERR Invalid FREQ value, must be >= 0 and <= 255")
+				}
+			default:
+				return router.ErrSyntax
+			}
+		}
+
+		if ttl < 0 {
+			return router.Error("ERR Invalid TTL value, must be >= 0")
+		}
+
+		entry, err := rdb.Load([]byte(tokens[3]))
+		if errors.Is(err, rdb.ErrInvalidChecksum) || errors.Is(err, rdb.ErrInvalidVersion) {
+			return router.Error("ERR DUMP payload version or checksum are wrong")
+		}
+
+		if err != nil {
+			return router.Error("ERR Bad data format")
+		}
+
+		entry.Key = tokens[1]
+
+		if absolute && ttl > 0 && time.UnixMilli(ttl).Before(time.Now()) {
+			// like Redis, a key that expired is not created, removing the
+			// key it replaces
+			if replace {
+				_, _, err = client.Delete(ctx, entry.Key)
+			} else {
+				var found bool
+
+				_, found, err = client.Get(ctx, entry.Key)
+				if found {
+					return router.Error("BUSYKEY Target key name already exists.")
+				}
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not execute RESTORE: %w", err)
+			}
+
+			_, err = io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}
+
+		err = client.RestoreEntry(ctx, entry, replace)
+		if errors.Is(err, db.ErrKeyExists) {
+			return router.Error("BUSYKEY Target key name already exists.")
+		}
+
+		if errors.Is(err, errors.ErrUnsupported) {
+			return router.Error(fmt.Sprintf("ERR values of type %s can't be restored, only strings and lists", entry.Type))
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not execute RESTORE: %w", err)
+		}
+
+		_, err = io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
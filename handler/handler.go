@@ -2,25 +2,109 @@ package handler
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
+	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/jtarchie/sqlettuce/aof"
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/config"
 	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/latency"
+	"github.com/jtarchie/sqlettuce/monitor"
+	"github.com/jtarchie/sqlettuce/persistence"
+	"github.com/jtarchie/sqlettuce/pubsub"
+	"github.com/jtarchie/sqlettuce/replication"
+	"github.com/jtarchie/sqlettuce/router"
+	"github.com/jtarchie/sqlettuce/sentinel"
+	"github.com/jtarchie/sqlettuce/slowlog"
 	"github.com/jtarchie/sqlettuce/tcp"
+	"github.com/jtarchie/sqlettuce/tracking"
 )
 
 type Handler struct {
-	client *db.Client
+	client     *db.Client
+	server     Server
+	config     *config.Registry
+	clients    *clients.Registry
+	pubsub     *pubsub.Hub
+	tracking   *tracking.Table
+	stats      *router.Stats
+	slowlog    *slowlog.Log
+	latency    *latency.Monitor
+	monitor    *monitor.Hub
+	saver      *persistence.Saver
+	primary    *replication.Primary
+	replica    atomic.Pointer[replication.Replica]
+	sentinel   *sentinel.Sentinel
+	appendOnly atomic.Pointer[aof.File]
+	middleware []router.Middleware
+	timeout    atomic.Int64
+	cluster    atomic.Bool
+	maxMemory  atomic.Int64
+	started    time.Time
+	runID      string
+
+	replicaReadOnly atomic.Bool
+	aofSettings     appendOnlySettings
 }
 
-func New(client *db.Client) *Handler {
-	return &Handler{
-		client: client,
+// New creates the handler and registers its parameters with the config.
+// The middleware is applied to every command after the command stats used
+// by INFO.
+func New(
+	client *db.Client,
+	server Server,
+	registry *config.Registry,
+	middleware ...router.Middleware,
+) (*Handler, error) {
+	handler := &Handler{
+		client:     client,
+		server:     server,
+		config:     registry,
+		clients:    clients.NewRegistry(),
+		pubsub:     pubsub.NewHub(),
+		stats:      router.NewStats(),
+		slowlog:    slowlog.New(defaultSlowlogThreshold, defaultSlowlogMaxLen),
+		latency:    latency.NewMonitor(0),
+		monitor:    monitor.NewHub(),
+		saver:      persistence.New(client.Snapshot),
+		middleware: middleware,
+		started:    time.Now(),
+		runID:      newRunID(),
+	}
+
+	handler.primary = replication.NewPrimary(handler.runID, defaultBacklogSize)
+	handler.replicaReadOnly.Store(true)
+	handler.aofSettings = appendOnlySettings{
+		filename:      defaultAOFFilename,
+		policy:        aof.EverySec,
+		loadTruncated: true,
+		percentage:    defaultAOFRewritePercentage,
+		minSize:       defaultAOFRewriteMinSize,
+	}
+
+	handler.tracking = tracking.NewTable(handler.invalidate)
+	client.Observe(handler.onChange)
+	client.Observe(handler.countChanges)
+	client.ObserveStatements(handler.observeStatement)
+
+	err := handler.registerConfig(registry)
+	if err != nil {
+		return nil, err
 	}
+
+	return handler, nil
 }
 
 var _ tcp.Handler = &Handler{}
@@ -31,15 +115,26 @@ var (
 )
 
 func (h *Handler) OnConnection(ctx context.Context, conn io.ReadWriter) error {
+	writer := clients.NewWriter(conn)
+	current := h.register(conn, writer)
+
+	defer h.unregister(current)
+
+	ctx = clients.WithClient(ctx, current)
 	reader := bufio.NewReader(conn)
-	routes := NewRoutes(ctx, h.client)
+	routes := router.WithMiddleware(
+		h.NewRoutes(ctx, current),
+		append([]router.Middleware{h.stats.Middleware, h.observe(current)}, h.middleware...)...,
+	)
 
 	for {
 		var tokens []string
 
+		h.setDeadline(conn, current)
+
 		lineCount, err := readNumber('*', reader)
 
-		if errors.Is(err, io.EOF) {
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || errors.Is(err, os.ErrDeadlineExceeded) {
 			return nil
 		}
 
@@ -62,34 +157,160 @@ func (h *Handler) OnConnection(ctx context.Context, conn io.ReadWriter) error {
 			return ErrNoCommandFound
 		}
 
+		name := routes.Name(tokens)
+		current.Touch(name)
+
+		h.monitor.Publish(monitor.Event{
+			Time:   time.Now(),
+			DB:     current.DB(),
+			Addr:   current.Addr,
+			Tokens: tokens,
+		})
+
+		spec, described := routes.Spec(tokens)
+
+		// CLIENT commands are never paused, so that CLIENT UNPAUSE can be sent
+		if command, _, _ := strings.Cut(name, "|"); command != "client" {
+			err = h.clients.WaitUnpaused(ctx, spec.HasFlag("write"))
+			if err != nil {
+				return fmt.Errorf("could not wait for clients to unpause: %w", err)
+			}
+		}
+
 		callback, found := routes.Lookup(tokens)
 		if !found {
 			slog.Debug("could not found route", slog.String("tokens", strings.Join(tokens, " ")))
 		}
 
-		err = callback(tokens, conn)
+		if !h.allowedInContext(current, name) {
+			callback = subscribedContextCallback(name)
+		}
+
+		if described && h.crossSlot(spec, tokens) {
+			callback = crossSlotCallback
+		}
+
+		if described && h.outOfMemory(ctx, current, spec) {
+			callback = outOfMemoryCallback
+		}
+
+		// MIGRATE propagates the keys it deletes, see migrateRouter
+		if found && described && spec.HasFlag("write") && name != "migrate" {
+			callback = h.replicate(current, spec, callback)
+		}
+
+		err = callback(tokens, writer)
+		if err != nil {
+			h.replyError(writer, name, err)
+		}
+
+		if described && spec.HasFlag("readonly") {
+			h.tracking.Track(current.ID, spec.Keys(tokens)...)
+		}
+
+		if name != "client|caching" {
+			h.tracking.EndCommand(current.ID)
+		}
+
+		err = writer.Flush()
 		if err != nil {
-			return fmt.Errorf("could not process callback: %w", err)
+			return fmt.Errorf("could not flush reply: %w", err)
 		}
 	}
 }
 
+// replyError replaces the partial reply of a failed command with the error.
+// The connection stays open, only reading and flushing can fail it.
+func (h *Handler) replyError(writer *clients.Writer, name string, err error) {
+	var reply router.Error
+	if !errors.As(err, &reply) {
+		slog.Error("command failed", slog.String("command", name), slog.String("error", err.Error()))
+	}
+
+	writer.Discard()
+	_ = router.ReplyError(writer, err)
+}
+
+// setDeadline closes idle clients after the configured timeout.
+// Like redis, clients in pub/sub or MONITOR mode, and replicas, are never
+// timed out.
+func (h *Handler) setDeadline(conn io.ReadWriter, current *clients.Client) {
+	netConn, ok := conn.(net.Conn)
+	if !ok {
+		return
+	}
+
+	timeout := time.Duration(h.timeout.Load())
+	if timeout == 0 || current.Subscriptions() > 0 || current.Monitor() || current.Replica() {
+		_ = netConn.SetReadDeadline(time.Time{})
+
+		return
+	}
+
+	_ = netConn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+func (h *Handler) register(conn io.ReadWriter, writer *clients.Writer) *clients.Client {
+	var addr, localAddr string
+
+	if netConn, ok := conn.(net.Conn); ok {
+		addr = netConn.RemoteAddr().String()
+		localAddr = netConn.LocalAddr().String()
+	}
+
+	closer, _ := conn.(io.Closer)
+
+	return h.clients.Register(addr, localAddr, writer, closer)
+}
+
+func (h *Handler) unregister(current *clients.Client) {
+	h.monitor.Unsubscribe(current.ID)
+	h.primary.Detach(current.ID)
+	h.tracking.Disable(current.ID)
+
+	for _, channel := range h.pubsub.Channels(current) {
+		h.pubsub.Unsubscribe(current, channel)
+	}
+
+	h.clients.Unregister(current)
+}
+
+// newRunID returns the random identifier of the server process.
+func newRunID() string {
+	id := make([]byte, 20)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// maxBulkLength is the length of the largest string, like the default
+// proto-max-bulk-len of Redis.
+const maxBulkLength = 512 * 1024 * 1024
+
+// readString reads a bulk string by its length, as it can contain line
+// breaks, like the payloads of DUMP.
 func readString(reader *bufio.Reader) ([]byte, error) {
 	expectedLength, err := readNumber('$', reader)
 	if err != nil {
 		return nil, fmt.Errorf("could not read string length: %w", err)
 	}
 
-	line, _, err := reader.ReadLine()
+	if expectedLength < 0 || expectedLength > maxBulkLength {
+		return nil, fmt.Errorf("could not read string of length %d: %w", expectedLength, ErrIncorrectTokens)
+	}
+
+	line := make([]byte, expectedLength+2)
+
+	_, err = io.ReadFull(reader, line)
 	if err != nil {
 		return nil, fmt.Errorf("could not read string: %w", err)
 	}
 
-	if int64(len(line)) != expectedLength {
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
 		return nil, fmt.Errorf("could not read string of expected length: %w", ErrIncorrectTokens)
 	}
 
-	return line, nil
+	return line[:expectedLength], nil
 }
 
 func readNumber(prefix byte, rw *bufio.Reader) (int64, error) {
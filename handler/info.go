@@ -0,0 +1,457 @@
+//nolint:ireturn
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/replication"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+// Server reports the state of the listener accepting connections.
+type Server interface {
+	Port() uint
+	ActiveConnections() int64
+	TotalConnections() uint64
+}
+
+type infoSection struct {
+	name     string
+	fields   func(h *Handler, ctx context.Context) ([][2]string, error)
+	fallback bool
+}
+
+// infoSections are in the order they are reported. Sections that are not
+// part of the default are only returned when requested by name, `all` or
+// `everything`.
+var infoSections = []infoSection{
+	{name: "server", fields: (*Handler).infoServer, fallback: true},
+	{name: "clients", fields: (*Handler).infoClients, fallback: true},
+	{name: "memory", fields: (*Handler).infoMemory, fallback: true},
+	{name: "persistence", fields: (*Handler).infoPersistence, fallback: true},
+	{name: "stats", fields: (*Handler).infoStats, fallback: true},
+	{name: "replication", fields: (*Handler).infoReplication, fallback: true},
+	{name: "commandstats", fields: (*Handler).infoCommandStats},
+	{name: "cluster", fields: (*Handler).infoCluster, fallback: true},
+	{name: "keyspace", fields: (*Handler).infoKeyspace, fallback: true},
+}
+
+func infoRouter(ctx context.Context, h *Handler, sections []infoSection) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		requested := map[string]bool{}
+		for _, section := range tokens[1:] {
+			requested[strings.ToLower(section)] = true
+		}
+
+		everything := requested["all"] || requested["everything"]
+		fallback := len(requested) == 0 || requested["default"]
+
+		var builder strings.Builder
+
+		for _, section := range sections {
+			if !(everything || requested[section.name] || (fallback && section.fallback)) {
+				continue
+			}
+
+			fields, err := section.fields(h, ctx)
+			if err != nil {
+				return fmt.Errorf("could not gather INFO %s: %w", section.name, err)
+			}
+
+			if builder.Len() > 0 {
+				builder.WriteString("\r\n")
+			}
+
+			builder.WriteString("# ")
+			builder.WriteString(strings.ToUpper(section.name[:1]) + section.name[1:])
+			builder.WriteString("\r\n")
+
+			for _, field := range fields {
+				builder.WriteString(field[0])
+				builder.WriteString(":")
+				builder.WriteString(field[1])
+				builder.WriteString("\r\n")
+			}
+		}
+
+		err := writeBulkString(conn, builder.String())
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (h *Handler) infoServer(_ context.Context) ([][2]string, error) {
+	uptime := time.Since(h.started)
+	executable, _ := os.Executable()
+
+	return [][2]string{
+		{"redis_version", Version},
+		{"redis_mode", h.mode()},
+		{"os", runtime.GOOS},
+		{"arch_bits", fmt.Sprintf("%d", 32<<(^uint(0)>>63))},
+		{"go_version", runtime.Version()},
+		{"process_id", fmt.Sprintf("%d", os.Getpid())},
+		{"run_id", h.runID},
+		{"tcp_port", fmt.Sprintf("%d", h.server.Port())},
+		{"server_time_usec", fmt.Sprintf("%d", time.Now().UnixMicro())},
+		{"uptime_in_seconds", fmt.Sprintf("%d", int64(uptime.Seconds()))},
+		{"uptime_in_days", fmt.Sprintf("%d", int64(uptime.Hours()/24))},
+		{"executable", executable},
+	}, nil
+}
+
+func (h *Handler) mode() string {
+	if h.sentinel != nil {
+		return "sentinel"
+	}
+
+	if h.cluster.Load() {
+		return "cluster"
+	}
+
+	return "standalone"
+}
+
+func (h *Handler) infoClients(_ context.Context) ([][2]string, error) {
+	return [][2]string{
+		{"connected_clients", fmt.Sprintf("%d", h.server.ActiveConnections())},
+		{"blocked_clients", "0"},
+		{"tracking_clients", fmt.Sprintf("%d", h.tracking.Len())},
+		{"pubsub_clients", fmt.Sprintf("%d", h.pubsub.Len())},
+	}, nil
+}
+
+func (h *Handler) infoMemory(ctx context.Context) ([][2]string, error) {
+	stats, err := h.client.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read database stats: %w", err)
+	}
+
+	var memory runtime.MemStats
+
+	runtime.ReadMemStats(&memory)
+
+	return [][2]string{
+		{"used_memory", fmt.Sprintf("%d", memory.Alloc)},
+		{"used_memory_human", humanBytes(int64(memory.Alloc))},
+		{"used_memory_rss", fmt.Sprintf("%d", memory.Sys)},
+		{"used_memory_rss_human", humanBytes(int64(memory.Sys))},
+		{"sqlite_page_size", fmt.Sprintf("%d", stats.PageSize)},
+		{"sqlite_page_count", fmt.Sprintf("%d", stats.PageCount)},
+		{"sqlite_freelist_count", fmt.Sprintf("%d", stats.FreelistCount)},
+		{"sqlite_database_size", fmt.Sprintf("%d", stats.DatabaseSize())},
+		{"sqlite_database_size_human", humanBytes(stats.DatabaseSize())},
+	}, nil
+}
+
+func (h *Handler) infoPersistence(ctx context.Context) ([][2]string, error) {
+	stats, err := h.client.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read database stats: %w", err)
+	}
+
+	save := h.saver.Status()
+
+	inProgress, current := "0", "-1"
+	if save.InProgress {
+		inProgress = "1"
+		current = fmt.Sprintf("%d", int64(time.Since(save.Started).Seconds()))
+	}
+
+	lastSaveStatus, lastSaveDuration := "ok", "-1"
+	if save.LastError != nil {
+		lastSaveStatus = "err"
+	}
+
+	if !save.Started.IsZero() && !save.InProgress {
+		lastSaveDuration = fmt.Sprintf("%d", int64(save.LastDuration.Seconds()))
+	}
+
+	fields := [][2]string{
+		{"loading", "0"},
+		{"rdb_changes_since_last_save", fmt.Sprintf("%d", save.Changes)},
+		{"rdb_bgsave_in_progress", inProgress},
+		{"rdb_last_save_time", fmt.Sprintf("%d", save.LastSave.Unix())},
+		{"rdb_last_bgsave_status", lastSaveStatus},
+		{"rdb_last_bgsave_time_sec", lastSaveDuration},
+		{"rdb_current_bgsave_time_sec", current},
+		{"rdb_saves", fmt.Sprintf("%d", save.Saves)},
+	}
+
+	fields = append(fields, h.infoAppendOnly()...)
+	fields = append(fields, [][2]string{
+		{"sqlite_filename", stats.Filename},
+		{"sqlite_wal_size", fmt.Sprintf("%d", stats.WALSize)},
+		{"sqlite_wal_size_human", humanBytes(stats.WALSize)},
+	}...)
+
+	if h.client.Journals() {
+		journal, err := h.client.JournalStatus(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not read change journal: %w", err)
+		}
+
+		enabled := "0"
+		if journal.Enabled {
+			enabled = "1"
+		}
+
+		fields = append(fields, [][2]string{
+			{"sqlite_journal_enabled", enabled},
+			{"sqlite_journal_entries", fmt.Sprintf("%d", journal.Entries)},
+			{"sqlite_journal_first_time", fmt.Sprintf("%d", unixTime(journal.First.At))},
+			{"sqlite_journal_last_time", fmt.Sprintf("%d", unixTime(journal.Last.At))},
+		}...)
+	}
+
+	status, ok := h.client.MaintenanceStatus()
+	if !ok {
+		return fields, nil
+	}
+
+	busy := "0"
+	if status.LastCheckpointResult.Busy {
+		busy = "1"
+	}
+
+	// like rdb_last_bgsave_status, the error itself is logged
+	lastStatus := "ok"
+	if status.LastError != nil {
+		lastStatus = "err"
+	}
+
+	return append(fields, [][2]string{
+		{"sqlite_checkpoints", fmt.Sprintf("%d", status.Checkpoints)},
+		{"sqlite_last_checkpoint_time", fmt.Sprintf("%d", unixTime(status.LastCheckpoint))},
+		{"sqlite_last_checkpoint_mode", strings.ToLower(status.LastCheckpointMode)},
+		{"sqlite_last_checkpoint_busy", busy},
+		{"sqlite_last_checkpoint_log_frames", fmt.Sprintf("%d", status.LastCheckpointResult.Log)},
+		{"sqlite_last_checkpoint_frames", fmt.Sprintf("%d", status.LastCheckpointResult.Checkpointed)},
+		{"sqlite_vacuums", fmt.Sprintf("%d", status.Vacuums)},
+		{"sqlite_last_vacuum_time", fmt.Sprintf("%d", unixTime(status.LastVacuum))},
+		{"sqlite_last_optimize_time", fmt.Sprintf("%d", unixTime(status.LastOptimize))},
+		{"sqlite_last_analyze_time", fmt.Sprintf("%d", unixTime(status.LastAnalyze))},
+		{"sqlite_last_journal_trim_time", fmt.Sprintf("%d", unixTime(status.LastJournalTrim))},
+		{"sqlite_journal_trimmed", fmt.Sprintf("%d", status.JournalTrimmed)},
+		{"sqlite_last_maintenance_status", lastStatus},
+	}...), nil
+}
+
+// infoAppendOnly are the aof fields of INFO persistence, with the sizes
+// only when it is enabled, like Redis.
+func (h *Handler) infoAppendOnly() [][2]string {
+	file := h.appendOnly.Load()
+	if file == nil {
+		return [][2]string{{"aof_enabled", "0"}}
+	}
+
+	status := file.Status()
+
+	inProgress, current := "0", "-1"
+	if status.RewriteInProgress {
+		inProgress = "1"
+		current = fmt.Sprintf("%d", int64(time.Since(status.RewriteStarted).Seconds()))
+	}
+
+	lastRewriteStatus, lastRewriteDuration := "ok", "-1"
+	if status.LastRewriteError != nil {
+		lastRewriteStatus = "err"
+	}
+
+	if !status.RewriteStarted.IsZero() && !status.RewriteInProgress {
+		lastRewriteDuration = fmt.Sprintf("%d", int64(status.LastRewriteDuration.Seconds()))
+	}
+
+	lastWriteStatus := "ok"
+	if status.LastWriteError != nil {
+		lastWriteStatus = "err"
+	}
+
+	return [][2]string{
+		{"aof_enabled", "1"},
+		{"aof_rewrite_in_progress", inProgress},
+		{"aof_rewrite_scheduled", "0"},
+		{"aof_last_rewrite_time_sec", lastRewriteDuration},
+		{"aof_current_rewrite_time_sec", current},
+		{"aof_last_bgrewrite_status", lastRewriteStatus},
+		{"aof_rewrites", fmt.Sprintf("%d", status.Rewrites)},
+		{"aof_last_write_status", lastWriteStatus},
+		{"aof_current_size", fmt.Sprintf("%d", status.Size)},
+		{"aof_base_size", fmt.Sprintf("%d", status.BaseSize)},
+	}
+}
+
+// unixTime is zero for a time that never happened, like Redis' rdb_last_save_time.
+func unixTime(at time.Time) int64 {
+	if at.IsZero() {
+		return 0
+	}
+
+	return at.Unix()
+}
+
+func (h *Handler) infoStats(ctx context.Context) ([][2]string, error) {
+	stats, err := h.client.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read database stats: %w", err)
+	}
+
+	return [][2]string{
+		{"total_connections_received", fmt.Sprintf("%d", h.server.TotalConnections())},
+		{"total_commands_processed", fmt.Sprintf("%d", h.stats.TotalCommands())},
+		{"keyspace_hits", fmt.Sprintf("%d", stats.Hits)},
+		{"keyspace_misses", fmt.Sprintf("%d", stats.Misses)},
+		{"sqlite_group_commits", fmt.Sprintf("%d", stats.GroupCommits)},
+		{"sqlite_group_commit_writes", fmt.Sprintf("%d", stats.GroupCommitWrites)},
+		{"pubsub_channels", fmt.Sprintf("%d", len(h.pubsub.ActiveChannels()))},
+		{"tracking_total_keys", fmt.Sprintf("%d", h.tracking.Keys())},
+	}, nil
+}
+
+func (h *Handler) infoReplication(_ context.Context) ([][2]string, error) {
+	var fields [][2]string
+
+	if replica := h.replica.Load(); replica != nil {
+		status := "down"
+		if replica.State() == replication.StateConnected {
+			status = "up"
+		}
+
+		syncing := "0"
+		if replica.State() == replication.StateSync {
+			syncing = "1"
+		}
+
+		readOnly := "0"
+		if h.replicaReadOnly.Load() {
+			readOnly = "1"
+		}
+
+		fields = append(fields,
+			[2]string{"role", "slave"},
+			[2]string{"master_host", replica.Host},
+			[2]string{"master_port", fmt.Sprintf("%d", replica.Port)},
+			[2]string{"master_link_status", status},
+			[2]string{"master_last_io_seconds_ago", fmt.Sprintf("%d", int64(replica.LastIO().Seconds()))},
+			[2]string{"master_sync_in_progress", syncing},
+			[2]string{"slave_repl_offset", fmt.Sprintf("%d", replica.Offset())},
+			[2]string{"slave_read_only", readOnly},
+		)
+	} else {
+		fields = append(fields, [2]string{"role", "master"})
+	}
+
+	links := h.primary.Links()
+	fields = append(fields, [2]string{"connected_slaves", fmt.Sprintf("%d", len(links))})
+
+	for index, link := range links {
+		fields = append(fields, [2]string{
+			fmt.Sprintf("slave%d", index),
+			fmt.Sprintf(
+				"ip=%s,port=%d,state=online,offset=%d,lag=%d",
+				linkIP(link), link.ListeningPort, link.Acked(), int64(link.Lag().Seconds()),
+			),
+		})
+	}
+
+	backlog := h.primary.Backlog()
+
+	return append(fields,
+		[2]string{"master_replid", h.primary.ReplID()},
+		[2]string{"master_repl_offset", fmt.Sprintf("%d", h.primary.Offset())},
+		[2]string{"repl_backlog_active", "1"},
+		[2]string{"repl_backlog_size", fmt.Sprintf("%d", backlog.Size())},
+		[2]string{"repl_backlog_first_byte_offset", fmt.Sprintf("%d", backlog.First())},
+		[2]string{"repl_backlog_histlen", fmt.Sprintf("%d", backlog.Len())},
+	), nil
+}
+
+func (h *Handler) infoCommandStats(_ context.Context) ([][2]string, error) {
+	commands := h.stats.Commands()
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	fields := make([][2]string, 0, len(names))
+
+	for _, name := range names {
+		stats := commands[name]
+		usec := stats.Duration.Microseconds()
+
+		fields = append(fields, [2]string{
+			"cmdstat_" + name,
+			fmt.Sprintf(
+				"calls=%d,usec=%d,usec_per_call=%.2f,rejected_calls=0,failed_calls=%d",
+				stats.Calls,
+				usec,
+				float64(usec)/float64(stats.Calls),
+				stats.Failed,
+			),
+		})
+	}
+
+	return fields, nil
+}
+
+func (h *Handler) infoCluster(_ context.Context) ([][2]string, error) {
+	enabled := "0"
+	if h.cluster.Load() {
+		enabled = "1"
+	}
+
+	return [][2]string{
+		{"cluster_enabled", enabled},
+	}, nil
+}
+
+func (h *Handler) infoKeyspace(ctx context.Context) ([][2]string, error) {
+	stats, err := h.client.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read database stats: %w", err)
+	}
+
+	if stats.Keys == 0 {
+		return nil, nil
+	}
+
+	return [][2]string{
+		{"db0", fmt.Sprintf("keys=%d,expires=0,avg_ttl=0", stats.Keys)},
+	}, nil
+}
+
+func humanBytes(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	value := float64(size)
+	suffixes := []string{"K", "M", "G", "T", "P"}
+
+	var suffix string
+
+	for _, suffix = range suffixes {
+		value /= unit
+
+		if value < unit {
+			break
+		}
+	}
+
+	return fmt.Sprintf("%.2f%s", value, suffix)
+}
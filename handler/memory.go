@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+// ErrOOM is the error for a command that may use more memory, when the
+// database is larger than maxmemory.
+const ErrOOM router.Error = "OOM command not allowed when used memory > 'maxmemory'."
+
+// outOfMemory reports if a command is denied by maxmemory. Keys are never
+// evicted, so once the database is larger than the limit the commands
+// flagged denyoom are rejected, like the noeviction policy of redis. The
+// size is the one of the SQLite file, its pages, not of the process. The
+// commands of a primary are always applied by its replicas.
+func (h *Handler) outOfMemory(ctx context.Context, current *clients.Client, spec router.Spec) bool {
+	limit := h.maxMemory.Load()
+	if limit <= 0 || current.Primary() || !spec.HasFlag("denyoom") {
+		return false
+	}
+
+	size, err := h.client.Size(ctx)
+	if err != nil {
+		slog.Error("could not read database size", slog.String("error", err.Error()))
+
+		return false
+	}
+
+	return size > limit
+}
+
+func outOfMemoryCallback(_ []string, _ io.Writer) error {
+	return ErrOOM
+}
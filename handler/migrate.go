@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/rdb"
+	"github.com/jtarchie/sqlettuce/router"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	errMigrateConnect = router.Error("IOERR error or timeout connecting to the client")
+	errMigrateRead    = router.Error("IOERR error or timeout reading to target instance")
+)
+
+// migrateOptions are the arguments of MIGRATE, after the timeout.
+type migrateOptions struct {
+	copy     bool
+	replace  bool
+	username string
+	password string
+	keys     []string
+}
+
+func parseMigrateOptions(tokens []string) (*migrateOptions, error) {
+	options := &migrateOptions{}
+
+	for index := 0; index < len(tokens); index++ {
+		switch option := strings.ToUpper(tokens[index]); {
+		case option == "COPY":
+			options.copy = true
+		case option == "REPLACE":
+			options.replace = true
+		case option == "AUTH" && index+1 < len(tokens):
+			options.password = tokens[index+1]
+			index++
+		case option == "AUTH2" && index+2 < len(tokens):
+			options.username, options.password = tokens[index+1], tokens[index+2]
+			index += 2
+		case option == "KEYS":
+			options.keys = tokens[index+1:]
+			index = len(tokens)
+		default:
+			return nil, router.ErrSyntax
+		}
+	}
+
+	return options, nil
+}
+
+// migrateRouter restores keys on another server with the payloads of DUMP,
+// then deletes them, unless copied. Like Redis, the deletion is propagated to
+// the replicas and the AOF as a DEL, as replaying MIGRATE would migrate again.
+// The writes of the keys wait until they are migrated.
+func migrateRouter(ctx context.Context, h *Handler, current *clients.Client) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		db, err := strconv.Atoi(tokens[4])
+		if err != nil {
+			return router.ErrNotInteger
+		}
+
+		timeout, err := strconv.ParseInt(tokens[5], 10, 64)
+		if err != nil {
+			return router.ErrNotInteger
+		}
+
+		if timeout <= 0 {
+			timeout = 1000
+		}
+
+		options, err := parseMigrateOptions(tokens[6:])
+		if err != nil {
+			return err
+		}
+
+		names := []string{tokens[3]}
+
+		if options.keys != nil {
+			if tokens[3] != "" {
+				return router.Error("ERR When using MIGRATE KEYS option, the key argument must be set to the empty string")
+			}
+
+			names = options.keys
+		}
+
+		if !options.copy && h.replica.Load() != nil && h.replicaReadOnly.Load() && !current.Primary() {
+			return errReadOnly
+		}
+
+		var (
+			found      bool
+			migrateErr error
+		)
+
+		// the keys are locked until deleted, so their writes are not lost
+		err = h.executeFunc(names, func() ([]string, error) {
+			entries, err := h.client.Entries(ctx, names...)
+			if err != nil {
+				return nil, fmt.Errorf("could not execute MIGRATE: %w", err)
+			}
+
+			found = len(entries) > 0
+			if !found {
+				return nil, nil
+			}
+
+			addr := net.JoinHostPort(tokens[1], tokens[2])
+
+			var migrated []string
+
+			migrated, migrateErr = migrate(ctx, addr, db, time.Duration(timeout)*time.Millisecond, options, entries)
+			if len(migrated) == 0 || options.copy {
+				return nil, nil
+			}
+
+			_, _, err = h.client.Delete(ctx, migrated...)
+			if err != nil {
+				return nil, fmt.Errorf("could not delete migrated keys: %w", err)
+			}
+
+			return append([]string{"DEL"}, migrated...), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if migrateErr != nil {
+			return migrateErr
+		}
+
+		if !found {
+			_, err = io.WriteString(conn, "+NOKEY\r\n")
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}
+
+		_, err = io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// migrate restores the entries on the server at the address, returning the
+// keys that were restored, even on errors.
+func migrate(
+	ctx context.Context,
+	addr string,
+	db int,
+	timeout time.Duration,
+	options *migrateOptions,
+	entries []*rdb.Entry,
+) ([]string, error) {
+	target := redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Username:     options.username,
+		Password:     options.password,
+		DB:           db,
+		Protocol:     2,
+		MaxRetries:   -1,
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+		PoolSize:     1,
+	})
+	defer target.Close()
+
+	err := target.Ping(ctx).Err()
+	if err != nil {
+		return nil, targetError(err, errMigrateConnect)
+	}
+
+	pipeline := target.Pipeline()
+	commands := make([]*redis.StatusCmd, 0, len(entries))
+
+	for _, entry := range entries {
+		payload, err := rdb.Dump(entry)
+		if err != nil {
+			return nil, fmt.Errorf("could not dump %q: %w", entry.Key, err)
+		}
+
+		if options.replace {
+			commands = append(commands, pipeline.RestoreReplace(ctx, entry.Key, 0, string(payload)))
+		} else {
+			commands = append(commands, pipeline.Restore(ctx, entry.Key, 0, string(payload)))
+		}
+	}
+
+	_, _ = pipeline.Exec(ctx)
+
+	var (
+		migrated []string
+		first    error
+	)
+
+	for index, command := range commands {
+		err := command.Err()
+		if err != nil {
+			if first == nil {
+				first = targetError(err, errMigrateRead)
+			}
+
+			continue
+		}
+
+		migrated = append(migrated, entries[index].Key)
+	}
+
+	return migrated, first
+}
+
+// targetError reports the error replies of the target server, other errors
+// being timeouts or closed connections.
+func targetError(err error, fallback router.Error) error {
+	var reply redis.Error
+	if errors.As(err, &reply) {
+		return router.Error("ERR Target instance replied with error: " + reply.Error())
+	}
+
+	return fallback
+}
@@ -0,0 +1,28 @@
+//nolint:ireturn
+package handler
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/monitor"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+func monitorRouter(hub *monitor.Hub, current *clients.Client) router.Router {
+	return router.CallbackRouter(func(_ []string, _ io.Writer) error {
+		// the reply is pushed, so it is sent before any monitored command
+		err := current.Push([]byte(router.OKResponse))
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		current.SetMonitor(true)
+		hub.Subscribe(current.ID, func(message string) error {
+			return current.Push([]byte("+" + message + "\r\n"))
+		})
+
+		return nil
+	})
+}
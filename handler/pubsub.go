@@ -0,0 +1,186 @@
+//nolint:ireturn
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/glob"
+	"github.com/jtarchie/sqlettuce/pubsub"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+// subscribedCommands are the only commands a RESP2 client can send while
+// it is subscribed to channels.
+var subscribedCommands = map[string]struct{}{
+	"ping":         {},
+	"psubscribe":   {},
+	"punsubscribe": {},
+	"quit":         {},
+	"reset":        {},
+	"subscribe":    {},
+	"unsubscribe":  {},
+}
+
+func (h *Handler) allowedInContext(current *clients.Client, name string) bool {
+	if current.Protocol() >= 3 || current.Subscriptions() == 0 {
+		return true
+	}
+
+	_, ok := subscribedCommands[name]
+
+	return ok
+}
+
+func subscribedContextCallback(name string) router.Callback {
+	return func(_ []string, _ io.Writer) error {
+		return router.Error(fmt.Sprintf(
+			"ERR Can't execute '%s': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context",
+			name,
+		))
+	}
+}
+
+func subscribeRouter(
+	hub *pubsub.Hub,
+	current *clients.Client,
+) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		for _, channel := range tokens[1:] {
+			count := hub.Subscribe(current, channel)
+
+			err := writeSubscription(conn, current.Protocol(), "subscribe", channel, count)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func unsubscribeRouter(
+	hub *pubsub.Hub,
+	current *clients.Client,
+) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		channels := tokens[1:]
+		if len(channels) == 0 {
+			channels = hub.Channels(current)
+		}
+
+		if len(channels) == 0 {
+			_ = writePush(conn, current.Protocol(), 3)
+			_ = writeBulkString(conn, "unsubscribe")
+			_, _ = io.WriteString(conn, router.NullResponse)
+
+			err := writeInt(conn, 0)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}
+
+		for _, channel := range channels {
+			count := hub.Unsubscribe(current, channel)
+
+			err := writeSubscription(conn, current.Protocol(), "unsubscribe", channel, count)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func writeSubscription(conn io.Writer, protocol int, kind, channel string, count int) error {
+	_ = writePush(conn, protocol, 3)
+	_ = writeBulkString(conn, kind)
+	_ = writeBulkString(conn, channel)
+
+	return writeInt(conn, int64(count))
+}
+
+func publishRouter(hub *pubsub.Hub) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		err := writeInt(conn, int64(publish(hub, tokens[1], tokens[2])))
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// publish delivers the message to the subscribers of the channel, returning
+// the number of subscribers.
+func publish(hub *pubsub.Hub, channel, payload string) int {
+	subscribers := hub.Subscribers(channel)
+
+	for _, subscriber := range subscribers {
+		message := &bytes.Buffer{}
+		_ = writePush(message, subscriber.Protocol(), 3)
+		_ = writeBulkString(message, "message")
+		_ = writeBulkString(message, channel)
+		_ = writeBulkString(message, payload)
+
+		push(subscriber, message.Bytes())
+	}
+
+	return len(subscribers)
+}
+
+func pubsubRouter(hub *pubsub.Hub) router.Router {
+	return router.Command{
+		"CHANNELS": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			if len(tokens) > 3 {
+				return router.ErrSyntax
+			}
+
+			channels := hub.ActiveChannels()
+
+			if len(tokens) == 3 {
+				channels = filter(channels, tokens[2])
+			}
+
+			err := writeBulkStrings(conn, channels)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"NUMSUB": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			channels := tokens[2:]
+
+			_ = writeArray(conn, len(channels)*2)
+
+			for _, channel := range channels {
+				_ = writeBulkString(conn, channel)
+
+				err := writeInt(conn, int64(len(hub.Subscribers(channel))))
+				if err != nil {
+					return fmt.Errorf("could not write value: %w", err)
+				}
+			}
+
+			return nil
+		}),
+	}
+}
+
+func filter(values []string, pattern string) []string {
+	matched := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if glob.Match(pattern, value) {
+			matched = append(matched, value)
+		}
+	}
+
+	return matched
+}
@@ -0,0 +1,499 @@
+//nolint:ireturn
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/replication"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+const defaultBacklogSize = 1024 * 1024
+
+var errInvalidReplicaOf = errors.New("argument must be a host and a port")
+
+const (
+	errReadOnly        router.Error = "READONLY You can't write against a read only replica."
+	errWaitOnReplica   router.Error = "ERR WAIT cannot be used with replica instances. Please also note that writes to replicas are just local and are not propagated."
+	errNegativeTimeout router.Error = "ERR timeout is negative"
+)
+
+// replicate propagates the successful writes to the replicas. Read-only
+// replicas only accept the writes of their primary.
+func (h *Handler) replicate(current *clients.Client, spec router.Spec, next router.Callback) router.Callback {
+	return func(tokens []string, conn io.Writer) error {
+		if h.replica.Load() != nil && h.replicaReadOnly.Load() && !current.Primary() {
+			return errReadOnly
+		}
+
+		return h.execute(spec.Keys(tokens), tokens, func() error {
+			return next(tokens, conn)
+		})
+	}
+}
+
+// replicaOf follows the primary at the address, replacing any primary being
+// followed. An empty host promotes the server back to a primary.
+func (h *Handler) replicaOf(host string, port int) {
+	if previous := h.replica.Swap(nil); previous != nil {
+		previous.Stop()
+	}
+
+	if host == "" {
+		return
+	}
+
+	ctx := context.Background()
+
+	// the commands of the primary are applied as a client, like redis
+	current := h.clients.Register(net.JoinHostPort(host, strconv.Itoa(port)), "", clients.NewWriter(io.Discard), nil)
+	current.SetPrimary(true)
+
+	replica := replication.NewReplica(host, port, int(h.server.Port()), "", 0, h.load, h.applier(ctx, current))
+	h.replica.Store(replica)
+
+	replica.Start(ctx)
+
+	go func() {
+		<-replica.Done()
+		h.clients.Unregister(current)
+	}()
+}
+
+// parseReplicaOf parses the `host port` of the replicaof config.
+func parseReplicaOf(value string) (string, int, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return "", 0, errInvalidReplicaOf
+	}
+
+	port, err := strconv.Atoi(fields[1])
+	if err != nil || port < 0 || port > 65535 {
+		return "", 0, errInvalidReplicaOf
+	}
+
+	return fields[0], port, nil
+}
+
+// load replaces the database with the snapshot of the primary. Replicas of
+// this server were following the replaced history, so they need a full
+// resync.
+func (h *Handler) load(ctx context.Context, snapshot io.Reader) error {
+	dir, err := os.MkdirTemp("", "sqlettuce-sync")
+	if err != nil {
+		return fmt.Errorf("could not create directory for snapshot: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "snapshot.db")
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create snapshot: %w", err)
+	}
+
+	_, err = io.Copy(file, snapshot)
+	_ = file.Close()
+
+	if err != nil {
+		return fmt.Errorf("could not receive snapshot: %w", err)
+	}
+
+	err = h.client.Restore(ctx, path)
+	if err != nil {
+		return fmt.Errorf("could not restore snapshot: %w", err)
+	}
+
+	h.primary.SetReplID(newRunID())
+	h.primary.Disconnect()
+
+	// the AOF was recreating the replaced keys
+	if file := h.appendOnly.Load(); file != nil {
+		err = h.rewriteAppendOnly(ctx, file)
+		if err != nil {
+			slog.Warn("could not rewrite the AOF after the resync", slog.String("error", err.Error()))
+		}
+	}
+
+	return nil
+}
+
+// applier executes the commands of the primary, propagating them to the
+// replicas of this server.
+func (h *Handler) applier(ctx context.Context, current *clients.Client) replication.Applier {
+	routes := h.NewRoutes(ctx, current)
+	writer := clients.NewWriter(io.Discard)
+
+	return func(_ context.Context, tokens []string) error {
+		current.Touch(routes.Name(tokens))
+
+		callback, found := routes.Lookup(tokens)
+
+		if spec, described := routes.Spec(tokens); found && described && spec.HasFlag("write") {
+			callback = h.replicate(current, spec, callback)
+		}
+
+		defer writer.Discard()
+
+		return callback(tokens, writer)
+	}
+}
+
+// snapshot copies the database to a temporary file for the full resync of a
+// replica, the returned function removes it.
+func (h *Handler) snapshot(ctx context.Context) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "sqlettuce-sync")
+	if err != nil {
+		return "", nil, fmt.Errorf("could not create directory for snapshot: %w", err)
+	}
+
+	remove := func() { _ = os.RemoveAll(dir) }
+	path := filepath.Join(dir, "snapshot.db")
+
+	err = h.client.Snapshot(ctx, path)
+	if err != nil {
+		remove()
+
+		return "", nil, fmt.Errorf("could not snapshot: %w", err)
+	}
+
+	return path, remove, nil
+}
+
+func replicaOfRouter(h *Handler) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		if h.cluster.Load() {
+			return router.Error("ERR REPLICAOF not allowed in cluster mode.")
+		}
+
+		if strings.EqualFold(tokens[1], "no") && strings.EqualFold(tokens[2], "one") {
+			h.replicaOf("", 0)
+
+			_, err := io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}
+
+		port, err := strconv.Atoi(tokens[2])
+		if err != nil || port < 0 || port > 65535 {
+			return router.Error("ERR Invalid master port")
+		}
+
+		if replica := h.replica.Load(); replica != nil && replica.Host == tokens[1] && replica.Port == port {
+			_, err = io.WriteString(conn, "+OK Already connected to specified master\r\n")
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}
+
+		h.replicaOf(tokens[1], port)
+
+		_, err = io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func roleRouter(h *Handler) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		if h.sentinel != nil {
+			masters := h.sentinel.Masters()
+
+			names := make([]string, 0, len(masters))
+			for _, state := range masters {
+				names = append(names, state.Name)
+			}
+
+			_ = writeArray(conn, 2)
+			_ = writeBulkString(conn, "sentinel")
+
+			err := writeBulkStrings(conn, names)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}
+
+		if replica := h.replica.Load(); replica != nil {
+			_ = writeArray(conn, 5)
+			_ = writeBulkString(conn, "slave")
+			_ = writeBulkString(conn, replica.Host)
+			_ = writeInt(conn, int64(replica.Port))
+			_ = writeBulkString(conn, string(replica.State()))
+
+			err := writeInt(conn, replica.Offset())
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}
+
+		links := h.primary.Links()
+
+		_ = writeArray(conn, 3)
+		_ = writeBulkString(conn, "master")
+		_ = writeInt(conn, h.primary.Offset())
+
+		err := writeArray(conn, len(links))
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		for _, link := range links {
+			_ = writeArray(conn, 3)
+			_ = writeBulkString(conn, linkIP(link))
+			_ = writeBulkString(conn, strconv.Itoa(link.ListeningPort))
+
+			err = writeBulkString(conn, strconv.FormatInt(link.Acked(), 10))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func linkIP(link *replication.Link) string {
+	ip, _, err := net.SplitHostPort(link.Addr)
+	if err != nil {
+		return link.Addr
+	}
+
+	return ip
+}
+
+// waitReplicas blocks until the replicas acknowledged the writes before it.
+func (h *Handler) waitReplicas(ctx context.Context, count string, timeout string) (int, error) {
+	replicas, err := strconv.Atoi(count)
+	if err != nil {
+		return 0, router.ErrNotInteger
+	}
+
+	milliseconds, err := strconv.ParseInt(timeout, 10, 64)
+	if err != nil {
+		return 0, router.Error("ERR timeout is not an integer or out of range")
+	}
+
+	if milliseconds < 0 {
+		return 0, errNegativeTimeout
+	}
+
+	offset := h.primary.Offset()
+
+	if replicas > 0 {
+		h.primary.RequestAck()
+	}
+
+	return h.primary.Wait(ctx, offset, replicas, time.Duration(milliseconds)*time.Millisecond), nil
+}
+
+func waitRouter(ctx context.Context, h *Handler) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		if h.replica.Load() != nil {
+			return errWaitOnReplica
+		}
+
+		acked, err := h.waitReplicas(ctx, tokens[1], tokens[2])
+		if err != nil {
+			return err
+		}
+
+		err = writeInt(conn, int64(acked))
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// waitAOFRouter waits for writes to be durable. Every write is committed to
+// SQLite before it is replied to, so the local write is always durable.
+func waitAOFRouter(ctx context.Context, h *Handler) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		local, err := strconv.Atoi(tokens[1])
+		if err != nil {
+			return router.ErrNotInteger
+		}
+
+		if h.replica.Load() != nil && tokens[2] != "0" {
+			return router.Error("ERR WAITAOF cannot be used with replica instances. Please also note that writes to replicas are just local and are not propagated.")
+		}
+
+		acked, err := h.waitReplicas(ctx, tokens[2], tokens[3])
+		if err != nil {
+			return err
+		}
+
+		_ = writeArray(conn, 2)
+		_ = writeInt(conn, int64(min(local, 1)))
+
+		err = writeInt(conn, int64(acked))
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// replconfRouter configures the replication of the client with option and
+// value pairs. Acknowledgements are never replied to.
+func replconfRouter(h *Handler, current *clients.Client) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		if len(tokens)%2 == 0 {
+			return router.ErrSyntax
+		}
+
+		for index := 1; index < len(tokens); index += 2 {
+			option, value := strings.ToLower(tokens[index]), tokens[index+1]
+
+			switch option {
+			case "ack":
+				offset, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return router.ErrNotInteger
+				}
+
+				h.primary.Ack(current.ID, offset)
+
+				return nil
+			case "getack":
+				return nil
+			case "listening-port":
+				port, err := strconv.Atoi(value)
+				if err != nil || port < 0 || port > 65535 {
+					return router.ErrNotInteger
+				}
+
+				current.SetListeningPort(port)
+			case "capa", "ip-address", "rdb-only", "rdb-filter-only":
+			default:
+				return router.Error("ERR Unrecognized REPLCONF option: " + tokens[index])
+			}
+		}
+
+		_, err := io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// psyncRouter attaches the client as a replica. It receives a snapshot, or
+// the backlog since its offset, followed by the replication stream.
+// SYNC always receives a snapshot, without the FULLRESYNC header.
+func psyncRouter(ctx context.Context, h *Handler, current *clients.Client, header bool) router.Router {
+	return router.CallbackRouter(func(tokens []string, _ io.Writer) error {
+		if current.Replica() {
+			return nil
+		}
+
+		replID, offset := "?", int64(-1)
+
+		if header {
+			replID = tokens[1]
+
+			var err error
+
+			offset, err = strconv.ParseInt(tokens[2], 10, 64)
+			if err != nil {
+				return router.ErrNotInteger
+			}
+		}
+
+		link := replication.NewLink(current.ID, current.Addr, current.ListeningPort(), func() {
+			_ = current.Close()
+		})
+
+		var (
+			snapshot string
+			remove   = func() {}
+		)
+
+		// the offset of PSYNC is the next byte the replica expects. Writes
+		// are only paused while the snapshot is taken, it is sent after.
+		resync, err := h.primary.Attach(link, replID, offset-1, func() error {
+			var err error
+
+			snapshot, remove, err = h.snapshot(ctx)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("could not attach replica: %w", err)
+		}
+		defer remove()
+
+		current.SetReplica(true)
+
+		// pushed, so the stream is never interleaved with the replies
+		if resync.Full {
+			err = pushSnapshot(current, snapshot, resync, header)
+		} else {
+			err = current.Push(fmt.Appendf(nil, "+CONTINUE %s\r\n%s", resync.ReplID, resync.Backlog))
+		}
+
+		if err != nil {
+			h.primary.Detach(link.ID)
+
+			return fmt.Errorf("could not send resync: %w", err)
+		}
+
+		h.primary.Start(link, func(data []byte) error {
+			return current.Push(data)
+		})
+
+		return nil
+	})
+}
+
+// pushSnapshot streams the snapshot file to the replica, with the
+// FULLRESYNC header of PSYNC.
+func pushSnapshot(current *clients.Client, path string, resync replication.Resync, header bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("could not read snapshot size: %w", err)
+	}
+
+	var reply bytes.Buffer
+
+	if header {
+		fmt.Fprintf(&reply, "+FULLRESYNC %s %d\r\n", resync.ReplID, resync.Offset)
+	}
+
+	fmt.Fprintf(&reply, "$%d\r\n", info.Size())
+
+	return current.PushFrom(reply.Bytes(), file) //nolint:wrapcheck
+}
@@ -3,45 +3,69 @@ package handler
 import (
 	"context"
 
-	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/clients"
 	"github.com/jtarchie/sqlettuce/router"
 )
 
 //nolint:funlen
-func NewRoutes(
+func (h *Handler) NewRoutes(
 	ctx context.Context,
-	client *db.Client,
+	current *clients.Client,
 ) router.Command {
+	if h.sentinel != nil {
+		return h.sentinelRoutes(ctx, current)
+	}
+
+	client := h.client
+
 	commands := router.Command{
-		"APPEND": appendRouter(ctx, client),
-		"CONFIG": router.Command{
-			"GET": router.Command{
-				"save":       router.StaticResponseRouter(router.EmptyStringResponse),
-				"appendonly": router.StaticResponseRouter("+no\r\n"),
-			},
-		},
-		"COMMAND": router.Command{
-			"DOCS": router.StaticResponseRouter(router.EmptyStringResponse),
-		},
-		"DECR":        decrRouter(ctx, client),
-		"DECRBY":      decrByRouter(ctx, client),
-		"DEL":         delRouter(ctx, client),
-		"ECHO":        echoRouter(),
-		"FLUSHALL":    flushAllRouter(ctx, client),
-		"GET":         getRouter(ctx, client),
-		"GETDEL":      getDelRouter(ctx, client),
-		"GETRANGE":    getRangeRouter(ctx, client),
-		"INCR":        incrRouter(ctx, client),
-		"INCRBY":      incrByRouter(ctx, client),
-		"INCRBYFLOAT": incrByFloatRouter(ctx, client),
-		"LRANGE":      lrangeRouter(ctx, client),
-		"MGET":        mgetRouter(ctx, client),
-		"MSET":        msetRouter(ctx, client),
-		"PING":        router.StaticResponseRouter("+PONG\r\n"),
-		"RPUSH":       rpushRouter(ctx, client),
-		"RPUSHX":      rpushXRouter(ctx, client),
-		"SET":         setRouter(ctx, client),
-		"STRLEN":      strlenRouter(ctx, client),
+		"APPEND":       appendRouter(ctx, client),
+		"BGREWRITEAOF": bgrewriteaofRouter(ctx, h),
+		"BGSAVE":       bgsaveRouter(h),
+		"CLIENT":       clientRouter(h.clients, h.tracking, current),
+		"CLUSTER":      clusterRouter(ctx, h, current),
+		"CONFIG":       configRouter(h, current),
+		"DEBUG":        debugRouter(ctx, client),
+		"DECR":         decrRouter(ctx, client),
+		"DECRBY":       decrByRouter(ctx, client),
+		"DEL":          delRouter(ctx, client),
+		"DUMP":         dumpRouter(ctx, client),
+		"ECHO":         echoRouter(),
+		"FLUSHALL":     flushAllRouter(ctx, client),
+		"GET":          getRouter(ctx, client),
+		"GETDEL":       getDelRouter(ctx, client),
+		"GETRANGE":     getRangeRouter(ctx, client),
+		"HELLO":        helloRouter(h, current),
+		"INCR":         incrRouter(ctx, client),
+		"INCRBY":       incrByRouter(ctx, client),
+		"INCRBYFLOAT":  incrByFloatRouter(ctx, client),
+		"INFO":         infoRouter(ctx, h, infoSections),
+		"LASTSAVE":     lastsaveRouter(h),
+		"LATENCY":      latencyRouter(h.latency),
+		"LRANGE":       lrangeRouter(ctx, client),
+		"MGET":         mgetRouter(ctx, client),
+		"MIGRATE":      migrateRouter(ctx, h, current),
+		"MONITOR":      monitorRouter(h.monitor, current),
+		"MSET":         msetRouter(ctx, client),
+		"PING":         router.StaticResponseRouter("+PONG\r\n"),
+		"PSYNC":        psyncRouter(ctx, h, current, true),
+		"PUBLISH":      publishRouter(h.pubsub),
+		"PUBSUB":       pubsubRouter(h.pubsub),
+		"REPLCONF":     replconfRouter(h, current),
+		"REPLICAOF":    replicaOfRouter(h),
+		"ROLE":         roleRouter(h),
+		"RESTORE":      restoreRouter(ctx, client),
+		"RPUSH":        rpushRouter(ctx, client),
+		"RPUSHX":       rpushXRouter(ctx, client),
+		"SAVE":         saveRouter(ctx, h),
+		"SET":          setRouter(ctx, client),
+		"SLOWLOG":      slowlogRouter(h.slowlog),
+		"STRLEN":       strlenRouter(ctx, client),
+		"SUBSCRIBE":    subscribeRouter(h.pubsub, current),
+		"SYNC":         psyncRouter(ctx, h, current, false),
+		"UNSUBSCRIBE":  unsubscribeRouter(h.pubsub, current),
+		"WAIT":         waitRouter(ctx, h),
+		"WAITAOF":      waitAOFRouter(ctx, h),
 
 		// deprecated commands, let's not support them
 		"RPOPLPUSH":  router.StaticResponseRouter("-Deprecated command, please use LMOVE with the RIGHT and LEFT\r\n"),
@@ -56,6 +80,8 @@ func NewRoutes(
 
 	commands["FLUSHDB"] = commands["FLUSHALL"]
 	commands["UNLINK"] = commands["DEL"]
+	commands["SLAVEOF"] = commands["REPLICAOF"]
+	commands["COMMAND"] = commandRouter(commands, current)
 
-	return commands
+	return describe("", commands)
 }
@@ -0,0 +1,98 @@
+//nolint:ireturn
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/jtarchie/sqlettuce/persistence"
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+const errSaveInProgress = router.Error("ERR Background save already in progress")
+
+// countChanges counts the modified keys for the save points, flushing
+// every key counts as one.
+func (h *Handler) countChanges(_ context.Context, names []string) {
+	h.saver.Changed(max(len(names), 1))
+}
+
+func saveRouter(ctx context.Context, h *Handler) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		if !h.client.Snapshots() {
+			return router.Error("ERR snapshots are not supported by the storage driver")
+		}
+
+		err := h.saver.Save(ctx)
+		if errors.Is(err, persistence.ErrInProgress) {
+			return errSaveInProgress
+		}
+
+		if err != nil {
+			slog.Error("could not SAVE", slog.String("error", err.Error()))
+
+			return router.Error("ERR")
+		}
+
+		_, err = io.WriteString(conn, router.OKResponse)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func bgsaveRouter(h *Handler) router.Router {
+	return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+		schedule := false
+
+		if len(tokens) > 1 {
+			if len(tokens) > 2 || !strings.EqualFold(tokens[1], "schedule") {
+				return router.ErrSyntax
+			}
+
+			schedule = true
+		}
+
+		if !h.client.Snapshots() {
+			return router.Error("ERR snapshots are not supported by the storage driver")
+		}
+
+		scheduled, err := h.saver.BackgroundSave(schedule)
+		if errors.Is(err, persistence.ErrInProgress) {
+			return errSaveInProgress
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not start background save: %w", err)
+		}
+
+		reply := "+Background saving started\r\n"
+		if scheduled {
+			reply = "+Background saving scheduled\r\n"
+		}
+
+		_, err = io.WriteString(conn, reply)
+		if err != nil {
+			return fmt.Errorf("could not send reply: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func lastsaveRouter(h *Handler) router.Router {
+	return router.CallbackRouter(func(_ []string, conn io.Writer) error {
+		err := writeInt(conn, h.saver.LastSave().Unix())
+		if err != nil {
+			return fmt.Errorf("could not write value: %w", err)
+		}
+
+		return nil
+	})
+}
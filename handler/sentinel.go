@@ -0,0 +1,367 @@
+//nolint:ireturn
+package handler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/router"
+	"github.com/jtarchie/sqlettuce/sentinel"
+)
+
+const errNoSuchMaster router.Error = "ERR No such master with that name"
+
+// sentinelInfoSections are reported by INFO in sentinel mode.
+var sentinelInfoSections = []infoSection{
+	{name: "server", fields: (*Handler).infoServer, fallback: true},
+	{name: "clients", fields: (*Handler).infoClients, fallback: true},
+	{name: "stats", fields: (*Handler).infoStats, fallback: true},
+	{name: "sentinel", fields: (*Handler).infoSentinel, fallback: true},
+}
+
+// Sentinel switches the handler to sentinel mode, monitoring the primaries
+// instead of serving data. Events, like `+switch-master`, are published to
+// the clients of the sentinel. It must be called before serving clients.
+func (h *Handler) Sentinel(configs ...sentinel.Config) (*sentinel.Sentinel, error) {
+	monitor := sentinel.New(h.runID, "127.0.0.1", int(h.server.Port()), func(channel, message string) {
+		publish(h.pubsub, channel, message)
+	})
+
+	for _, config := range configs {
+		err := monitor.Monitor(config)
+		if err != nil {
+			return nil, fmt.Errorf("could not monitor %q: %w", config.Name, err)
+		}
+	}
+
+	h.sentinel = monitor
+
+	return monitor, nil
+}
+
+// sentinelRoutes are the commands of sentinel mode, like redis sentinel.
+func (h *Handler) sentinelRoutes(ctx context.Context, current *clients.Client) router.Command {
+	commands := router.Command{
+		"CLIENT":      clientRouter(h.clients, h.tracking, current),
+		"HELLO":       helloRouter(h, current),
+		"INFO":        infoRouter(ctx, h, sentinelInfoSections),
+		"PING":        router.StaticResponseRouter("+PONG\r\n"),
+		"PUBLISH":     publishRouter(h.pubsub),
+		"ROLE":        roleRouter(h),
+		"SENTINEL":    sentinelRouter(ctx, h, current),
+		"SUBSCRIBE":   subscribeRouter(h.pubsub, current),
+		"UNSUBSCRIBE": unsubscribeRouter(h.pubsub, current),
+	}
+
+	commands["COMMAND"] = commandRouter(commands, current)
+
+	return describe("", commands)
+}
+
+func (h *Handler) infoSentinel(_ context.Context) ([][2]string, error) {
+	masters := h.sentinel.Masters()
+
+	fields := [][2]string{
+		{"sentinel_masters", strconv.Itoa(len(masters))},
+		{"sentinel_tilt", "0"},
+		{"sentinel_running_scripts", "0"},
+		{"sentinel_scripts_queue_length", "0"},
+	}
+
+	for index, state := range masters {
+		status := "ok"
+
+		switch {
+		case state.ODown:
+			status = "odown"
+		case state.Primary.Down:
+			status = "sdown"
+		}
+
+		fields = append(fields, [2]string{
+			fmt.Sprintf("master%d", index),
+			fmt.Sprintf(
+				"name=%s,status=%s,address=%s,slaves=%d,sentinels=%d",
+				state.Name, status, state.Primary.Addr(), len(state.Replicas), len(state.Peers)+1,
+			),
+		})
+	}
+
+	return fields, nil
+}
+
+func masterFields(state sentinel.State) [][2]string {
+	flags := []string{"master"}
+
+	if state.Primary.Down {
+		flags = append(flags, "s_down")
+	}
+
+	if state.ODown {
+		flags = append(flags, "o_down")
+	}
+
+	if state.FailingOver {
+		flags = append(flags, "failover_in_progress")
+	}
+
+	return [][2]string{
+		{"name", state.Name},
+		{"ip", state.Primary.Host},
+		{"port", strconv.Itoa(state.Primary.Port)},
+		{"runid", state.Primary.RunID},
+		{"flags", strings.Join(flags, ",")},
+		{"last-ping-reply", strconv.FormatInt(time.Since(state.Primary.LastPong).Milliseconds(), 10)},
+		{"down-after-milliseconds", strconv.FormatInt(state.DownAfter.Milliseconds(), 10)},
+		{"failover-timeout", strconv.FormatInt(state.FailoverTimeout.Milliseconds(), 10)},
+		{"role-reported", "master"},
+		{"config-epoch", strconv.FormatInt(state.ConfigEpoch, 10)},
+		{"num-slaves", strconv.Itoa(len(state.Replicas))},
+		{"num-other-sentinels", strconv.Itoa(len(state.Peers))},
+		{"quorum", strconv.Itoa(state.Quorum)},
+	}
+}
+
+func replicaFields(replica sentinel.Instance) [][2]string {
+	flags := "slave"
+	if replica.Down {
+		flags = "s_down,slave"
+	}
+
+	status := "err"
+	if replica.LinkUp {
+		status = "ok"
+	}
+
+	return [][2]string{
+		{"name", replica.Addr()},
+		{"ip", replica.Host},
+		{"port", strconv.Itoa(replica.Port)},
+		{"runid", replica.RunID},
+		{"flags", flags},
+		{"last-ping-reply", strconv.FormatInt(time.Since(replica.LastPong).Milliseconds(), 10)},
+		{"role-reported", cmp.Or(replica.Role, "slave")},
+		{"master-link-status", status},
+		{"master-host", replica.MasterHost},
+		{"master-port", strconv.Itoa(replica.MasterPort)},
+		{"slave-repl-offset", strconv.FormatInt(replica.Offset, 10)},
+	}
+}
+
+func peerFields(peer sentinel.Peer) [][2]string {
+	return [][2]string{
+		{"name", peer.RunID},
+		{"ip", peer.Host},
+		{"port", strconv.Itoa(peer.Port)},
+		{"runid", peer.RunID},
+		{"flags", "sentinel"},
+		{"last-hello-message", strconv.FormatInt(time.Since(peer.LastHello).Milliseconds(), 10)},
+		{"voted-leader", cmp.Or(peer.Leader, "?")},
+		{"voted-leader-epoch", strconv.FormatInt(peer.LeaderEpoch, 10)},
+	}
+}
+
+// writeFieldMaps writes a list of flattened maps, like SENTINEL MASTERS.
+func writeFieldMaps(conn io.Writer, protocol int, list [][][2]string) error {
+	err := writeArray(conn, len(list))
+	if err != nil {
+		return err
+	}
+
+	for _, fields := range list {
+		err = writeFields(conn, protocol, fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeFields(conn io.Writer, protocol int, fields [][2]string) error {
+	err := writeMap(conn, protocol, len(fields))
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		_ = writeBulkString(conn, field[0])
+
+		err = writeBulkString(conn, field[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//nolint:funlen,cyclop
+func sentinelRouter(ctx context.Context, h *Handler, current *clients.Client) router.Router {
+	// master looks up the primary named by the third token
+	master := func(callback func(state sentinel.State, conn io.Writer) error) router.Router {
+		return router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			state, ok := h.sentinel.Master(tokens[2])
+			if !ok {
+				return errNoSuchMaster
+			}
+
+			err := callback(state, conn)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		})
+	}
+
+	replicas := master(func(state sentinel.State, conn io.Writer) error {
+		list := make([][][2]string, 0, len(state.Replicas))
+		for _, replica := range state.Replicas {
+			list = append(list, replicaFields(replica))
+		}
+
+		return writeFieldMaps(conn, current.Protocol(), list)
+	})
+
+	return router.Command{
+		"CKQUORUM": master(func(state sentinel.State, conn io.Writer) error {
+			usable := 1
+
+			for _, peer := range state.Peers {
+				if time.Since(peer.LastHello) < state.DownAfter {
+					usable++
+				}
+			}
+
+			if usable < state.Quorum {
+				return router.Error(fmt.Sprintf(
+					"NOQUORUM %d usable Sentinels. Not enough available Sentinels to reach the specified quorum for this master",
+					usable,
+				))
+			}
+
+			if usable < (len(state.Peers)+1)/2+1 {
+				return router.Error(fmt.Sprintf(
+					"NOQUORUM %d usable Sentinels. Not enough available Sentinels to reach the majority and authorize a failover",
+					usable,
+				))
+			}
+
+			_, err := fmt.Fprintf(conn, "+OK %d usable Sentinels. Quorum and failover authorization can be reached\r\n", usable)
+
+			return err
+		}),
+		"FAILOVER": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			err := h.sentinel.Failover(ctx, tokens[2])
+
+			switch {
+			case errors.Is(err, sentinel.ErrNoSuchMaster):
+				return errNoSuchMaster
+			case errors.Is(err, sentinel.ErrNoGoodReplica):
+				return router.Error("NOGOODSLAVE No suitable replica to promote")
+			case errors.Is(err, sentinel.ErrInProgress):
+				return router.Error("INPROG Failover already in progress")
+			case err != nil:
+				return fmt.Errorf("could not failover: %w", err)
+			}
+
+			_, err = io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}),
+		"GET-MASTER-ADDR-BY-NAME": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			state, ok := h.sentinel.Master(tokens[2])
+			if !ok {
+				_, err := io.WriteString(conn, "*-1\r\n")
+				if err != nil {
+					return fmt.Errorf("could not send reply: %w", err)
+				}
+
+				return nil
+			}
+
+			err := writeBulkStrings(conn, []string{state.Primary.Host, strconv.Itoa(state.Primary.Port)})
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"IS-MASTER-DOWN-BY-ADDR": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			port, err := strconv.Atoi(tokens[3])
+			if err != nil {
+				return router.ErrNotInteger
+			}
+
+			epoch, err := strconv.ParseInt(tokens[4], 10, 64)
+			if err != nil {
+				return router.ErrNotInteger
+			}
+
+			down, leader, leaderEpoch := h.sentinel.IsMasterDown(tokens[2], port, epoch, tokens[5])
+
+			downState := int64(0)
+			if down {
+				downState = 1
+			}
+
+			_ = writeArray(conn, 3)
+			_ = writeInt(conn, downState)
+			_ = writeBulkString(conn, leader)
+
+			err = writeInt(conn, leaderEpoch)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"MASTER": master(func(state sentinel.State, conn io.Writer) error {
+			return writeFields(conn, current.Protocol(), masterFields(state))
+		}),
+		"MASTERS": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			masters := h.sentinel.Masters()
+
+			list := make([][][2]string, 0, len(masters))
+			for _, state := range masters {
+				list = append(list, masterFields(state))
+			}
+
+			err := writeFieldMaps(conn, current.Protocol(), list)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"MYID": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			err := writeBulkString(conn, h.sentinel.ID)
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"REPLICAS": replicas,
+		"SENTINELS": master(func(state sentinel.State, conn io.Writer) error {
+			list := make([][][2]string, 0, len(state.Peers))
+			for _, peer := range state.Peers {
+				list = append(list, peerFields(peer))
+			}
+
+			return writeFieldMaps(conn, current.Protocol(), list)
+		}),
+		"SLAVES": replicas,
+	}
+}
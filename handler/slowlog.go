@@ -0,0 +1,189 @@
+//nolint:ireturn
+package handler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/latency"
+	"github.com/jtarchie/sqlettuce/router"
+	"github.com/jtarchie/sqlettuce/slowlog"
+)
+
+const (
+	defaultSlowlogCount     = 10
+	defaultSlowlogThreshold = 10 * time.Millisecond
+	defaultSlowlogMaxLen    = 128
+)
+
+// latencyEvents map the operations timed by the database to the events
+// reported by LATENCY.
+var latencyEvents = map[string]string{
+	"Commit":     "sqlite-commit",
+	"Checkpoint": "sqlite-checkpoint",
+}
+
+// observe records the duration of every command of the client to SLOWLOG
+// and LATENCY.
+func (h *Handler) observe(current *clients.Client) router.Middleware {
+	return func(_ string, next router.Callback) router.Callback {
+		return func(tokens []string, conn io.Writer) error {
+			started := time.Now()
+			err := next(tokens, conn)
+			duration := time.Since(started)
+
+			h.slowlog.Record(slowlog.Entry{
+				Time:       started,
+				Duration:   duration,
+				Tokens:     tokens,
+				Addr:       current.Addr,
+				ClientName: current.Name(),
+			})
+			h.latency.Record("command", duration)
+
+			return err
+		}
+	}
+}
+
+func (h *Handler) observeStatement(operation string, duration time.Duration) {
+	if event, ok := latencyEvents[operation]; ok {
+		h.latency.Record(event, duration)
+	}
+}
+
+func slowlogRouter(log *slowlog.Log) router.Router {
+	return router.Command{
+		"GET": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			if len(tokens) > 3 {
+				return router.ErrSyntax
+			}
+
+			count := defaultSlowlogCount
+
+			if len(tokens) > 2 {
+				var err error
+
+				count, err = strconv.Atoi(tokens[2])
+				if err != nil || count < -1 {
+					return router.Error("ERR count should be greater than or equal to -1")
+				}
+			}
+
+			entries := log.Get(count)
+
+			err := writeArray(conn, len(entries))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			for _, entry := range entries {
+				_ = writeArray(conn, 6)
+				_ = writeInt(conn, int64(entry.ID))
+				_ = writeInt(conn, entry.Time.Unix())
+				_ = writeInt(conn, entry.Duration.Microseconds())
+				_ = writeBulkStrings(conn, entry.Tokens)
+				_ = writeBulkString(conn, entry.Addr)
+
+				err = writeBulkString(conn, entry.ClientName)
+				if err != nil {
+					return fmt.Errorf("could not write value: %w", err)
+				}
+			}
+
+			return nil
+		}),
+		"LEN": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			err := writeInt(conn, int64(log.Len()))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"RESET": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			log.Reset()
+
+			_, err := io.WriteString(conn, router.OKResponse)
+			if err != nil {
+				return fmt.Errorf("could not send reply: %w", err)
+			}
+
+			return nil
+		}),
+	}
+}
+
+func latencyRouter(monitor *latency.Monitor) router.Router {
+	return router.Command{
+		"DOCTOR": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			err := writeBulkString(conn, monitor.Doctor())
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+		"HISTORY": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			samples := monitor.History(tokens[2])
+
+			err := writeArray(conn, len(samples))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			for _, sample := range samples {
+				_ = writeArray(conn, 2)
+				_ = writeInt(conn, sample.Time.Unix())
+
+				err = writeInt(conn, sample.Latency.Milliseconds())
+				if err != nil {
+					return fmt.Errorf("could not write value: %w", err)
+				}
+			}
+
+			return nil
+		}),
+		"LATEST": router.CallbackRouter(func(_ []string, conn io.Writer) error {
+			events := monitor.Latest()
+
+			err := writeArray(conn, len(events))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			for _, event := range events {
+				latest := event.Latest()
+
+				_ = writeArray(conn, 4)
+				_ = writeBulkString(conn, event.Name)
+				_ = writeInt(conn, latest.Time.Unix())
+				_ = writeInt(conn, latest.Latency.Milliseconds())
+
+				err = writeInt(conn, event.Max.Milliseconds())
+				if err != nil {
+					return fmt.Errorf("could not write value: %w", err)
+				}
+			}
+
+			return nil
+		}),
+		"RESET": router.CallbackRouter(func(tokens []string, conn io.Writer) error {
+			names := make([]string, 0, len(tokens)-2)
+			for _, name := range tokens[2:] {
+				names = append(names, strings.ToLower(name))
+			}
+
+			err := writeInt(conn, int64(monitor.Reset(names...)))
+			if err != nil {
+				return fmt.Errorf("could not write value: %w", err)
+			}
+
+			return nil
+		}),
+	}
+}
@@ -0,0 +1,647 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jtarchie/sqlettuce/router"
+)
+
+// specs are the metadata of the commands, as reported by COMMAND.
+// They also drive the arity checks, `CLIENT PAUSE WRITE` and replication
+// (write flag) and client side caching (readonly flag and key positions).
+//
+//nolint:gochecknoglobals
+var specs = indexSpecs([]router.Spec{
+	{
+		Name: "append", Arity: 3, Flags: []string{"write", "denyoom", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "string", "fast"},
+		Summary: "Appends a string to the value of a key. Creates the key if it doesn't exist.",
+		Since:   "2.0.0", Group: "string",
+	},
+	{
+		Name: "bgrewriteaof", Arity: 1, Flags: []string{"admin", "noscript", "no_async_loading"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Asynchronously rewrites the append-only file to disk.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "bgsave", Arity: -1, Flags: []string{"admin", "noscript", "no_async_loading"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Asynchronously saves the database(s) to disk.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "client", Arity: -2, Categories: []string{"slow"},
+		Summary: "A container for client connection commands.",
+		Since:   "2.4.0", Group: "connection",
+	},
+	{
+		Name: "client|caching", Arity: 3, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Instructs the server whether to track the keys in the next request.",
+		Since:      "6.0.0", Group: "connection",
+	},
+	{
+		Name: "client|getname", Arity: 2, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns the name of the connection.",
+		Since:      "2.6.9", Group: "connection",
+	},
+	{
+		Name: "client|getredir", Arity: 2, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns the client ID to which the connection's tracking notifications are redirected.",
+		Since:      "6.0.0", Group: "connection",
+	},
+	{
+		Name: "client|id", Arity: 2, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns the unique client ID of the connection.",
+		Since:      "5.0.0", Group: "connection",
+	},
+	{
+		Name: "client|info", Arity: 2, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns information about the connection.",
+		Since:      "6.2.0", Group: "connection",
+	},
+	{
+		Name: "client|kill", Arity: -3, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous", "connection"},
+		Summary:    "Terminates open connections.",
+		Since:      "2.4.0", Group: "connection",
+	},
+	{
+		Name: "client|list", Arity: -2, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous", "connection"},
+		Summary:    "Lists open connections.",
+		Since:      "2.4.0", Group: "connection",
+	},
+	{
+		Name: "client|no-evict", Arity: 3, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous", "connection"},
+		Summary:    "Sets the client eviction mode of the connection.",
+		Since:      "7.0.0", Group: "connection",
+	},
+	{
+		Name: "client|pause", Arity: -3, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous", "connection"},
+		Summary:    "Suspends commands processing.",
+		Since:      "3.0.0", Group: "connection",
+	},
+	{
+		Name: "client|setinfo", Arity: 4, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Sets information specific to the client or connection.",
+		Since:      "7.2.0", Group: "connection",
+	},
+	{
+		Name: "client|setname", Arity: 3, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Sets the connection name.",
+		Since:      "2.6.9", Group: "connection",
+	},
+	{
+		Name: "client|tracking", Arity: -3, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Controls server-assisted client-side caching for the connection.",
+		Since:      "6.0.0", Group: "connection",
+	},
+	{
+		Name: "client|trackinginfo", Arity: 2, Flags: []string{"noscript", "loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns information about server-assisted client-side caching for the connection.",
+		Since:      "6.2.0", Group: "connection",
+	},
+	{
+		Name: "client|unpause", Arity: 2, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous", "connection"},
+		Summary:    "Resumes processing commands from paused clients.",
+		Since:      "6.2.0", Group: "connection",
+	},
+	{
+		Name: "cluster", Arity: -2, Categories: []string{"slow"},
+		Summary: "A container for Redis Cluster commands.",
+		Since:   "3.0.0", Group: "cluster",
+	},
+	{
+		Name: "cluster|countkeysinslot", Arity: 3, Flags: []string{"stale"}, Categories: []string{"slow"},
+		Summary: "Returns the number of keys in a hash slot.",
+		Since:   "3.0.0", Group: "cluster",
+	},
+	{
+		Name: "cluster|getkeysinslot", Arity: 4, Flags: []string{"stale"}, Categories: []string{"slow"},
+		Summary: "Returns the key names in a hash slot.",
+		Since:   "3.0.0", Group: "cluster",
+	},
+	{
+		Name: "cluster|info", Arity: 2, Flags: []string{"loading", "stale"}, Categories: []string{"slow"},
+		Summary: "Returns information about the state of a node.",
+		Since:   "3.0.0", Group: "cluster",
+	},
+	{
+		Name: "cluster|keyslot", Arity: 3, Flags: []string{"stale"}, Categories: []string{"slow"},
+		Summary: "Returns the hash slot for a key.",
+		Since:   "3.0.0", Group: "cluster",
+	},
+	{
+		Name: "cluster|myid", Arity: 2, Flags: []string{"loading", "stale"}, Categories: []string{"slow"},
+		Summary: "Returns the ID of a node.",
+		Since:   "3.0.0", Group: "cluster",
+	},
+	{
+		Name: "cluster|nodes", Arity: 2, Flags: []string{"loading", "stale"}, Categories: []string{"slow"},
+		Summary: "Returns the cluster configuration for a node.",
+		Since:   "3.0.0", Group: "cluster",
+	},
+	{
+		Name: "cluster|shards", Arity: 2, Flags: []string{"loading", "stale"}, Categories: []string{"slow"},
+		Summary: "Returns the mapping of cluster slots to shards.",
+		Since:   "7.0.0", Group: "cluster",
+	},
+	{
+		Name: "cluster|slots", Arity: 2, Flags: []string{"loading", "stale"}, Categories: []string{"slow"},
+		Summary: "Returns the mapping of cluster slots to nodes.",
+		Since:   "3.0.0", Group: "cluster",
+	},
+	{
+		Name: "command", Arity: -1, Flags: []string{"loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns detailed information about all commands.",
+		Since:      "2.8.13", Group: "server",
+	},
+	{
+		Name: "command|count", Arity: 2, Flags: []string{"loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns a count of commands.",
+		Since:      "2.8.13", Group: "server",
+	},
+	{
+		Name: "command|docs", Arity: -2, Flags: []string{"loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns documentary information about one, multiple or all commands.",
+		Since:      "7.0.0", Group: "server",
+	},
+	{
+		Name: "command|getkeys", Arity: -3, Flags: []string{"loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Extracts the key names from an arbitrary command.",
+		Since:      "2.8.13", Group: "server",
+	},
+	{
+		Name: "command|info", Arity: -2, Flags: []string{"loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns information about one, multiple or all commands.",
+		Since:      "2.8.13", Group: "server",
+	},
+	{
+		Name: "command|list", Arity: -2, Flags: []string{"loading", "stale"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Returns a list of command names.",
+		Since:      "7.0.0", Group: "server",
+	},
+	{
+		Name: "config", Arity: -2, Categories: []string{"slow"},
+		Summary: "A container for server configuration commands.",
+		Since:   "2.0.0", Group: "server",
+	},
+	{
+		Name: "config|get", Arity: -3, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns the effective values of configuration parameters.",
+		Since:      "2.0.0", Group: "server",
+	},
+	{
+		Name: "config|resetstat", Arity: 2, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Resets the server's statistics.",
+		Since:      "2.0.0", Group: "server",
+	},
+	{
+		Name: "config|rewrite", Arity: 2, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Persists the effective configuration to file.",
+		Since:      "2.8.0", Group: "server",
+	},
+	{
+		Name: "config|set", Arity: -4, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Sets configuration parameters in-flight.",
+		Since:      "2.0.0", Group: "server",
+	},
+	{
+		Name: "debug", Arity: -2, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "A container for debugging commands.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "decr", Arity: 2, Flags: []string{"write", "denyoom", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "string", "fast"},
+		Summary: "Decrements the integer value of a key by one. Uses 0 as initial value if the key doesn't exist.",
+		Since:   "1.0.0", Group: "string",
+	},
+	{
+		Name: "decrby", Arity: 3, Flags: []string{"write", "denyoom", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "string", "fast"},
+		Summary: "Decrements a number from the integer value of a key. Uses 0 as initial value if the key doesn't exist.",
+		Since:   "1.0.0", Group: "string",
+	},
+	{
+		Name: "del", Arity: -2, Flags: []string{"write"},
+		FirstKey: 1, LastKey: -1, Step: 1, Categories: []string{"keyspace", "write", "slow"},
+		Summary: "Deletes one or more keys.",
+		Since:   "1.0.0", Group: "generic",
+	},
+	{
+		Name: "dump", Arity: 2, Flags: []string{"readonly"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace", "read", "slow"},
+		Summary: "Returns a serialized representation of the value stored at a key.",
+		Since:   "2.6.0", Group: "generic",
+	},
+	{
+		Name: "echo", Arity: 2, Flags: []string{"loading", "stale", "fast"},
+		Categories: []string{"fast", "connection"},
+		Summary:    "Returns the given string.",
+		Since:      "1.0.0", Group: "connection",
+	},
+	{
+		Name: "flushall", Arity: -1, Flags: []string{"write"},
+		Categories: []string{"keyspace", "write", "slow", "dangerous"},
+		Summary:    "Removes all keys from all databases.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "flushdb", Arity: -1, Flags: []string{"write"},
+		Categories: []string{"keyspace", "write", "slow", "dangerous"},
+		Summary:    "Remove all keys from the current database.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "get", Arity: 2, Flags: []string{"readonly", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"read", "string", "fast"},
+		Summary: "Returns the string value of a key.",
+		Since:   "1.0.0", Group: "string",
+	},
+	{
+		Name: "getdel", Arity: 2, Flags: []string{"write", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "string", "fast"},
+		Summary: "Returns the string value of a key after deleting the key.",
+		Since:   "6.2.0", Group: "string",
+	},
+	{
+		Name: "getrange", Arity: 4, Flags: []string{"readonly"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"read", "string", "slow"},
+		Summary: "Returns a substring of the string stored at a key.",
+		Since:   "2.4.0", Group: "string",
+	},
+	{
+		Name: "hello", Arity: -1, Flags: []string{"noscript", "loading", "stale", "fast", "no_auth"},
+		Categories: []string{"fast", "connection"},
+		Summary:    "Handshakes with the Redis server.",
+		Since:      "6.0.0", Group: "connection",
+	},
+	{
+		Name: "incr", Arity: 2, Flags: []string{"write", "denyoom", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "string", "fast"},
+		Summary: "Increments the integer value of a key by one. Uses 0 as initial value if the key doesn't exist.",
+		Since:   "1.0.0", Group: "string",
+	},
+	{
+		Name: "incrby", Arity: 3, Flags: []string{"write", "denyoom", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "string", "fast"},
+		Summary: "Increments the integer value of a key by a number. Uses 0 as initial value if the key doesn't exist.",
+		Since:   "1.0.0", Group: "string",
+	},
+	{
+		Name: "incrbyfloat", Arity: 3, Flags: []string{"write", "denyoom", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "string", "fast"},
+		Summary: "Increment the floating point value of a key by a number. Uses 0 as initial value if the key doesn't exist.",
+		Since:   "2.6.0", Group: "string",
+	},
+	{
+		Name: "info", Arity: -1, Flags: []string{"loading", "stale"},
+		Categories: []string{"slow", "dangerous"},
+		Summary:    "Returns information and statistics about the server.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "lastsave", Arity: 1, Flags: []string{"loading", "stale", "fast"},
+		Categories: []string{"admin", "fast", "dangerous"},
+		Summary:    "Returns the Unix timestamp of the last successful save to disk.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "latency", Arity: -2, Categories: []string{"slow"},
+		Summary: "A container for latency diagnostics commands.",
+		Since:   "2.8.13", Group: "server",
+	},
+	{
+		Name: "latency|doctor", Arity: 2, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns a human-readable latency analysis report.",
+		Since:      "2.8.13", Group: "server",
+	},
+	{
+		Name: "latency|history", Arity: 3, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns timestamp-latency samples for an event.",
+		Since:      "2.8.13", Group: "server",
+	},
+	{
+		Name: "latency|latest", Arity: 2, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns the latest latency samples for all events.",
+		Since:      "2.8.13", Group: "server",
+	},
+	{
+		Name: "latency|reset", Arity: -2, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Resets the latency data for one or more events.",
+		Since:      "2.8.13", Group: "server",
+	},
+	{
+		Name: "lrange", Arity: 4, Flags: []string{"readonly"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"read", "list", "slow"},
+		Summary: "Returns a range of elements from a list.",
+		Since:   "1.0.0", Group: "list",
+	},
+	{
+		Name: "mget", Arity: -2, Flags: []string{"readonly", "fast"},
+		FirstKey: 1, LastKey: -1, Step: 1, Categories: []string{"read", "string", "fast"},
+		Summary: "Atomically returns the string values of one or more keys.",
+		Since:   "1.0.0", Group: "string",
+	},
+	{
+		Name: "migrate", Arity: -6, Flags: []string{"write", "movablekeys"},
+		FirstKey: 3, LastKey: 3, Step: 1, Categories: []string{"keyspace", "write", "slow", "dangerous"},
+		Summary: "Atomically transfers a key from one Redis instance to another.",
+		Since:   "2.6.0", Group: "generic",
+	},
+	{
+		Name: "monitor", Arity: 1, Flags: []string{"admin", "noscript", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Listens for all requests received by the server in real-time.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "mset", Arity: -3, Flags: []string{"write", "denyoom"},
+		FirstKey: 1, LastKey: -1, Step: 2, Categories: []string{"write", "string", "slow"},
+		Summary: "Atomically creates or modifies the string values of one or more keys.",
+		Since:   "1.0.1", Group: "string",
+	},
+	{
+		Name: "ping", Arity: -1, Flags: []string{"fast"},
+		Categories: []string{"fast", "connection"},
+		Summary:    "Returns the server's liveliness response.",
+		Since:      "1.0.0", Group: "connection",
+	},
+	{
+		Name: "psync", Arity: -3, Flags: []string{"admin", "noscript", "no_async_loading", "no_multi"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "An internal command used in replication.",
+		Since:      "2.8.0", Group: "server",
+	},
+	{
+		Name: "publish", Arity: 3, Flags: []string{"pubsub", "loading", "stale", "fast"},
+		Categories: []string{"pubsub", "fast"},
+		Summary:    "Posts a message to a channel.",
+		Since:      "2.0.0", Group: "pubsub",
+	},
+	{
+		Name: "pubsub", Arity: -2, Categories: []string{"slow"},
+		Summary: "A container for Pub/Sub commands.",
+		Since:   "2.8.0", Group: "pubsub",
+	},
+	{
+		Name: "pubsub|channels", Arity: -2, Flags: []string{"pubsub", "loading", "stale"},
+		Categories: []string{"pubsub", "slow"},
+		Summary:    "Returns the active channels.",
+		Since:      "2.8.0", Group: "pubsub",
+	},
+	{
+		Name: "pubsub|numsub", Arity: -2, Flags: []string{"pubsub", "loading", "stale"},
+		Categories: []string{"pubsub", "slow"},
+		Summary:    "Returns a count of subscribers to channels.",
+		Since:      "2.8.0", Group: "pubsub",
+	},
+	{
+		Name: "replconf", Arity: -1, Flags: []string{"admin", "noscript", "loading", "stale", "allow_busy"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "An internal command for configuring the replication stream.",
+		Since:      "3.0.0", Group: "server",
+	},
+	{
+		Name: "replicaof", Arity: 3, Flags: []string{"admin", "noscript", "stale", "no_async_loading"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Configures a server as replica of another, or promotes it to a master.",
+		Since:      "5.0.0", Group: "server",
+	},
+	{
+		Name: "restore", Arity: -4, Flags: []string{"write", "denyoom"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace", "write", "slow", "dangerous"},
+		Summary: "Creates a key from the serialized representation of a value.",
+		Since:   "2.6.0", Group: "generic",
+	},
+	{
+		Name: "role", Arity: 1, Flags: []string{"noscript", "loading", "stale", "fast"},
+		Categories: []string{"admin", "fast", "dangerous"},
+		Summary:    "Returns the replication role.",
+		Since:      "2.8.12", Group: "server",
+	},
+	{
+		Name: "rpush", Arity: -3, Flags: []string{"write", "denyoom", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "list", "fast"},
+		Summary: "Appends one or more elements to a list. Creates the key if it doesn't exist.",
+		Since:   "1.0.0", Group: "list",
+	},
+	{
+		Name: "rpushx", Arity: -3, Flags: []string{"write", "denyoom", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "list", "fast"},
+		Summary: "Appends an element to a list only when the list exists.",
+		Since:   "2.2.0", Group: "list",
+	},
+	{
+		Name: "save", Arity: 1, Flags: []string{"admin", "noscript", "no_async_loading", "no_multi"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Synchronously saves the database(s) to disk.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "sentinel", Arity: -2, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "A container for Redis Sentinel commands.",
+		Since:      "2.8.4", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|ckquorum", Arity: 3, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Checks for a Redis Sentinel quorum.",
+		Since:      "2.8.4", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|failover", Arity: 3, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Forces a Redis Sentinel failover.",
+		Since:      "2.8.4", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|get-master-addr-by-name", Arity: 3, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns the port and address of a master instance.",
+		Since:      "2.8.4", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|is-master-down-by-addr", Arity: 6, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Determines whether a master instance is down.",
+		Since:      "2.8.4", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|master", Arity: 3, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns the state of a master instance.",
+		Since:      "2.8.4", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|masters", Arity: 2, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns a list of monitored masters.",
+		Since:      "2.8.4", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|myid", Arity: 2, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns the Redis Sentinel instance ID.",
+		Since:      "6.2.0", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|replicas", Arity: 3, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns a list of the monitored replicas.",
+		Since:      "5.0.0", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|sentinels", Arity: 3, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns a list of Sentinel instances.",
+		Since:      "2.8.4", Group: "sentinel",
+	},
+	{
+		Name: "sentinel|slaves", Arity: 3, Flags: []string{"admin", "sentinel", "only_sentinel"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns a list of the monitored replicas.",
+		Since:      "2.8.0", Group: "sentinel",
+	},
+	{
+		Name: "set", Arity: -3, Flags: []string{"write", "denyoom"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"write", "string", "slow"},
+		Summary: "Sets the string value of a key, ignoring its type. The key is created if it doesn't exist.",
+		Since:   "1.0.0", Group: "string",
+	},
+	{
+		Name: "slaveof", Arity: 3, Flags: []string{"admin", "noscript", "stale", "no_async_loading"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Sets a server as a replica of another, or promotes it to being a master.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "slowlog", Arity: -2, Categories: []string{"slow"},
+		Summary: "A container for slow log commands.",
+		Since:   "2.2.12", Group: "server",
+	},
+	{
+		Name: "slowlog|get", Arity: -2, Flags: []string{"admin", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns the slow log's entries.",
+		Since:      "2.2.12", Group: "server",
+	},
+	{
+		Name: "slowlog|len", Arity: 2, Flags: []string{"admin", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Returns the number of entries in the slow log.",
+		Since:      "2.2.12", Group: "server",
+	},
+	{
+		Name: "slowlog|reset", Arity: 2, Flags: []string{"admin", "loading", "stale"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "Clears all entries from the slow log.",
+		Since:      "2.2.12", Group: "server",
+	},
+	{
+		Name: "strlen", Arity: 2, Flags: []string{"readonly", "fast"},
+		FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"read", "string", "fast"},
+		Summary: "Returns the length of a string value.",
+		Since:   "2.2.0", Group: "string",
+	},
+	{
+		Name: "subscribe", Arity: -2, Flags: []string{"pubsub", "noscript", "loading", "stale"},
+		Categories: []string{"pubsub", "slow"},
+		Summary:    "Listens for messages published to channels.",
+		Since:      "2.0.0", Group: "pubsub",
+	},
+	{
+		Name: "sync", Arity: 1, Flags: []string{"admin", "noscript", "no_async_loading", "no_multi"},
+		Categories: []string{"admin", "slow", "dangerous"},
+		Summary:    "An internal command used in replication.",
+		Since:      "1.0.0", Group: "server",
+	},
+	{
+		Name: "unlink", Arity: -2, Flags: []string{"write", "fast"},
+		FirstKey: 1, LastKey: -1, Step: 1, Categories: []string{"keyspace", "write", "fast"},
+		Summary: "Asynchronously deletes one or more keys.",
+		Since:   "4.0.0", Group: "generic",
+	},
+	{
+		Name: "unsubscribe", Arity: -1, Flags: []string{"pubsub", "noscript", "loading", "stale"},
+		Categories: []string{"pubsub", "slow"},
+		Summary:    "Stops listening to messages posted to channels.",
+		Since:      "2.0.0", Group: "pubsub",
+	},
+	{
+		Name: "wait", Arity: 3, Flags: []string{"noscript"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Blocks until the asynchronous replication of all preceding write commands sent by the connection is completed.",
+		Since:      "3.0.0", Group: "generic",
+	},
+	{
+		Name: "waitaof", Arity: 4, Flags: []string{"noscript"},
+		Categories: []string{"slow", "connection"},
+		Summary:    "Blocks until all of the preceding write commands sent by the connection are written to the append-only file of the master and/or replicas.",
+		Since:      "7.2.0", Group: "generic",
+	},
+})
+
+func indexSpecs(list []router.Spec) map[string]router.Spec {
+	index := make(map[string]router.Spec, len(list))
+
+	for _, spec := range list {
+		index[spec.Name] = spec
+	}
+
+	return index
+}
+
+// describe attaches the specs to the commands, and their subcommands, by name.
+// Commands without a spec, like the deprecated ones, are left as is.
+func describe(prefix string, commands router.Command) router.Command {
+	for command, next := range commands {
+		name := strings.ToLower(command)
+		if prefix != "" {
+			name = fmt.Sprintf("%s|%s", prefix, name)
+		}
+
+		if sub, ok := next.(router.Command); ok {
+			next = describe(name, sub)
+		}
+
+		if spec, ok := specs[name]; ok {
+			next = router.WithSpec(spec, next)
+		}
+
+		commands[command] = next
+	}
+
+	return commands
+}
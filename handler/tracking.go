@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/tracking"
+)
+
+const invalidateChannel = "__redis__:invalidate"
+
+func (h *Handler) onChange(ctx context.Context, names []string) {
+	if names == nil {
+		h.tracking.InvalidateAll()
+
+		return
+	}
+
+	var origin uint64
+	if current, ok := clients.FromContext(ctx); ok {
+		origin = current.ID
+	}
+
+	h.tracking.Invalidate(origin, names...)
+}
+
+// invalidate sends invalidation messages as RESP3 pushes. Clients using
+// RESP2 receive them through the `__redis__:invalidate` channel, which
+// requires a redirect to a subscribed connection. The messages are queued,
+// so the write invalidating the keys does not wait on the receivers.
+func (h *Handler) invalidate(id uint64, options tracking.Options, keys []string) {
+	target := id
+	if options.Redirect != 0 {
+		target = options.Redirect
+	}
+
+	receiver, ok := h.clients.Get(target)
+	if !ok {
+		if source, ok := h.clients.Get(id); ok && source.Protocol() == 3 {
+			message := &bytes.Buffer{}
+			_ = writePush(message, 3, 2)
+			_ = writeBulkString(message, "tracking-redir-broken")
+			_ = writeInt(message, int64(target))
+
+			push(source, message.Bytes())
+		}
+
+		return
+	}
+
+	message := &bytes.Buffer{}
+
+	switch {
+	case receiver.Protocol() == 3:
+		_ = writePush(message, 3, 2)
+		_ = writeBulkString(message, "invalidate")
+	case h.pubsub.IsSubscribed(receiver, invalidateChannel):
+		_ = writePush(message, 2, 3)
+		_ = writeBulkString(message, "message")
+		_ = writeBulkString(message, invalidateChannel)
+	default:
+		return
+	}
+
+	writeInvalidatedKeys(message, receiver.Protocol(), keys)
+	push(receiver, message.Bytes())
+}
+
+func writeInvalidatedKeys(message io.Writer, protocol int, keys []string) {
+	switch {
+	case keys != nil:
+		_ = writeBulkStrings(message, keys)
+	case protocol == 3:
+		_, _ = io.WriteString(message, "_\r\n")
+	default:
+		_, _ = io.WriteString(message, "*-1\r\n")
+	}
+}
+
+func push(receiver *clients.Client, message []byte) {
+	err := receiver.Queue(message)
+	if err != nil {
+		slog.Error("could not queue message",
+			slog.Uint64("client", receiver.ID),
+			slog.String("error", err.Error()),
+		)
+	}
+}
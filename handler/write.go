@@ -6,18 +6,6 @@ import (
 	"strconv"
 )
 
-func writeError(conn io.Writer, message string) error {
-	_, _ = io.WriteString(conn, "-")
-	_, _ = io.WriteString(conn, message)
-
-	_, err := io.WriteString(conn, "\r\n")
-	if err != nil {
-		return fmt.Errorf("could not write error: %w", err)
-	}
-
-	return nil
-}
-
 func writeFloat(conn io.Writer, value float64) error {
 	_, _ = io.WriteString(conn, ",")
 	_, _ = io.WriteString(conn, strconv.FormatFloat(value, 'f', 17, 64))
@@ -55,3 +43,67 @@ func writeBulkString(conn io.Writer, value string) error {
 
 	return nil
 }
+
+func writeArray(conn io.Writer, length int) error {
+	_, _ = io.WriteString(conn, "*")
+	_, _ = io.WriteString(conn, strconv.Itoa(length))
+
+	_, err := io.WriteString(conn, "\r\n")
+	if err != nil {
+		return fmt.Errorf("could not send array: %w", err)
+	}
+
+	return nil
+}
+
+// writePush writes the header of an out-of-band message.
+// RESP2 has no push type, so an array is used like for pub/sub messages.
+func writePush(conn io.Writer, protocol int, length int) error {
+	if protocol < 3 {
+		return writeArray(conn, length)
+	}
+
+	_, _ = io.WriteString(conn, ">")
+	_, _ = io.WriteString(conn, strconv.Itoa(length))
+
+	_, err := io.WriteString(conn, "\r\n")
+	if err != nil {
+		return fmt.Errorf("could not send push: %w", err)
+	}
+
+	return nil
+}
+
+// writeMap writes the header of a map with the number of pairs.
+// RESP2 has no map type, so a flattened array is used.
+func writeMap(conn io.Writer, protocol int, pairs int) error {
+	if protocol < 3 {
+		return writeArray(conn, pairs*2)
+	}
+
+	_, _ = io.WriteString(conn, "%")
+	_, _ = io.WriteString(conn, strconv.Itoa(pairs))
+
+	_, err := io.WriteString(conn, "\r\n")
+	if err != nil {
+		return fmt.Errorf("could not send map: %w", err)
+	}
+
+	return nil
+}
+
+func writeBulkStrings(conn io.Writer, values []string) error {
+	err := writeArray(conn, len(values))
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		err = writeBulkString(conn, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
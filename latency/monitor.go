@@ -0,0 +1,206 @@
+package latency
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSamples is the number of samples kept for each event, like redis.
+const maxSamples = 160
+
+// Sample is the worst latency of an event within a second.
+type Sample struct {
+	Time    time.Time
+	Latency time.Duration
+}
+
+// Event is the history of latency spikes of an event.
+type Event struct {
+	Name    string
+	Samples []Sample
+	Max     time.Duration
+}
+
+// Latest returns the most recent sample.
+func (e Event) Latest() Sample {
+	return e.Samples[len(e.Samples)-1]
+}
+
+// Monitor records latency spikes over a threshold for named events, for
+// example `command` or `sqlite-commit`.
+type Monitor struct {
+	mutex     sync.RWMutex
+	threshold time.Duration
+	events    map[string]*Event
+}
+
+// NewMonitor creates a monitor. A zero threshold disables it.
+func NewMonitor(threshold time.Duration) *Monitor {
+	return &Monitor{
+		threshold: threshold,
+		events:    map[string]*Event{},
+	}
+}
+
+func (m *Monitor) Threshold() time.Duration {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.threshold
+}
+
+func (m *Monitor) SetThreshold(threshold time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.threshold = threshold
+}
+
+// Record adds a sample when the latency is over the threshold. Samples within
+// the same second are merged, keeping the worst latency.
+func (m *Monitor) Record(name string, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.threshold <= 0 || latency < m.threshold {
+		return
+	}
+
+	now := time.Now().Truncate(time.Second)
+
+	event, ok := m.events[name]
+	if !ok {
+		event = &Event{Name: name}
+		m.events[name] = event
+	}
+
+	event.Max = max(event.Max, latency)
+
+	if count := len(event.Samples); count > 0 && event.Samples[count-1].Time.Equal(now) {
+		event.Samples[count-1].Latency = max(event.Samples[count-1].Latency, latency)
+
+		return
+	}
+
+	event.Samples = append(event.Samples, Sample{Time: now, Latency: latency})
+	if len(event.Samples) > maxSamples {
+		event.Samples = event.Samples[1:]
+	}
+}
+
+// Latest returns every event with samples, sorted by name.
+func (m *Monitor) Latest() []Event {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	events := make([]Event, 0, len(m.events))
+	for _, event := range m.events {
+		events = append(events, Event{
+			Name:    event.Name,
+			Samples: slices.Clone(event.Samples),
+			Max:     event.Max,
+		})
+	}
+
+	slices.SortFunc(events, func(a, b Event) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return events
+}
+
+// History returns the samples of the event, oldest first.
+func (m *Monitor) History(name string) []Sample {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	event, ok := m.events[name]
+	if !ok {
+		return nil
+	}
+
+	return slices.Clone(event.Samples)
+}
+
+// Reset removes the samples of the events, or of every event when none are
+// given. It returns the number of events that were removed.
+func (m *Monitor) Reset(names ...string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(names) == 0 {
+		count := len(m.events)
+		m.events = map[string]*Event{}
+
+		return count
+	}
+
+	count := 0
+
+	for _, name := range names {
+		if _, ok := m.events[name]; ok {
+			delete(m.events, name)
+
+			count++
+		}
+	}
+
+	return count
+}
+
+// Doctor returns a human readable report of the latency spikes.
+func (m *Monitor) Doctor() string {
+	threshold := m.Threshold()
+	if threshold <= 0 {
+		return "I'm sorry, Dave, I can't do that. Latency monitoring is disabled in this " +
+			"server. Enable it with CONFIG SET latency-monitor-threshold <milliseconds>.\n"
+	}
+
+	events := m.Latest()
+	if len(events) == 0 {
+		return "Dave, no latency spike was observed during the lifetime of this server.\n"
+	}
+
+	var report strings.Builder
+
+	report.WriteString("Dave, I have observed latency spikes in this server.\n\n")
+
+	for index, event := range events {
+		var total time.Duration
+		for _, sample := range event.Samples {
+			total += sample.Latency
+		}
+
+		fmt.Fprintf(
+			&report,
+			"%d. %s: %d latency spikes (average %dms, worst %dms, latest %dms).\n",
+			index+1,
+			event.Name,
+			len(event.Samples),
+			(total / time.Duration(len(event.Samples))).Milliseconds(),
+			event.Max.Milliseconds(),
+			event.Latest().Latency.Milliseconds(),
+		)
+	}
+
+	report.WriteString("\nI have a few advices for you:\n\n")
+
+	for _, event := range events {
+		switch {
+		case strings.HasPrefix(event.Name, "sqlite-commit"):
+			report.WriteString("- Commits to SQLite are slow. Check the disk the database is on, " +
+				"or batch writes into fewer transactions.\n")
+		case strings.HasPrefix(event.Name, "sqlite-checkpoint"):
+			report.WriteString("- Checkpoints of the write ahead log are slow. Checkpoint more " +
+				"often so each one has less to copy.\n")
+		case strings.HasPrefix(event.Name, "command"):
+			report.WriteString("- Commands are slow. Use SLOWLOG GET to find the commands " +
+				"and the queries they run.\n")
+		}
+	}
+
+	return report.String()
+}
@@ -0,0 +1,46 @@
+package latency_test
+
+import (
+	"time"
+
+	"github.com/jtarchie/sqlettuce/latency"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Monitor", func() {
+	It("records latency over the threshold", func() {
+		monitor := latency.NewMonitor(10 * time.Millisecond)
+
+		monitor.Record("command", time.Millisecond)
+		monitor.Record("command", 20*time.Millisecond)
+		monitor.Record("command", 50*time.Millisecond)
+		monitor.Record("sqlite-commit", 15*time.Millisecond)
+
+		events := monitor.Latest()
+		Expect(events).To(HaveLen(2))
+		Expect(events[0].Name).To(Equal("command"))
+		Expect(events[0].Max).To(Equal(50 * time.Millisecond))
+		Expect(events[1].Name).To(Equal("sqlite-commit"))
+
+		history := monitor.History("command")
+		Expect(history).ToNot(BeEmpty())
+		Expect(history[len(history)-1].Latency).To(Equal(50 * time.Millisecond))
+
+		Expect(monitor.History("unknown")).To(BeEmpty())
+		Expect(monitor.Doctor()).To(ContainSubstring("command"))
+
+		Expect(monitor.Reset("command", "unknown")).To(Equal(1))
+		Expect(monitor.Latest()).To(HaveLen(1))
+		Expect(monitor.Reset()).To(Equal(1))
+		Expect(monitor.Latest()).To(BeEmpty())
+	})
+
+	It("is disabled with a zero threshold", func() {
+		monitor := latency.NewMonitor(0)
+		monitor.Record("command", time.Hour)
+
+		Expect(monitor.Latest()).To(BeEmpty())
+		Expect(monitor.Doctor()).To(ContainSubstring("disabled"))
+	})
+})
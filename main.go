@@ -11,8 +11,8 @@ func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
 	slog.SetDefault(logger)
 
-	cli := &CLI{}
-	ctx := kong.Parse(cli)
+	commands := &Commands{}
+	ctx := kong.Parse(commands, kong.Configuration(configResolver))
 
 	err := ctx.Run()
 	if err != nil {
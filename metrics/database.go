@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	databaseSize = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sqlite", "database_size_bytes"),
+		"Size of the database file.",
+		nil, nil,
+	)
+	walSize = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sqlite", "wal_size_bytes"),
+		"Size of the write ahead log file.",
+		nil, nil,
+	)
+	keys = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sqlite", "keys"),
+		"Number of keys stored.",
+		nil, nil,
+	)
+	transactionRetries = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sqlite", "transaction_retries_total"),
+		"Number of transactions retried because the database was busy.",
+		nil, nil,
+	)
+)
+
+// databaseCollector reads the stats of the database on each scrape.
+type databaseCollector struct {
+	//nolint:containedctx
+	ctx    context.Context
+	client *db.Client
+}
+
+func (d *databaseCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- databaseSize
+	descs <- walSize
+	descs <- keys
+	descs <- transactionRetries
+}
+
+func (d *databaseCollector) Collect(metrics chan<- prometheus.Metric) {
+	stats, err := d.client.Stats(d.ctx)
+	if err != nil {
+		slog.Error("could not collect database metrics", slog.String("error", err.Error()))
+
+		return
+	}
+
+	metrics <- prometheus.MustNewConstMetric(databaseSize, prometheus.GaugeValue, float64(stats.DatabaseSize()))
+	metrics <- prometheus.MustNewConstMetric(walSize, prometheus.GaugeValue, float64(stats.WALSize))
+	metrics <- prometheus.MustNewConstMetric(keys, prometheus.GaugeValue, float64(stats.Keys))
+	metrics <- prometheus.MustNewConstMetric(transactionRetries, prometheus.CounterValue, float64(stats.Retries))
+}
+
+var _ prometheus.Collector = &databaseCollector{}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/router"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sqlettuce"
+
+// Server reports the traffic of the listener accepting connections.
+type Server interface {
+	ActiveConnections() int64
+	TotalConnections() uint64
+	BytesRead() uint64
+	BytesWritten() uint64
+}
+
+// Metrics exposes the server, commands and database to Prometheus.
+type Metrics struct {
+	registry   *prometheus.Registry
+	commands   *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	statements *prometheus.HistogramVec
+}
+
+func New(ctx context.Context, client *db.Client, server Server) (*Metrics, error) {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "commands_total",
+			Help:      "Number of commands processed by name and result.",
+		}, []string{"command", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "command_duration_seconds",
+			Help:      "Latency of commands by name.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+		}, []string{"command"}),
+		statements: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "sqlite_statement_duration_seconds",
+			Help:      "Latency of operations against the database.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+		}, []string{"operation"}),
+	}
+
+	collectors := []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.commands,
+		metrics.latency,
+		metrics.statements,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "connections_active",
+			Help:      "Number of connected clients.",
+		}, func() float64 {
+			return float64(server.ActiveConnections())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "connections_received_total",
+			Help:      "Number of connections accepted.",
+		}, func() float64 {
+			return float64(server.TotalConnections())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "net_read_bytes_total",
+			Help:      "Number of bytes read from clients.",
+		}, func() float64 {
+			return float64(server.BytesRead())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "net_written_bytes_total",
+			Help:      "Number of bytes written to clients.",
+		}, func() float64 {
+			return float64(server.BytesWritten())
+		}),
+		&databaseCollector{ctx: ctx, client: client},
+	}
+
+	for _, collector := range collectors {
+		err := metrics.registry.Register(collector)
+		if err != nil {
+			return nil, fmt.Errorf("could not register metric: %w", err)
+		}
+	}
+
+	client.ObserveStatements(metrics.observeStatement)
+
+	return metrics, nil
+}
+
+// Middleware records the result and latency of every command.
+func (m *Metrics) Middleware(name string, next router.Callback) router.Callback {
+	return func(tokens []string, conn io.Writer) error {
+		started := time.Now()
+		err := next(tokens, conn)
+
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+
+		m.commands.WithLabelValues(name, result).Inc()
+		m.latency.WithLabelValues(name).Observe(time.Since(started).Seconds())
+
+		return err
+	}
+}
+
+func (m *Metrics) observeStatement(operation string, duration time.Duration) {
+	m.statements.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus text or OpenMetrics format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// Listen serves the metrics on `/metrics` until the context is done.
+func (m *Metrics) Listen(ctx context.Context, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	err := server.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("could not serve metrics: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeServer struct{}
+
+func (fakeServer) ActiveConnections() int64 { return 2 }
+func (fakeServer) TotalConnections() uint64 { return 3 }
+func (fakeServer) BytesRead() uint64        { return 100 }
+func (fakeServer) BytesWritten() uint64     { return 200 }
+
+var _ = Describe("Metrics", func() {
+	var client *db.Client
+
+	BeforeEach(func() {
+		var err error
+
+		client, err = db.NewClient("sqlite://:memory:?cache=shared&mode=memory")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		client.Close()
+	})
+
+	scrape := func(observer *metrics.Metrics) string {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		observer.Handler().ServeHTTP(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		return recorder.Body.String()
+	}
+
+	It("records commands by name and result", func() {
+		observer, err := metrics.New(context.TODO(), client, fakeServer{})
+		Expect(err).NotTo(HaveOccurred())
+
+		ok := observer.Middleware("get", func(_ []string, _ io.Writer) error {
+			return nil
+		})
+		failed := observer.Middleware("set", func(_ []string, _ io.Writer) error {
+			return errors.New("some error")
+		})
+
+		Expect(ok([]string{"GET", "key"}, &bytes.Buffer{})).NotTo(HaveOccurred())
+		Expect(ok([]string{"GET", "key"}, &bytes.Buffer{})).NotTo(HaveOccurred())
+		Expect(failed([]string{"SET", "key"}, &bytes.Buffer{})).To(HaveOccurred())
+
+		body := scrape(observer)
+		Expect(body).To(ContainSubstring(`sqlettuce_commands_total{command="get",result="ok"} 2`))
+		Expect(body).To(ContainSubstring(`sqlettuce_commands_total{command="set",result="error"} 1`))
+		Expect(body).To(ContainSubstring(`sqlettuce_command_duration_seconds_count{command="get"} 2`))
+	})
+
+	It("reports the server and database", func() {
+		observer, err := metrics.New(context.TODO(), client, fakeServer{})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.Set(context.TODO(), "key", "value")
+		Expect(err).NotTo(HaveOccurred())
+
+		body := scrape(observer)
+		Expect(body).To(ContainSubstring("sqlettuce_connections_active 2"))
+		Expect(body).To(ContainSubstring("sqlettuce_connections_received_total 3"))
+		Expect(body).To(ContainSubstring("sqlettuce_net_read_bytes_total 100"))
+		Expect(body).To(ContainSubstring("sqlettuce_net_written_bytes_total 200"))
+		Expect(body).To(ContainSubstring("sqlettuce_sqlite_keys 1"))
+		Expect(body).To(ContainSubstring("sqlettuce_sqlite_database_size_bytes"))
+		Expect(body).To(ContainSubstring("sqlettuce_sqlite_wal_size_bytes"))
+		Expect(body).To(ContainSubstring("sqlettuce_sqlite_transaction_retries_total 0"))
+		Expect(body).To(ContainSubstring(`sqlettuce_sqlite_statement_duration_seconds_count{operation="Set"} 1`))
+	})
+})
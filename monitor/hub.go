@@ -0,0 +1,179 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// bufferSize is the number of messages queued for a monitor before new
+// messages are dropped.
+const bufferSize = 1024
+
+// Event is a command processed by the server.
+type Event struct {
+	Time   time.Time
+	DB     int
+	Addr   string
+	Tokens []string
+}
+
+// String formats the event like the lines sent by redis MONITOR, with the
+// credentials redacted.
+func (e Event) String() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%d.%06d [%d %s]", e.Time.Unix(), e.Time.Nanosecond()/1000, e.DB, e.Addr)
+
+	for _, token := range redact(e.Tokens) {
+		builder.WriteString(" ")
+		builder.WriteString(quote(token))
+	}
+
+	return builder.String()
+}
+
+// Deliver sends a formatted event to a monitor.
+type Deliver func(message string) error
+
+type subscriber struct {
+	messages chan string
+	done     chan struct{}
+}
+
+// Hub fans out processed commands to every monitoring client.
+// Publishing never blocks, messages are dropped for monitors that are
+// too slow to keep up.
+type Hub struct {
+	mutex       sync.RWMutex
+	subscribers map[uint64]*subscriber
+	count       atomic.Int64
+	dropped     atomic.Uint64
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: map[uint64]*subscriber{},
+	}
+}
+
+// Subscribe delivers every published event to the client until it
+// unsubscribes or the delivery fails.
+func (h *Hub) Subscribe(client uint64, deliver Deliver) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.subscribers[client]; ok {
+		return
+	}
+
+	current := &subscriber{
+		messages: make(chan string, bufferSize),
+		done:     make(chan struct{}),
+	}
+
+	h.subscribers[client] = current
+	h.count.Add(1)
+
+	go func() {
+		for {
+			select {
+			case <-current.done:
+				return
+			case message := <-current.messages:
+				err := deliver(message)
+				if err != nil {
+					h.Unsubscribe(client)
+
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (h *Hub) Unsubscribe(client uint64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	current, ok := h.subscribers[client]
+	if !ok {
+		return
+	}
+
+	close(current.done)
+	delete(h.subscribers, client)
+	h.count.Add(-1)
+}
+
+// Len is the number of monitoring clients.
+func (h *Hub) Len() int {
+	return int(h.count.Load())
+}
+
+// Dropped is the number of messages not delivered to slow monitors.
+func (h *Hub) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+// Publish sends the event to every monitor. It is cheap when there are no
+// monitors, so it can be called for every command.
+func (h *Hub) Publish(event Event) {
+	if h.count.Load() == 0 {
+		return
+	}
+
+	message := event.String()
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for _, current := range h.subscribers {
+		select {
+		case current.messages <- message:
+		default:
+			h.dropped.Add(1)
+		}
+	}
+}
+
+// quote escapes the value like redis does for MONITOR output.
+func quote(value string) string {
+	var builder strings.Builder
+
+	builder.WriteByte('"')
+
+	for index := 0; index < len(value); index++ {
+		char := value[index]
+
+		switch char {
+		case '\\', '"':
+			builder.WriteByte('\\')
+			builder.WriteByte(char)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\a':
+			builder.WriteString(`\a`)
+		case '\b':
+			builder.WriteString(`\b`)
+		default:
+			if char < ' ' || char > '~' {
+				builder.WriteString(`\x`)
+				builder.WriteString(strconv.FormatUint(uint64(char)|0x100, 16)[1:])
+			} else {
+				builder.WriteByte(char)
+			}
+		}
+	}
+
+	builder.WriteByte('"')
+
+	return builder.String()
+}
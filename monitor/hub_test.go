@@ -0,0 +1,107 @@
+package monitor_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/monitor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hub", func() {
+	It("formats events like redis", func() {
+		event := monitor.Event{
+			Time:   time.Unix(1339518083, 107412000),
+			DB:     0,
+			Addr:   "127.0.0.1:60866",
+			Tokens: []string{"SET", "key", "a \"quoted\"\nvalue\x01"},
+		}
+
+		Expect(event.String()).To(Equal(
+			`1339518083.107412 [0 127.0.0.1:60866] "SET" "key" "a \"quoted\"\nvalue\x01"`,
+		))
+	})
+
+	It("redacts credentials", func() {
+		format := func(tokens ...string) string {
+			return monitor.Event{Time: time.Unix(0, 0), Addr: "127.0.0.1:1", Tokens: tokens}.String()
+		}
+
+		Expect(format("AUTH", "user", "secret")).To(HaveSuffix(`"AUTH" "(redacted)" "(redacted)"`))
+		Expect(format("hello", "3", "auth", "user", "secret", "SETNAME", "name")).To(HaveSuffix(
+			`"hello" "3" "auth" "(redacted)" "(redacted)" "SETNAME" "name"`,
+		))
+		Expect(format("MIGRATE", "host", "6379", "", "0", "5000", "AUTH", "secret", "KEYS", "AUTH")).To(HaveSuffix(
+			`"MIGRATE" "host" "6379" "" "0" "5000" "AUTH" "(redacted)" "KEYS" "AUTH"`,
+		))
+		Expect(format("MIGRATE", "host", "6379", "key", "0", "5000", "COPY", "AUTH2", "user", "secret")).To(HaveSuffix(
+			`"COPY" "AUTH2" "(redacted)" "(redacted)"`,
+		))
+		Expect(format("SET", "AUTH", "secret")).To(HaveSuffix(`"SET" "AUTH" "secret"`))
+	})
+
+	It("delivers events to every monitor", func() {
+		hub := monitor.NewHub()
+
+		var (
+			mutex    sync.Mutex
+			messages []string
+		)
+
+		hub.Subscribe(1, func(message string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			messages = append(messages, message)
+
+			return nil
+		})
+		Expect(hub.Len()).To(Equal(1))
+
+		hub.Publish(monitor.Event{Time: time.Now(), Tokens: []string{"PING"}})
+
+		Eventually(func() []string {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			return messages
+		}).Should(ConsistOf(ContainSubstring(`"PING"`)))
+
+		hub.Unsubscribe(1)
+		Expect(hub.Len()).To(Equal(0))
+	})
+
+	It("unsubscribes monitors that fail", func() {
+		hub := monitor.NewHub()
+
+		hub.Subscribe(1, func(_ string) error {
+			return errors.New("closed")
+		})
+
+		hub.Publish(monitor.Event{Time: time.Now(), Tokens: []string{"PING"}})
+
+		Eventually(hub.Len).Should(Equal(0))
+	})
+
+	It("drops events for slow monitors", func() {
+		hub := monitor.NewHub()
+		blocked := make(chan struct{})
+
+		hub.Subscribe(1, func(_ string) error {
+			<-blocked
+
+			return nil
+		})
+
+		for range 2000 {
+			hub.Publish(monitor.Event{Time: time.Now(), Tokens: []string{"PING"}})
+		}
+
+		Expect(hub.Dropped()).To(BeNumerically(">", 0))
+
+		close(blocked)
+		hub.Unsubscribe(1)
+	})
+})
@@ -0,0 +1,53 @@
+package monitor
+
+import "strings"
+
+// redactedToken replaces credentials in the output, like redis does.
+const redactedToken = "(redacted)"
+
+// redact replaces the passwords and usernames of AUTH, HELLO and MIGRATE.
+// The tokens are copied, as they are still executed.
+func redact(tokens []string) []string {
+	if len(tokens) == 0 {
+		return tokens
+	}
+
+	// the index options start at and the credentials following them
+	var (
+		start   int
+		options map[string]int
+	)
+
+	switch strings.ToUpper(tokens[0]) {
+	case "AUTH":
+		start, options = 1, nil
+	case "HELLO":
+		start, options = 2, map[string]int{"AUTH": 2}
+	case "MIGRATE":
+		start, options = 6, map[string]int{"AUTH": 1, "AUTH2": 2}
+	default:
+		return tokens
+	}
+
+	redacted := append([]string(nil), tokens...)
+
+	for index := start; index < len(redacted); index++ {
+		if options == nil {
+			redacted[index] = redactedToken
+
+			continue
+		}
+
+		option := strings.ToUpper(redacted[index])
+		if option == "KEYS" {
+			break
+		}
+
+		for count := options[option]; count > 0 && index+1 < len(redacted); count-- {
+			index++
+			redacted[index] = redactedToken
+		}
+	}
+
+	return redacted
+}
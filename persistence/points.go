@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidPoints = errors.New("invalid save params")
+
+// Point saves once a number of keys changed within a period.
+type Point struct {
+	Period  time.Duration
+	Changes int64
+}
+
+// ParsePoints reads the `save` parameter of Redis, pairs of seconds and
+// changes, like `3600 1 300 100`. An empty value has no points.
+func ParsePoints(value string) ([]Point, error) {
+	fields := strings.Fields(value)
+	if len(fields)%2 != 0 {
+		return nil, ErrInvalidPoints
+	}
+
+	points := make([]Point, 0, len(fields)/2)
+
+	for index := 0; index < len(fields); index += 2 {
+		seconds, err := strconv.ParseInt(fields[index], 10, 64)
+		if err != nil || seconds < 1 {
+			return nil, fmt.Errorf("%w: %q is not a number of seconds", ErrInvalidPoints, fields[index])
+		}
+
+		changes, err := strconv.ParseInt(fields[index+1], 10, 64)
+		if err != nil || changes < 0 {
+			return nil, fmt.Errorf("%w: %q is not a number of changes", ErrInvalidPoints, fields[index+1])
+		}
+
+		points = append(points, Point{
+			Period:  time.Duration(seconds) * time.Second,
+			Changes: changes,
+		})
+	}
+
+	return points, nil
+}
+
+// SetPoints replaces the save points, checked every second in the
+// background. No points disables the saves.
+func (s *Saver) SetPoints(points []Point) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopPoints != nil {
+		s.stopPoints()
+		s.stopPoints = nil
+	}
+
+	if len(points) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopPoints = cancel
+
+	go s.runPoints(ctx, points)
+}
+
+func (s *Saver) runPoints(ctx context.Context, points []Point) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.due(points, time.Now()) {
+				continue
+			}
+
+			_, err := s.BackgroundSave(false)
+			if err != nil && !errors.Is(err, ErrInProgress) {
+				slog.Error("could not start save", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// due is true when a point is reached. Like Redis, a failed save is only
+// retried after a few seconds.
+func (s *Saver) due(points []Point, now time.Time) bool {
+	const retryDelay = 5 * time.Second
+
+	status := s.Status()
+
+	if status.InProgress || status.Changes == 0 {
+		return false
+	}
+
+	if status.LastError != nil && now.Sub(status.Started) < retryDelay {
+		return false
+	}
+
+	for _, point := range points {
+		if status.Changes >= point.Changes && now.Sub(status.LastSave) >= point.Period {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Close stops the save points.
+func (s *Saver) Close() {
+	s.SetPoints(nil)
+}
@@ -0,0 +1,302 @@
+// Package persistence saves point in time copies of the database, like the
+// RDB files of Redis. Each copy is a SQLite database.
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrInProgress = errors.New("save already in progress")
+
+// SnapshotFunc writes a consistent copy of the database to a new file.
+type SnapshotFunc func(ctx context.Context, path string) error
+
+// Status describes the saves, like the rdb fields of INFO persistence.
+type Status struct {
+	InProgress bool
+	// Started is when the save in progress started.
+	Started  time.Time
+	LastSave time.Time
+	// LastError is the error of the last save, nil when it succeeded.
+	LastError    error
+	LastDuration time.Duration
+	Saves        int64
+	Changes      int64
+}
+
+// Saver writes the snapshots to a file of a directory. Previous snapshots
+// are kept next to it, with a number, up to the retention.
+type Saver struct {
+	snapshot SnapshotFunc
+
+	mutex     sync.Mutex
+	dir       string
+	filename  string
+	retention int
+	status    Status
+	scheduled bool
+
+	changes atomic.Int64
+
+	stopPoints context.CancelFunc
+}
+
+// New creates a saver writing to `dump.db` of the working directory, keeping
+// only the last snapshot.
+func New(snapshot SnapshotFunc) *Saver {
+	return &Saver{
+		snapshot:  snapshot,
+		dir:       ".",
+		filename:  "dump.db",
+		retention: 1,
+		status: Status{
+			// like Redis, the server starting counts as a save
+			LastSave: time.Now(),
+		},
+	}
+}
+
+// SetDir changes the directory of the snapshots.
+func (s *Saver) SetDir(dir string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.dir = dir
+}
+
+// Dir is the directory of the snapshots, like the other files of the
+// server.
+func (s *Saver) Dir() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.dir
+}
+
+// SetFilename changes the name of the snapshots.
+func (s *Saver) SetFilename(filename string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.filename = filename
+}
+
+// SetRetention changes how many snapshots are kept, the last one included.
+func (s *Saver) SetRetention(retention int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.retention = max(retention, 1)
+}
+
+// Path is where the last snapshot is written.
+func (s *Saver) Path() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return filepath.Join(s.dir, s.filename)
+}
+
+// Changed counts the keys modified since the last save.
+func (s *Saver) Changed(count int) {
+	s.changes.Add(int64(count))
+}
+
+// Status returns the status of the saves.
+func (s *Saver) Status() Status {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := s.status
+	status.Changes = s.changes.Load()
+
+	return status
+}
+
+// LastSave is when the last save succeeded.
+func (s *Saver) LastSave() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.status.LastSave
+}
+
+// Save writes a snapshot, returning once it is written.
+func (s *Saver) Save(ctx context.Context) error {
+	err := s.start()
+	if err != nil {
+		return err
+	}
+
+	return s.save(ctx)
+}
+
+// BackgroundSave starts writing a snapshot. When a save is in progress, it
+// returns ErrInProgress, unless schedule is true; then another save starts
+// once it is done, and scheduled is true.
+func (s *Saver) BackgroundSave(schedule bool) (bool, error) {
+	err := s.start()
+	if errors.Is(err, ErrInProgress) && schedule {
+		s.mutex.Lock()
+		s.scheduled = true
+		s.mutex.Unlock()
+
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	go s.background()
+
+	return false, nil
+}
+
+func (s *Saver) background() {
+	err := s.save(context.Background())
+	if err != nil {
+		slog.Error("could not save in the background", slog.String("error", err.Error()))
+	}
+
+	s.mutex.Lock()
+	scheduled := s.scheduled
+	s.scheduled = false
+	s.mutex.Unlock()
+
+	if scheduled {
+		_, _ = s.BackgroundSave(false)
+	}
+}
+
+func (s *Saver) start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.status.InProgress {
+		return ErrInProgress
+	}
+
+	s.status.InProgress = true
+	s.status.Started = time.Now()
+
+	return nil
+}
+
+func (s *Saver) save(ctx context.Context) error {
+	s.mutex.Lock()
+	dir, filename, retention, started := s.dir, s.filename, s.retention, s.status.Started
+	s.mutex.Unlock()
+
+	// the keys changed while saving count for the next save
+	changes := s.changes.Load()
+
+	err := s.write(ctx, dir, filename, retention)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.status.InProgress = false
+	s.status.LastError = err
+	s.status.LastDuration = time.Since(started)
+
+	if err != nil {
+		return err
+	}
+
+	s.status.Saves++
+	s.status.LastSave = time.Now()
+	s.changes.Add(-changes)
+
+	return nil
+}
+
+// write snapshots to a temporary file, which replaces the last snapshot
+// once it is complete. The previous snapshots are renamed with a number.
+func (s *Saver) write(ctx context.Context, dir, filename string, retention int) error {
+	path := filepath.Join(dir, filename)
+	temporary := fmt.Sprintf("%s.temp-%d-%d", path, os.Getpid(), time.Now().UnixNano())
+
+	err := s.snapshot(ctx, temporary)
+	if err != nil {
+		_ = os.Remove(temporary)
+
+		return fmt.Errorf("could not snapshot: %w", err)
+	}
+
+	// renaming replaces the oldest snapshot
+	for index := retention - 1; index > 0; index-- {
+		err = os.Rename(rotated(path, index-1), rotated(path, index))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			_ = os.Remove(temporary)
+
+			return fmt.Errorf("could not rotate snapshot: %w", err)
+		}
+	}
+
+	err = os.Rename(temporary, path)
+	if err != nil {
+		return fmt.Errorf("could not replace snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Snapshots returns the paths of the snapshots of a directory, from the last
+// one to the oldest one.
+func Snapshots(dir, filename string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read snapshots: %w", err)
+	}
+
+	var indexes []int
+
+	for _, entry := range entries {
+		suffix, found := strings.CutPrefix(entry.Name(), filename)
+		if !found || entry.IsDir() {
+			continue
+		}
+
+		if suffix == "" {
+			indexes = append(indexes, 0)
+
+			continue
+		}
+
+		// temporary files are not numbered
+		index, err := strconv.Atoi(strings.TrimPrefix(suffix, "."))
+		if err == nil && index > 0 && suffix == "."+strconv.Itoa(index) {
+			indexes = append(indexes, index)
+		}
+	}
+
+	slices.Sort(indexes)
+
+	paths := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		paths = append(paths, rotated(filepath.Join(dir, filename), index))
+	}
+
+	return paths, nil
+}
+
+// rotated is the path of a previous snapshot, zero is the last one.
+func rotated(path string, index int) string {
+	if index == 0 {
+		return path
+	}
+
+	return path + "." + strconv.Itoa(index)
+}
@@ -0,0 +1,169 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/persistence"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Saver", func() {
+	var (
+		dir       string
+		snapshots atomic.Int64
+		release   chan struct{}
+		saver     *persistence.Saver
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		snapshots.Store(0)
+		release = nil
+
+		saver = persistence.New(func(_ context.Context, path string) error {
+			if release != nil {
+				<-release
+			}
+
+			count := snapshots.Add(1)
+
+			return os.WriteFile(path, []byte(strconv.FormatInt(count, 10)), 0o600)
+		})
+		saver.SetDir(dir)
+		DeferCleanup(saver.Close)
+	})
+
+	contents := func(name string) string {
+		contents, err := os.ReadFile(filepath.Join(dir, name))
+		Expect(err).NotTo(HaveOccurred())
+
+		return string(contents)
+	}
+
+	It("saves to the file of the directory", func() {
+		before := saver.LastSave()
+
+		saver.Changed(2)
+		Expect(saver.Status().Changes).To(BeEquivalentTo(2))
+
+		err := saver.Save(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(saver.Path()).To(Equal(filepath.Join(dir, "dump.db")))
+		Expect(contents("dump.db")).To(Equal("1"))
+
+		status := saver.Status()
+		Expect(status.Saves).To(BeEquivalentTo(1))
+		Expect(status.Changes).To(BeZero())
+		Expect(status.LastError).NotTo(HaveOccurred())
+		Expect(saver.LastSave()).To(BeTemporally(">=", before))
+	})
+
+	It("keeps previous snapshots up to the retention", func() {
+		saver.SetFilename("data.db")
+		saver.SetRetention(3)
+
+		for range 4 {
+			err := saver.Save(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(contents("data.db")).To(Equal("4"))
+		Expect(contents("data.db.1")).To(Equal("3"))
+		Expect(contents("data.db.2")).To(Equal("2"))
+		Expect(filepath.Join(dir, "data.db.3")).NotTo(BeAnExistingFile())
+
+		entries, err := os.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(3))
+
+		Expect(os.WriteFile(filepath.Join(dir, "data.db.temp-1"), nil, 0o600)).To(Succeed())
+
+		paths, err := persistence.Snapshots(dir, "data.db")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paths).To(Equal([]string{
+			filepath.Join(dir, "data.db"),
+			filepath.Join(dir, "data.db.1"),
+			filepath.Join(dir, "data.db.2"),
+		}))
+	})
+
+	It("saves one snapshot at a time", func() {
+		release = make(chan struct{})
+
+		scheduled, err := saver.BackgroundSave(false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scheduled).To(BeFalse())
+		Expect(saver.Status().InProgress).To(BeTrue())
+
+		err = saver.Save(context.Background())
+		Expect(err).To(MatchError(persistence.ErrInProgress))
+
+		_, err = saver.BackgroundSave(false)
+		Expect(err).To(MatchError(persistence.ErrInProgress))
+
+		scheduled, err = saver.BackgroundSave(true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scheduled).To(BeTrue())
+
+		close(release)
+
+		Eventually(func() int64 { return saver.Status().Saves }).Should(BeEquivalentTo(2))
+		Eventually(func() bool { return saver.Status().InProgress }).Should(BeFalse())
+	})
+
+	It("reports the errors of the snapshots", func() {
+		failing := persistence.New(func(context.Context, string) error {
+			return errors.New("disk is full")
+		})
+		failing.SetDir(dir)
+
+		err := failing.Save(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("disk is full")))
+		Expect(failing.Status().LastError).To(HaveOccurred())
+
+		entries, err := os.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("saves at the save points", func() {
+		saver.SetPoints([]persistence.Point{{Period: time.Second, Changes: 2}})
+
+		saver.Changed(1)
+		Consistently(func() int64 { return saver.Status().Saves }, "1500ms").Should(BeZero())
+
+		saver.Changed(1)
+		Eventually(func() int64 { return saver.Status().Saves }, "3s").Should(BeEquivalentTo(1))
+	})
+
+	DescribeTable("parsing save points",
+		func(value string, expected []persistence.Point) {
+			points, err := persistence.ParsePoints(value)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(points).To(Equal(expected))
+		},
+		Entry("none", "", []persistence.Point{}),
+		Entry("pairs", "3600 1 300 100", []persistence.Point{
+			{Period: time.Hour, Changes: 1},
+			{Period: 5 * time.Minute, Changes: 100},
+		}),
+	)
+
+	DescribeTable("invalid save points",
+		func(value string) {
+			_, err := persistence.ParsePoints(value)
+			Expect(err).To(MatchError(persistence.ErrInvalidPoints))
+		},
+		Entry("odd", "3600"),
+		Entry("not a number", "hour 1"),
+		Entry("negative changes", "60 -1"),
+	)
+})
@@ -0,0 +1,136 @@
+package pubsub
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/jtarchie/sqlettuce/clients"
+)
+
+// Hub keeps the channel subscriptions of clients.
+// Delivering messages is left to the caller, as the format depends on
+// the protocol of each subscriber.
+type Hub struct {
+	mutex    sync.RWMutex
+	channels map[string]map[uint64]*clients.Client
+	clients  map[uint64]map[string]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		channels: map[string]map[uint64]*clients.Client{},
+		clients:  map[uint64]map[string]struct{}{},
+	}
+}
+
+// Subscribe adds the client to the channel and returns the number of
+// channels the client is subscribed to.
+func (h *Hub) Subscribe(client *clients.Client, channel string) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	subscribers, ok := h.channels[channel]
+	if !ok {
+		subscribers = map[uint64]*clients.Client{}
+		h.channels[channel] = subscribers
+	}
+
+	subscribers[client.ID] = client
+
+	channels, ok := h.clients[client.ID]
+	if !ok {
+		channels = map[string]struct{}{}
+		h.clients[client.ID] = channels
+	}
+
+	channels[channel] = struct{}{}
+	client.SetSubscriptions(len(channels))
+
+	return len(channels)
+}
+
+// Unsubscribe removes the client from the channel and returns the number of
+// channels the client is still subscribed to.
+func (h *Hub) Unsubscribe(client *clients.Client, channel string) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if subscribers, ok := h.channels[channel]; ok {
+		delete(subscribers, client.ID)
+
+		if len(subscribers) == 0 {
+			delete(h.channels, channel)
+		}
+	}
+
+	channels := h.clients[client.ID]
+	delete(channels, channel)
+
+	if len(channels) == 0 {
+		delete(h.clients, client.ID)
+	}
+
+	client.SetSubscriptions(len(channels))
+
+	return len(channels)
+}
+
+// Channels returns the channels the client is subscribed to, sorted.
+func (h *Hub) Channels(client *clients.Client) []string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	channels := make([]string, 0, len(h.clients[client.ID]))
+	for channel := range h.clients[client.ID] {
+		channels = append(channels, channel)
+	}
+
+	slices.Sort(channels)
+
+	return channels
+}
+
+func (h *Hub) IsSubscribed(client *clients.Client, channel string) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	_, ok := h.channels[channel][client.ID]
+
+	return ok
+}
+
+// Len is the number of clients subscribed to at least one channel.
+func (h *Hub) Len() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	return len(h.clients)
+}
+
+// Subscribers returns the clients subscribed to the channel.
+func (h *Hub) Subscribers(channel string) []*clients.Client {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	subscribers := make([]*clients.Client, 0, len(h.channels[channel]))
+	for _, client := range h.channels[channel] {
+		subscribers = append(subscribers, client)
+	}
+
+	return subscribers
+}
+
+// ActiveChannels returns the channels with at least one subscriber, sorted.
+func (h *Hub) ActiveChannels() []string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	channels := make([]string, 0, len(h.channels))
+	for channel := range h.channels {
+		channels = append(channels, channel)
+	}
+
+	slices.Sort(channels)
+
+	return channels
+}
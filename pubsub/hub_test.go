@@ -0,0 +1,34 @@
+package pubsub_test
+
+import (
+	"github.com/jtarchie/sqlettuce/clients"
+	"github.com/jtarchie/sqlettuce/pubsub"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hub", func() {
+	It("tracks subscriptions per client and channel", func() {
+		registry := clients.NewRegistry()
+		first := registry.Register("127.0.0.1:1000", "", nil, nil)
+		second := registry.Register("127.0.0.1:1001", "", nil, nil)
+
+		hub := pubsub.NewHub()
+		Expect(hub.Subscribe(first, "news")).To(Equal(1))
+		Expect(hub.Subscribe(first, "sports")).To(Equal(2))
+		Expect(hub.Subscribe(second, "news")).To(Equal(1))
+
+		Expect(first.Subscriptions()).To(Equal(2))
+		Expect(hub.Len()).To(Equal(2))
+		Expect(hub.Channels(first)).To(Equal([]string{"news", "sports"}))
+		Expect(hub.ActiveChannels()).To(Equal([]string{"news", "sports"}))
+		Expect(hub.Subscribers("news")).To(ConsistOf(first, second))
+		Expect(hub.IsSubscribed(second, "sports")).To(BeFalse())
+
+		Expect(hub.Unsubscribe(first, "sports")).To(Equal(1))
+		Expect(hub.ActiveChannels()).To(Equal([]string{"news"}))
+		Expect(hub.Unsubscribe(first, "news")).To(Equal(0))
+		Expect(first.Subscriptions()).To(Equal(0))
+		Expect(hub.Subscribers("news")).To(ConsistOf(second))
+	})
+})
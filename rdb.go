@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/rdb"
+)
+
+// batchSize is how many keys are read or written at once.
+const batchSize = 1000
+
+type ImportCommand struct {
+	Filename string `default:"sqlite://test.db" help:"DSN of the database the keys are imported to"`
+	RDB      string `help:"path of the RDB file to import" name:"rdb" required:"" type:"existingfile"`
+}
+
+// Run imports the strings and lists of the first database, replacing the
+// keys with the same name. Other types can't be stored yet, and keys can't
+// expire, so they are skipped or imported without their expiry.
+func (c *ImportCommand) Run() error {
+	ctx := context.TODO()
+
+	file, err := os.Open(c.RDB)
+	if err != nil {
+		return fmt.Errorf("could not open RDB file: %w", err)
+	}
+	defer file.Close()
+
+	client, err := db.NewClient(c.Filename)
+	if err != nil {
+		return fmt.Errorf("could not start db client: %w", err)
+	}
+	defer client.Close()
+
+	var (
+		pairs    []string
+		imported int
+		expired  int
+		expiring int
+		skipped  = map[string]int{}
+		now      = time.Now()
+	)
+
+	flush := func() error {
+		if len(pairs) == 0 {
+			return nil
+		}
+
+		err := client.MSet(ctx, pairs...)
+		if err != nil {
+			return fmt.Errorf("could not import strings: %w", err)
+		}
+
+		pairs = pairs[:0]
+
+		return nil
+	}
+
+	err = rdb.Read(file, func(entry *rdb.Entry) error {
+		if entry.DB != 0 {
+			skipped[fmt.Sprintf("db%d", entry.DB)]++
+
+			return nil
+		}
+
+		if entry.Expired(now) {
+			expired++
+
+			return nil
+		}
+
+		switch entry.Type {
+		case rdb.String:
+			pairs = append(pairs, entry.Key, entry.Value)
+			if len(pairs) >= 2*batchSize {
+				err := flush()
+				if err != nil {
+					return err
+				}
+			}
+		case rdb.List:
+			err := client.RestoreEntry(ctx, entry, true)
+			if err != nil {
+				return fmt.Errorf("could not import %q: %w", entry.Key, err)
+			}
+		default:
+			skipped[string(entry.Type)]++
+
+			return nil
+		}
+
+		imported++
+
+		if !entry.ExpiresAt.IsZero() {
+			expiring++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not read RDB file: %w", err)
+	}
+
+	err = flush()
+	if err != nil {
+		return err
+	}
+
+	for kind, count := range skipped {
+		slog.Warn("skipped keys that can't be imported", slog.String("type", kind), slog.Int("count", count))
+	}
+
+	if expiring > 0 {
+		slog.Warn("imported keys without their expiry", slog.Int("count", expiring))
+	}
+
+	slog.Info("imported RDB file", slog.Int("keys", imported), slog.Int("expired", expired))
+
+	return nil
+}
+
+type ExportCommand struct {
+	Filename string `default:"sqlite://test.db" help:"DSN of the database the keys are exported from"`
+	RDB      string `help:"path of the RDB file to write" name:"rdb" required:""`
+}
+
+// Run writes every key to a temporary file, replacing the RDB file once it
+// is complete.
+func (c *ExportCommand) Run() error {
+	ctx := context.TODO()
+
+	client, err := db.NewClient(c.Filename)
+	if err != nil {
+		return fmt.Errorf("could not start db client: %w", err)
+	}
+	defer client.Close()
+
+	temporary := fmt.Sprintf("%s.temp-%d", c.RDB, os.Getpid())
+
+	count, err := export(ctx, client, temporary)
+	if err != nil {
+		_ = os.Remove(temporary)
+
+		return err
+	}
+
+	err = os.Rename(temporary, c.RDB)
+	if err != nil {
+		return fmt.Errorf("could not replace RDB file: %w", err)
+	}
+
+	slog.Info("exported RDB file", slog.Int("keys", count))
+
+	return nil
+}
+
+func export(ctx context.Context, client *db.Client, path string) (int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not create RDB file: %w", err)
+	}
+	defer file.Close()
+
+	writer, err := rdb.NewWriter(file)
+	if err != nil {
+		return 0, fmt.Errorf("could not start RDB file: %w", err)
+	}
+
+	names, err := client.Keys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not list keys: %w", err)
+	}
+
+	count := 0
+
+	for start := 0; start < len(names); start += batchSize {
+		batch := names[start:min(start+batchSize, len(names))]
+
+		entries, err := client.Entries(ctx, batch...)
+		if err != nil {
+			return 0, fmt.Errorf("could not read keys: %w", err)
+		}
+
+		for _, entry := range entries {
+			err = writer.Write(entry)
+			if err != nil {
+				return 0, fmt.Errorf("could not export: %w", err)
+			}
+		}
+
+		count += len(entries)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return 0, fmt.Errorf("could not finish RDB file: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return 0, fmt.Errorf("could not close RDB file: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,12 @@
+package rdb
+
+import "hash/crc64"
+
+// The checksum of RDB files is the CRC-64 of Jones, reflected, without the
+// inversions of the standard library.
+// https://github.com/redis/redis/blob/unstable/src/crc64.c
+var jones = crc64.MakeTable(0x95ac9329ac4bc9b5)
+
+func checksum(crc uint64, data []byte) uint64 {
+	return ^crc64.Update(^crc, jones, data)
+}
@@ -0,0 +1,71 @@
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// the version of the files and payloads of Redis 7.4
+const maxVersion = 12
+
+// Dump serializes the value of a key like the DUMP command: its type and
+// encoding, the version of the encoding, then a checksum.
+func Dump(entry *Entry) ([]byte, error) {
+	kind, err := kind(entry)
+	if err != nil {
+		return nil, fmt.Errorf("could not dump %q: %w", entry.Key, err)
+	}
+
+	buffer := &bytes.Buffer{}
+	e := &encoder{writer: buffer}
+
+	e.write([]byte{kind})
+	e.value(entry)
+	e.write(binary.LittleEndian.AppendUint16(nil, version))
+	e.sum()
+
+	return buffer.Bytes(), nil
+}
+
+// Load deserializes the value of a key from a payload of the DUMP command,
+// of Redis or Dump. The key of the entry is empty.
+func Load(payload []byte) (*Entry, error) {
+	// the type, the version and the checksum
+	if len(payload) < 1+2+8 {
+		return nil, ErrInvalidFile
+	}
+
+	body, footer := payload[:len(payload)-8], payload[len(payload)-8:]
+
+	expected := binary.LittleEndian.Uint64(footer)
+	if expected != 0 && expected != checksum(0, body) {
+		return nil, ErrInvalidChecksum
+	}
+
+	if binary.LittleEndian.Uint16(body[len(body)-2:]) > maxVersion {
+		return nil, ErrInvalidVersion
+	}
+
+	reader := bytes.NewReader(body[:len(body)-2])
+	r := &decoder{reader: bufio.NewReader(reader)}
+
+	kind, err := r.byte()
+	if err != nil {
+		return nil, fmt.Errorf("could not read type: %w", err)
+	}
+
+	entry := &Entry{}
+
+	err = r.value(kind, entry)
+	if err != nil {
+		return nil, fmt.Errorf("could not read value: %w", err)
+	}
+
+	if r.reader.Buffered() > 0 || reader.Len() > 0 {
+		return nil, fmt.Errorf("trailing bytes: %w", ErrInvalidFile)
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,375 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// decompress expands the strings compressed with LZF.
+// https://github.com/redis/redis/blob/unstable/src/lzf_d.c
+func decompress(input []byte, size int) ([]byte, error) {
+	output := make([]byte, 0, size)
+
+	for index := 0; index < len(input); {
+		control := int(input[index])
+		index++
+
+		if control < 32 {
+			// a literal run of control + 1 bytes
+			end := index + control + 1
+			if end > len(input) {
+				return nil, fmt.Errorf("lzf literal: %w", ErrInvalidFile)
+			}
+
+			output = append(output, input[index:end]...)
+			index = end
+
+			continue
+		}
+
+		// a back reference
+		length := control >> 5
+		if length == 7 {
+			if index >= len(input) {
+				return nil, fmt.Errorf("lzf reference: %w", ErrInvalidFile)
+			}
+
+			length += int(input[index])
+			index++
+		}
+
+		if index >= len(input) {
+			return nil, fmt.Errorf("lzf reference: %w", ErrInvalidFile)
+		}
+
+		reference := len(output) - (control&0x1f)<<8 - int(input[index]) - 1
+		index++
+
+		if reference < 0 {
+			return nil, fmt.Errorf("lzf reference: %w", ErrInvalidFile)
+		}
+
+		// the reference can overlap what it copies, so it is byte by byte
+		for offset := range length + 2 {
+			output = append(output, output[reference+offset])
+		}
+	}
+
+	if len(output) != size {
+		return nil, fmt.Errorf("lzf length %d instead of %d: %w", len(output), size, ErrInvalidFile)
+	}
+
+	return output, nil
+}
+
+// ziplist decodes the elements of a ziplist.
+// https://github.com/redis/redis/blob/7.0/src/ziplist.c
+func ziplist(data []byte) ([]string, error) {
+	// the bytes, the offset of the tail and the length
+	const header = 4 + 4 + 2
+
+	if len(data) < header+1 {
+		return nil, fmt.Errorf("ziplist header: %w", ErrInvalidFile)
+	}
+
+	var elements []string
+
+	for index := header; ; {
+		if index >= len(data) {
+			return nil, fmt.Errorf("ziplist end: %w", ErrInvalidFile)
+		}
+
+		if data[index] == 0xff {
+			return elements, nil
+		}
+
+		// the length of the previous entry
+		if data[index] == 0xfe {
+			index += 5
+		} else {
+			index++
+		}
+
+		if index >= len(data) {
+			return nil, fmt.Errorf("ziplist entry: %w", ErrInvalidFile)
+		}
+
+		element, size, err := ziplistEntry(data[index:])
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, element)
+		index += size
+	}
+}
+
+// ziplistEntry decodes the encoding and the content of an entry.
+func ziplistEntry(data []byte) (string, int, error) {
+	encoding := data[0]
+
+	switch encoding >> 6 {
+	case 0:
+		return slice(data, 1, int(encoding&0x3f))
+	case 1:
+		if len(data) < 2 {
+			return "", 0, fmt.Errorf("ziplist string: %w", ErrInvalidFile)
+		}
+
+		return slice(data, 2, int(encoding&0x3f)<<8|int(data[1]))
+	case 2:
+		if len(data) < 5 {
+			return "", 0, fmt.Errorf("ziplist string: %w", ErrInvalidFile)
+		}
+
+		return slice(data, 5, int(binary.BigEndian.Uint32(data[1:5])))
+	}
+
+	switch encoding {
+	case 0xc0:
+		return integer(data, 1, 2)
+	case 0xd0:
+		return integer(data, 1, 4)
+	case 0xe0:
+		return integer(data, 1, 8)
+	case 0xf0:
+		return integer(data, 1, 3)
+	case 0xfe:
+		return integer(data, 1, 1)
+	}
+
+	// the immediate integers from 0 to 12
+	if encoding >= 0xf1 && encoding <= 0xfd {
+		return strconv.Itoa(int(encoding&0x0f) - 1), 1, nil
+	}
+
+	return "", 0, fmt.Errorf("ziplist encoding %#x: %w", encoding, ErrUnsupported)
+}
+
+// listpack decodes the elements of a listpack.
+// https://github.com/antirez/listpack/blob/master/listpack.md
+func listpack(data []byte) ([]string, error) {
+	// the bytes and the length
+	const header = 4 + 2
+
+	if len(data) < header+1 {
+		return nil, fmt.Errorf("listpack header: %w", ErrInvalidFile)
+	}
+
+	var elements []string
+
+	for index := header; ; {
+		if index >= len(data) {
+			return nil, fmt.Errorf("listpack end: %w", ErrInvalidFile)
+		}
+
+		if data[index] == 0xff {
+			return elements, nil
+		}
+
+		element, size, err := listpackEntry(data[index:])
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, element)
+		index += size + backlength(size)
+	}
+}
+
+// listpackEntry decodes the encoding and the content of an entry, without
+// its length at the end.
+func listpackEntry(data []byte) (string, int, error) {
+	encoding := data[0]
+
+	switch {
+	case encoding>>7 == 0:
+		return strconv.Itoa(int(encoding)), 1, nil
+	case encoding>>6 == 2:
+		return slice(data, 1, int(encoding&0x3f))
+	case encoding>>5 == 6:
+		if len(data) < 2 {
+			return "", 0, fmt.Errorf("listpack integer: %w", ErrInvalidFile)
+		}
+
+		// a 13 bit signed integer
+		value := int(encoding&0x1f)<<8 | int(data[1])
+		if value >= 1<<12 {
+			value -= 1 << 13
+		}
+
+		return strconv.Itoa(value), 2, nil
+	case encoding>>4 == 14:
+		if len(data) < 2 {
+			return "", 0, fmt.Errorf("listpack string: %w", ErrInvalidFile)
+		}
+
+		return slice(data, 2, int(encoding&0x0f)<<8|int(data[1]))
+	}
+
+	switch encoding {
+	case 0xf0:
+		if len(data) < 5 {
+			return "", 0, fmt.Errorf("listpack string: %w", ErrInvalidFile)
+		}
+
+		return slice(data, 5, int(binary.LittleEndian.Uint32(data[1:5])))
+	case 0xf1:
+		return integer(data, 1, 2)
+	case 0xf2:
+		return integer(data, 1, 3)
+	case 0xf3:
+		return integer(data, 1, 4)
+	case 0xf4:
+		return integer(data, 1, 8)
+	}
+
+	return "", 0, fmt.Errorf("listpack encoding %#x: %w", encoding, ErrUnsupported)
+}
+
+// backlength is the size of the length of an entry, written after it so
+// listpacks can be read backwards.
+func backlength(size int) int {
+	switch {
+	case size < 1<<7:
+		return 1
+	case size < 1<<14:
+		return 2
+	case size < 1<<21:
+		return 3
+	case size < 1<<28:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// intset decodes the members of an intset, little endian integers of the
+// same size.
+func intset(data []byte) ([]string, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("intset header: %w", ErrInvalidFile)
+	}
+
+	size := int(binary.LittleEndian.Uint32(data[0:4]))
+	length := int(binary.LittleEndian.Uint32(data[4:8]))
+
+	if size != 2 && size != 4 && size != 8 {
+		return nil, fmt.Errorf("intset encoding %d: %w", size, ErrUnsupported)
+	}
+
+	if len(data)-8 < length*size {
+		return nil, fmt.Errorf("intset length: %w", ErrInvalidFile)
+	}
+
+	members := make([]string, 0, length)
+
+	for index := range length {
+		member, _, err := integer(data[8+index*size:], 0, size)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// zipmap decodes the fields and values of a zipmap, in order.
+// https://github.com/redis/redis/blob/6.2/src/zipmap.c
+func zipmap(data []byte) ([]string, error) {
+	var values []string
+
+	// the first byte is the length
+	index := 1
+
+	// next reads a length, with the free bytes after a value, then the string
+	next := func(isValue bool) (string, error) {
+		if index >= len(data) {
+			return "", fmt.Errorf("zipmap length: %w", ErrInvalidFile)
+		}
+
+		length := int(data[index])
+		index++
+
+		if length >= 254 {
+			if index+4 > len(data) {
+				return "", fmt.Errorf("zipmap length: %w", ErrInvalidFile)
+			}
+
+			length = int(binary.LittleEndian.Uint32(data[index : index+4]))
+			index += 4
+		}
+
+		free := 0
+
+		if isValue {
+			if index >= len(data) {
+				return "", fmt.Errorf("zipmap value: %w", ErrInvalidFile)
+			}
+
+			free = int(data[index])
+			index++
+		}
+
+		value, size, err := slice(data[index:], 0, length)
+		if err != nil {
+			return "", err
+		}
+
+		index += size + free
+
+		return value, nil
+	}
+
+	for {
+		if index >= len(data) {
+			return nil, fmt.Errorf("zipmap end: %w", ErrInvalidFile)
+		}
+
+		if data[index] == 0xff {
+			return values, nil
+		}
+
+		field, err := next(false)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := next(true)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, field, value)
+	}
+}
+
+// slice returns the string after the encoding, and the size of both.
+func slice(data []byte, offset, length int) (string, int, error) {
+	if length < 0 || offset+length > len(data) {
+		return "", 0, fmt.Errorf("string of %d bytes: %w", length, ErrInvalidFile)
+	}
+
+	return string(data[offset : offset+length]), offset + length, nil
+}
+
+// integer returns the little endian signed integer after the encoding, and
+// the size of both.
+func integer(data []byte, offset, size int) (string, int, error) {
+	if offset+size > len(data) {
+		return "", 0, fmt.Errorf("integer of %d bytes: %w", size, ErrInvalidFile)
+	}
+
+	var value uint64
+	for index := size - 1; index >= 0; index-- {
+		value = value<<8 | uint64(data[offset+index])
+	}
+
+	// extend the sign of the highest bit
+	shift := 64 - 8*size
+
+	return strconv.FormatInt(int64(value<<shift)>>shift, 10), offset + size, nil
+}
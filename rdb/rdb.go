@@ -0,0 +1,103 @@
+// Package rdb reads and writes the RDB files of Redis, to move keys between
+// Redis and sqlettuce.
+// https://rdb.fnordig.de/file_format.html
+package rdb
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidFile     = errors.New("not a RDB file")
+	ErrInvalidChecksum = errors.New("invalid checksum")
+	ErrInvalidVersion  = errors.New("unsupported version")
+	ErrUnsupported     = errors.New("unsupported encoding")
+)
+
+// Type is the type of a key, like the TYPE command.
+type Type string
+
+const (
+	String    Type = "string"
+	List      Type = "list"
+	Set       Type = "set"
+	SortedSet Type = "zset"
+	Hash      Type = "hash"
+	Stream    Type = "stream"
+)
+
+// Entry is a key of a RDB file. Only the field of its type is set.
+type Entry struct {
+	DB   int
+	Key  string
+	Type Type
+	// ExpiresAt is zero for keys without an expiry.
+	ExpiresAt time.Time
+
+	// Value is the value of a string.
+	Value string
+	// Elements are the elements of a list, or the members of a set.
+	Elements []string
+	// Fields are the fields of a hash, with their value.
+	Fields []Field
+	// Members are the members of a sorted set, with their score.
+	Members []Member
+}
+
+type Field struct {
+	Name  string
+	Value string
+}
+
+type Member struct {
+	Name  string
+	Score float64
+}
+
+// Expired is true when the key has expired at the time.
+func (e *Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !e.ExpiresAt.After(now)
+}
+
+// the version written, the first with the LIST type still loaded by Redis 7
+// and the module and stream types of Redis 5.
+const version = 9
+
+// https://github.com/redis/redis/blob/unstable/src/rdb.h
+const (
+	typeString          = 0
+	typeList            = 1
+	typeSet             = 2
+	typeZSet            = 3
+	typeHash            = 4
+	typeZSet2           = 5
+	typeModulePreGA     = 6
+	typeModule2         = 7
+	typeHashZipmap      = 9
+	typeListZiplist     = 10
+	typeSetIntset       = 11
+	typeZSetZiplist     = 12
+	typeHashZiplist     = 13
+	typeListQuicklist   = 14
+	typeStreamListpacks = 15
+	typeHashListpack    = 16
+	typeZSetListpack    = 17
+	typeListQuicklist2  = 18
+	typeStreamListpack2 = 19
+	typeSetListpack     = 20
+	typeStreamListpack3 = 21
+
+	opcodeSlotInfo      = 244
+	opcodeFunction2     = 245
+	opcodeFunctionPreGA = 246
+	opcodeModuleAux     = 247
+	opcodeIdle          = 248
+	opcodeFreq          = 249
+	opcodeAux           = 250
+	opcodeResizeDB      = 251
+	opcodeExpireTimeMS  = 252
+	opcodeExpireTime    = 253
+	opcodeSelectDB      = 254
+	opcodeEOF           = 255
+)
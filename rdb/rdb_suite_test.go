@@ -0,0 +1,13 @@
+package rdb_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRDB(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RDB Suite")
+}
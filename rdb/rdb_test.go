@@ -0,0 +1,370 @@
+package rdb_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/rdb"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// file wraps the contents of a RDB file, with a checksum of zero, which
+// disables its verification.
+func file(contents ...[]byte) []byte {
+	data := []byte("REDIS0011")
+	data = append(data, bytes.Join(contents, nil)...)
+	data = append(data, 0xff)
+
+	return append(data, make([]byte, 8)...)
+}
+
+// str encodes a string shorter than 64 bytes.
+func str(value string) []byte {
+	return append([]byte{byte(len(value))}, value...)
+}
+
+// blob encodes the bytes of an encoding as a string.
+func blob(data ...byte) []byte {
+	return append([]byte{byte(len(data))}, data...)
+}
+
+func key(kind byte, name string, value ...[]byte) []byte {
+	return append(append([]byte{kind}, str(name)...), bytes.Join(value, nil)...)
+}
+
+func read(data []byte) ([]*rdb.Entry, error) {
+	var entries []*rdb.Entry
+
+	err := rdb.Read(bytes.NewReader(data), func(entry *rdb.Entry) error {
+		entries = append(entries, entry)
+
+		return nil
+	})
+
+	return entries, err
+}
+
+var _ = Describe("Read", func() {
+	It("reads strings with their encodings", func() {
+		entries, err := read(file(
+			key(0, "plain", str("bar")),
+			key(0, "int8", []byte{0xc0, 0x85}),
+			key(0, "int16", []byte{0xc1, 0x39, 0x30}),
+			key(0, "int32", []byte{0xc2, 0x00, 0x00, 0x00, 0x80}),
+			// a literal "a", then a reference copying it 19 times
+			key(0, "lzf", []byte{0xc3, 0x05, 0x14, 0x00, 'a', 0xe0, 0x0a, 0x00}),
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(5))
+
+		values := map[string]string{}
+		for _, entry := range entries {
+			Expect(entry.Type).To(Equal(rdb.String))
+			values[entry.Key] = entry.Value
+		}
+
+		Expect(values).To(Equal(map[string]string{
+			"plain": "bar",
+			"int8":  "-123",
+			"int16": "12345",
+			"int32": "-2147483648",
+			"lzf":   "aaaaaaaaaaaaaaaaaaaa",
+		}))
+	})
+
+	It("reads the lists of every encoding", func() {
+		// a string, then the immediate integer 2 and the int16 -2
+		ziplist := []byte{
+			0x14, 0, 0, 0, 0x0f, 0, 0, 0, 3, 0,
+			0x00, 0x03, 'a', 'b', 'c',
+			0x05, 0xf3,
+			0x02, 0xc0, 0xfe, 0xff,
+			0xff,
+		}
+
+		// a string, the 7 bit integer 5, the 13 bit integer -1 and the int16 -1000
+		listpack := []byte{
+			0x16, 0, 0, 0, 4, 0,
+			0x83, 'a', 'b', 'c', 0x04,
+			0x05, 0x01,
+			0xdf, 0xff, 0x02,
+			0xf1, 0x18, 0xfc, 0x03,
+			0xff,
+		}
+
+		entries, err := read(file(
+			key(1, "list", []byte{0x02}, str("a"), str("b")),
+			key(10, "ziplist", blob(ziplist...)),
+			key(14, "quicklist", []byte{0x02}, blob(ziplist...), blob(ziplist...)),
+			key(18, "quicklist2", []byte{0x02, 0x02}, blob(listpack...), []byte{0x01}, str("plain")),
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(4))
+
+		for _, entry := range entries {
+			Expect(entry.Type).To(Equal(rdb.List))
+		}
+
+		Expect(entries[0].Elements).To(Equal([]string{"a", "b"}))
+		Expect(entries[1].Elements).To(Equal([]string{"abc", "2", "-2"}))
+		Expect(entries[2].Elements).To(Equal([]string{"abc", "2", "-2", "abc", "2", "-2"}))
+		Expect(entries[3].Elements).To(Equal([]string{"abc", "5", "-1", "-1000", "plain"}))
+	})
+
+	It("reads sets, hashes and sorted sets", func() {
+		intset := []byte{2, 0, 0, 0, 3, 0, 0, 0, 0x01, 0x00, 0xff, 0xff, 0x03, 0x00}
+
+		// fields of a single byte, the value of b with a free byte
+		zipmap := []byte{0x02, 0x01, 'a', 0x02, 0x00, 'x', 'y', 0x01, 'b', 0x01, 0x01, 'z', 0x00, 0xff}
+
+		hashListpack := []byte{
+			0x0d, 0, 0, 0, 2, 0,
+			0x81, 'f', 0x02,
+			0x81, 'v', 0x02,
+			0xff,
+		}
+
+		zsetZiplist := []byte{
+			0x15, 0, 0, 0, 0x0f, 0, 0, 0, 2, 0,
+			0x00, 0x01, 'm',
+			0x03, 0x03, '1', '.', '5',
+			0xff,
+		}
+
+		score := make([]byte, 8)
+		binary.LittleEndian.PutUint64(score, math.Float64bits(-0.25))
+
+		entries, err := read(file(
+			key(2, "set", []byte{0x02}, str("a"), str("b")),
+			key(11, "intset", blob(intset...)),
+			key(4, "hash", []byte{0x01}, str("f"), str("v")),
+			key(9, "zipmap", blob(zipmap...)),
+			key(16, "hashListpack", blob(hashListpack...)),
+			key(3, "zset", []byte{0x02}, str("m"), str("2.5"), str("n"), []byte{254}),
+			key(5, "zset2", []byte{0x01}, str("m"), score),
+			key(12, "zsetZiplist", blob(zsetZiplist...)),
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(8))
+
+		Expect(entries[0].Type).To(Equal(rdb.Set))
+		Expect(entries[0].Elements).To(Equal([]string{"a", "b"}))
+		Expect(entries[1].Type).To(Equal(rdb.Set))
+		Expect(entries[1].Elements).To(Equal([]string{"1", "-1", "3"}))
+
+		Expect(entries[2].Type).To(Equal(rdb.Hash))
+		Expect(entries[2].Fields).To(Equal([]rdb.Field{{Name: "f", Value: "v"}}))
+		Expect(entries[3].Fields).To(Equal([]rdb.Field{{Name: "a", Value: "xy"}, {Name: "b", Value: "z"}}))
+		Expect(entries[4].Fields).To(Equal([]rdb.Field{{Name: "f", Value: "v"}}))
+
+		Expect(entries[5].Type).To(Equal(rdb.SortedSet))
+		Expect(entries[5].Members).To(Equal([]rdb.Member{{Name: "m", Score: 2.5}, {Name: "n", Score: math.Inf(1)}}))
+		Expect(entries[6].Members).To(Equal([]rdb.Member{{Name: "m", Score: -0.25}}))
+		Expect(entries[7].Members).To(Equal([]rdb.Member{{Name: "m", Score: 1.5}}))
+	})
+
+	It("skips the contents of streams", func() {
+		stream := bytes.Join([][]byte{
+			// no nodes, then the length and the IDs
+			{0x00, 0, 0, 0, 0, 0, 0, 0, 0},
+			// a group with an ID, its entries read and a pending entry
+			{0x01}, str("group"), {0, 0, 0},
+			{0x01}, make([]byte, 24), {0x01},
+			// a consumer with its times and a pending entry
+			{0x01}, str("consumer"), make([]byte, 16),
+			{0x01}, make([]byte, 16),
+		}, nil)
+
+		entries, err := read(file(
+			key(21, "stream", stream),
+			key(0, "after", str("value")),
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Key).To(Equal("stream"))
+		Expect(entries[0].Type).To(Equal(rdb.Stream))
+		Expect(entries[1].Value).To(Equal("value"))
+	})
+
+	It("reads the databases and expiries of keys", func() {
+		expiresAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+
+		milliseconds := make([]byte, 8)
+		binary.LittleEndian.PutUint64(milliseconds, uint64(expiresAt.UnixMilli()))
+
+		entries, err := read(file(
+			[]byte{0xfa}, str("redis-ver"), str("7.2.4"),
+			[]byte{0xfa}, str("redis-bits"), []byte{0xc0, 0x40},
+			[]byte{0xf5}, str("#!lua name=library"),
+			[]byte{0xfe, 0x00, 0xfb, 0x01, 0x01},
+			[]byte{0xfc}, milliseconds, key(0, "expiring", str("a")),
+			[]byte{0xfe, 0x02, 0xf4, 0x01, 0x01, 0x00},
+			[]byte{0xfd, 0x01, 0x00, 0x00, 0x00}, []byte{0xf9, 0x05}, key(0, "expired", str("b")),
+			[]byte{0xf8, 0x10}, key(0, "persistent", str("c")),
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(3))
+
+		Expect(entries[0].DB).To(Equal(0))
+		Expect(entries[0].ExpiresAt).To(BeTemporally("==", expiresAt))
+		Expect(entries[0].Expired(time.Now())).To(BeFalse())
+
+		Expect(entries[1].DB).To(Equal(2))
+		Expect(entries[1].ExpiresAt).To(BeTemporally("==", time.Unix(1, 0)))
+		Expect(entries[1].Expired(time.Now())).To(BeTrue())
+
+		Expect(entries[2].DB).To(Equal(2))
+		Expect(entries[2].ExpiresAt.IsZero()).To(BeTrue())
+		Expect(entries[2].Expired(time.Now())).To(BeFalse())
+	})
+
+	It("fails on invalid files", func() {
+		_, err := read([]byte("NOTREDIS0011"))
+		Expect(err).To(MatchError(rdb.ErrInvalidFile))
+
+		_, err = read(file(key(7, "module", str("value"))))
+		Expect(err).To(MatchError(rdb.ErrUnsupported))
+
+		data := file(key(0, "key", str("value")))
+		_, err = read(data[:len(data)-12])
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+
+		_, err = read(file(key(1, "list", []byte{0xbf, 0xff, 0xff, 0xff})))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("stops on the errors of the callback", func() {
+		err := rdb.Read(bytes.NewReader(file(key(0, "a", str("a")), key(0, "b", str("b")))), func(*rdb.Entry) error {
+			return io.ErrClosedPipe
+		})
+		Expect(err).To(MatchError(io.ErrClosedPipe))
+	})
+})
+
+var _ = Describe("Writer", func() {
+	It("writes keys that are read back", func() {
+		expiresAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+
+		entries := []*rdb.Entry{
+			{Key: "string", Type: rdb.String, Value: "value"},
+			{Key: "integer", Type: rdb.String, Value: "-12345"},
+			{Key: "padded", Type: rdb.String, Value: "0012"},
+			{Key: "large", Type: rdb.String, Value: string(bytes.Repeat([]byte("a"), 20000))},
+			{Key: "list", Type: rdb.List, Elements: []string{"a", "1", ""}, ExpiresAt: expiresAt},
+			{Key: "set", Type: rdb.Set, Elements: []string{"a"}},
+			{Key: "hash", Type: rdb.Hash, Fields: []rdb.Field{{Name: "f", Value: "v"}}},
+			{DB: 3, Key: "zset", Type: rdb.SortedSet, Members: []rdb.Member{{Name: "m", Score: 1.5}}},
+		}
+
+		buffer := &bytes.Buffer{}
+
+		writer, err := rdb.NewWriter(buffer)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, entry := range entries {
+			Expect(writer.Write(entry)).To(Succeed())
+		}
+
+		Expect(writer.Close()).To(Succeed())
+
+		read, err := read(buffer.Bytes())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read).To(HaveLen(len(entries)))
+
+		for index, entry := range entries {
+			Expect(read[index].Key).To(Equal(entry.Key))
+			Expect(read[index].DB).To(Equal(entry.DB))
+			Expect(read[index].Type).To(Equal(entry.Type))
+			Expect(read[index].Value).To(Equal(entry.Value))
+			Expect(read[index].Elements).To(Equal(entry.Elements))
+			Expect(read[index].Fields).To(Equal(entry.Fields))
+			Expect(read[index].Members).To(Equal(entry.Members))
+			Expect(read[index].ExpiresAt).To(BeTemporally("==", entry.ExpiresAt))
+		}
+	})
+
+	It("writes a checksum that is verified", func() {
+		buffer := &bytes.Buffer{}
+
+		writer, err := rdb.NewWriter(buffer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writer.Write(&rdb.Entry{Key: "key", Type: rdb.String, Value: "value"})).To(Succeed())
+		Expect(writer.Close()).To(Succeed())
+
+		data := buffer.Bytes()
+		Expect(data[len(data)-8:]).NotTo(Equal(make([]byte, 8)))
+
+		data[len(data)-10] ^= 0xff
+		_, err = read(data)
+		Expect(err).To(MatchError(rdb.ErrInvalidChecksum))
+	})
+
+	It("can't write streams", func() {
+		writer, err := rdb.NewWriter(io.Discard)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = writer.Write(&rdb.Entry{Key: "stream", Type: rdb.Stream})
+		Expect(err).To(MatchError(rdb.ErrUnsupported))
+	})
+})
+
+var _ = Describe("Dump", func() {
+	// the payload of `DUMP mykey` after `SET mykey 10`, in the Redis documentation
+	payload := []byte{0x00, 0xc0, 0x0a, 0x09, 0x00, 0xbe, 0x6d, 0x06, 0x89, 0x5a, 0x28, 0x00, 0x0a}
+
+	It("serializes like Redis", func() {
+		dumped, err := rdb.Dump(&rdb.Entry{Key: "mykey", Type: rdb.String, Value: "10"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dumped).To(Equal(payload))
+	})
+
+	It("loads the payloads of Redis", func() {
+		entry, err := rdb.Load(payload)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Type).To(Equal(rdb.String))
+		Expect(entry.Value).To(Equal("10"))
+	})
+
+	It("loads what it serializes", func() {
+		for _, expected := range []*rdb.Entry{
+			{Type: rdb.String, Value: "Hello"},
+			{Type: rdb.String, Value: "-1000"},
+			{Type: rdb.String, Value: "100000"},
+			{Type: rdb.List, Elements: []string{"a", "1", ""}},
+			{Type: rdb.Hash, Fields: []rdb.Field{{Name: "f", Value: "v"}}},
+		} {
+			dumped, err := rdb.Dump(expected)
+			Expect(err).NotTo(HaveOccurred())
+
+			entry, err := rdb.Load(dumped)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entry).To(Equal(expected))
+		}
+	})
+
+	It("fails on invalid payloads", func() {
+		_, err := rdb.Load(payload[:5])
+		Expect(err).To(MatchError(rdb.ErrInvalidFile))
+
+		corrupted := bytes.Clone(payload)
+		corrupted[2] = 0x0b
+		_, err = rdb.Load(corrupted)
+		Expect(err).To(MatchError(rdb.ErrInvalidChecksum))
+
+		// a version from the future, without a checksum
+		future := []byte{0x00, 0xc0, 0x0a, 0xff, 0x00, 0, 0, 0, 0, 0, 0, 0, 0}
+		_, err = rdb.Load(future)
+		Expect(err).To(MatchError(rdb.ErrInvalidVersion))
+
+		trailing := []byte{0x00, 0xc0, 0x0a, 0x00, 0x09, 0x00, 0, 0, 0, 0, 0, 0, 0, 0}
+		_, err = rdb.Load(trailing)
+		Expect(err).To(MatchError(rdb.ErrInvalidFile))
+
+		_, err = rdb.Dump(&rdb.Entry{Type: rdb.Stream})
+		Expect(err).To(MatchError(rdb.ErrUnsupported))
+	})
+})
@@ -0,0 +1,699 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Read calls fn with every key of a RDB file, in order. The checksum at
+// the end of the file is verified, unless it was disabled when saving.
+// Streams are read, but only their key is returned.
+func Read(reader io.Reader, fn func(entry *Entry) error) error {
+	r := &decoder{reader: bufio.NewReader(reader)}
+
+	err := r.header()
+	if err != nil {
+		return err
+	}
+
+	var (
+		db        int
+		expiresAt time.Time
+	)
+
+	for {
+		opcode, err := r.byte()
+		if err != nil {
+			return fmt.Errorf("could not read opcode: %w", err)
+		}
+
+		switch opcode {
+		case opcodeEOF:
+			return r.footer()
+		case opcodeSelectDB:
+			index, err := r.length()
+			if err != nil {
+				return fmt.Errorf("could not read database: %w", err)
+			}
+
+			db = int(index)
+		case opcodeResizeDB:
+			_, err = r.lengths(2)
+		case opcodeSlotInfo:
+			_, err = r.lengths(3)
+		case opcodeAux:
+			_, err = r.strings(2)
+		case opcodeFunction2:
+			_, err = r.string()
+		case opcodeFreq:
+			_, err = r.byte()
+		case opcodeIdle:
+			_, err = r.length()
+		case opcodeExpireTime:
+			var seconds []byte
+
+			seconds, err = r.read(4)
+			if err == nil {
+				expiresAt = time.Unix(int64(binary.LittleEndian.Uint32(seconds)), 0)
+			}
+		case opcodeExpireTimeMS:
+			var milliseconds []byte
+
+			milliseconds, err = r.read(8)
+			if err == nil {
+				expiresAt = time.UnixMilli(int64(binary.LittleEndian.Uint64(milliseconds)))
+			}
+		case opcodeModuleAux, opcodeFunctionPreGA:
+			return fmt.Errorf("could not read opcode %d: %w", opcode, ErrUnsupported)
+		default:
+			key, err := r.string()
+			if err != nil {
+				return fmt.Errorf("could not read key: %w", err)
+			}
+
+			entry := &Entry{DB: db, Key: key, ExpiresAt: expiresAt}
+
+			err = r.value(opcode, entry)
+			if err != nil {
+				return fmt.Errorf("could not read %q: %w", key, err)
+			}
+
+			expiresAt = time.Time{}
+
+			err = fn(entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not read opcode %d: %w", opcode, err)
+		}
+	}
+}
+
+// decoder reads the encodings of a RDB file, keeping the checksum of what
+// was read.
+type decoder struct {
+	reader   *bufio.Reader
+	checksum uint64
+	version  int
+}
+
+// maxSize is the size of the largest string, like proto-max-bulk-len, so a
+// corrupted length can't allocate all the memory.
+const maxSize = 512 * 1024 * 1024
+
+func (r *decoder) read(size int) ([]byte, error) {
+	if size < 0 || size > maxSize {
+		return nil, fmt.Errorf("size %d: %w", size, ErrInvalidFile)
+	}
+
+	data := make([]byte, size)
+
+	_, err := io.ReadFull(r.reader, data)
+	if err != nil {
+		return nil, unexpected(err)
+	}
+
+	r.checksum = checksum(r.checksum, data)
+
+	return data, nil
+}
+
+func (r *decoder) byte() (byte, error) {
+	data, err := r.read(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return data[0], nil
+}
+
+func (r *decoder) header() error {
+	magic, err := r.read(9)
+	if err != nil || string(magic[:5]) != "REDIS" {
+		return ErrInvalidFile
+	}
+
+	r.version, err = strconv.Atoi(string(magic[5:]))
+	if err != nil {
+		return ErrInvalidFile
+	}
+
+	if r.version > maxVersion {
+		return ErrInvalidVersion
+	}
+
+	return nil
+}
+
+// footer verifies the checksum, which files before version 5 don't have.
+func (r *decoder) footer() error {
+	if r.version < 5 {
+		return nil
+	}
+
+	expected := r.checksum
+
+	data, err := r.read(8)
+	if err != nil {
+		return fmt.Errorf("could not read checksum: %w", err)
+	}
+
+	actual := binary.LittleEndian.Uint64(data)
+	if actual != 0 && actual != expected {
+		return ErrInvalidChecksum
+	}
+
+	return nil
+}
+
+// the lengths with the two highest bits set are the encodings of strings
+const (
+	encodingInt8 = iota
+	encodingInt16
+	encodingInt32
+	encodingLZF
+)
+
+// encodedLength returns a length, or the encoding of a string when encoded
+// is true.
+func (r *decoder) encodedLength() (uint64, bool, error) {
+	first, err := r.byte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch first >> 6 {
+	case 0:
+		return uint64(first & 0x3f), false, nil
+	case 1:
+		second, err := r.byte()
+		if err != nil {
+			return 0, false, err
+		}
+
+		return uint64(first&0x3f)<<8 | uint64(second), false, nil
+	case 2:
+		switch first {
+		case 0x80:
+			data, err := r.read(4)
+			if err != nil {
+				return 0, false, err
+			}
+
+			return uint64(binary.BigEndian.Uint32(data)), false, nil
+		case 0x81:
+			data, err := r.read(8)
+			if err != nil {
+				return 0, false, err
+			}
+
+			return binary.BigEndian.Uint64(data), false, nil
+		}
+
+		return 0, false, fmt.Errorf("length %#x: %w", first, ErrUnsupported)
+	default:
+		return uint64(first & 0x3f), true, nil
+	}
+}
+
+func (r *decoder) length() (uint64, error) {
+	length, encoded, err := r.encodedLength()
+	if err != nil {
+		return 0, err
+	}
+
+	if encoded {
+		return 0, fmt.Errorf("string instead of length: %w", ErrUnsupported)
+	}
+
+	return length, nil
+}
+
+func (r *decoder) lengths(count int) ([]uint64, error) {
+	lengths := make([]uint64, count)
+
+	for index := range lengths {
+		length, err := r.length()
+		if err != nil {
+			return nil, err
+		}
+
+		lengths[index] = length
+	}
+
+	return lengths, nil
+}
+
+func (r *decoder) string() (string, error) {
+	length, encoded, err := r.encodedLength()
+	if err != nil {
+		return "", err
+	}
+
+	if !encoded {
+		data, err := r.read(int(length))
+		if err != nil {
+			return "", err
+		}
+
+		return string(data), nil
+	}
+
+	switch length {
+	case encodingInt8:
+		data, err := r.read(1)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(int64(int8(data[0])), 10), nil
+	case encodingInt16:
+		data, err := r.read(2)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(int64(int16(binary.LittleEndian.Uint16(data))), 10), nil
+	case encodingInt32:
+		data, err := r.read(4)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(int64(int32(binary.LittleEndian.Uint32(data))), 10), nil
+	case encodingLZF:
+		sizes, err := r.lengths(2)
+		if err != nil {
+			return "", err
+		}
+
+		if sizes[1] > maxSize {
+			return "", fmt.Errorf("size %d: %w", sizes[1], ErrInvalidFile)
+		}
+
+		compressed, err := r.read(int(sizes[0]))
+		if err != nil {
+			return "", err
+		}
+
+		data, err := decompress(compressed, int(sizes[1]))
+		if err != nil {
+			return "", err
+		}
+
+		return string(data), nil
+	}
+
+	return "", fmt.Errorf("string encoding %d: %w", length, ErrUnsupported)
+}
+
+func (r *decoder) strings(count int) ([]string, error) {
+	// a corrupted count fails on the end of the file, not when allocating
+	values := make([]string, 0, min(count, 1024))
+
+	for range count {
+		value, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// stringList reads a length, then as many strings.
+func (r *decoder) stringList(multiple int) ([]string, error) {
+	length, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+
+	if length > maxSize {
+		return nil, fmt.Errorf("length %d: %w", length, ErrInvalidFile)
+	}
+
+	return r.strings(int(length) * multiple)
+}
+
+// float reads the scores of the first sorted sets, as text.
+func (r *decoder) float() (float64, error) {
+	length, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch length {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+
+	data, err := r.read(int(length))
+	if err != nil {
+		return 0, err
+	}
+
+	score, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse score: %w", err)
+	}
+
+	return score, nil
+}
+
+func (r *decoder) binaryFloat() (float64, error) {
+	data, err := r.read(8)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+}
+
+func (r *decoder) value(kind byte, entry *Entry) error {
+	var err error
+
+	switch kind {
+	case typeString:
+		entry.Type = String
+		entry.Value, err = r.string()
+	case typeList:
+		entry.Type = List
+		entry.Elements, err = r.stringList(1)
+	case typeSet:
+		entry.Type = Set
+		entry.Elements, err = r.stringList(1)
+	case typeZSet, typeZSet2:
+		entry.Type = SortedSet
+		entry.Members, err = r.sortedSet(kind == typeZSet2)
+	case typeHash:
+		var values []string
+
+		entry.Type = Hash
+		values, err = r.stringList(2)
+		entry.Fields = fields(values)
+	case typeHashZipmap:
+		var values []string
+
+		entry.Type = Hash
+		values, err = r.blob(zipmap)
+		entry.Fields = fields(values)
+	case typeListZiplist:
+		entry.Type = List
+		entry.Elements, err = r.blob(ziplist)
+	case typeSetIntset:
+		entry.Type = Set
+		entry.Elements, err = r.blob(intset)
+	case typeSetListpack:
+		entry.Type = Set
+		entry.Elements, err = r.blob(listpack)
+	case typeZSetZiplist, typeZSetListpack:
+		var values []string
+
+		entry.Type = SortedSet
+		values, err = r.blob(encoding(kind == typeZSetListpack))
+		if err == nil {
+			entry.Members, err = members(values)
+		}
+	case typeHashZiplist, typeHashListpack:
+		var values []string
+
+		entry.Type = Hash
+		values, err = r.blob(encoding(kind == typeHashListpack))
+		entry.Fields = fields(values)
+	case typeListQuicklist:
+		entry.Type = List
+		entry.Elements, err = r.quicklist()
+	case typeListQuicklist2:
+		entry.Type = List
+		entry.Elements, err = r.quicklist2()
+	case typeStreamListpacks, typeStreamListpack2, typeStreamListpack3:
+		entry.Type = Stream
+		err = r.skipStream(kind)
+	default:
+		// modules need their code to be read
+		return fmt.Errorf("type %d: %w", kind, ErrUnsupported)
+	}
+
+	return err
+}
+
+// blob reads a string, then its elements with one of the encodings.
+func (r *decoder) blob(decode func([]byte) ([]string, error)) ([]string, error) {
+	data, err := r.string()
+	if err != nil {
+		return nil, err
+	}
+
+	return decode([]byte(data))
+}
+
+func encoding(isListpack bool) func([]byte) ([]string, error) {
+	if isListpack {
+		return listpack
+	}
+
+	return ziplist
+}
+
+func (r *decoder) sortedSet(isBinary bool) ([]Member, error) {
+	length, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]Member, 0, min(length, 1024))
+
+	for range length {
+		name, err := r.string()
+		if err != nil {
+			return nil, err
+		}
+
+		var score float64
+		if isBinary {
+			score, err = r.binaryFloat()
+		} else {
+			score, err = r.float()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, Member{Name: name, Score: score})
+	}
+
+	return members, nil
+}
+
+// quicklist reads a list of ziplists.
+func (r *decoder) quicklist() ([]string, error) {
+	length, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+
+	var elements []string
+
+	for range length {
+		values, err := r.blob(ziplist)
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, values...)
+	}
+
+	return elements, nil
+}
+
+// the containers of the nodes of quicklists
+const (
+	containerPlain  = 1
+	containerPacked = 2
+)
+
+// quicklist2 reads a list of listpacks, where large elements are in their
+// own node.
+func (r *decoder) quicklist2() ([]string, error) {
+	length, err := r.length()
+	if err != nil {
+		return nil, err
+	}
+
+	var elements []string
+
+	for range length {
+		container, err := r.length()
+		if err != nil {
+			return nil, err
+		}
+
+		switch container {
+		case containerPlain:
+			value, err := r.string()
+			if err != nil {
+				return nil, err
+			}
+
+			elements = append(elements, value)
+		case containerPacked:
+			values, err := r.blob(listpack)
+			if err != nil {
+				return nil, err
+			}
+
+			elements = append(elements, values...)
+		default:
+			return nil, fmt.Errorf("quicklist container %d: %w", container, ErrUnsupported)
+		}
+	}
+
+	return elements, nil
+}
+
+// skipStream reads a stream, without decoding its entries.
+// https://github.com/redis/redis/blob/unstable/src/rdb.c
+func (r *decoder) skipStream(kind byte) error {
+	// the nodes are a master ID and a listpack
+	_, err := r.stringList(2)
+	if err != nil {
+		return err
+	}
+
+	// the length and last ID, then the first ID, the max deleted ID and the
+	// entries added
+	count := 3
+	if kind >= typeStreamListpack2 {
+		count += 5
+	}
+
+	_, err = r.lengths(count)
+	if err != nil {
+		return err
+	}
+
+	groups, err := r.length()
+	if err != nil {
+		return err
+	}
+
+	for range groups {
+		_, err = r.string()
+		if err != nil {
+			return err
+		}
+
+		// the last ID, then the entries read
+		count := 2
+		if kind >= typeStreamListpack2 {
+			count++
+		}
+
+		_, err = r.lengths(count)
+		if err != nil {
+			return err
+		}
+
+		pending, err := r.length()
+		if err != nil {
+			return err
+		}
+
+		for range pending {
+			// the ID and the delivery time
+			_, err = r.read(16 + 8)
+			if err != nil {
+				return err
+			}
+
+			_, err = r.length()
+			if err != nil {
+				return err
+			}
+		}
+
+		consumers, err := r.length()
+		if err != nil {
+			return err
+		}
+
+		for range consumers {
+			_, err = r.string()
+			if err != nil {
+				return err
+			}
+
+			// the seen time, then the active time
+			size := 8
+			if kind >= typeStreamListpack3 {
+				size += 8
+			}
+
+			_, err = r.read(size)
+			if err != nil {
+				return err
+			}
+
+			pending, err := r.length()
+			if err != nil {
+				return err
+			}
+
+			_, err = r.read(int(pending) * 16)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func fields(values []string) []Field {
+	fields := make([]Field, 0, len(values)/2)
+
+	for index := 0; index+1 < len(values); index += 2 {
+		fields = append(fields, Field{Name: values[index], Value: values[index+1]})
+	}
+
+	return fields
+}
+
+func members(values []string) ([]Member, error) {
+	members := make([]Member, 0, len(values)/2)
+
+	for index := 0; index+1 < len(values); index += 2 {
+		score, err := strconv.ParseFloat(values[index+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse score: %w", err)
+		}
+
+		members = append(members, Member{Name: values[index], Score: score})
+	}
+
+	return members, nil
+}
+
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
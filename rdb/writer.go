@@ -0,0 +1,200 @@
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Writer writes keys to a RDB file, without compressing them. Files are
+// written with version 9, loaded by Redis 5 and later.
+type Writer struct {
+	encoder
+	buffer *bufio.Writer
+	db     int
+}
+
+// NewWriter writes the header of a RDB file, with the version of Redis
+// it is compatible with.
+func NewWriter(writer io.Writer) (*Writer, error) {
+	buffered := bufio.NewWriter(writer)
+	w := &Writer{encoder: encoder{writer: buffered}, buffer: buffered}
+
+	w.write([]byte(fmt.Sprintf("REDIS%04d", version)))
+	w.write([]byte{opcodeAux})
+	w.string("redis-ver")
+	w.string("5.0.0")
+	w.write([]byte{opcodeSelectDB})
+	w.length(0)
+
+	if w.err != nil {
+		return nil, fmt.Errorf("could not write header: %w", w.err)
+	}
+
+	return w, nil
+}
+
+// Write writes a key. Streams can't be written.
+func (w *Writer) Write(entry *Entry) error {
+	kind, err := kind(entry)
+	if err != nil {
+		return fmt.Errorf("could not write %q: %w", entry.Key, err)
+	}
+
+	if entry.DB != w.db {
+		w.write([]byte{opcodeSelectDB})
+		w.length(uint64(entry.DB))
+		w.db = entry.DB
+	}
+
+	if !entry.ExpiresAt.IsZero() {
+		milliseconds := make([]byte, 8)
+		binary.LittleEndian.PutUint64(milliseconds, uint64(entry.ExpiresAt.UnixMilli()))
+
+		w.write([]byte{opcodeExpireTimeMS})
+		w.write(milliseconds)
+	}
+
+	w.write([]byte{kind})
+	w.string(entry.Key)
+	w.value(entry)
+
+	if w.err != nil {
+		return fmt.Errorf("could not write %q: %w", entry.Key, w.err)
+	}
+
+	return nil
+}
+
+// Close writes the end of the file and its checksum, without closing the
+// underlying writer.
+func (w *Writer) Close() error {
+	w.write([]byte{opcodeEOF})
+	w.sum()
+
+	if w.err != nil {
+		return fmt.Errorf("could not write end: %w", w.err)
+	}
+
+	err := w.buffer.Flush()
+	if err != nil {
+		return fmt.Errorf("could not flush: %w", err)
+	}
+
+	return nil
+}
+
+// encoder writes the encodings of a RDB file, keeping the checksum of what
+// was written.
+type encoder struct {
+	writer   io.Writer
+	checksum uint64
+	err      error
+}
+
+// kind is the type of an entry in a file. Values are written without the
+// encodings of small values, which Redis converts to when loading.
+func kind(entry *Entry) (byte, error) {
+	switch entry.Type {
+	case String:
+		return typeString, nil
+	case List:
+		return typeList, nil
+	case Set:
+		return typeSet, nil
+	case Hash:
+		return typeHash, nil
+	case SortedSet:
+		return typeZSet2, nil
+	}
+
+	return 0, fmt.Errorf("type %s: %w", entry.Type, ErrUnsupported)
+}
+
+func (e *encoder) value(entry *Entry) {
+	switch entry.Type {
+	case String:
+		e.string(entry.Value)
+	case List, Set:
+		e.length(uint64(len(entry.Elements)))
+
+		for _, element := range entry.Elements {
+			e.string(element)
+		}
+	case Hash:
+		e.length(uint64(len(entry.Fields)))
+
+		for _, field := range entry.Fields {
+			e.string(field.Name)
+			e.string(field.Value)
+		}
+	case SortedSet:
+		e.length(uint64(len(entry.Members)))
+
+		for _, member := range entry.Members {
+			score := make([]byte, 8)
+			binary.LittleEndian.PutUint64(score, math.Float64bits(member.Score))
+
+			e.string(member.Name)
+			e.write(score)
+		}
+	}
+}
+
+// sum writes the checksum of what was written.
+func (e *encoder) sum() {
+	sum := make([]byte, 8)
+	binary.LittleEndian.PutUint64(sum, e.checksum)
+	e.write(sum)
+}
+
+// write keeps the first error, so the encodings don't check every write.
+func (e *encoder) write(data []byte) {
+	if e.err != nil {
+		return
+	}
+
+	e.checksum = checksum(e.checksum, data)
+	_, e.err = e.writer.Write(data)
+}
+
+func (e *encoder) length(length uint64) {
+	switch {
+	case length < 1<<6:
+		e.write([]byte{byte(length)})
+	case length < 1<<14:
+		e.write([]byte{byte(length>>8) | 0x40, byte(length)})
+	case length <= math.MaxUint32:
+		data := []byte{0x80, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(data[1:], uint32(length))
+		e.write(data)
+	default:
+		data := []byte{0x81, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint64(data[1:], length)
+		e.write(data)
+	}
+}
+
+// string writes integers that fit in 32 bits with the smallest of their
+// encodings, like Redis.
+func (e *encoder) string(value string) {
+	integer, err := strconv.ParseInt(value, 10, 32)
+	if err == nil && strconv.FormatInt(integer, 10) == value {
+		switch {
+		case integer >= math.MinInt8 && integer <= math.MaxInt8:
+			e.write([]byte{0xc0 | encodingInt8, byte(integer)})
+		case integer >= math.MinInt16 && integer <= math.MaxInt16:
+			e.write(binary.LittleEndian.AppendUint16([]byte{0xc0 | encodingInt16}, uint16(integer)))
+		default:
+			e.write(binary.LittleEndian.AppendUint32([]byte{0xc0 | encodingInt32}, uint32(integer)))
+		}
+
+		return
+	}
+
+	e.length(uint64(len(value)))
+	e.write([]byte(value))
+}
@@ -0,0 +1,131 @@
+// Package replication streams the writes of a primary to its replicas.
+//
+// A replica connects over RESP and sends PSYNC. The primary replies with
+// a snapshot of its database, followed by every write command as it is
+// executed. The stream is kept in a backlog, so a replica that reconnects
+// can continue from its offset without a new snapshot.
+package replication
+
+import "sync"
+
+// Backlog keeps the latest bytes of the replication stream in a ring buffer.
+// Offsets are the number of bytes written to the stream since the primary
+// started.
+type Backlog struct {
+	mutex  sync.RWMutex
+	ring   []byte
+	start  int
+	length int
+	offset int64
+}
+
+func NewBacklog(size int) *Backlog {
+	return &Backlog{
+		ring: make([]byte, size),
+	}
+}
+
+// Write appends to the stream, dropping the oldest bytes over the size of
+// the backlog. It returns the offset after the write.
+func (b *Backlog) Write(p []byte) int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.offset += int64(len(p))
+
+	size := len(b.ring)
+	if size == 0 {
+		return b.offset
+	}
+
+	if len(p) >= size {
+		copy(b.ring, p[len(p)-size:])
+		b.start, b.length = 0, size
+
+		return b.offset
+	}
+
+	end := (b.start + b.length) % size
+	copied := copy(b.ring[end:], p)
+	copy(b.ring, p[copied:])
+
+	b.length += len(p)
+	if b.length > size {
+		b.start = (b.start + b.length - size) % size
+		b.length = size
+	}
+
+	return b.offset
+}
+
+// Offset is the offset of the end of the stream.
+func (b *Backlog) Offset() int64 {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.offset
+}
+
+// First is the offset of the first byte kept in the backlog.
+func (b *Backlog) First() int64 {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.offset - int64(b.length) + 1
+}
+
+// Len is the number of bytes kept in the backlog.
+func (b *Backlog) Len() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return b.length
+}
+
+func (b *Backlog) Size() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return len(b.ring)
+}
+
+// SetSize resizes the backlog, keeping the latest bytes.
+func (b *Backlog) SetSize(size int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	kept := b.last(min(b.length, size))
+
+	b.ring = make([]byte, size)
+	b.start, b.length = 0, copy(b.ring, kept)
+}
+
+// Since returns the bytes of the stream after the offset. It is false when
+// the bytes are no longer, or not yet, in the backlog.
+func (b *Backlog) Since(offset int64) ([]byte, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	count := b.offset - offset
+	if count < 0 || count > int64(b.length) {
+		return nil, false
+	}
+
+	return b.last(int(count)), true
+}
+
+func (b *Backlog) last(count int) []byte {
+	data := make([]byte, 0, count)
+	if count == 0 {
+		return data
+	}
+
+	from := (b.start + b.length - count) % len(b.ring)
+	if from+count <= len(b.ring) {
+		return append(data, b.ring[from:from+count]...)
+	}
+
+	data = append(data, b.ring[from:]...)
+
+	return append(data, b.ring[:count-len(data)]...)
+}
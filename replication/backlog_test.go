@@ -0,0 +1,62 @@
+package replication_test
+
+import (
+	"github.com/jtarchie/sqlettuce/replication"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backlog", func() {
+	It("returns the stream after an offset", func() {
+		backlog := replication.NewBacklog(8)
+
+		Expect(backlog.Write([]byte("abc"))).To(BeEquivalentTo(3))
+		Expect(backlog.Write([]byte("def"))).To(BeEquivalentTo(6))
+
+		data, ok := backlog.Since(2)
+		Expect(ok).To(BeTrue())
+		Expect(string(data)).To(Equal("cdef"))
+
+		data, ok = backlog.Since(6)
+		Expect(ok).To(BeTrue())
+		Expect(data).To(BeEmpty())
+
+		_, ok = backlog.Since(7)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("drops the oldest bytes", func() {
+		backlog := replication.NewBacklog(4)
+
+		backlog.Write([]byte("abc"))
+		backlog.Write([]byte("def"))
+
+		Expect(backlog.Len()).To(Equal(4))
+		Expect(backlog.First()).To(BeEquivalentTo(3))
+
+		data, ok := backlog.Since(2)
+		Expect(ok).To(BeTrue())
+		Expect(string(data)).To(Equal("cdef"))
+
+		_, ok = backlog.Since(1)
+		Expect(ok).To(BeFalse())
+
+		backlog.Write([]byte("ghijkl"))
+
+		data, ok = backlog.Since(8)
+		Expect(ok).To(BeTrue())
+		Expect(string(data)).To(Equal("ijkl"))
+	})
+
+	It("keeps the latest bytes when resized", func() {
+		backlog := replication.NewBacklog(4)
+
+		backlog.Write([]byte("abcdef"))
+		backlog.SetSize(2)
+
+		data, ok := backlog.Since(4)
+		Expect(ok).To(BeTrue())
+		Expect(string(data)).To(Equal("ef"))
+		Expect(backlog.Size()).To(Equal(2))
+	})
+})
@@ -0,0 +1,369 @@
+package replication
+
+import (
+	"context"
+	"hash/maphash"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// bufferSize is the number of writes queued for a replica before it is
+	// disconnected for being too slow, like the replica output buffer limit.
+	bufferSize = 16384
+	// stripes is the number of locks the keys of the writes are spread over.
+	stripes = 256
+)
+
+// Deliver sends part of the replication stream to a replica.
+type Deliver func(data []byte) error
+
+// Link is a replica attached to the primary.
+type Link struct {
+	ID            uint64
+	Addr          string
+	ListeningPort int
+
+	acked    atomic.Int64
+	ackedAt  atomic.Int64
+	messages chan []byte
+	done     chan struct{}
+	closer   func()
+}
+
+// NewLink creates the link of a replica. The closer disconnects the replica
+// when it can't keep up with the stream.
+func NewLink(id uint64, addr string, listeningPort int, closer func()) *Link {
+	return &Link{
+		ID:            id,
+		Addr:          addr,
+		ListeningPort: listeningPort,
+		messages:      make(chan []byte, bufferSize),
+		done:          make(chan struct{}),
+		closer:        closer,
+	}
+}
+
+// Acked is the latest offset acknowledged by the replica.
+func (l *Link) Acked() int64 {
+	return l.acked.Load()
+}
+
+// Lag is the time since the replica last acknowledged its offset.
+func (l *Link) Lag() time.Duration {
+	return time.Since(time.Unix(0, l.ackedAt.Load()))
+}
+
+// Resync is how a replica catches up with the primary when it is attached.
+type Resync struct {
+	// Full is true when the replica needs a snapshot, taken at the offset.
+	// Otherwise, the replica continues with the backlog.
+	Full    bool
+	ReplID  string
+	Offset  int64
+	Backlog []byte
+}
+
+// Primary propagates write commands to the attached replicas.
+type Primary struct {
+	// writes is held by every write, and alone by the writes without keys
+	// and by pauses, which run between the other writes
+	writes sync.RWMutex
+	// stripes serialize the writes of a key with their propagation, so
+	// replicas apply them in the order they were executed. Writes of other
+	// keys run concurrently, and can be committed together.
+	stripes [stripes]sync.Mutex
+	seed    maphash.Seed
+
+	// mutex orders the propagation of the writes
+	mutex   sync.Mutex
+	replID  string
+	backlog *Backlog
+
+	linksMutex sync.RWMutex
+	links      map[uint64]*Link
+	acks       chan struct{}
+}
+
+func NewPrimary(replID string, backlogSize int) *Primary {
+	return &Primary{
+		seed:    maphash.MakeSeed(),
+		replID:  replID,
+		backlog: NewBacklog(backlogSize),
+		links:   map[uint64]*Link{},
+		acks:    make(chan struct{}),
+	}
+}
+
+func (p *Primary) ReplID() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.replID
+}
+
+// SetReplID starts a new replication history, like when a replica is
+// promoted. Replicas of the previous history need a full resync.
+func (p *Primary) SetReplID(replID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.replID = replID
+}
+
+// Offset is the offset of the end of the replication stream.
+func (p *Primary) Offset() int64 {
+	return p.backlog.Offset()
+}
+
+func (p *Primary) Backlog() *Backlog {
+	return p.backlog
+}
+
+// Execute runs a write command of the keys, propagating it when it
+// succeeds. The writes of a key are executed and propagated one at a time,
+// the writes of other keys concurrently. A write without keys, like
+// FLUSHALL, runs alone.
+func (p *Primary) Execute(keys []string, tokens []string, execute func() error) error {
+	return p.ExecuteFunc(keys, func() ([]string, error) {
+		return tokens, execute()
+	})
+}
+
+// ExecuteFunc is Execute for a write that returns the command propagated,
+// like MIGRATE propagating the keys it deleted. Nothing is propagated when
+// it returns no command.
+func (p *Primary) ExecuteFunc(keys []string, execute func() ([]string, error)) error {
+	unlock := p.lock(keys)
+	defer unlock()
+
+	tokens, err := execute()
+	if err != nil || len(tokens) == 0 {
+		return err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.propagate(Encode(tokens...))
+
+	return nil
+}
+
+// lock locks the stripes of the keys, in order so writes of the same keys
+// can't wait on each other, or every write when there is no key.
+func (p *Primary) lock(keys []string) func() {
+	if len(keys) == 0 {
+		p.writes.Lock()
+
+		return p.writes.Unlock
+	}
+
+	indexes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		indexes = append(indexes, int(maphash.String(p.seed, key)%stripes))
+	}
+
+	slices.Sort(indexes)
+	indexes = slices.Compact(indexes)
+
+	p.writes.RLock()
+
+	for _, index := range indexes {
+		p.stripes[index].Lock()
+	}
+
+	return func() {
+		for _, index := range indexes {
+			p.stripes[index].Unlock()
+		}
+
+		p.writes.RUnlock()
+	}
+}
+
+// Pause runs fn while no write command is executing, like to snapshot the
+// database between two writes.
+func (p *Primary) Pause(fn func() error) error {
+	p.writes.Lock()
+	defer p.writes.Unlock()
+
+	return fn()
+}
+
+// RequestAck asks every replica to acknowledge its offset.
+func (p *Primary) RequestAck() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.propagate(Encode("REPLCONF", "GETACK", "*"))
+}
+
+func (p *Primary) propagate(data []byte) {
+	p.backlog.Write(data)
+
+	p.linksMutex.RLock()
+	defer p.linksMutex.RUnlock()
+
+	for _, link := range p.links {
+		select {
+		case link.messages <- data:
+		default:
+			go p.Detach(link.ID)
+			go link.closer()
+		}
+	}
+}
+
+// Attach adds a replica. A replica with the same replication ID, and an
+// offset still in the backlog, continues from the backlog. Otherwise,
+// snapshot is called while writes are paused, for a full resync.
+// The replica receives the writes after the resync once it is started.
+func (p *Primary) Attach(link *Link, replID string, offset int64, snapshot func() error) (Resync, error) {
+	p.writes.Lock()
+	defer p.writes.Unlock()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	resync := Resync{
+		ReplID: p.replID,
+		Offset: p.backlog.Offset(),
+	}
+
+	backlog, ok := p.backlog.Since(offset)
+	if replID == p.replID && ok {
+		resync.Backlog = backlog
+
+		link.acked.Store(offset)
+	} else {
+		err := snapshot()
+		if err != nil {
+			return Resync{}, err
+		}
+
+		resync.Full = true
+	}
+
+	link.ackedAt.Store(time.Now().UnixNano())
+
+	p.linksMutex.Lock()
+	defer p.linksMutex.Unlock()
+
+	p.links[link.ID] = link
+
+	return resync, nil
+}
+
+// Start delivers the stream to an attached replica, until it is detached
+// or the delivery fails.
+func (p *Primary) Start(link *Link, deliver Deliver) {
+	go func() {
+		for {
+			select {
+			case <-link.done:
+				return
+			case data := <-link.messages:
+				err := deliver(data)
+				if err != nil {
+					p.Detach(link.ID)
+
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (p *Primary) Detach(id uint64) {
+	p.linksMutex.Lock()
+	defer p.linksMutex.Unlock()
+
+	link, ok := p.links[id]
+	if !ok {
+		return
+	}
+
+	close(link.done)
+	delete(p.links, id)
+}
+
+// Disconnect detaches and closes every replica, like when the history of
+// the stream is replaced.
+func (p *Primary) Disconnect() {
+	for _, link := range p.Links() {
+		p.Detach(link.ID)
+		link.closer()
+	}
+}
+
+// Ack records the offset acknowledged by a replica, waking up WAIT.
+func (p *Primary) Ack(id uint64, offset int64) {
+	p.linksMutex.Lock()
+	defer p.linksMutex.Unlock()
+
+	link, ok := p.links[id]
+	if !ok {
+		return
+	}
+
+	link.ackedAt.Store(time.Now().UnixNano())
+
+	if offset > link.acked.Load() {
+		link.acked.Store(offset)
+	}
+
+	close(p.acks)
+	p.acks = make(chan struct{})
+}
+
+// Links returns the attached replicas.
+func (p *Primary) Links() []*Link {
+	p.linksMutex.RLock()
+	defer p.linksMutex.RUnlock()
+
+	links := make([]*Link, 0, len(p.links))
+	for _, link := range p.links {
+		links = append(links, link)
+	}
+
+	return links
+}
+
+// Wait blocks until count replicas acknowledged the offset, or the timeout.
+// A zero timeout waits forever. It returns the number of replicas that
+// acknowledged the offset.
+func (p *Primary) Wait(ctx context.Context, offset int64, count int, timeout time.Duration) int {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		p.linksMutex.RLock()
+		acked := 0
+
+		for _, link := range p.links {
+			if link.acked.Load() >= offset {
+				acked++
+			}
+		}
+
+		wake := p.acks
+		p.linksMutex.RUnlock()
+
+		if acked >= count {
+			return acked
+		}
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return acked
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package replication_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/replication"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type stream struct {
+	mutex sync.Mutex
+	data  []byte
+}
+
+func (s *stream) deliver(data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data = append(s.data, data...)
+
+	return nil
+}
+
+func (s *stream) String() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return string(s.data)
+}
+
+var _ = Describe("Primary", func() {
+	var primary *replication.Primary
+
+	execute := func(tokens ...string) {
+		err := primary.Execute(tokens[1:2], tokens, func() error { return nil })
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		primary = replication.NewPrimary("id", 1024)
+	})
+
+	It("propagates successful writes to the backlog", func() {
+		execute("SET", "a", "1")
+
+		err := primary.Execute([]string{"b"}, []string{"SET", "b", "2"}, func() error {
+			return errors.New("failed")
+		})
+		Expect(err).To(MatchError("failed"))
+
+		set := string(replication.Encode("SET", "a", "1"))
+		Expect(primary.Offset()).To(BeEquivalentTo(len(set)))
+	})
+
+	It("executes the writes of other keys concurrently", func() {
+		started := make(chan struct{})
+		release := make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+
+			err := primary.Execute([]string{"a"}, []string{"SET", "a", "1"}, func() error {
+				close(started)
+				<-release
+
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}()
+
+		Eventually(started).Should(BeClosed())
+
+		// a write of another key is not waiting
+		execute("SET", "b", "2")
+
+		executed := make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+
+			execute("SET", "a", "2")
+			close(executed)
+		}()
+
+		flushed := make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+
+			err := primary.Execute(nil, []string{"FLUSHALL"}, func() error { return nil })
+			Expect(err).NotTo(HaveOccurred())
+			close(flushed)
+		}()
+
+		Consistently(executed, 50*time.Millisecond).ShouldNot(BeClosed())
+		Consistently(flushed, 50*time.Millisecond).ShouldNot(BeClosed())
+
+		close(release)
+		Eventually(executed).Should(BeClosed())
+		Eventually(flushed).Should(BeClosed())
+
+		backlog, ok := primary.Backlog().Since(0)
+		Expect(ok).To(BeTrue())
+		Expect(string(backlog)).To(HavePrefix(
+			string(replication.Encode("SET", "b", "2")) + string(replication.Encode("SET", "a", "1")),
+		))
+	})
+
+	It("waits for the pause to end before executing writes", func() {
+		executed := make(chan struct{})
+
+		err := primary.Pause(func() error {
+			go func() {
+				defer GinkgoRecover()
+
+				execute("SET", "a", "1")
+				close(executed)
+			}()
+
+			Consistently(executed, 50*time.Millisecond).ShouldNot(BeClosed())
+
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(executed).Should(BeClosed())
+	})
+
+	It("delivers the writes to started replicas", func() {
+		link := replication.NewLink(1, "127.0.0.1:1", 0, func() {})
+
+		resync, err := primary.Attach(link, "?", -1, func() error { return nil })
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resync.Full).To(BeTrue())
+		Expect(resync.ReplID).To(Equal("id"))
+
+		execute("SET", "a", "1")
+
+		received := &stream{}
+		primary.Start(link, received.deliver)
+
+		Eventually(received.String).Should(Equal(string(replication.Encode("SET", "a", "1"))))
+		Expect(primary.Links()).To(HaveLen(1))
+
+		primary.Detach(link.ID)
+		Expect(primary.Links()).To(BeEmpty())
+	})
+
+	It("continues from the backlog for a known replication ID", func() {
+		execute("SET", "a", "1")
+		offset := primary.Offset()
+		execute("SET", "b", "2")
+
+		snapshots := 0
+		snapshot := func() error {
+			snapshots++
+
+			return nil
+		}
+
+		resync, err := primary.Attach(replication.NewLink(1, "", 0, func() {}), "id", offset, snapshot)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resync.Full).To(BeFalse())
+		Expect(string(resync.Backlog)).To(Equal(string(replication.Encode("SET", "b", "2"))))
+
+		_, err = primary.Attach(replication.NewLink(2, "", 0, func() {}), "other", offset, snapshot)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots).To(Equal(1))
+	})
+
+	It("waits for replicas to acknowledge an offset", func() {
+		link := replication.NewLink(1, "", 0, func() {})
+		_, err := primary.Attach(link, "?", -1, func() error { return nil })
+		Expect(err).NotTo(HaveOccurred())
+
+		execute("SET", "a", "1")
+		offset := primary.Offset()
+
+		Expect(primary.Wait(context.Background(), offset, 1, 10*time.Millisecond)).To(Equal(0))
+
+		go func() {
+			defer GinkgoRecover()
+
+			time.Sleep(10 * time.Millisecond)
+			primary.Ack(link.ID, offset)
+		}()
+
+		Expect(primary.Wait(context.Background(), offset, 1, 0)).To(Equal(1))
+		Expect(link.Acked()).To(Equal(offset))
+	})
+})
@@ -0,0 +1,371 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State is the state of the link of a replica with its primary, as reported
+// by ROLE.
+type State string
+
+const (
+	StateConnect    State = "connect"
+	StateConnecting State = "connecting"
+	StateSync       State = "sync"
+	StateConnected  State = "connected"
+)
+
+const (
+	ackInterval       = time.Second
+	reconnectInterval = time.Second
+)
+
+// Loader replaces the database with the snapshot sent for a full resync.
+type Loader func(ctx context.Context, snapshot io.Reader) error
+
+// Applier executes a write command received from the primary.
+type Applier func(ctx context.Context, tokens []string) error
+
+// Replica follows a primary, reconnecting until it is stopped.
+type Replica struct {
+	Host          string
+	Port          int
+	ListeningPort int
+
+	loader  Loader
+	applier Applier
+
+	mutex      sync.RWMutex
+	state      State
+	replID     string
+	lastIO     time.Time
+	offset     atomic.Int64
+	cancel     context.CancelFunc
+	done       chan struct{}
+	writeMutex sync.Mutex
+}
+
+// NewReplica creates a replica of the primary at host and port. The
+// replication ID and offset are the history already applied, so the
+// primary can continue from its backlog, like a primary demoted to replica.
+func NewReplica(
+	host string,
+	port int,
+	listeningPort int,
+	replID string,
+	offset int64,
+	loader Loader,
+	applier Applier,
+) *Replica {
+	replica := &Replica{
+		Host:          host,
+		Port:          port,
+		ListeningPort: listeningPort,
+		loader:        loader,
+		applier:       applier,
+		state:         StateConnect,
+		replID:        replID,
+		done:          make(chan struct{}),
+	}
+	replica.offset.Store(offset)
+
+	return replica
+}
+
+func (r *Replica) Addr() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
+
+func (r *Replica) State() State {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.state
+}
+
+// ReplID is the replication ID of the primary being followed.
+func (r *Replica) ReplID() string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.replID
+}
+
+// Offset is the offset of the replication stream applied by the replica.
+func (r *Replica) Offset() int64 {
+	return r.offset.Load()
+}
+
+// LastIO is the time since the last data received from the primary.
+func (r *Replica) LastIO() time.Duration {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.lastIO.IsZero() {
+		return 0
+	}
+
+	return time.Since(r.lastIO)
+}
+
+// Start follows the primary in the background until Stop is called.
+func (r *Replica) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.mutex.Lock()
+	r.cancel = cancel
+	r.mutex.Unlock()
+
+	go func() {
+		defer close(r.done)
+
+		for {
+			err := r.follow(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			slog.Warn("lost connection to primary", slog.String("addr", r.Addr()), slog.String("error", err.Error()))
+			r.setState(StateConnect)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reconnectInterval):
+			}
+		}
+	}()
+}
+
+// Stop disconnects from the primary, waiting for the last command being
+// applied.
+func (r *Replica) Stop() {
+	r.mutex.RLock()
+	cancel := r.cancel
+	r.mutex.RUnlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-r.done
+}
+
+// Done is closed once the replica stopped following the primary.
+func (r *Replica) Done() <-chan struct{} {
+	return r.done
+}
+
+func (r *Replica) setState(state State) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.state = state
+}
+
+func (r *Replica) touch() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastIO = time.Now()
+}
+
+func (r *Replica) follow(ctx context.Context) error {
+	r.setState(StateConnecting)
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", r.Addr())
+	if err != nil {
+		return fmt.Errorf("could not connect to primary: %w", err)
+	}
+	defer conn.Close()
+
+	// unblock reads when stopped
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	reader := bufio.NewReader(conn)
+
+	err = r.handshake(ctx, conn, reader)
+	if err != nil {
+		return err
+	}
+
+	r.setState(StateConnected)
+
+	go r.acknowledge(ctx, conn)
+
+	return r.stream(ctx, conn, reader)
+}
+
+func (r *Replica) send(conn io.Writer, tokens ...string) error {
+	r.writeMutex.Lock()
+	defer r.writeMutex.Unlock()
+
+	_, err := conn.Write(Encode(tokens...))
+	if err != nil {
+		return fmt.Errorf("could not send %s: %w", tokens[0], err)
+	}
+
+	return nil
+}
+
+func (r *Replica) handshake(ctx context.Context, conn io.Writer, reader *bufio.Reader) error {
+	requests := [][]string{
+		{"PING"},
+		{"REPLCONF", "listening-port", strconv.Itoa(r.ListeningPort)},
+		{"REPLCONF", "capa", "psync2"},
+	}
+
+	for _, request := range requests {
+		err := r.send(conn, request...)
+		if err != nil {
+			return err
+		}
+
+		_, err = readReply(reader)
+		if err != nil {
+			return fmt.Errorf("could not handshake %s: %w", request[0], err)
+		}
+	}
+
+	replID, offset := r.ReplID(), strconv.FormatInt(r.Offset()+1, 10)
+	if replID == "" {
+		replID, offset = "?", "-1"
+	}
+
+	err := r.send(conn, "PSYNC", replID, offset)
+	if err != nil {
+		return err
+	}
+
+	reply, err := readReply(reader)
+	if err != nil {
+		return fmt.Errorf("could not PSYNC: %w", err)
+	}
+
+	fields := strings.Fields(reply)
+
+	switch {
+	case len(fields) == 3 && fields[0] == "FULLRESYNC":
+		offset, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid offset %q", ErrProtocol, reply)
+		}
+
+		r.setState(StateSync)
+
+		err = r.load(ctx, reader)
+		if err != nil {
+			return err
+		}
+
+		r.mutex.Lock()
+		r.replID = fields[1]
+		r.mutex.Unlock()
+		r.offset.Store(offset)
+	case len(fields) >= 1 && fields[0] == "CONTINUE":
+		if len(fields) == 2 {
+			r.mutex.Lock()
+			r.replID = fields[1]
+			r.mutex.Unlock()
+		}
+	default:
+		return fmt.Errorf("%w: unexpected PSYNC reply %q", ErrProtocol, reply)
+	}
+
+	r.touch()
+
+	return nil
+}
+
+// load reads the snapshot, sent as a bulk string without the trailing `\r\n`.
+func (r *Replica) load(ctx context.Context, reader *bufio.Reader) error {
+	line, err := readLine(reader)
+	if err != nil {
+		return err
+	}
+
+	length, err := bulkLength(line)
+	if err != nil {
+		return err
+	}
+
+	snapshot := io.LimitReader(reader, length)
+
+	err = r.loader(ctx, snapshot)
+	if err != nil {
+		return fmt.Errorf("could not load snapshot: %w", err)
+	}
+
+	// the loader may not read all of the snapshot
+	_, err = io.Copy(io.Discard, snapshot)
+	if err != nil {
+		return fmt.Errorf("could not read snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Replica) stream(ctx context.Context, conn io.Writer, reader *bufio.Reader) error {
+	for {
+		tokens, read, err := readCommand(reader)
+		if err != nil {
+			return err
+		}
+
+		r.touch()
+
+		switch {
+		case len(tokens) == 0:
+		case strings.EqualFold(tokens[0], "PING"):
+		case strings.EqualFold(tokens[0], "REPLCONF"):
+			// the acknowledged offset excludes the GETACK itself
+			if len(tokens) > 1 && strings.EqualFold(tokens[1], "GETACK") {
+				err = r.send(conn, "REPLCONF", "ACK", strconv.FormatInt(r.Offset(), 10))
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			err = r.applier(ctx, tokens)
+			if err != nil {
+				slog.Error("could not apply replicated command",
+					slog.String("command", tokens[0]), slog.String("error", err.Error()))
+			}
+		}
+
+		r.offset.Add(read)
+	}
+}
+
+// acknowledge sends the applied offset every second, so the primary knows
+// its replicas are alive.
+func (r *Replica) acknowledge(ctx context.Context, conn io.Writer) {
+	ticker := time.NewTicker(ackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := r.send(conn, "REPLCONF", "ACK", strconv.FormatInt(r.Offset(), 10))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package replication_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jtarchie/sqlettuce/replication"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// readTokens reads a command sent by the replica.
+func readTokens(reader *bufio.Reader) []string {
+	line, err := reader.ReadString('\n')
+	Expect(err).NotTo(HaveOccurred())
+
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	Expect(err).NotTo(HaveOccurred())
+
+	tokens := make([]string, 0, count)
+
+	for range count {
+		_, err = reader.ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+
+		token, err := reader.ReadString('\n')
+		Expect(err).NotTo(HaveOccurred())
+
+		tokens = append(tokens, strings.TrimSpace(token))
+	}
+
+	return tokens
+}
+
+var _ = Describe("Replica", func() {
+	It("loads the snapshot and applies the stream", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer listener.Close()
+
+		acks := make(chan []string, 10)
+
+		go func() {
+			defer GinkgoRecover()
+
+			conn, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			reader := bufio.NewReader(conn)
+
+			Expect(readTokens(reader)).To(Equal([]string{"PING"}))
+			_, _ = io.WriteString(conn, "+PONG\r\n")
+			Expect(readTokens(reader)).To(Equal([]string{"REPLCONF", "listening-port", "6380"}))
+			_, _ = io.WriteString(conn, "+OK\r\n")
+			Expect(readTokens(reader)).To(Equal([]string{"REPLCONF", "capa", "psync2"}))
+			_, _ = io.WriteString(conn, "+OK\r\n")
+			Expect(readTokens(reader)).To(Equal([]string{"PSYNC", "?", "-1"}))
+			_, _ = io.WriteString(conn, "+FULLRESYNC abc 100\r\n$8\r\nsnapshot")
+			_, _ = conn.Write(replication.Encode("SET", "a", "1"))
+			_, _ = conn.Write(replication.Encode("REPLCONF", "GETACK", "*"))
+
+			// acknowledgements until the replica disconnects
+			for {
+				_, err := reader.Peek(1)
+				if err != nil {
+					return
+				}
+
+				acks <- readTokens(reader)
+			}
+		}()
+
+		var (
+			mutex   sync.Mutex
+			loaded  string
+			applied [][]string
+		)
+
+		port := listener.Addr().(*net.TCPAddr).Port
+		replica := replication.NewReplica("127.0.0.1", port, 6380, "", 0,
+			func(_ context.Context, snapshot io.Reader) error {
+				contents, err := io.ReadAll(snapshot)
+				Expect(err).NotTo(HaveOccurred())
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				loaded = string(contents)
+
+				return nil
+			},
+			func(_ context.Context, tokens []string) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				applied = append(applied, tokens)
+
+				return nil
+			},
+		)
+
+		replica.Start(context.Background())
+		defer replica.Stop()
+
+		set := int64(len(replication.Encode("SET", "a", "1")))
+
+		Eventually(acks).Should(Receive(Equal([]string{"REPLCONF", "ACK", fmt.Sprint(100 + set)})))
+		Expect(replica.State()).To(Equal(replication.StateConnected))
+		Expect(replica.ReplID()).To(Equal("abc"))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		Expect(loaded).To(Equal("snapshot"))
+		Expect(applied).To(Equal([][]string{{"SET", "a", "1"}}))
+	})
+})
@@ -0,0 +1,13 @@
+package replication_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestReplication(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Replication Suite")
+}
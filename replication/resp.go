@@ -0,0 +1,121 @@
+package replication
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrProtocol = errors.New("unexpected replication protocol")
+
+// Encode formats the command as a RESP array of bulk strings, like it is
+// sent in the replication stream.
+func Encode(tokens ...string) []byte {
+	var builder strings.Builder
+
+	builder.WriteString("*")
+	builder.WriteString(strconv.Itoa(len(tokens)))
+	builder.WriteString("\r\n")
+
+	for _, token := range tokens {
+		builder.WriteString("$")
+		builder.WriteString(strconv.Itoa(len(token)))
+		builder.WriteString("\r\n")
+		builder.WriteString(token)
+		builder.WriteString("\r\n")
+	}
+
+	return []byte(builder.String())
+}
+
+// readLine reads a line without its `\r\n`.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("could not read line: %w", err)
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// readReply reads a simple reply, returning an error reply as an error.
+func readReply(reader *bufio.Reader) (string, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(line, "-") {
+		return "", fmt.Errorf("%w: %s", ErrProtocol, line[1:])
+	}
+
+	if !strings.HasPrefix(line, "+") {
+		return "", fmt.Errorf("%w: expected a status reply, got %q", ErrProtocol, line)
+	}
+
+	return line[1:], nil
+}
+
+// readCommand reads a command of the replication stream, returning the
+// number of bytes it took in the stream.
+func readCommand(reader *bufio.Reader) ([]string, int64, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	read := int64(len(line) + 2)
+
+	if !strings.HasPrefix(line, "*") {
+		return nil, read, fmt.Errorf("%w: expected an array, got %q", ErrProtocol, line)
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, read, fmt.Errorf("%w: invalid array length %q", ErrProtocol, line)
+	}
+
+	tokens := make([]string, 0, count)
+
+	for range count {
+		line, err = readLine(reader)
+		if err != nil {
+			return nil, read, err
+		}
+
+		read += int64(len(line) + 2)
+
+		length, err := bulkLength(line)
+		if err != nil {
+			return nil, read, err
+		}
+
+		token := make([]byte, length+2)
+
+		_, err = io.ReadFull(reader, token)
+		if err != nil {
+			return nil, read, fmt.Errorf("could not read bulk string: %w", err)
+		}
+
+		read += int64(len(token))
+		tokens = append(tokens, string(token[:length]))
+	}
+
+	return tokens, read, nil
+}
+
+func bulkLength(line string) (int64, error) {
+	if !strings.HasPrefix(line, "$") {
+		return 0, fmt.Errorf("%w: expected a bulk string, got %q", ErrProtocol, line)
+	}
+
+	length, err := strconv.ParseInt(line[1:], 10, 64)
+	if err != nil || length < 0 {
+		return 0, fmt.Errorf("%w: invalid bulk length %q", ErrProtocol, line)
+	}
+
+	return length, nil
+}
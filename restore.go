@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/db/drivers"
+	"github.com/jtarchie/sqlettuce/persistence"
+)
+
+var (
+	ErrOutputExists = errors.New("the output already exists")
+	ErrNotJournaled = errors.New("no snapshot or change journal reaches back to the time")
+)
+
+type RestoreCommand struct {
+	Filename   string    `default:"sqlite://test.db" help:"DSN of the SQLite database with the change journal"`
+	Dir        string    `default:"."                help:"directory of the snapshots written by SAVE and BGSAVE"`
+	Dbfilename string    `default:"dump.db"          help:"filename of the snapshots"                                name:"dbfilename"`
+	To         time.Time `help:"time the keys are restored as of, like 2026-10-01T12:00:00Z"                         required:""`
+	Output     string    `help:"path of the SQLite file to write"                                                    required:""`
+}
+
+// Run copies the latest snapshot taken before the time, then applies the
+// changes of the journal that followed it, up to the time. Without such a
+// snapshot, the journal is applied from its start, when it was never
+// trimmed. The file is written to a temporary file, renamed once complete.
+func (c *RestoreCommand) Run() error {
+	ctx := context.TODO()
+
+	_, err := os.Stat(c.Output)
+	if err == nil {
+		return fmt.Errorf("could not restore to %q: %w", c.Output, ErrOutputExists)
+	}
+
+	source, err := db.NewClient(c.Filename)
+	if err != nil {
+		return fmt.Errorf("could not start db client: %w", err)
+	}
+	defer source.Close()
+
+	journal, err := source.JournalStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read change journal: %w", err)
+	}
+
+	snapshots, err := persistence.Snapshots(c.Dir, c.Dbfilename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err //nolint:wrapcheck
+	}
+
+	temporary := fmt.Sprintf("%s.temp-%d", c.Output, os.Getpid())
+	defer removeDatabase(temporary)
+
+	client, snapshot, err := c.base(ctx, snapshots, journal, temporary)
+	if err != nil {
+		return err
+	}
+
+	applied, err := client.Recover(ctx, source, c.To)
+	if err != nil {
+		_ = client.Close()
+
+		return fmt.Errorf("could not apply change journal: %w", err)
+	}
+
+	stats, err := client.Stats(ctx)
+	if err != nil {
+		_ = client.Close()
+
+		return fmt.Errorf("could not count keys: %w", err)
+	}
+
+	err = client.Close()
+	if err != nil {
+		return fmt.Errorf("could not close restored database: %w", err)
+	}
+
+	err = os.Rename(temporary, c.Output)
+	if err != nil {
+		return fmt.Errorf("could not write restored database: %w", err)
+	}
+
+	slog.Info("restored database",
+		slog.String("snapshot", snapshot),
+		slog.Int64("changes", applied),
+		slog.Int64("keys", stats.Keys),
+	)
+
+	return nil
+}
+
+// base opens a copy of the latest snapshot whose last change was made
+// before the time, or an empty database when the journal was never trimmed.
+// Snapshots without changes can't be placed in time, they are skipped.
+func (c *RestoreCommand) base(
+	ctx context.Context,
+	snapshots []string,
+	journal *drivers.JournalStatus,
+	path string,
+) (*db.Client, string, error) {
+	for _, snapshot := range snapshots {
+		client, position, err := openCopy(ctx, snapshot, path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if position.ID > 0 && !position.At.After(c.To) && position.ID <= journal.Last.ID {
+			return client, snapshot, nil
+		}
+
+		_ = client.Close()
+		removeDatabase(path)
+	}
+
+	if journal.First.ID != 1 || journal.First.At.After(c.To) {
+		return nil, "", fmt.Errorf("could not restore to %s: %w", c.To.Format(time.RFC3339), ErrNotJournaled)
+	}
+
+	client, err := db.NewClient("sqlite://" + path)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not create database: %w", err)
+	}
+
+	return client, "", nil
+}
+
+// openCopy copies a snapshot, returning the last change of its journal.
+func openCopy(ctx context.Context, snapshot, path string) (*db.Client, drivers.JournalPosition, error) {
+	err := copyFile(snapshot, path)
+	if err != nil {
+		return nil, drivers.JournalPosition{}, err
+	}
+
+	client, err := db.NewClient("sqlite://" + path)
+	if err != nil {
+		return nil, drivers.JournalPosition{}, fmt.Errorf("could not open snapshot %q: %w", snapshot, err)
+	}
+
+	status, err := client.JournalStatus(ctx)
+	if err != nil {
+		_ = client.Close()
+
+		return nil, drivers.JournalPosition{}, fmt.Errorf("could not read journal of snapshot %q: %w", snapshot, err)
+	}
+
+	return client, status.Last, nil
+}
+
+func copyFile(from, to string) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return fmt.Errorf("could not open snapshot: %w", err)
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(to, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not create copy of snapshot: %w", err)
+	}
+	defer destination.Close()
+
+	_, err = io.Copy(destination, source)
+	if err != nil {
+		return fmt.Errorf("could not copy snapshot: %w", err)
+	}
+
+	err = destination.Close()
+	if err != nil {
+		return fmt.Errorf("could not close copy of snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// removeDatabase removes a SQLite file with its write ahead log.
+func removeDatabase(path string) {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		_ = os.Remove(path + suffix)
+	}
+}
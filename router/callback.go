@@ -2,6 +2,9 @@ package router
 
 import "io"
 
+// Callback runs a command, writing the reply. A returned error is a failure
+// of the command, not the connection: it is sent to the client as an error
+// reply instead of anything written so far. See ReplyError.
 type Callback func([]string, io.Writer) error
 
 type CallbackRouter Callback
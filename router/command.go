@@ -1,14 +1,31 @@
 package router
 
 import (
-	"fmt"
+	"io"
 	"log/slog"
+	"slices"
 	"strings"
 )
 
 type Command map[string]Router
 
+// Lookup routes to the command named by the first token. When there are no
+// tokens, like a container command sent without a subcommand, the route with
+// the empty name is used.
 func (c Command) Lookup(tokens []string) (Callback, bool) {
+	remaining := len(tokens)
+
+	if len(tokens) == 0 {
+		next, ok := c[""]
+		if !ok {
+			return func(tokens []string, _ io.Writer) error {
+				return WrongArity(lookupName(tokens, remaining))
+			}, false
+		}
+
+		return next.Lookup(tokens)
+	}
+
 	command := strings.ToUpper(tokens[0])
 
 	next, ok := c[command]
@@ -17,10 +34,91 @@ func (c Command) Lookup(tokens []string) (Callback, bool) {
 			slog.String("command", command),
 		)
 
-		return staticResponseCallback(fmt.Sprintf("-Unsupported command %q\r\n", command)), false
+		// the callback receives all the tokens, so it knows if this is a
+		// subcommand of another command
+		return func(tokens []string, _ io.Writer) error {
+			consumed := len(tokens) - remaining
+			if consumed <= 0 {
+				return UnknownCommand(tokens[0], tokens[1:])
+			}
+
+			return UnknownSubcommand(tokens[consumed-1], tokens[consumed])
+		}, false
 	}
 
 	return next.Lookup(tokens[1:])
 }
 
+// Name returns the name of the command the tokens route to, in the lower case
+// form redis uses for reporting. Subcommands are joined with a `|`, for
+// example `client|list`.
+func (c Command) Name(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	command := strings.ToUpper(tokens[0])
+	name := strings.ToLower(command)
+
+	next := c[command]
+	if described, ok := next.(*SpecRouter); ok {
+		next = described.next
+	}
+
+	if sub, ok := next.(Command); ok && len(tokens) > 1 {
+		if _, ok := sub[strings.ToUpper(tokens[1])]; ok {
+			return name + "|" + strings.ToLower(tokens[1])
+		}
+	}
+
+	return name
+}
+
+// Spec returns the spec of the command the tokens route to, preferring the
+// spec of a subcommand.
+func (c Command) Spec(tokens []string) (Spec, bool) {
+	if len(tokens) == 0 {
+		return Spec{}, false
+	}
+
+	described, ok := c[strings.ToUpper(tokens[0])].(*SpecRouter)
+	if !ok {
+		return Spec{}, false
+	}
+
+	if sub, ok := described.next.(Command); ok && len(tokens) > 1 {
+		if spec, ok := sub.Spec(tokens[1:]); ok {
+			return spec, true
+		}
+	}
+
+	return described.spec, true
+}
+
+// Specs returns the spec of every command, with their subcommands, sorted by
+// name. Commands without a spec are not included.
+func (c Command) Specs() []Spec {
+	specs := make([]Spec, 0, len(c))
+
+	for _, next := range c {
+		described, ok := next.(*SpecRouter)
+		if !ok {
+			continue
+		}
+
+		spec := described.spec
+		if sub, ok := described.next.(Command); ok {
+			spec.Subcommands = sub.Specs()
+		}
+
+		specs = append(specs, spec)
+	}
+
+	slices.SortFunc(specs, func(a, b Spec) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return specs
+}
+
 var _ Router = Command{}
@@ -45,8 +45,8 @@ var _ = Describe("Command", func() {
 				Expect(found).To(BeFalse())
 
 				err := callback(tokens, writer)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(writer.String()).To(ContainSubstring("Unsupported command"))
+				Expect(err).To(MatchError("ERR unknown command 'HELLO', with args beginning with: "))
+				Expect(writer.String()).To(BeEmpty())
 			})
 		})
 
@@ -91,10 +91,34 @@ var _ = Describe("Command", func() {
 					writer := &bytes.Buffer{}
 
 					err := callback(tokens, writer)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(writer.String()).To(ContainSubstring("Unsupported command"))
+					Expect(err).To(MatchError("ERR unknown subcommand 'WORLD'. Try HELLO HELP."))
+					Expect(writer.String()).To(BeEmpty())
 				})
 			})
 		})
 	})
+
+	When("naming commands", func() {
+		It("uses the lower case command name", func() {
+			routes := router.Command{
+				"HELLO": router.StaticResponseRouter("Hello"),
+			}
+
+			Expect(routes.Name([]string{"hello", "world"})).To(Equal("hello"))
+			Expect(routes.Name([]string{"UNKNOWN"})).To(Equal("unknown"))
+			Expect(routes.Name(nil)).To(Equal(""))
+		})
+
+		It("includes known subcommands", func() {
+			routes := router.Command{
+				"HELLO": router.Command{
+					"WORLD": router.StaticResponseRouter("Hello"),
+				},
+			}
+
+			Expect(routes.Name([]string{"HELLO", "world"})).To(Equal("hello|world"))
+			Expect(routes.Name([]string{"HELLO", "there"})).To(Equal("hello"))
+			Expect(routes.Name([]string{"HELLO"})).To(Equal("hello"))
+		})
+	})
 })
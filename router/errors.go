@@ -0,0 +1,94 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Error is an error reply for the client. It starts with the error code,
+// like `ERR` or `WRONGTYPE`, and is rendered into RESP as `-<error>\r\n`.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+const (
+	ErrSyntax     Error = "ERR syntax error"
+	ErrWrongType  Error = "WRONGTYPE Operation against a key holding the wrong kind of value"
+	ErrNotInteger Error = "ERR value is not an integer or out of range"
+	ErrNotFloat   Error = "ERR value is not a valid float"
+)
+
+// WrongArity is the error for a command sent with the wrong number of
+// arguments. Subcommands are named like `client|setname`.
+func WrongArity(name string) Error {
+	return Error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+}
+
+// UnknownCommand is the error for a command that has no route.
+func UnknownCommand(command string, args []string) Error {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, "'"+arg+"'")
+	}
+
+	return Error(fmt.Sprintf(
+		"ERR unknown command '%s', with args beginning with: %s",
+		command,
+		strings.Join(quoted, " "),
+	))
+}
+
+// UnknownSubcommand is the error for a subcommand that has no route.
+func UnknownSubcommand(command, subcommand string) Error {
+	return Error(fmt.Sprintf(
+		"ERR unknown subcommand '%s'. Try %s HELP.",
+		subcommand,
+		strings.ToUpper(command),
+	))
+}
+
+// ReplyError writes the error returned by a callback as an error reply.
+// An Error is sent as is, any other error is reported with the `ERR` code.
+func ReplyError(w io.Writer, err error) error {
+	var reply Error
+	if !errors.As(err, &reply) {
+		reply = Error("ERR " + err.Error())
+	}
+
+	return WriteError(w, reply)
+}
+
+// WriteError writes the error reply. Line breaks would end the reply early,
+// so they are replaced with spaces.
+func WriteError(w io.Writer, err Error) error {
+	_, writeErr := io.WriteString(w, "-"+lineBreaks.Replace(err.Error())+"\r\n")
+	if writeErr != nil {
+		return fmt.Errorf("could not write error %q: %w", err, writeErr)
+	}
+
+	return nil
+}
+
+var lineBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+func errorCallback(err Error) Callback {
+	return func(_ []string, _ io.Writer) error {
+		return err
+	}
+}
+
+// lookupName returns the name of the command, joined with its subcommands
+// like `client|setname`, from the tokens given to the callback and the
+// remaining tokens given to the lookup.
+func lookupName(tokens []string, remaining int) string {
+	consumed := len(tokens) - remaining
+	if consumed <= 0 {
+		return ""
+	}
+
+	return strings.ToLower(strings.Join(tokens[:consumed], "|"))
+}
@@ -0,0 +1,35 @@
+package router_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/jtarchie/sqlettuce/router"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	It("replies with the error code", func() {
+		writer := &bytes.Buffer{}
+
+		err := router.ReplyError(writer, fmt.Errorf("could not execute: %w", router.ErrWrongType))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writer.String()).To(Equal("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"))
+	})
+
+	It("replies with ERR for other errors", func() {
+		writer := &bytes.Buffer{}
+
+		err := router.ReplyError(writer, errors.New("database is locked\nretry"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writer.String()).To(Equal("-ERR database is locked retry\r\n"))
+	})
+
+	It("formats the errors like redis", func() {
+		Expect(router.WrongArity("client|setname").Error()).To(Equal("ERR wrong number of arguments for 'client|setname' command"))
+		Expect(router.UnknownCommand("FOO", []string{"a"}).Error()).To(Equal("ERR unknown command 'FOO', with args beginning with: 'a'"))
+		Expect(router.UnknownSubcommand("client", "FOO").Error()).To(Equal("ERR unknown subcommand 'FOO'. Try CLIENT HELP."))
+	})
+})
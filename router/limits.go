@@ -1,6 +1,6 @@
 package router
 
-import "fmt"
+import "io"
 
 type TokensLimitsRouter struct {
 	min, max int
@@ -8,24 +8,14 @@ type TokensLimitsRouter struct {
 }
 
 func (t *TokensLimitsRouter) Lookup(tokens []string) (Callback, bool) {
-	if 0 < t.min && len(tokens) < t.min {
-		return staticResponseCallback(
-			fmt.Sprintf(
-				"expected minimum number of tokens %d received %d",
-				t.min,
-				len(tokens),
-			),
-		), false
-	}
+	if (0 < t.min && len(tokens) < t.min) || (0 < t.max && len(tokens) > t.max) {
+		remaining := len(tokens)
 
-	if 0 < t.max && len(tokens) > t.max {
-		return staticResponseCallback(
-			fmt.Sprintf(
-				"expected maximum number of tokens %d received %d",
-				t.max,
-				len(tokens),
-			),
-		), false
+		// the callback receives all the tokens, which includes the names of
+		// the command and subcommand for the error
+		return func(tokens []string, _ io.Writer) error {
+			return WrongArity(lookupName(tokens, remaining))
+		}, false
 	}
 
 	return t.callback, true
@@ -0,0 +1,46 @@
+package router
+
+// Middleware wraps the callback of a named command, for example to record
+// its latency. The name is the one reported by Command.Name.
+type Middleware func(name string, next Callback) Callback
+
+// Chain applies middleware to every callback found by the commands. It is
+// the boundary where every command that is executed can be observed.
+type Chain struct {
+	commands   Command
+	middleware []Middleware
+}
+
+// WithMiddleware returns a router that applies the middleware in order,
+// the first being the outermost.
+func WithMiddleware(commands Command, middleware ...Middleware) *Chain {
+	return &Chain{
+		commands:   commands,
+		middleware: middleware,
+	}
+}
+
+func (c *Chain) Lookup(tokens []string) (Callback, bool) {
+	callback, found := c.commands.Lookup(tokens)
+	if !found {
+		return callback, found
+	}
+
+	name := c.commands.Name(tokens)
+
+	for index := len(c.middleware) - 1; index >= 0; index-- {
+		callback = c.middleware[index](name, callback)
+	}
+
+	return callback, found
+}
+
+func (c *Chain) Name(tokens []string) string {
+	return c.commands.Name(tokens)
+}
+
+func (c *Chain) Spec(tokens []string) (Spec, bool) {
+	return c.commands.Spec(tokens)
+}
+
+var _ Router = &Chain{}
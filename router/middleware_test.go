@@ -0,0 +1,59 @@
+package router_test
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/jtarchie/sqlettuce/router"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Middleware", func() {
+	It("wraps found callbacks in order", func() {
+		var calls []string
+
+		middleware := func(prefix string) router.Middleware {
+			return func(name string, next router.Callback) router.Callback {
+				return func(tokens []string, writer io.Writer) error {
+					calls = append(calls, prefix+":"+name)
+
+					return next(tokens, writer)
+				}
+			}
+		}
+
+		routes := router.WithMiddleware(router.Command{
+			"HELLO": router.Command{
+				"WORLD": router.StaticResponseRouter("Hello"),
+			},
+		}, middleware("first"), middleware("second"))
+
+		tokens := []string{"hello", "world"}
+
+		callback, found := routes.Lookup(tokens)
+		Expect(found).To(BeTrue())
+		Expect(routes.Name(tokens)).To(Equal("hello|world"))
+
+		writer := &bytes.Buffer{}
+
+		err := callback(tokens, writer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writer.String()).To(Equal("Hello"))
+		Expect(calls).To(Equal([]string{"first:hello|world", "second:hello|world"}))
+	})
+
+	It("does not wrap unknown commands", func() {
+		called := false
+
+		routes := router.WithMiddleware(router.Command{}, func(_ string, next router.Callback) router.Callback {
+			called = true
+
+			return next
+		})
+
+		_, found := routes.Lookup([]string{"HELLO"})
+		Expect(found).To(BeFalse())
+		Expect(called).To(BeFalse())
+	})
+})
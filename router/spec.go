@@ -0,0 +1,93 @@
+package router
+
+import (
+	"slices"
+	"strings"
+)
+
+// Spec is the metadata of a command, as reported by COMMAND INFO and
+// COMMAND DOCS.
+type Spec struct {
+	// Name is lower case, with subcommands joined by a `|`,
+	// for example `client|list`.
+	Name string
+	// Arity is the number of tokens including the command and subcommand
+	// names. A negative arity is the minimum number of tokens.
+	Arity      int
+	Flags      []string
+	FirstKey   int
+	LastKey    int
+	Step       int
+	Categories []string
+	Summary    string
+	Since      string
+	Group      string
+	// Subcommands are filled in by Command.Specs.
+	Subcommands []Spec
+}
+
+// HasFlag reports if the command has a flag, like `write`.
+func (s Spec) HasFlag(flag string) bool {
+	return slices.Contains(s.Flags, flag)
+}
+
+// Keys returns the keys in the tokens of the command. A negative last key
+// is relative to the end of the tokens.
+func (s Spec) Keys(tokens []string) []string {
+	if s.FirstKey == 0 || s.Step == 0 {
+		return nil
+	}
+
+	last := s.LastKey
+	if last < 0 {
+		last += len(tokens)
+	}
+
+	var keys []string
+
+	for index := s.FirstKey; index <= last && index < len(tokens); index += s.Step {
+		keys = append(keys, tokens[index])
+	}
+
+	return keys
+}
+
+// ValidArity reports if the number of tokens, including the command and
+// subcommand names, matches the arity.
+func (s Spec) ValidArity(count int) bool {
+	if s.Arity < 0 {
+		return count >= -s.Arity
+	}
+
+	return count == s.Arity
+}
+
+// SpecRouter attaches a spec to a router and enforces its arity.
+type SpecRouter struct {
+	spec Spec
+	next Router
+}
+
+func WithSpec(spec Spec, next Router) *SpecRouter {
+	return &SpecRouter{
+		spec: spec,
+		next: next,
+	}
+}
+
+func (s *SpecRouter) Spec() Spec {
+	return s.spec
+}
+
+// Lookup receives the tokens after the command, and subcommand, names.
+func (s *SpecRouter) Lookup(tokens []string) (Callback, bool) {
+	consumed := strings.Count(s.spec.Name, "|") + 1
+
+	if !s.spec.ValidArity(len(tokens) + consumed) {
+		return errorCallback(WrongArity(s.spec.Name)), false
+	}
+
+	return s.next.Lookup(tokens)
+}
+
+var _ Router = &SpecRouter{}
@@ -0,0 +1,96 @@
+package router_test
+
+import (
+	"bytes"
+
+	"github.com/jtarchie/sqlettuce/router"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spec", func() {
+	routes := router.Command{
+		"GET": router.WithSpec(
+			router.Spec{Name: "get", Arity: 2, Flags: []string{"readonly"}, FirstKey: 1, LastKey: 1, Step: 1},
+			router.StaticResponseRouter("+value\r\n"),
+		),
+		"CLIENT": router.WithSpec(
+			router.Spec{Name: "client", Arity: -2},
+			router.Command{
+				"SETNAME": router.WithSpec(
+					router.Spec{Name: "client|setname", Arity: 3},
+					router.StaticResponseRouter("+OK\r\n"),
+				),
+			},
+		),
+		"PING": router.StaticResponseRouter("+PONG\r\n"),
+	}
+
+	It("enforces the arity", func() {
+		callback, found := routes.Lookup([]string{"GET", "key"})
+		Expect(found).To(BeTrue())
+
+		writer := &bytes.Buffer{}
+		Expect(callback(nil, writer)).To(Succeed())
+		Expect(writer.String()).To(Equal("+value\r\n"))
+
+		callback, found = routes.Lookup([]string{"GET", "key", "other"})
+		Expect(found).To(BeFalse())
+
+		writer.Reset()
+		Expect(callback(nil, writer)).To(MatchError("ERR wrong number of arguments for 'get' command"))
+		Expect(writer.String()).To(BeEmpty())
+
+		_, found = routes.Lookup([]string{"CLIENT", "SETNAME", "name"})
+		Expect(found).To(BeTrue())
+
+		callback, found = routes.Lookup([]string{"CLIENT", "SETNAME"})
+		Expect(found).To(BeFalse())
+
+		writer.Reset()
+		Expect(callback(nil, writer)).To(MatchError("ERR wrong number of arguments for 'client|setname' command"))
+		Expect(writer.String()).To(BeEmpty())
+
+		_, found = routes.Lookup([]string{"CLIENT"})
+		Expect(found).To(BeFalse())
+	})
+
+	It("finds the spec of commands and subcommands", func() {
+		spec, ok := routes.Spec([]string{"get", "key"})
+		Expect(ok).To(BeTrue())
+		Expect(spec.Name).To(Equal("get"))
+		Expect(spec.HasFlag("readonly")).To(BeTrue())
+		Expect(spec.HasFlag("write")).To(BeFalse())
+
+		spec, ok = routes.Spec([]string{"client", "setname", "name"})
+		Expect(ok).To(BeTrue())
+		Expect(spec.Name).To(Equal("client|setname"))
+
+		spec, ok = routes.Spec([]string{"client", "unknown"})
+		Expect(ok).To(BeTrue())
+		Expect(spec.Name).To(Equal("client"))
+
+		_, ok = routes.Spec([]string{"ping"})
+		Expect(ok).To(BeFalse())
+
+		Expect(routes.Name([]string{"client", "setname", "name"})).To(Equal("client|setname"))
+	})
+
+	It("lists the specs with subcommands", func() {
+		specs := routes.Specs()
+		Expect(specs).To(HaveLen(2))
+		Expect(specs[0].Name).To(Equal("client"))
+		Expect(specs[0].Subcommands).To(HaveLen(1))
+		Expect(specs[1].Name).To(Equal("get"))
+	})
+
+	It("returns the keys", func() {
+		spec := router.Spec{FirstKey: 1, LastKey: -1, Step: 2}
+		Expect(spec.Keys([]string{"MSET", "a", "1", "b", "2"})).To(Equal([]string{"a", "b"}))
+
+		spec = router.Spec{FirstKey: 1, LastKey: -1, Step: 1}
+		Expect(spec.Keys([]string{"DEL", "a", "b"})).To(Equal([]string{"a", "b"}))
+
+		Expect(router.Spec{}.Keys([]string{"PING"})).To(BeEmpty())
+	})
+})
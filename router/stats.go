@@ -0,0 +1,83 @@
+package router
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type CommandStats struct {
+	Calls    uint64
+	Failed   uint64
+	Duration time.Duration
+}
+
+// Stats records the calls and latency of each command.
+type Stats struct {
+	mutex    sync.RWMutex
+	commands map[string]*CommandStats
+	total    atomic.Uint64
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		commands: map[string]*CommandStats{},
+	}
+}
+
+func (s *Stats) Middleware(name string, next Callback) Callback {
+	return func(tokens []string, conn io.Writer) error {
+		started := time.Now()
+		err := next(tokens, conn)
+		s.Observe(name, time.Since(started), err)
+
+		return err
+	}
+}
+
+func (s *Stats) Observe(name string, duration time.Duration, err error) {
+	s.total.Add(1)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats, ok := s.commands[name]
+	if !ok {
+		stats = &CommandStats{}
+		s.commands[name] = stats
+	}
+
+	stats.Calls++
+	stats.Duration += duration
+
+	if err != nil {
+		stats.Failed++
+	}
+}
+
+// TotalCommands returns the number of commands processed.
+func (s *Stats) TotalCommands() uint64 {
+	return s.total.Load()
+}
+
+// Commands returns a copy of the stats for every command that was called.
+func (s *Stats) Commands() map[string]CommandStats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	commands := make(map[string]CommandStats, len(s.commands))
+	for name, stats := range s.commands {
+		commands[name] = *stats
+	}
+
+	return commands
+}
+
+func (s *Stats) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.commands = map[string]*CommandStats{}
+	s.total.Store(0)
+}
@@ -0,0 +1,42 @@
+package router_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/jtarchie/sqlettuce/router"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stats", func() {
+	It("records calls and failures per command", func() {
+		stats := router.NewStats()
+
+		routes := router.WithMiddleware(router.Command{
+			"OK": router.StaticResponseRouter("+OK\r\n"),
+			"FAIL": router.CallbackRouter(func(_ []string, _ io.Writer) error {
+				return errors.New("failed")
+			}),
+		}, stats.Middleware)
+
+		for _, command := range []string{"OK", "ok", "FAIL"} {
+			callback, _ := routes.Lookup([]string{command})
+			_ = callback([]string{command}, &bytes.Buffer{})
+		}
+
+		Expect(stats.TotalCommands()).To(BeEquivalentTo(3))
+
+		commands := stats.Commands()
+		Expect(commands).To(HaveLen(2))
+		Expect(commands["ok"].Calls).To(BeEquivalentTo(2))
+		Expect(commands["ok"].Failed).To(BeEquivalentTo(0))
+		Expect(commands["fail"].Calls).To(BeEquivalentTo(1))
+		Expect(commands["fail"].Failed).To(BeEquivalentTo(1))
+
+		stats.Reset()
+		Expect(stats.TotalCommands()).To(BeEquivalentTo(0))
+		Expect(stats.Commands()).To(BeEmpty())
+	})
+})
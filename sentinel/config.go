@@ -0,0 +1,98 @@
+package sentinel
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultDownAfter       = 30 * time.Second
+	DefaultFailoverTimeout = 3 * time.Minute
+)
+
+var (
+	ErrInvalidMonitor = errors.New("monitor must be a name, host, port and quorum")
+	ErrUnknownOption  = errors.New("unknown sentinel option")
+	ErrInvalidOption  = errors.New("option must be a positive number of milliseconds")
+)
+
+// Config is a primary monitored by the sentinel.
+type Config struct {
+	Name   string
+	Host   string
+	Port   int
+	Quorum int
+	// DownAfter is the time the primary must be unreachable for the
+	// sentinel to consider it down.
+	DownAfter time.Duration
+	// FailoverTimeout is the time before a failed failover is retried.
+	FailoverTimeout time.Duration
+}
+
+// ParseMonitor parses a primary to monitor in the format of the
+// `sentinel monitor` directive, like `mymaster 127.0.0.1 6379 2`.
+// The host is resolved to an IP, as sentinels identify servers by IP.
+func ParseMonitor(value string) (Config, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return Config{}, fmt.Errorf("%w: %q", ErrInvalidMonitor, value)
+	}
+
+	port, err := strconv.Atoi(fields[2])
+	if err != nil || port <= 0 || port > 65535 {
+		return Config{}, fmt.Errorf("%w: invalid port %q", ErrInvalidMonitor, fields[2])
+	}
+
+	quorum, err := strconv.Atoi(fields[3])
+	if err != nil || quorum <= 0 {
+		return Config{}, fmt.Errorf("%w: invalid quorum %q", ErrInvalidMonitor, fields[3])
+	}
+
+	ips, err := net.LookupHost(fields[1])
+	if err != nil || len(ips) == 0 {
+		return Config{}, fmt.Errorf("could not resolve %q: %w", fields[1], err)
+	}
+
+	return Config{
+		Name:            fields[0],
+		Host:            ips[0],
+		Port:            port,
+		Quorum:          quorum,
+		DownAfter:       DefaultDownAfter,
+		FailoverTimeout: DefaultFailoverTimeout,
+	}, nil
+}
+
+// SetOption changes an option in the format of the sentinel directives,
+// like `down-after-milliseconds 5000`.
+func (c *Config) SetOption(option, value string) error {
+	milliseconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || milliseconds <= 0 {
+		return fmt.Errorf("%w: %s %q", ErrInvalidOption, option, value)
+	}
+
+	switch strings.ToLower(option) {
+	case "down-after-milliseconds":
+		c.DownAfter = time.Duration(milliseconds) * time.Millisecond
+	case "failover-timeout":
+		c.FailoverTimeout = time.Duration(milliseconds) * time.Millisecond
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownOption, option)
+	}
+
+	return nil
+}
+
+// interval is how often the servers are checked, at least as often as
+// they can be considered down.
+func (c Config) interval() time.Duration {
+	return min(time.Second, c.DownAfter/2)
+}
+
+func (c Config) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
@@ -0,0 +1,46 @@
+package sentinel_test
+
+import (
+	"time"
+
+	"github.com/jtarchie/sqlettuce/sentinel"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	It("parses a primary to monitor", func() {
+		config, err := sentinel.ParseMonitor("mymaster 127.0.0.1 6379 2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Name).To(Equal("mymaster"))
+		Expect(config.Addr()).To(Equal("127.0.0.1:6379"))
+		Expect(config.Quorum).To(Equal(2))
+		Expect(config.DownAfter).To(Equal(sentinel.DefaultDownAfter))
+		Expect(config.FailoverTimeout).To(Equal(sentinel.DefaultFailoverTimeout))
+	})
+
+	It("rejects invalid primaries", func() {
+		_, err := sentinel.ParseMonitor("mymaster 127.0.0.1 6379")
+		Expect(err).To(MatchError(sentinel.ErrInvalidMonitor))
+
+		_, err = sentinel.ParseMonitor("mymaster 127.0.0.1 port 2")
+		Expect(err).To(MatchError(sentinel.ErrInvalidMonitor))
+
+		_, err = sentinel.ParseMonitor("mymaster 127.0.0.1 6379 0")
+		Expect(err).To(MatchError(sentinel.ErrInvalidMonitor))
+	})
+
+	It("sets options in milliseconds", func() {
+		config, err := sentinel.ParseMonitor("mymaster 127.0.0.1 6379 2")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.SetOption("down-after-milliseconds", "5000")).To(Succeed())
+		Expect(config.DownAfter).To(Equal(5 * time.Second))
+
+		Expect(config.SetOption("failover-timeout", "60000")).To(Succeed())
+		Expect(config.FailoverTimeout).To(Equal(time.Minute))
+
+		Expect(config.SetOption("parallel-syncs", "1")).To(MatchError(sentinel.ErrUnknownOption))
+		Expect(config.SetOption("failover-timeout", "-1")).To(MatchError(sentinel.ErrInvalidOption))
+	})
+})
@@ -0,0 +1,173 @@
+package sentinel
+
+import (
+	"cmp"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Instance is a server monitored by the sentinel.
+type Instance struct {
+	Host string
+	Port int
+
+	RunID      string
+	Role       string
+	MasterHost string
+	MasterPort int
+	LinkUp     bool
+	Offset     int64
+	LastPong   time.Time
+	Down       bool
+}
+
+func (i Instance) Addr() string {
+	return net.JoinHostPort(i.Host, strconv.Itoa(i.Port))
+}
+
+// Peer is another sentinel monitoring the same primary, discovered by its
+// hello messages.
+type Peer struct {
+	RunID       string
+	Host        string
+	Port        int
+	LastHello   time.Time
+	Leader      string
+	LeaderEpoch int64
+}
+
+func (p Peer) Addr() string {
+	return net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+}
+
+// State is a snapshot of a monitored primary, with its replicas and the
+// other sentinels monitoring it.
+type State struct {
+	Config
+	Primary     Instance
+	ODown       bool
+	ConfigEpoch int64
+	FailingOver bool
+	Leader      string
+	LeaderEpoch int64
+	Replicas    []Instance
+	Peers       []Peer
+}
+
+// master is the state of a monitored primary.
+type master struct {
+	Config
+
+	mutex       sync.RWMutex
+	primary     *Instance
+	replicas    map[string]*Instance
+	peers       map[string]*Peer
+	odown       bool
+	configEpoch int64
+	failingOver bool
+	// lastFailover is the last failover started, or voted for, which
+	// delays the next one by the failover timeout
+	lastFailover time.Time
+	leader       string
+	leaderEpoch  int64
+}
+
+func newMaster(config Config) *master {
+	return &master{
+		Config: config,
+		primary: &Instance{
+			Host:     config.Host,
+			Port:     config.Port,
+			Role:     "master",
+			LastPong: time.Now(),
+		},
+		replicas: map[string]*Instance{},
+		peers:    map[string]*Peer{},
+	}
+}
+
+func (m *master) state() State {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	state := State{
+		Config:      m.Config,
+		Primary:     *m.primary,
+		ODown:       m.odown,
+		ConfigEpoch: m.configEpoch,
+		FailingOver: m.failingOver,
+		Leader:      m.leader,
+		LeaderEpoch: m.leaderEpoch,
+		Replicas:    make([]Instance, 0, len(m.replicas)),
+		Peers:       make([]Peer, 0, len(m.peers)),
+	}
+
+	for _, replica := range m.replicas {
+		state.Replicas = append(state.Replicas, *replica)
+	}
+
+	for _, peer := range m.peers {
+		state.Peers = append(state.Peers, *peer)
+	}
+
+	slices.SortFunc(state.Replicas, func(a, b Instance) int {
+		return cmp.Compare(a.Addr(), b.Addr())
+	})
+	slices.SortFunc(state.Peers, func(a, b Peer) int {
+		return cmp.Compare(a.RunID, b.RunID)
+	})
+
+	return state
+}
+
+// instances returns the primary followed by the replicas.
+func (m *master) instances() []Instance {
+	state := m.state()
+
+	return append([]Instance{state.Primary}, state.Replicas...)
+}
+
+// vote elects the leader of the failover for an epoch. Only the first
+// sentinel asking in an epoch gets the vote.
+func (m *master) vote(runID string, epoch int64) (string, int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if epoch > m.leaderEpoch {
+		m.leader = runID
+		m.leaderEpoch = epoch
+		m.lastFailover = time.Now()
+	}
+
+	return m.leader, m.leaderEpoch
+}
+
+// switchPrimary makes the replica at the address the primary. The previous
+// primary is kept as a replica, to be reconfigured when it is back.
+func (m *master) switchPrimary(host string, port int, epoch int64) Instance {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	previous := *m.primary
+	previous.Role = ""
+
+	promoted := Instance{Host: host, Port: port}
+	if replica, ok := m.replicas[promoted.Addr()]; ok {
+		promoted = *replica
+		delete(m.replicas, promoted.Addr())
+	}
+
+	promoted.Role = "master"
+	promoted.LastPong = time.Now()
+	promoted.Down = false
+
+	m.primary = &promoted
+	m.replicas[previous.Addr()] = &previous
+	m.configEpoch = epoch
+	m.odown = false
+
+	return previous
+}
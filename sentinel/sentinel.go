@@ -0,0 +1,797 @@
+// Package sentinel monitors primaries and their replicas. When a quorum of
+// sentinels agree a primary is down, one of them is elected to promote a
+// replica, like redis sentinel.
+//
+// Sentinels discover the replicas from the INFO of the primary, and each
+// other from the hello messages they publish on the primary.
+package sentinel
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const helloChannel = "__sentinel__:hello"
+
+var (
+	ErrDuplicateMaster = errors.New("duplicate master name")
+	ErrNoSuchMaster    = errors.New("no such master with that name")
+	ErrNoGoodReplica   = errors.New("no suitable replica to promote")
+	ErrInProgress      = errors.New("failover already in progress")
+)
+
+// Publish sends an event to the clients of the sentinel, like
+// `+switch-master`.
+type Publish func(channel, message string)
+
+// Sentinel monitors primaries, failing over to their replicas.
+type Sentinel struct {
+	ID   string
+	Host string
+	Port int
+
+	publish Publish
+	epoch   atomic.Int64
+
+	mutex   sync.RWMutex
+	masters map[string]*master
+	clients map[string]*redis.Client
+}
+
+// New creates a sentinel announced to the other sentinels at host and port.
+func New(id string, host string, port int, publish Publish) *Sentinel {
+	return &Sentinel{
+		ID:      id,
+		Host:    host,
+		Port:    port,
+		publish: publish,
+		masters: map[string]*master{},
+		clients: map[string]*redis.Client{},
+	}
+}
+
+// Monitor adds a primary, monitored once the sentinel runs.
+func (s *Sentinel) Monitor(config Config) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.masters[config.Name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateMaster, config.Name)
+	}
+
+	s.masters[config.Name] = newMaster(config)
+
+	return nil
+}
+
+// Epoch is the current epoch, incremented for every failover.
+func (s *Sentinel) Epoch() int64 {
+	return s.epoch.Load()
+}
+
+func (s *Sentinel) master(name string) (*master, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	m, ok := s.masters[name]
+
+	return m, ok
+}
+
+// Master returns the state of a monitored primary.
+func (s *Sentinel) Master(name string) (State, bool) {
+	m, ok := s.master(name)
+	if !ok {
+		return State{}, false
+	}
+
+	return m.state(), true
+}
+
+// Masters returns the state of every monitored primary, sorted by name.
+func (s *Sentinel) Masters() []State {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	states := make([]State, 0, len(s.masters))
+	for _, m := range s.masters {
+		states = append(states, m.state())
+	}
+
+	slices.SortFunc(states, func(a, b State) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return states
+}
+
+// Run monitors the primaries until the context is done.
+func (s *Sentinel) Run(ctx context.Context) {
+	s.mutex.RLock()
+	masters := make([]*master, 0, len(s.masters))
+
+	for _, m := range s.masters {
+		masters = append(masters, m)
+	}
+	s.mutex.RUnlock()
+
+	var group sync.WaitGroup
+
+	for _, m := range masters {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			s.monitor(ctx, m)
+		}()
+	}
+
+	group.Wait()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for addr, client := range s.clients {
+		_ = client.Close()
+		delete(s.clients, addr)
+	}
+}
+
+// client returns a connection pool to a server, reused between checks.
+func (s *Sentinel) client(addr string) *redis.Client {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	client, ok := s.clients[addr]
+	if !ok {
+		client = redis.NewClient(&redis.Options{
+			Addr:       addr,
+			Protocol:   2,
+			MaxRetries: -1,
+			// a server that stops answering is noticed within a check
+			ContextTimeoutEnabled: true,
+		})
+		s.clients[addr] = client
+	}
+
+	return client
+}
+
+func (s *Sentinel) event(channel string, message string) {
+	slog.Info("sentinel event", slog.String("event", channel), slog.String("message", message))
+	s.publish(channel, message)
+}
+
+// describe formats an instance like in the events of redis sentinel.
+func describe(kind string, instance Instance, m *master) string {
+	if kind == "master" {
+		return fmt.Sprintf("master %s %s %d", m.Name, instance.Host, instance.Port)
+	}
+
+	primary := m.primarySnapshot()
+
+	return fmt.Sprintf(
+		"%s %s %s %d @ %s %s %d",
+		kind, instance.Addr(), instance.Host, instance.Port,
+		m.Name, primary.Host, primary.Port,
+	)
+}
+
+func (m *master) primarySnapshot() Instance {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return *m.primary
+}
+
+func (s *Sentinel) monitor(ctx context.Context, m *master) {
+	ticker := time.NewTicker(m.interval())
+	defer ticker.Stop()
+
+	// hello messages are received from every server, so the sentinels
+	// still hear each other when the primary changes
+	subscriptions := map[string]*redis.PubSub{}
+
+	defer func() {
+		for _, subscription := range subscriptions {
+			_ = subscription.Close()
+		}
+	}()
+
+	for {
+		for _, instance := range m.instances() {
+			if _, ok := subscriptions[instance.Addr()]; ok {
+				continue
+			}
+
+			subscription := s.client(instance.Addr()).Subscribe(ctx, helloChannel)
+			subscriptions[instance.Addr()] = subscription
+
+			go s.receiveHellos(subscription)
+		}
+
+		s.check(ctx, m)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sentinel) check(ctx context.Context, m *master) {
+	primary := m.primarySnapshot()
+	s.refresh(ctx, m, primary.Addr(), true)
+
+	for _, replica := range m.state().Replicas {
+		s.refresh(ctx, m, replica.Addr(), false)
+	}
+
+	s.sayHello(ctx, m)
+	s.checkDown(ctx, m)
+	s.reconfigure(ctx, m)
+
+	if m.state().ODown {
+		s.tryFailover(ctx, m)
+	}
+}
+
+// refresh pings a server and reads its replication INFO. The replicas of
+// the primary are discovered from its INFO.
+func (s *Sentinel) refresh(ctx context.Context, m *master, addr string, isPrimary bool) {
+	ctx, cancel := context.WithTimeout(ctx, m.interval())
+	defer cancel()
+
+	client := s.client(addr)
+
+	err := client.Ping(ctx).Err()
+	if err != nil {
+		return
+	}
+
+	info, err := client.Info(ctx, "server", "replication").Result()
+	if err != nil {
+		return
+	}
+
+	fields, replicas := parseInfo(info)
+
+	m.mutex.Lock()
+
+	instance := m.primary
+	if !isPrimary {
+		instance = m.replicas[addr]
+	}
+
+	if instance == nil || instance.Addr() != addr {
+		m.mutex.Unlock()
+
+		return
+	}
+
+	instance.LastPong = time.Now()
+	instance.RunID = fields["run_id"]
+	instance.Role = fields["role"]
+	instance.MasterHost = fields["master_host"]
+	instance.MasterPort, _ = strconv.Atoi(fields["master_port"])
+	instance.LinkUp = fields["master_link_status"] == "up"
+
+	if isPrimary {
+		instance.Offset, _ = strconv.ParseInt(fields["master_repl_offset"], 10, 64)
+	} else {
+		instance.Offset, _ = strconv.ParseInt(fields["slave_repl_offset"], 10, 64)
+	}
+
+	var discovered []Instance
+
+	if isPrimary && instance.Role == "master" {
+		for _, replica := range replicas {
+			if _, ok := m.replicas[replica.Addr()]; ok {
+				continue
+			}
+
+			replica.LastPong = time.Now()
+			m.replicas[replica.Addr()] = &replica
+			discovered = append(discovered, replica)
+		}
+	}
+
+	m.mutex.Unlock()
+
+	for _, replica := range discovered {
+		s.event("+slave", describe("slave", replica, m))
+	}
+}
+
+// parseInfo returns the fields of INFO, and the replicas it lists.
+func parseInfo(info string) (map[string]string, []Instance) {
+	fields := map[string]string{}
+
+	var replicas []Instance
+
+	for _, line := range strings.Split(info, "\r\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields[name] = value
+
+		if !strings.HasPrefix(name, "slave") || strings.Contains(name, "_") {
+			continue
+		}
+
+		replica := Instance{Role: "slave"}
+
+		for _, pair := range strings.Split(value, ",") {
+			key, value, _ := strings.Cut(pair, "=")
+
+			switch key {
+			case "ip":
+				replica.Host = value
+			case "port":
+				replica.Port, _ = strconv.Atoi(value)
+			case "offset":
+				replica.Offset, _ = strconv.ParseInt(value, 10, 64)
+			}
+		}
+
+		if replica.Host != "" && replica.Port > 0 {
+			replicas = append(replicas, replica)
+		}
+	}
+
+	return fields, replicas
+}
+
+// sayHello announces the sentinel, and its view of the primary, to the other
+// sentinels through every reachable server.
+func (s *Sentinel) sayHello(ctx context.Context, m *master) {
+	m.mutex.RLock()
+	message := strings.Join([]string{
+		s.Host,
+		strconv.Itoa(s.Port),
+		s.ID,
+		strconv.FormatInt(s.epoch.Load(), 10),
+		m.Name,
+		m.primary.Host,
+		strconv.Itoa(m.primary.Port),
+		strconv.FormatInt(m.configEpoch, 10),
+	}, ",")
+	m.mutex.RUnlock()
+
+	for _, instance := range m.instances() {
+		if instance.Down {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, m.interval())
+		_ = s.client(instance.Addr()).Publish(ctx, helloChannel, message).Err()
+
+		cancel()
+	}
+}
+
+func (s *Sentinel) receiveHellos(hello *redis.PubSub) {
+	for message := range hello.Channel() {
+		s.hello(message.Payload)
+	}
+}
+
+// hello records another sentinel. A primary with a newer config epoch is the
+// result of a failover by the other sentinel, so it is switched to.
+func (s *Sentinel) hello(payload string) {
+	fields := strings.Split(payload, ",")
+	if len(fields) != 8 || fields[2] == s.ID {
+		return
+	}
+
+	m, ok := s.master(fields[4])
+	if !ok {
+		return
+	}
+
+	port, _ := strconv.Atoi(fields[1])
+	epoch, _ := strconv.ParseInt(fields[3], 10, 64)
+	primaryPort, _ := strconv.Atoi(fields[6])
+	configEpoch, _ := strconv.ParseInt(fields[7], 10, 64)
+
+	s.updateEpoch(epoch)
+
+	m.mutex.Lock()
+
+	peer, known := m.peers[fields[2]]
+	if !known {
+		peer = &Peer{RunID: fields[2]}
+		m.peers[peer.RunID] = peer
+	}
+
+	peer.Host, peer.Port, peer.LastHello = fields[0], port, time.Now()
+
+	previous := *m.primary
+	switched := configEpoch > m.configEpoch &&
+		(fields[5] != previous.Host || primaryPort != previous.Port)
+
+	m.mutex.Unlock()
+
+	if !known {
+		s.event("+sentinel", fmt.Sprintf("sentinel %s %s %d @ %s %s %d",
+			peer.RunID, peer.Host, peer.Port, m.Name, previous.Host, previous.Port))
+	}
+
+	if switched {
+		m.switchPrimary(fields[5], primaryPort, configEpoch)
+
+		s.event("+config-update-from", fmt.Sprintf("sentinel %s %s %d @ %s %s %d",
+			peer.RunID, peer.Host, peer.Port, m.Name, previous.Host, previous.Port))
+		s.event("+switch-master", fmt.Sprintf("%s %s %d %s %d",
+			m.Name, previous.Host, previous.Port, fields[5], primaryPort))
+	}
+}
+
+func (s *Sentinel) updateEpoch(epoch int64) {
+	for {
+		current := s.epoch.Load()
+		if epoch <= current {
+			return
+		}
+
+		if s.epoch.CompareAndSwap(current, epoch) {
+			s.event("+new-epoch", strconv.FormatInt(epoch, 10))
+
+			return
+		}
+	}
+}
+
+// checkDown flags the servers that didn't reply within the down after time.
+// The primary is objectively down when a quorum of sentinels agree.
+func (s *Sentinel) checkDown(ctx context.Context, m *master) {
+	now := time.Now()
+
+	type change struct {
+		kind     string
+		instance Instance
+		down     bool
+	}
+
+	var changes []change
+
+	m.mutex.Lock()
+
+	for kind, instances := range map[string][]*Instance{
+		"master": {m.primary},
+		"slave":  mapValues(m.replicas),
+	} {
+		for _, instance := range instances {
+			down := now.Sub(instance.LastPong) > m.DownAfter
+			if down != instance.Down {
+				instance.Down = down
+				changes = append(changes, change{kind: kind, instance: *instance, down: down})
+			}
+		}
+	}
+
+	sdown := m.primary.Down
+	wasODown := m.odown
+	primary := *m.primary
+	m.mutex.Unlock()
+
+	for _, change := range changes {
+		if change.down {
+			s.event("+sdown", describe(change.kind, change.instance, m))
+		} else {
+			s.event("-sdown", describe(change.kind, change.instance, m))
+		}
+	}
+
+	votes := 0
+
+	if sdown {
+		votes = 1
+
+		for _, reply := range s.askPeers(ctx, m, primary, s.epoch.Load(), "*") {
+			if reply.down {
+				votes++
+			}
+		}
+	}
+
+	odown := votes >= m.Quorum
+
+	m.mutex.Lock()
+	m.odown = odown
+	m.mutex.Unlock()
+
+	switch {
+	case odown && !wasODown:
+		s.event("+odown", fmt.Sprintf("%s #quorum %d/%d", describe("master", primary, m), votes, m.Quorum))
+	case !odown && wasODown:
+		s.event("-odown", describe("master", primary, m))
+	}
+}
+
+func mapValues[K comparable, V any](values map[K]V) []V {
+	list := make([]V, 0, len(values))
+	for _, value := range values {
+		list = append(list, value)
+	}
+
+	return list
+}
+
+type reply struct {
+	down        bool
+	leader      string
+	leaderEpoch int64
+}
+
+// askPeers asks the other sentinels if the primary is down. With a run ID,
+// they also vote for it as the leader of the failover of the epoch.
+func (s *Sentinel) askPeers(ctx context.Context, m *master, primary Instance, epoch int64, runID string) []reply {
+	peers := m.state().Peers
+	replies := make([]reply, 0, len(peers))
+
+	for _, peer := range peers {
+		ctx, cancel := context.WithTimeout(ctx, m.interval())
+
+		values, err := s.client(peer.Addr()).Do(
+			ctx, "SENTINEL", "IS-MASTER-DOWN-BY-ADDR",
+			primary.Host, primary.Port, epoch, runID,
+		).Slice()
+
+		cancel()
+
+		if err != nil || len(values) != 3 {
+			continue
+		}
+
+		down, _ := values[0].(int64)
+		leader, _ := values[1].(string)
+		leaderEpoch, _ := values[2].(int64)
+
+		replies = append(replies, reply{down: down == 1, leader: leader, leaderEpoch: leaderEpoch})
+
+		if runID != "*" {
+			m.mutex.Lock()
+			if known, ok := m.peers[peer.RunID]; ok {
+				known.Leader, known.LeaderEpoch = leader, leaderEpoch
+			}
+			m.mutex.Unlock()
+		}
+	}
+
+	return replies
+}
+
+// IsMasterDown replies to another sentinel asking if the primary at the
+// address is down. With a run ID other than `*`, the sentinel votes for it
+// as the leader of the failover, once per epoch.
+func (s *Sentinel) IsMasterDown(host string, port int, epoch int64, runID string) (bool, string, int64) {
+	var found *master
+
+	s.mutex.RLock()
+	for _, m := range s.masters {
+		if primary := m.primarySnapshot(); primary.Host == host && primary.Port == port {
+			found = m
+		}
+	}
+	s.mutex.RUnlock()
+
+	if found == nil {
+		return false, "*", 0
+	}
+
+	down := found.primarySnapshot().Down
+
+	if runID == "*" {
+		return down, "*", 0
+	}
+
+	s.updateEpoch(epoch)
+	leader, leaderEpoch := found.vote(runID, epoch)
+
+	if leader == runID && leaderEpoch == epoch {
+		s.event("+vote-for-leader", fmt.Sprintf("%s %d", runID, epoch))
+	}
+
+	return down, leader, leaderEpoch
+}
+
+// tryFailover starts an election for the failover of a primary that is
+// objectively down. A majority of the sentinels, and at least the quorum,
+// must vote for this sentinel.
+func (s *Sentinel) tryFailover(ctx context.Context, m *master) {
+	m.mutex.Lock()
+
+	if m.failingOver || time.Since(m.lastFailover) < 2*m.FailoverTimeout {
+		m.mutex.Unlock()
+
+		return
+	}
+
+	m.lastFailover = time.Now()
+	primary := *m.primary
+	sentinels := len(m.peers) + 1
+	m.mutex.Unlock()
+
+	epoch := s.epoch.Add(1)
+	s.event("+new-epoch", strconv.FormatInt(epoch, 10))
+	s.event("+try-failover", describe("master", primary, m))
+
+	votes := 0
+
+	if leader, leaderEpoch := m.vote(s.ID, epoch); leader == s.ID && leaderEpoch == epoch {
+		votes++
+	}
+
+	for _, reply := range s.askPeers(ctx, m, primary, epoch, s.ID) {
+		if reply.leader == s.ID && reply.leaderEpoch == epoch {
+			votes++
+		}
+	}
+
+	if votes < max(m.Quorum, sentinels/2+1) {
+		s.event("-failover-abort-not-elected", describe("master", primary, m))
+
+		return
+	}
+
+	s.event("+elected-leader", describe("master", primary, m))
+
+	err := s.failover(ctx, m, epoch)
+	if err != nil {
+		slog.Error("failover failed", slog.String("master", m.Name), slog.String("error", err.Error()))
+	}
+}
+
+// Failover promotes a replica without the agreement of the other sentinels,
+// like SENTINEL FAILOVER.
+func (s *Sentinel) Failover(ctx context.Context, name string) error {
+	m, ok := s.master(name)
+	if !ok {
+		return ErrNoSuchMaster
+	}
+
+	m.mutex.Lock()
+	m.lastFailover = time.Now()
+	m.mutex.Unlock()
+
+	epoch := s.epoch.Add(1)
+	s.event("+new-epoch", strconv.FormatInt(epoch, 10))
+
+	return s.failover(ctx, m, epoch)
+}
+
+// failover promotes the replica with the most recent data, reconfigures the
+// other replicas to replicate it, and announces the new primary.
+func (s *Sentinel) failover(ctx context.Context, m *master, epoch int64) error {
+	m.mutex.Lock()
+
+	if m.failingOver {
+		m.mutex.Unlock()
+
+		return ErrInProgress
+	}
+
+	m.failingOver = true
+	m.mutex.Unlock()
+
+	defer func() {
+		m.mutex.Lock()
+		m.failingOver = false
+		m.mutex.Unlock()
+	}()
+
+	state := m.state()
+
+	promoted, ok := selectReplica(state.Replicas)
+	if !ok {
+		s.event("-failover-abort-no-good-slave", describe("master", state.Primary, m))
+
+		return ErrNoGoodReplica
+	}
+
+	s.event("+selected-slave", describe("slave", promoted, m))
+
+	ctx, cancel := context.WithTimeout(ctx, m.FailoverTimeout)
+	defer cancel()
+
+	err := s.client(promoted.Addr()).Do(ctx, "REPLICAOF", "NO", "ONE").Err()
+	if err != nil {
+		s.event("-failover-abort-slave-timeout", describe("master", state.Primary, m))
+
+		return fmt.Errorf("could not promote %s: %w", promoted.Addr(), err)
+	}
+
+	s.event("+promoted-slave", describe("slave", promoted, m))
+	s.event("+failover-state-reconf-slaves", describe("master", state.Primary, m))
+
+	for _, replica := range state.Replicas {
+		if replica.Addr() == promoted.Addr() || replica.Down {
+			continue
+		}
+
+		err = s.client(replica.Addr()).Do(ctx, "REPLICAOF", promoted.Host, promoted.Port).Err()
+		if err != nil {
+			continue
+		}
+
+		s.event("+slave-reconf-sent", describe("slave", replica, m))
+		s.event("+slave-reconf-done", describe("slave", replica, m))
+	}
+
+	previous := m.switchPrimary(promoted.Host, promoted.Port, epoch)
+
+	s.event("+failover-end", describe("master", previous, m))
+	s.event("+switch-master", fmt.Sprintf("%s %s %d %s %d", m.Name, previous.Host, previous.Port, promoted.Host, promoted.Port))
+
+	return nil
+}
+
+// selectReplica picks the reachable replica with the largest offset.
+func selectReplica(replicas []Instance) (Instance, bool) {
+	var candidates []Instance
+
+	for _, replica := range replicas {
+		if !replica.Down && replica.Role == "slave" {
+			candidates = append(candidates, replica)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Instance{}, false
+	}
+
+	slices.SortFunc(candidates, func(a, b Instance) int {
+		return cmp.Or(cmp.Compare(b.Offset, a.Offset), cmp.Compare(a.Addr(), b.Addr()))
+	})
+
+	return candidates[0], true
+}
+
+// reconfigure turns servers that are back as primaries, like the primary
+// before a failover, into replicas of the current primary. Nothing is
+// changed while the primary is down, as a replica reporting as a primary
+// may have been promoted by another sentinel.
+func (s *Sentinel) reconfigure(ctx context.Context, m *master) {
+	state := m.state()
+	if state.Primary.Down {
+		return
+	}
+
+	for _, replica := range state.Replicas {
+		if replica.Down || replica.Role != "master" {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, m.interval())
+
+		err := s.client(replica.Addr()).Do(ctx, "REPLICAOF", state.Primary.Host, state.Primary.Port).Err()
+
+		cancel()
+
+		if err == nil {
+			s.event("+convert-to-slave", describe("slave", replica, m))
+		}
+	}
+}
+
+// Addr is the address the sentinel is announced at.
+func (s *Sentinel) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
@@ -0,0 +1,77 @@
+package sentinel_test
+
+import (
+	"context"
+
+	"github.com/jtarchie/sqlettuce/sentinel"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sentinel", func() {
+	var (
+		monitor *sentinel.Sentinel
+		events  []string
+	)
+
+	BeforeEach(func() {
+		events = nil
+		monitor = sentinel.New("myid", "127.0.0.1", 26379, func(channel, message string) {
+			events = append(events, channel+" "+message)
+		})
+
+		config, err := sentinel.ParseMonitor("mymaster 127.0.0.1 6379 2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(monitor.Monitor(config)).To(Succeed())
+	})
+
+	It("monitors primaries by name", func() {
+		config, err := sentinel.ParseMonitor("mymaster 127.0.0.1 6380 1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(monitor.Monitor(config)).To(MatchError(sentinel.ErrDuplicateMaster))
+
+		state, ok := monitor.Master("mymaster")
+		Expect(ok).To(BeTrue())
+		Expect(state.Primary.Addr()).To(Equal("127.0.0.1:6379"))
+		Expect(state.Replicas).To(BeEmpty())
+
+		_, ok = monitor.Master("unknown")
+		Expect(ok).To(BeFalse())
+
+		Expect(monitor.Masters()).To(HaveLen(1))
+	})
+
+	It("votes for one leader per epoch", func() {
+		down, leader, epoch := monitor.IsMasterDown("127.0.0.1", 6379, 1, "*")
+		Expect(down).To(BeFalse())
+		Expect(leader).To(Equal("*"))
+		Expect(epoch).To(BeEquivalentTo(0))
+
+		_, leader, epoch = monitor.IsMasterDown("127.0.0.1", 6379, 1, "first")
+		Expect(leader).To(Equal("first"))
+		Expect(epoch).To(BeEquivalentTo(1))
+
+		_, leader, epoch = monitor.IsMasterDown("127.0.0.1", 6379, 1, "second")
+		Expect(leader).To(Equal("first"))
+		Expect(epoch).To(BeEquivalentTo(1))
+
+		_, leader, epoch = monitor.IsMasterDown("127.0.0.1", 6379, 2, "second")
+		Expect(leader).To(Equal("second"))
+		Expect(epoch).To(BeEquivalentTo(2))
+
+		Expect(monitor.Epoch()).To(BeEquivalentTo(2))
+		Expect(events).To(ContainElement("+vote-for-leader second 2"))
+
+		_, leader, _ = monitor.IsMasterDown("127.0.0.1", 6380, 3, "second")
+		Expect(leader).To(Equal("*"))
+	})
+
+	It("can't fail over without replicas", func() {
+		err := monitor.Failover(context.Background(), "mymaster")
+		Expect(err).To(MatchError(sentinel.ErrNoGoodReplica))
+		Expect(events).To(ContainElement("-failover-abort-no-good-slave master mymaster 127.0.0.1 6379"))
+
+		err = monitor.Failover(context.Background(), "unknown")
+		Expect(err).To(MatchError(sentinel.ErrNoSuchMaster))
+	})
+})
@@ -0,0 +1,143 @@
+package slowlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	maxArguments      = 32
+	maxArgumentLength = 128
+)
+
+// Entry is a command that took longer than the threshold to execute.
+type Entry struct {
+	ID         uint64
+	Time       time.Time
+	Duration   time.Duration
+	Tokens     []string
+	Addr       string
+	ClientName string
+}
+
+// Log keeps the most recent slow commands, newest first.
+type Log struct {
+	mutex     sync.RWMutex
+	entries   []Entry
+	nextID    uint64
+	threshold time.Duration
+	maxLen    int
+}
+
+// New creates a log of commands slower than the threshold. A negative
+// threshold disables the log and a zero threshold logs every command.
+func New(threshold time.Duration, maxLen int) *Log {
+	return &Log{
+		threshold: threshold,
+		maxLen:    maxLen,
+	}
+}
+
+// Record adds the command to the log when its duration is over the threshold.
+// Like redis, long argument lists and values are truncated.
+func (l *Log) Record(entry Entry) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.threshold < 0 || entry.Duration < l.threshold || l.maxLen <= 0 {
+		return
+	}
+
+	entry.ID = l.nextID
+	entry.Tokens = truncate(entry.Tokens)
+	l.nextID++
+
+	l.entries = append([]Entry{entry}, l.entries...)
+	if len(l.entries) > l.maxLen {
+		l.entries = l.entries[:l.maxLen]
+	}
+}
+
+// Get returns up to count of the newest entries. A negative count returns
+// every entry.
+func (l *Log) Get(count int) []Entry {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	if count < 0 || count > len(l.entries) {
+		count = len(l.entries)
+	}
+
+	entries := make([]Entry, count)
+	copy(entries, l.entries)
+
+	return entries
+}
+
+func (l *Log) Len() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return len(l.entries)
+}
+
+func (l *Log) Reset() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = nil
+}
+
+func (l *Log) Threshold() time.Duration {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return l.threshold
+}
+
+func (l *Log) SetThreshold(threshold time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.threshold = threshold
+}
+
+func (l *Log) MaxLen() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return l.maxLen
+}
+
+// SetMaxLen changes the length of the log, dropping the oldest entries.
+func (l *Log) SetMaxLen(maxLen int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.maxLen = maxLen
+	if len(l.entries) > maxLen {
+		l.entries = l.entries[:max(maxLen, 0)]
+	}
+}
+
+func truncate(tokens []string) []string {
+	count := min(len(tokens), maxArguments)
+	truncated := make([]string, 0, count)
+
+	for index, token := range tokens[:count] {
+		if index == maxArguments-1 && len(tokens) > maxArguments {
+			truncated = append(truncated, fmt.Sprintf("... (%d more arguments)", len(tokens)-maxArguments+1))
+
+			break
+		}
+
+		if len(token) > maxArgumentLength {
+			token = fmt.Sprintf("%s... (%d more bytes)", token[:maxArgumentLength], len(token)-maxArgumentLength)
+		}
+
+		truncated = append(truncated, token)
+	}
+
+	return truncated
+}
@@ -0,0 +1,72 @@
+package slowlog_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jtarchie/sqlettuce/slowlog"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log", func() {
+	It("records commands over the threshold, newest first", func() {
+		log := slowlog.New(time.Millisecond, 10)
+
+		log.Record(slowlog.Entry{Duration: time.Microsecond, Tokens: []string{"GET", "fast"}})
+		log.Record(slowlog.Entry{Duration: time.Second, Tokens: []string{"GET", "slow"}, Addr: "127.0.0.1:1234"})
+		log.Record(slowlog.Entry{Duration: time.Second, Tokens: []string{"SET", "slow", "value"}})
+
+		Expect(log.Len()).To(Equal(2))
+
+		entries := log.Get(-1)
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].ID).To(BeEquivalentTo(1))
+		Expect(entries[0].Tokens).To(Equal([]string{"SET", "slow", "value"}))
+		Expect(entries[1].ID).To(BeEquivalentTo(0))
+		Expect(entries[1].Addr).To(Equal("127.0.0.1:1234"))
+
+		Expect(log.Get(1)).To(HaveLen(1))
+
+		log.Reset()
+		Expect(log.Len()).To(Equal(0))
+	})
+
+	It("is disabled with a negative threshold", func() {
+		log := slowlog.New(-1, 10)
+		log.Record(slowlog.Entry{Duration: time.Hour, Tokens: []string{"GET"}})
+
+		Expect(log.Len()).To(Equal(0))
+	})
+
+	It("keeps at most the max length", func() {
+		log := slowlog.New(0, 2)
+
+		for range 5 {
+			log.Record(slowlog.Entry{Tokens: []string{"PING"}})
+		}
+
+		Expect(log.Len()).To(Equal(2))
+		Expect(log.Get(-1)[0].ID).To(BeEquivalentTo(4))
+
+		log.SetMaxLen(1)
+		Expect(log.Len()).To(Equal(1))
+		Expect(log.MaxLen()).To(Equal(1))
+	})
+
+	It("truncates long arguments", func() {
+		log := slowlog.New(0, 1)
+
+		tokens := []string{"MSET", strings.Repeat("a", 130)}
+		for range 40 {
+			tokens = append(tokens, "b")
+		}
+
+		log.Record(slowlog.Entry{Tokens: tokens})
+
+		recorded := log.Get(1)[0].Tokens
+		Expect(recorded).To(HaveLen(32))
+		Expect(recorded[1]).To(Equal(strings.Repeat("a", 128) + "... (2 more bytes)"))
+		Expect(recorded[31]).To(Equal("... (11 more arguments)"))
+	})
+})
@@ -1,12 +1,25 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/alecthomas/kong"
 	"github.com/antelman107/net-wait-go/wait"
+	"github.com/jtarchie/sqlettuce/db"
+	"github.com/jtarchie/sqlettuce/rdb"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/phayes/freeport"
@@ -19,32 +32,35 @@ func TestSqlettuce(t *testing.T) {
 }
 
 var _ = Describe("CLI", func() {
-	var client *redis.Client
+	var (
+		client      *redis.Client
+		port        int
+		metricsPort int
+	)
 
 	BeforeEach(func() {
-		port, err := freeport.GetFreePort()
+		var err error
+
+		port, err = freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		metricsPort, err = freeport.GetFreePort()
 		Expect(err).NotTo(HaveOccurred())
 
 		cli := &CLI{
-			Port:     uint(port),
-			Filename: "sqlite://:memory:?cache=shared&mode=memory",
-			Workers:  1,
-		}
-		go func() {
-			defer GinkgoRecover()
+			Port:        uint(port),
+			Filename:    fmt.Sprintf("sqlite://cli-%d?cache=shared&mode=memory", port),
+			Workers:     10,
+			MetricsAddr: fmt.Sprintf("localhost:%d", metricsPort),
 
-			err := cli.Run()
-			Expect(err).NotTo(HaveOccurred())
-		}()
+			SlowlogLogSlowerThan:    0,
+			SlowlogMaxLen:           128,
+			LatencyMonitorThreshold: 0,
 
-		ok := wait.New().Do([]string{fmt.Sprintf("localhost:%d", port)})
-		Expect(ok).To(BeTrue())
+			GroupCommitSize: 128,
+		}
 
-		client = redis.NewClient(&redis.Options{
-			Addr:     fmt.Sprintf("localhost:%d", port),
-			Password: "", // no password set
-			DB:       0,  // use default DB
-		})
+		client = startCLI(cli)
 	})
 
 	It("can send PING", func() {
@@ -132,7 +148,7 @@ var _ = Describe("CLI", func() {
 		set(client, "mykey", "234293482390480948029348230948")
 
 		value, err = client.Decr(context.Background(), "mykey").Result()
-		Expect(err).To(HaveOccurred())
+		Expect(err).To(MatchError("ERR value is not an integer or out of range"))
 		Expect(value).To(BeEquivalentTo(0))
 
 		value, err = client.Decr(context.Background(), "newkey").Result()
@@ -154,7 +170,7 @@ var _ = Describe("CLI", func() {
 		set(client, "mykey", "234293482390480948029348230948")
 
 		value, err = client.DecrBy(context.Background(), "mykey", 3).Result()
-		Expect(err).To(HaveOccurred())
+		Expect(err).To(MatchError("ERR value is not an integer or out of range"))
 		Expect(value).To(BeEquivalentTo(0))
 
 		value, err = client.DecrBy(context.Background(), "newkey", 3).Result()
@@ -176,7 +192,7 @@ var _ = Describe("CLI", func() {
 		set(client, "mykey", "234293482390480948029348230948")
 
 		value, err = client.Incr(context.Background(), "mykey").Result()
-		Expect(err).To(HaveOccurred())
+		Expect(err).To(MatchError("ERR value is not an integer or out of range"))
 		Expect(value).To(BeEquivalentTo(0))
 
 		value, err = client.Incr(context.Background(), "newkey").Result()
@@ -198,7 +214,7 @@ var _ = Describe("CLI", func() {
 		set(client, "mykey", "234293482390480948029348230948")
 
 		value, err = client.IncrBy(context.Background(), "mykey", 1).Result()
-		Expect(err).To(HaveOccurred())
+		Expect(err).To(MatchError("ERR value is not an integer or out of range"))
 		Expect(value).To(BeEquivalentTo(0))
 
 		value, err = client.IncrBy(context.Background(), "newkey", 3).Result()
@@ -372,27 +388,1684 @@ var _ = Describe("CLI", func() {
 		Expect(values).To(BeEmpty())
 	})
 
-	It("had deprecated commands", func() {
-		_, err := client.RPopLPush(context.TODO(), "mylist", "myotherlist").Result()
-		Expect(err).To(MatchError(ContainSubstring("Deprecated")))
+	It("replies with redis errors", func() {
+		set(client, "mykey", "value")
 
-		_, err = client.BRPopLPush(context.TODO(), "mylist", "myotherlist", time.Hour).Result()
-		Expect(err).To(MatchError(ContainSubstring("Deprecated")))
+		_, err := client.LRange(context.TODO(), "mykey", 0, -1).Result()
+		Expect(err).To(MatchError("WRONGTYPE Operation against a key holding the wrong kind of value"))
 
-		_, err = client.GetSet(context.TODO(), "mylist", "myotherlist").Result()
-		Expect(err).To(MatchError(ContainSubstring("Deprecated")))
+		_, err = client.IncrByFloat(context.TODO(), "mykey", 1).Result()
+		Expect(err).To(MatchError("ERR value is not a valid float"))
 
-		_, err = client.SetEx(context.TODO(), "mylist", "myotherlist", time.Hour).Result()
-		Expect(err).To(MatchError(ContainSubstring("Deprecated")))
+		_, err = client.Do(context.TODO(), "UNKNOWN", "a", "b").Result()
+		Expect(err).To(MatchError("ERR unknown command 'UNKNOWN', with args beginning with: 'a' 'b'"))
+
+		_, err = client.Do(context.TODO(), "CLIENT", "UNKNOWN").Result()
+		Expect(err).To(MatchError("ERR unknown subcommand 'UNKNOWN'. Try CLIENT HELP."))
+
+		_, err = client.Do(context.TODO(), "MSET", "a", "1", "b").Result()
+		Expect(err).To(MatchError("ERR wrong number of arguments for 'mset' command"))
+
+		_, err = client.Do(context.TODO(), "SLOWLOG", "GET", "1", "2").Result()
+		Expect(err).To(MatchError("ERR syntax error"))
+
+		get(client, "mykey", "value")
 	})
-})
 
-func set(client *redis.Client, key, value string) {
+	It("keeps the connection open after a command fails", func() {
+		conn := client.Conn()
+		defer conn.Close()
+
+		id, err := conn.ClientID(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = conn.Set(context.TODO(), "mykey", "value", 0).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = conn.Incr(context.TODO(), "mykey").Result()
+		Expect(err).To(MatchError("ERR value is not an integer or out of range"))
+
+		_, err = conn.LRange(context.TODO(), "mykey", 0, -1).Result()
+		Expect(err).To(MatchError(ContainSubstring("WRONGTYPE")))
+
+		otherID, err := conn.ClientID(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(otherID).To(Equal(id))
+	})
+
+	It("can send CLIENT SETNAME and GETNAME", func() {
+		conn := client.Conn()
+		defer conn.Close()
+
+		value, err := conn.ClientGetName(context.TODO()).Result()
+		Expect(err).To(MatchError(redis.Nil))
+		Expect(value).To(Equal(""))
+
+		ok, err := conn.ClientSetName(context.TODO(), "worker").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		value, err = conn.ClientGetName(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("worker"))
+
+		_, err = conn.ClientSetName(context.TODO(), "has spaces").Result()
+		Expect(err).To(MatchError(ContainSubstring("cannot contain spaces")))
+	})
+
+	It("can send CLIENT ID and INFO", func() {
+		conn := client.Conn()
+		defer conn.Close()
+
+		id, err := conn.ClientID(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(BeNumerically(">", 0))
+
+		info, err := conn.ClientInfo(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ID).To(Equal(id))
+		Expect(info.LastCmd).To(Equal("client|info"))
+		Expect(info.LibName).To(HavePrefix("go-redis"))
+		Expect(info.LibVer).NotTo(BeEmpty())
+	})
+
+	It("can send CLIENT LIST and KILL", func() {
+		conn := client.Conn()
+		defer conn.Close()
+
+		other := client.Conn()
+		defer other.Close()
+
+		id, err := other.ClientID(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+
+		list, err := conn.ClientList(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list).To(ContainSubstring(fmt.Sprintf("id=%d ", id)))
+
+		killed, err := conn.ClientKillByFilter(context.TODO(), "ID", fmt.Sprintf("%d", id)).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(killed).To(BeEquivalentTo(1))
+
+		Eventually(func() string {
+			return conn.ClientList(context.TODO()).Val()
+		}).ShouldNot(ContainSubstring(fmt.Sprintf("id=%d ", id)))
+
+		err = conn.ClientKill(context.TODO(), "127.0.0.1:1").Err()
+		Expect(err).To(MatchError(ContainSubstring("No such client")))
+	})
+
+	It("can send CLIENT LIST and KILL by type", func() {
+		subscriber := client.Subscribe(context.TODO(), "channel")
+		defer subscriber.Close()
+
+		_, err := subscriber.Receive(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+
+		list, err := client.Do(context.TODO(), "CLIENT", "LIST", "TYPE", "pubsub").Text()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list).To(ContainSubstring("flags=P "))
+
+		err = client.Do(context.TODO(), "CLIENT", "LIST", "TYPE", "bogus").Err()
+		Expect(err).To(MatchError("ERR Unknown client type 'bogus'"))
+
+		killed, err := client.ClientKillByFilter(context.TODO(), "TYPE", "pubsub").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(killed).To(BeEquivalentTo(1))
+	})
+
+	It("can send CLIENT PAUSE and UNPAUSE", func() {
+		conn := client.Conn()
+		defer conn.Close()
+
+		paused, err := client.Do(context.TODO(), "CLIENT", "PAUSE", "10000", "WRITE").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(paused).To(Equal("OK"))
+
+		get(client, "mykey", "")
+
+		done := make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+
+			set(client, "mykey", "Hello")
+		}()
+
+		Consistently(done, "100ms").ShouldNot(BeClosed())
+
+		unpaused, err := conn.ClientUnpause(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unpaused).To(BeTrue())
+
+		Eventually(done).Should(BeClosed())
+		get(client, "mykey", "Hello")
+	})
+
+	It("can send CLIENT TRACKING with RESP3 push messages", func() {
+		conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+
+		send(conn, "HELLO", "3")
+		Expect(readLine(reader)).To(Equal("%7"))
+
+		for range 25 {
+			readLine(reader)
+		}
+
+		send(conn, "CLIENT", "TRACKING", "ON")
+		Expect(readLine(reader)).To(Equal("+OK"))
+
+		send(conn, "GET", "mykey")
+		Expect(readLine(reader)).To(Equal("$-1"))
+
+		set(client, "mykey", "Hello")
+
+		Expect(readLine(reader)).To(Equal(">2"))
+		Expect(readLine(reader)).To(Equal("$10"))
+		Expect(readLine(reader)).To(Equal("invalidate"))
+		Expect(readLine(reader)).To(Equal("*1"))
+		Expect(readLine(reader)).To(Equal("$5"))
+		Expect(readLine(reader)).To(Equal("mykey"))
+
+		send(conn, "CLIENT", "TRACKINGINFO")
+		Expect(readLine(reader)).To(Equal("%3"))
+		Expect(readLine(reader)).To(Equal("$5"))
+		Expect(readLine(reader)).To(Equal("flags"))
+		Expect(readLine(reader)).To(Equal("*1"))
+		Expect(readLine(reader)).To(Equal("$2"))
+		Expect(readLine(reader)).To(Equal("on"))
+	})
+
+	It("can send CLIENT TRACKING with a REDIRECT to __redis__:invalidate", func() {
+		subscriber := redis.NewClient(&redis.Options{
+			Addr:     fmt.Sprintf("localhost:%d", port),
+			Protocol: 2,
+		})
+		defer subscriber.Close()
+
+		pubsub := subscriber.Subscribe(context.TODO(), "__redis__:invalidate")
+		defer pubsub.Close()
+
+		_, err := pubsub.Receive(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+
+		list, err := client.ClientList(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+
+		var subscriberID string
+
+		for _, line := range strings.Split(list, "\n") {
+			if strings.Contains(line, "flags=P") {
+				subscriberID = strings.TrimPrefix(strings.Fields(line)[0], "id=")
+			}
+		}
+
+		Expect(subscriberID).NotTo(BeEmpty())
+
+		conn := client.Conn()
+		defer conn.Close()
+
+		err = conn.Process(context.TODO(), redis.NewStatusCmd(context.TODO(), "CLIENT", "TRACKING", "ON", "REDIRECT", subscriberID))
+		Expect(err).NotTo(HaveOccurred())
+
+		redirect := redis.NewIntCmd(context.TODO(), "CLIENT", "GETREDIR")
+		err = conn.Process(context.TODO(), redirect)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fmt.Sprintf("%d", redirect.Val())).To(Equal(subscriberID))
+
+		get(conn, "mykey", "")
+		set(client, "mykey", "Hello")
+
+		message, err := pubsub.ReceiveMessage(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(message.Channel).To(Equal("__redis__:invalidate"))
+		Expect(message.PayloadSlice).To(Equal([]string{"mykey"}))
+	})
+
+	It("can send PUBLISH and SUBSCRIBE", func() {
+		pubsub := client.Subscribe(context.TODO(), "news")
+		defer pubsub.Close()
+
+		_, err := pubsub.Receive(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+
+		channels, err := client.PubSubChannels(context.TODO(), "n*").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(channels).To(Equal([]string{"news"}))
+
+		count, err := client.Publish(context.TODO(), "news", "hello").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(BeEquivalentTo(1))
+
+		message, err := pubsub.ReceiveMessage(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(message.Channel).To(Equal("news"))
+		Expect(message.Payload).To(Equal("hello"))
+	})
+
+	It("can send INFO", func() {
+		set(client, "mykey", "Hello")
+		get(client, "mykey", "Hello")
+		get(client, "missing", "")
+
+		info, err := client.Info(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(And(
+			ContainSubstring("# Server\r\n"),
+			ContainSubstring(fmt.Sprintf("tcp_port:%d\r\n", port)),
+			ContainSubstring("# Clients\r\nconnected_clients:1\r\n"),
+			ContainSubstring("sqlite_page_count:"),
+			ContainSubstring("sqlite_wal_size:"),
+			ContainSubstring("keyspace_hits:1\r\n"),
+			ContainSubstring("keyspace_misses:1\r\n"),
+			ContainSubstring("# Keyspace\r\ndb0:keys=1,expires=0,avg_ttl=0\r\n"),
+			Not(ContainSubstring("# Commandstats")),
+		))
+
+		info, err = client.Info(context.TODO(), "commandstats").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(And(
+			HavePrefix("# Commandstats\r\n"),
+			ContainSubstring("cmdstat_get:calls=2,"),
+			ContainSubstring("cmdstat_set:calls=1,"),
+			Not(ContainSubstring("# Server")),
+		))
+	})
+
+	It("can send DUMP and RESTORE", func() {
+		set(client, "mykey", "10")
+
+		payload, err := client.Dump(context.TODO(), "mykey").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect([]byte(payload)).To(Equal([]byte{0x00, 0xc0, 0x0a, 0x09, 0x00, 0xbe, 0x6d, 0x06, 0x89, 0x5a, 0x28, 0x00, 0x0a}))
+
+		err = client.Dump(context.TODO(), "missing").Err()
+		Expect(err).To(Equal(redis.Nil))
+
+		err = client.Restore(context.TODO(), "mykey", 0, payload).Err()
+		Expect(err).To(MatchError("BUSYKEY Target key name already exists."))
+
+		err = client.Restore(context.TODO(), "copy", 0, payload).Err()
+		Expect(err).NotTo(HaveOccurred())
+		get(client, "copy", "10")
+
+		err = client.RPush(context.TODO(), "mylist", "a", "b").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		payload, err = client.Dump(context.TODO(), "mylist").Result()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.RestoreReplace(context.TODO(), "copy", 0, payload).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		values, err := client.LRange(context.TODO(), "copy", 0, -1).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal([]string{"a", "b"}))
+
+		err = client.Do(context.TODO(), "RESTORE", "copy", "1", payload, "REPLACE", "ABSTTL", "IDLETIME", "10").Err()
+		Expect(err).NotTo(HaveOccurred())
+		get(client, "copy", "")
+
+		err = client.Do(context.TODO(), "RESTORE", "copy", "0", payload, "IDLETIME", "10", "FREQ", "5").Err()
+		Expect(err).To(MatchError("ERR syntax error"))
+
+		err = client.Do(context.TODO(), "RESTORE", "copy", "0", payload, "FREQ", "256").Err()
+		Expect(err).To(MatchError("ERR Invalid FREQ value, must be >= 0 and <= 255"))
+
+		err = client.Do(context.TODO(), "RESTORE", "copy", "-1", payload).Err()
+		Expect(err).To(MatchError("ERR Invalid TTL value, must be >= 0"))
+
+		err = client.Do(context.TODO(), "RESTORE", "copy", "0", payload[:len(payload)-1]+"x").Err()
+		Expect(err).To(MatchError("ERR DUMP payload version or checksum are wrong"))
+	})
+
+	It("can send DEBUG CHECKPOINT and MAINTENANCE", func() {
+		set(client, "mykey", "Hello")
+
+		checkpoint, err := client.Do(context.TODO(), "DEBUG", "CHECKPOINT", "truncate").Slice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checkpoint).To(HaveLen(3))
+		Expect(checkpoint[0]).To(BeEquivalentTo(0))
+
+		err = client.Do(context.TODO(), "DEBUG", "CHECKPOINT", "sometimes").Err()
+		Expect(err).To(MatchError("ERR syntax error"))
+
+		err = client.Do(context.TODO(), "DEBUG", "MAINTENANCE").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := client.Info(context.TODO(), "persistence").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(And(
+			ContainSubstring("sqlite_checkpoints:1\r\n"),
+			ContainSubstring("sqlite_last_checkpoint_mode:truncate\r\n"),
+			ContainSubstring("sqlite_last_analyze_time:"),
+			ContainSubstring("sqlite_last_maintenance_status:ok\r\n"),
+		))
+	})
+
+	It("can send SAVE, BGSAVE and LASTSAVE", func() {
+		dir := GinkgoT().TempDir()
+
+		err := client.ConfigSet(context.TODO(), "dir", dir).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.ConfigSet(context.TODO(), "dbfilename", "backup.db").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.ConfigSet(context.TODO(), "dbfilename", "../backup.db").Err()
+		Expect(err).To(HaveOccurred())
+
+		err = client.ConfigSet(context.TODO(), "save-retention", "2").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		set(client, "mykey", "Hello")
+
+		info, err := client.Info(context.TODO(), "persistence").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(And(
+			ContainSubstring("rdb_changes_since_last_save:1\r\n"),
+			ContainSubstring("rdb_bgsave_in_progress:0\r\n"),
+			ContainSubstring("rdb_last_bgsave_time_sec:-1\r\n"),
+		))
+
+		started, err := client.LastSave(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(started).To(BeNumerically(">", 0))
+
+		err = client.Save(context.TODO()).Err()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(dir, "backup.db")).To(BeAnExistingFile())
+
+		lastSave, err := client.LastSave(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastSave).To(BeNumerically(">=", started))
+
+		reply, err := client.BgSave(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reply).To(Equal("Background saving started"))
+
+		Eventually(func() string {
+			info, _ := client.Info(context.TODO(), "persistence").Result()
+
+			return info
+		}).Should(And(
+			ContainSubstring("rdb_saves:2\r\n"),
+			ContainSubstring("rdb_bgsave_in_progress:0\r\n"),
+			ContainSubstring("rdb_last_bgsave_status:ok\r\n"),
+			ContainSubstring("rdb_changes_since_last_save:0\r\n"),
+		))
+		Expect(filepath.Join(dir, "backup.db.1")).To(BeAnExistingFile())
+
+		err = client.Do(context.TODO(), "BGSAVE", "later").Err()
+		Expect(err).To(MatchError("ERR syntax error"))
+
+		saved, err := db.NewClient("sqlite://" + filepath.Join(dir, "backup.db"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(saved.Close)
+
+		value, found, err := saved.Get(context.TODO(), "mykey")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal("Hello"))
+	})
+
+	It("can send CONFIG GET and SET", func() {
+		values, err := client.ConfigGet(context.TODO(), "slowlog-*").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]string{
+			"slowlog-log-slower-than": "0",
+			"slowlog-max-len":         "128",
+		}))
+
+		values, err = client.ConfigGet(context.TODO(), "port").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]string{"port": fmt.Sprintf("%d", port)}))
+
+		err = client.ConfigSet(context.TODO(), "maxmemory", "1mb").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		values, err = client.ConfigGet(context.TODO(), "maxmemory").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]string{"maxmemory": "1048576"}))
+
+		err = client.ConfigSet(context.TODO(), "maxmemory-policy", "allkeys-lru").Err()
+		Expect(err).To(MatchError(ContainSubstring("maxmemory-policy is not supported")))
+
+		err = client.ConfigSet(context.TODO(), "maxmemory", "0mb").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		values, err = client.ConfigGet(context.TODO(), "maxmemory*").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal(map[string]string{"maxmemory": "0", "maxmemory-policy": "noeviction"}))
+
+		err = client.ConfigSet(context.TODO(), "slowlog-max-len", "1").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		set(client, "mykey", "Hello")
+
+		length, err := client.Do(context.TODO(), "SLOWLOG", "LEN").Int64()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(length).To(BeEquivalentTo(1))
+
+		err = client.ConfigSet(context.TODO(), "port", "1234").Err()
+		Expect(err).To(MatchError("ERR CONFIG SET failed (possibly related to argument 'port') - can't set immutable config"))
+
+		err = client.ConfigSet(context.TODO(), "unknown", "value").Err()
+		Expect(err).To(MatchError("ERR Unknown option or number of arguments for CONFIG SET - 'unknown'"))
+
+		err = client.ConfigSet(context.TODO(), "sqlite-synchronous", "full").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.ConfigRewrite(context.TODO()).Err()
+		Expect(err).To(MatchError("ERR The server is running without a config file"))
+	})
+
+	It("can send CONFIG RESETSTAT", func() {
+		get(client, "missing", "")
+
+		err := client.ConfigResetStat(context.TODO()).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := client.Info(context.TODO(), "stats").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(ContainSubstring("keyspace_misses:0\r\n"))
+	})
+
+	It("rejects the commands that use memory over maxmemory", func() {
+		set(client, "mykey", "Hello")
+
+		err := client.ConfigSet(context.TODO(), "maxmemory", "1").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.Set(context.TODO(), "other", "World", 0).Err()
+		Expect(err).To(MatchError("OOM command not allowed when used memory > 'maxmemory'."))
+
+		get(client, "mykey", "Hello")
+
+		err = client.Del(context.TODO(), "mykey").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.ConfigSet(context.TODO(), "maxmemory", "0").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		set(client, "other", "World")
+	})
+
+	It("closes idle clients after the timeout", func() {
+		err := client.ConfigSet(context.TODO(), "timeout", "1").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		_, err = bufio.NewReader(conn).ReadByte()
+		Expect(err).To(MatchError(io.EOF))
+	})
+
+	It("can send SLOWLOG", func() {
+		value, err := client.Do(context.TODO(), "SLOWLOG", "RESET").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("OK"))
+
+		set(client, "mykey", "Hello")
+
+		entries, err := client.SlowLogGet(context.TODO(), 2).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Args).To(Equal([]string{"set", "mykey", "Hello", "ex", "3600"}))
+		Expect(entries[0].ClientAddr).NotTo(BeEmpty())
+		Expect(entries[1].Args).To(Equal([]string{"SLOWLOG", "RESET"}))
+
+		length, err := client.Do(context.TODO(), "SLOWLOG", "LEN").Int64()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(length).To(BeEquivalentTo(3))
+	})
+
+	It("can send MONITOR", func() {
+		// connect the client first, so its handshake is not monitored
+		err := client.Ping(context.TODO()).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+
+		send(conn, "MONITOR")
+		Expect(readLine(reader)).To(Equal("+OK"))
+
+		set(client, "mykey", "Hello")
+
+		Expect(readLine(reader)).To(MatchRegexp(
+			`^\+\d+\.\d{6} \[0 127\.0\.0\.1:\d+\] "set" "mykey" "Hello" "ex" "3600"$`,
+		))
+	})
+
+	It("can send LATENCY", func() {
+		latest, err := client.Do(context.TODO(), "LATENCY", "LATEST").Slice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(latest).To(BeEmpty())
+
+		history, err := client.Do(context.TODO(), "LATENCY", "HISTORY", "command").Slice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history).To(BeEmpty())
+
+		reset, err := client.Do(context.TODO(), "LATENCY", "RESET").Int64()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reset).To(BeEquivalentTo(0))
+
+		doctor, err := client.Do(context.TODO(), "LATENCY", "DOCTOR").Text()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(doctor).To(ContainSubstring("disabled"))
+	})
+
+	It("can send COMMAND", func() {
+		commands, err := client.Command(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commands).To(HaveKey("get"))
+		Expect(commands["get"].Arity).To(BeEquivalentTo(2))
+		Expect(commands["get"].ReadOnly).To(BeTrue())
+		Expect(commands["get"].ACLFlags).To(ContainElement("@read"))
+		Expect(commands["mset"].FirstKeyPos).To(BeEquivalentTo(1))
+		Expect(commands["mset"].LastKeyPos).To(BeEquivalentTo(-1))
+		Expect(commands["mset"].StepCount).To(BeEquivalentTo(2))
+		Expect(commands).NotTo(HaveKey("getset"))
+
+		count, err := client.Do(context.TODO(), "COMMAND", "COUNT").Int()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(len(commands)))
+
+		names, err := client.CommandList(context.TODO(), &redis.FilterBy{Pattern: "client|*"}).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(ContainElements("client|list", "client|setname"))
+
+		names, err = client.CommandList(context.TODO(), &redis.FilterBy{ACLCat: "pubsub"}).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(ContainElements("publish", "subscribe", "pubsub|channels"))
+
+		keys, err := client.CommandGetKeys(context.TODO(), "mset", "a", "1", "b", "2").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(Equal([]string{"a", "b"}))
+
+		_, err = client.CommandGetKeys(context.TODO(), "ping").Result()
+		Expect(err).To(MatchError("ERR The command has no key arguments"))
+
+		_, err = client.CommandGetKeys(context.TODO(), "unknown").Result()
+		Expect(err).To(MatchError("ERR Invalid command specified"))
+
+		docs, err := client.Do(context.TODO(), "COMMAND", "DOCS", "get").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(docs).To(HaveKeyWithValue("get", HaveKeyWithValue("group", "string")))
+	})
+
+	It("checks the arity of commands", func() {
+		_, err := client.Do(context.TODO(), "GET").Result()
+		Expect(err).To(MatchError("ERR wrong number of arguments for 'get' command"))
+
+		_, err = client.Do(context.TODO(), "CLIENT", "SETNAME").Result()
+		Expect(err).To(MatchError("ERR wrong number of arguments for 'client|setname' command"))
+	})
+
+	It("has cluster support disabled", func() {
+		_, err := client.ClusterSlots(context.TODO()).Result()
+		Expect(err).To(MatchError("ERR This instance has cluster support disabled"))
+	})
+
+	It("serves prometheus metrics", func() {
+		set(client, "hello", "world")
+		get(client, "hello", "world")
+
+		response, err := http.Get(fmt.Sprintf("http://localhost:%d/metrics", metricsPort))
+		Expect(err).NotTo(HaveOccurred())
+
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring(`sqlettuce_commands_total{command="set",result="ok"} 1`))
+		Expect(string(body)).To(ContainSubstring(`sqlettuce_command_duration_seconds_count{command="get"} 1`))
+		Expect(string(body)).To(ContainSubstring("sqlettuce_connections_active 1"))
+		Expect(string(body)).To(ContainSubstring("sqlettuce_net_read_bytes_total"))
+		Expect(string(body)).To(ContainSubstring(`sqlettuce_sqlite_statement_duration_seconds_count{operation="Set"}`))
+		Expect(string(body)).To(ContainSubstring("sqlettuce_sqlite_database_size_bytes"))
+	})
+
+	It("had deprecated commands", func() {
+		_, err := client.RPopLPush(context.TODO(), "mylist", "myotherlist").Result()
+		Expect(err).To(MatchError(ContainSubstring("Deprecated")))
+
+		_, err = client.BRPopLPush(context.TODO(), "mylist", "myotherlist", time.Hour).Result()
+		Expect(err).To(MatchError(ContainSubstring("Deprecated")))
+
+		_, err = client.GetSet(context.TODO(), "mylist", "myotherlist").Result()
+		Expect(err).To(MatchError(ContainSubstring("Deprecated")))
+
+		_, err = client.SetEx(context.TODO(), "mylist", "myotherlist", time.Hour).Result()
+		Expect(err).To(MatchError(ContainSubstring("Deprecated")))
+	})
+})
+
+var _ = Describe("CLI with a config file", func() {
+	It("loads the config file with flags taking precedence", func() {
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(GinkgoT().TempDir(), "redis.conf")
+
+		err = os.WriteFile(path, []byte(
+			"# an existing redis config\n"+
+				"port 1\n"+
+				"filename \"sqlite://:memory:?cache=shared&mode=memory\"\n"+
+				"workers 10\n"+
+				"daemonize no\n"+
+				"maxmemory 0mb\n"+
+				"slowlog-max-len 5\n",
+		), 0o600)
+		Expect(err).NotTo(HaveOccurred())
+
+		cli := &CLI{}
+
+		parser, err := kong.New(cli, kong.Configuration(configResolver))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = parser.Parse([]string{"--config", path, "--port", fmt.Sprintf("%d", port)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(cli.Workers).To(BeEquivalentTo(10))
+		Expect(cli.SlowlogMaxLen).To(Equal(5))
+
+		client := startCLI(cli)
+
+		values, err := client.ConfigGet(context.TODO(), "*").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(HaveKeyWithValue("port", fmt.Sprintf("%d", port)))
+		Expect(values).To(HaveKeyWithValue("maxmemory", "0"))
+		Expect(values).To(HaveKeyWithValue("slowlog-max-len", "5"))
+
+		err = client.ConfigSet(context.TODO(), "slowlog-max-len", "7").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.ConfigRewrite(context.TODO()).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(And(
+			HavePrefix("# an existing redis config\n"),
+			ContainSubstring(fmt.Sprintf("port %d\n", port)),
+			ContainSubstring("daemonize no\n"),
+			Not(ContainSubstring("maxmemory")),
+			ContainSubstring("slowlog-max-len 7\n"),
+		))
+	})
+})
+
+var _ = Describe("CLI with the memory driver", func() {
+	It("stores keys without a file", func() {
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		cli := &CLI{
+			Port:     uint(port),
+			Filename: "memory://",
+			Workers:  10,
+		}
+
+		client := startCLI(cli)
+
+		set(client, "key", "value")
+		get(client, "key", "value")
+
+		length, err := client.RPush(context.TODO(), "list", "a", "b").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(length).To(BeEquivalentTo(2))
+
+		values, err := client.LRange(context.TODO(), "list", 0, -1).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal([]string{"a", "b"}))
+	})
+})
+
+var _ = Describe("CLI with PRAGMAs in the DSN", func() {
+	It("reports them as the SQLite settings", func() {
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		cli := &CLI{
+			Port:     uint(port),
+			Filename: fmt.Sprintf("sqlite://pragmas-%d?cache=shared&mode=memory&_busy_timeout=10s&synchronous=2", port),
+			Workers:  10,
+		}
+
+		client := startCLI(cli)
+
+		values, err := client.ConfigGet(context.TODO(), "sqlite-*").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(HaveKeyWithValue("sqlite-busy-timeout", "10000"))
+		Expect(values).To(HaveKeyWithValue("sqlite-synchronous", "full"))
+	})
+})
+
+var _ = Describe("CLI grouping commits", func() {
+	It("commits the writes of concurrent connections together", func() {
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		cli := &CLI{
+			Port:             uint(port),
+			Filename:         fmt.Sprintf("sqlite://group-%d?cache=shared&mode=memory", port),
+			Workers:          10,
+			GroupCommitSize:  128,
+			GroupCommitDelay: 10_000,
+		}
+
+		client := startCLI(cli)
+
+		err = client.ConfigResetStat(context.TODO()).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		var group sync.WaitGroup
+
+		for index := range 20 {
+			group.Add(1)
+
+			go func() {
+				defer GinkgoRecover()
+				defer group.Done()
+
+				conn := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("127.0.0.1:%d", port)})
+				defer conn.Close()
+
+				err := conn.Set(context.TODO(), fmt.Sprintf("key%d", index), "value", 0).Err()
+				Expect(err).NotTo(HaveOccurred())
+			}()
+		}
+
+		group.Wait()
+
+		info, err := client.Info(context.TODO(), "stats").Result()
+		Expect(err).NotTo(HaveOccurred())
+
+		stat := func(name string) int {
+			for _, line := range strings.Split(info, "\r\n") {
+				value, ok := strings.CutPrefix(line, name+":")
+				if ok {
+					count, err := strconv.Atoi(value)
+					Expect(err).NotTo(HaveOccurred())
+
+					return count
+				}
+			}
+
+			Fail("missing " + name)
+
+			return 0
+		}
+
+		Expect(stat("sqlite_group_commit_writes")).To(Equal(20))
+		Expect(stat("sqlite_group_commits")).To(BeNumerically("<", 20))
+	})
+})
+
+var _ = Describe("CLI in cluster mode", func() {
+	It("answers as a single node cluster", func() {
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		cli := &CLI{
+			Port:           uint(port),
+			Filename:       "sqlite://" + filepath.Join(GinkgoT().TempDir(), "cluster.db"),
+			Workers:        10,
+			ClusterEnabled: true,
+		}
+
+		startCLI(cli)
+
+		client := redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs: []string{fmt.Sprintf("localhost:%d", port)},
+		})
+		defer client.Close()
+
+		slots, err := client.ClusterSlots(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slots).To(HaveLen(1))
+		Expect(slots[0].Start).To(Equal(0))
+		Expect(slots[0].End).To(Equal(16383))
+		Expect(slots[0].Nodes[0].Addr).To(Equal(fmt.Sprintf("127.0.0.1:%d", port)))
+
+		shards, err := client.ClusterShards(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shards).To(HaveLen(1))
+		Expect(shards[0].Nodes[0].Role).To(Equal("master"))
+
+		info, err := client.ClusterInfo(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(ContainSubstring("cluster_state:ok"))
+
+		nodes, err := client.ClusterNodes(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(ContainSubstring("myself,master - 0 0 1 connected 0-16383"))
+
+		slot, err := client.ClusterKeySlot(context.TODO(), "{user1000}.following").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slot).To(BeEquivalentTo(3443))
+
+		set(client, "{user1000}.following", "value")
+		set(client, "{user1000}.followers", "value")
+
+		count, err := client.ClusterCountKeysInSlot(context.TODO(), 3443).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(BeEquivalentTo(2))
+
+		keys, err := client.ClusterGetKeysInSlot(context.TODO(), 3443, 1).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(Equal([]string{"{user1000}.followers"}))
+
+		keys, err = client.ClusterGetKeysInSlot(context.TODO(), 3443, 5).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(Equal([]string{"{user1000}.followers", "{user1000}.following"}))
+
+		keys, err = client.ClusterGetKeysInSlot(context.TODO(), 3443, 0).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(BeEmpty())
+
+		values, err := client.MGet(context.TODO(), "{user1000}.following", "{user1000}.followers").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal([]interface{}{"value", "value"}))
+
+		conn := redis.NewClient(&redis.Options{
+			Addr: fmt.Sprintf("localhost:%d", port),
+		})
+		defer conn.Close()
+
+		err = conn.MSet(context.TODO(), "a", "1", "b", "2").Err()
+		Expect(err).To(MatchError("CROSSSLOT Keys in request don't hash to the same slot"))
+
+		server, err := conn.Info(context.TODO(), "cluster").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server).To(ContainSubstring("cluster_enabled:1"))
+
+		hello, err := conn.Do(context.TODO(), "HELLO", "3").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hello).To(HaveKeyWithValue("mode", "cluster"))
+	})
+})
+
+var _ = Describe("CLI with an append only file", func() {
+	start := func(dir string) *redis.Client {
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(dir, "redis.conf")
+
+		err = os.WriteFile(path, []byte(fmt.Sprintf("dir %q\nappendfsync always\n", dir)), 0o600)
+		Expect(err).NotTo(HaveOccurred())
+
+		cli := &CLI{
+			Config:     kong.ConfigFlag(path),
+			Port:       uint(port),
+			Filename:   "sqlite://" + filepath.Join(dir, "test.db"),
+			Workers:    10,
+			Appendonly: true,
+		}
+
+		return startCLI(cli)
+	}
+
+	persistence := func(client *redis.Client) func() string {
+		return func() string {
+			info, _ := client.Info(context.TODO(), "persistence").Result()
+
+			return info
+		}
+	}
+
+	It("replays the writes on another server", func() {
+		dir := GinkgoT().TempDir()
+		primary := start(dir)
+
+		Eventually(persistence(primary)).Should(And(
+			ContainSubstring("aof_enabled:1\r\n"),
+			ContainSubstring("aof_rewrites:1\r\n"),
+			ContainSubstring("aof_rewrite_in_progress:0\r\n"),
+		))
+
+		Expect(primary.Set(context.TODO(), "string", "Hello", 0).Err()).To(Succeed())
+		Expect(primary.RPush(context.TODO(), "list", "a", "b").Err()).To(Succeed())
+		Expect(primary.Incr(context.TODO(), "counter").Err()).To(Succeed())
+
+		reply, err := primary.BgRewriteAOF(context.TODO()).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reply).To(Equal("Background append only file rewriting started"))
+
+		Eventually(persistence(primary)).Should(And(
+			ContainSubstring("aof_rewrites:2\r\n"),
+			ContainSubstring("aof_last_bgrewrite_status:ok\r\n"),
+		))
+
+		Expect(primary.Incr(context.TODO(), "counter").Err()).To(Succeed())
+		Expect(primary.RPush(context.TODO(), "list", "c").Err()).To(Succeed())
+
+		contents, err := os.ReadFile(filepath.Join(dir, "appendonly.aof"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(HaveSuffix("*3\r\n$5\r\nrpush\r\n$4\r\nlist\r\n$1\r\nc\r\n"))
+
+		Expect(primary.ConfigSet(context.TODO(), "appendonly", "no").Err()).To(Succeed())
+		Expect(persistence(primary)()).To(ContainSubstring("aof_enabled:0\r\n"))
+
+		err = primary.BgRewriteAOF(context.TODO()).Err()
+		Expect(err).To(MatchError(ContainSubstring("the AOF is disabled")))
+
+		// the server stopped while appending a command
+		other := GinkgoT().TempDir()
+		aofPath := filepath.Join(other, "appendonly.aof")
+
+		err = os.WriteFile(aofPath, append(contents, "*3\r\n$3\r\nSET"...), 0o600)
+		Expect(err).NotTo(HaveOccurred())
+
+		replica := start(other)
+
+		get(replica, "string", "Hello")
+		get(replica, "counter", "2")
+
+		elements, err := replica.LRange(context.TODO(), "list", 0, -1).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(elements).To(Equal([]string{"a", "b", "c"}))
+
+		truncated, err := os.ReadFile(aofPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(truncated).To(Equal(contents))
+
+		Expect(replica.Set(context.TODO(), "other", "World", 0).Err()).To(Succeed())
+		Expect(persistence(replica)()).To(ContainSubstring(
+			fmt.Sprintf("aof_base_size:%d\r\n", len(contents)),
+		))
+	})
+
+	It("does not append rejected writes", func() {
+		dir := GinkgoT().TempDir()
+		client := start(dir)
+
+		Eventually(persistence(client)).Should(ContainSubstring("aof_rewrite_in_progress:0\r\n"))
+		Expect(client.Set(context.TODO(), "key", "value", 0).Err()).To(Succeed())
+
+		path := filepath.Join(dir, "appendonly.aof")
+
+		contents, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = client.Do(context.TODO(), "SET", "onlykey").Err()
+		Expect(err).To(MatchError("ERR wrong number of arguments for 'set' command"))
+
+		err = client.Incr(context.TODO(), "key").Err()
+		Expect(err).To(MatchError("ERR value is not an integer or out of range"))
+
+		unchanged, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unchanged).To(Equal(contents))
+	})
+})
+
+var _ = Describe("CLI with replication", func() {
+	It("replicates the writes of the primary", func() {
+		primaryPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		replicaPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		primary := startCLI(&CLI{
+			Port:     uint(primaryPort),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "primary.db"),
+			Workers:  10,
+		})
+
+		// written before the replica connects, so they are in the snapshot
+		set(primary, "before", "snapshot")
+
+		replica := startCLI(&CLI{
+			Port:      uint(replicaPort),
+			Filename:  "sqlite://" + filepath.Join(GinkgoT().TempDir(), "replica.db"),
+			Workers:   10,
+			ReplicaOf: fmt.Sprintf("localhost %d", primaryPort),
+		})
+
+		Eventually(func() string {
+			value, _ := replica.Get(context.TODO(), "before").Result()
+
+			return value
+		}).Should(Equal("snapshot"))
+
+		set(primary, "after", "stream")
+
+		acked, err := primary.Wait(context.TODO(), 1, time.Second).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acked).To(BeEquivalentTo(1))
+
+		get(replica, "after", "stream")
+
+		err = replica.Set(context.TODO(), "key", "value", 0).Err()
+		Expect(err).To(MatchError("READONLY You can't write against a read only replica."))
+
+		role, err := primary.Do(context.TODO(), "ROLE").Slice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(role[0]).To(Equal("master"))
+		Expect(role[2]).To(HaveLen(1))
+
+		role, err = replica.Do(context.TODO(), "ROLE").Slice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(role).To(HaveExactElements("slave", "localhost", BeEquivalentTo(primaryPort), "connected", BeNumerically(">", 0)))
+
+		info, err := replica.Info(context.TODO(), "replication").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(ContainSubstring("role:slave"))
+		Expect(info).To(ContainSubstring("master_link_status:up"))
+
+		hello, err := replica.Do(context.TODO(), "HELLO", "3").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hello).To(HaveKeyWithValue("role", "replica"))
+
+		hello, err = primary.Do(context.TODO(), "HELLO", "3").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hello).To(HaveKeyWithValue("role", "master"))
+
+		info, err = primary.Info(context.TODO(), "replication").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(ContainSubstring("connected_slaves:1"))
+		Expect(info).To(ContainSubstring(fmt.Sprintf("port=%d", replicaPort)))
+
+		err = replica.Do(context.TODO(), "REPLICAOF", "NO", "ONE").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		set(replica, "key", "value")
+
+		Eventually(func() int {
+			return len(primary.Do(context.TODO(), "ROLE").Val().([]interface{})[2].([]interface{}))
+		}).Should(Equal(0))
+	})
+
+	It("does not propagate rejected writes", func() {
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		client := startCLI(&CLI{
+			Port:     uint(port),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "primary.db"),
+			Workers:  10,
+		})
+
+		offset := func() int64 {
+			role, err := client.Do(context.TODO(), "ROLE").Slice()
+			Expect(err).NotTo(HaveOccurred())
+
+			return role[1].(int64)
+		}
+
+		set(client, "key", "value")
+
+		written := offset()
+		Expect(written).To(BeNumerically(">", 0))
+
+		err = client.Do(context.TODO(), "SET", "onlykey").Err()
+		Expect(err).To(MatchError("ERR wrong number of arguments for 'set' command"))
+
+		err = client.Incr(context.TODO(), "key").Err()
+		Expect(err).To(MatchError("ERR value is not an integer or out of range"))
+
+		Expect(offset()).To(Equal(written))
+	})
+
+	It("waits for no replicas", func() {
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		client := startCLI(&CLI{
+			Port:     uint(port),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "primary.db"),
+			Workers:  10,
+		})
+
+		acked, err := client.Wait(context.TODO(), 1, 10*time.Millisecond).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(acked).To(BeEquivalentTo(0))
+
+		durable, err := client.Do(context.TODO(), "WAITAOF", "1", "0", "0").Slice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(durable).To(Equal([]interface{}{int64(1), int64(0)}))
+	})
+})
+
+var _ = Describe("CLI migrating keys", func() {
+	It("moves keys to another server", func() {
+		sourcePort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		targetPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		source := startCLI(&CLI{
+			Port:     uint(sourcePort),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "source.db"),
+			Workers:  10,
+		})
+		target := startCLI(&CLI{
+			Port:     uint(targetPort),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "target.db"),
+			Workers:  10,
+		})
+
+		err = source.MSet(context.TODO(), "a", "1", "b", "2").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = source.RPush(context.TODO(), "list", "x", "y").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = target.Set(context.TODO(), "b", "3", 0).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		host, port := "localhost", strconv.Itoa(targetPort)
+
+		reply, err := source.Migrate(context.TODO(), host, port, "a", 0, time.Second).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reply).To(Equal("OK"))
+		get(source, "a", "")
+		get(target, "a", "1")
+
+		reply, err = source.Migrate(context.TODO(), host, port, "missing", 0, time.Second).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reply).To(Equal("NOKEY"))
+
+		err = source.Do(context.TODO(), "MIGRATE", host, port, "", "0", "1000", "KEYS", "b", "list").Err()
+		Expect(err).To(MatchError("ERR Target instance replied with error: BUSYKEY Target key name already exists."))
+		get(source, "b", "2")
+		get(target, "b", "3")
+
+		values, err := target.LRange(context.TODO(), "list", 0, -1).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal([]string{"x", "y"}))
+
+		values, err = source.LRange(context.TODO(), "list", 0, -1).Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(BeEmpty())
+
+		err = source.Do(context.TODO(), "MIGRATE", host, port, "", "0", "1000", "COPY", "REPLACE", "KEYS", "b").Err()
+		Expect(err).NotTo(HaveOccurred())
+		get(source, "b", "2")
+		get(target, "b", "2")
+
+		err = source.Do(context.TODO(), "MIGRATE", host, port, "b", "0", "1000", "KEYS", "b").Err()
+		Expect(err).To(MatchError("ERR When using MIGRATE KEYS option, the key argument must be set to the empty string"))
+
+		closedPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		err = source.Migrate(context.TODO(), host, strconv.Itoa(closedPort), "b", 0, time.Second).Err()
+		Expect(err).To(MatchError("IOERR error or timeout connecting to the client"))
+		get(source, "b", "2")
+	})
+
+	It("waits for the migration before writing the keys", func() {
+		sourcePort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		targetPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		source := startCLI(&CLI{
+			Port:     uint(sourcePort),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "source.db"),
+			Workers:  10,
+		})
+		target := startCLI(&CLI{
+			Port:     uint(targetPort),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "target.db"),
+			Workers:  10,
+		})
+
+		set(source, "a", "1")
+
+		// the RESTORE of the migration waits on the target
+		err = target.Do(context.TODO(), "CLIENT", "PAUSE", "5000", "WRITE").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		migrated := make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+
+			err := source.Migrate(context.TODO(), "localhost", strconv.Itoa(targetPort), "a", 0, 5*time.Second).Err()
+			Expect(err).NotTo(HaveOccurred())
+			close(migrated)
+		}()
+
+		Eventually(func() string {
+			return target.ClientList(context.TODO()).Val()
+		}).Should(ContainSubstring("cmd=restore"))
+
+		written := make(chan struct{})
+
+		go func() {
+			defer GinkgoRecover()
+
+			set(source, "a", "2")
+			close(written)
+		}()
+
+		Consistently(written, 100*time.Millisecond).ShouldNot(BeClosed())
+
+		err = target.ClientUnpause(context.TODO()).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(migrated).Should(BeClosed())
+		Eventually(written).Should(BeClosed())
+
+		get(target, "a", "1")
+		get(source, "a", "2")
+	})
+})
+
+var _ = Describe("CLI in sentinel mode", func() {
+	It("fails over to the replica of a primary", func() {
+		primaryPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		replicaPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		sentinelPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		primary := startCLI(&CLI{
+			Port:     uint(primaryPort),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "primary.db"),
+			Workers:  10,
+		})
+
+		replica := startCLI(&CLI{
+			Port:      uint(replicaPort),
+			Filename:  "sqlite://" + filepath.Join(GinkgoT().TempDir(), "replica.db"),
+			Workers:   10,
+			ReplicaOf: fmt.Sprintf("127.0.0.1 %d", primaryPort),
+		})
+
+		monitor := startCLI(&CLI{
+			Port:                    uint(sentinelPort),
+			Workers:                 10,
+			Sentinel:                true,
+			SentinelMonitor:         []string{fmt.Sprintf("mymaster 127.0.0.1 %d 1", primaryPort)},
+			SentinelDownAfter:       200 * time.Millisecond,
+			SentinelFailoverTimeout: time.Second,
+		})
+
+		addr, err := monitor.Do(context.TODO(), "SENTINEL", "GET-MASTER-ADDR-BY-NAME", "mymaster").StringSlice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addr).To(Equal([]string{"127.0.0.1", strconv.Itoa(primaryPort)}))
+
+		err = monitor.Do(context.TODO(), "SENTINEL", "GET-MASTER-ADDR-BY-NAME", "unknown").Err()
+		Expect(err).To(Equal(redis.Nil))
+
+		Eventually(func() int {
+			replicas, _ := monitor.Do(context.TODO(), "SENTINEL", "REPLICAS", "mymaster").Slice()
+
+			return len(replicas)
+		}).Should(Equal(1))
+
+		masters, err := monitor.Do(context.TODO(), "SENTINEL", "MASTERS").Slice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(masters).To(HaveLen(1))
+
+		info, err := monitor.Info(context.TODO(), "sentinel").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(ContainSubstring("name=mymaster,status=ok"))
+
+		err = monitor.Set(context.TODO(), "key", "value", 0).Err()
+		Expect(err).To(HaveOccurred())
+
+		failover := redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    "mymaster",
+			SentinelAddrs: []string{fmt.Sprintf("127.0.0.1:%d", sentinelPort)},
+		})
+		DeferCleanup(failover.Close)
+
+		set(failover, "before", "failover")
+
+		events := monitor.Subscribe(context.TODO(), "+switch-master")
+		DeferCleanup(events.Close)
+
+		_, err = events.Receive(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+
+		err = monitor.Do(context.TODO(), "SENTINEL", "FAILOVER", "mymaster").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		message, err := events.ReceiveMessage(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(message.Payload).To(Equal(fmt.Sprintf(
+			"mymaster 127.0.0.1 %d 127.0.0.1 %d", primaryPort, replicaPort,
+		)))
+
+		addr, err = monitor.Do(context.TODO(), "SENTINEL", "GET-MASTER-ADDR-BY-NAME", "mymaster").StringSlice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addr).To(Equal([]string{"127.0.0.1", strconv.Itoa(replicaPort)}))
+
+		role, err := replica.Do(context.TODO(), "ROLE").Slice()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(role[0]).To(Equal("master"))
+
+		Eventually(func() interface{} {
+			role, _ := primary.Do(context.TODO(), "ROLE").Slice()
+			if len(role) == 0 {
+				return nil
+			}
+
+			return role[0]
+		}).Should(Equal("slave"))
+
+		Eventually(func() error {
+			return failover.Set(context.TODO(), "after", "failover", 0).Err()
+		}).ShouldNot(HaveOccurred())
+
+		get(replica, "before", "failover")
+		get(replica, "after", "failover")
+	})
+
+	It("fails over when a quorum agrees the primary is down", func() {
+		primaryPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		replicaPort, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		primary := startCLI(&CLI{
+			Port:     uint(primaryPort),
+			Filename: "sqlite://" + filepath.Join(GinkgoT().TempDir(), "primary.db"),
+			Workers:  10,
+		})
+
+		_ = startCLI(&CLI{
+			Port:      uint(replicaPort),
+			Filename:  "sqlite://" + filepath.Join(GinkgoT().TempDir(), "replica.db"),
+			Workers:   10,
+			ReplicaOf: fmt.Sprintf("127.0.0.1 %d", primaryPort),
+		})
+
+		monitors := make([]*redis.Client, 0, 3)
+
+		for range 3 {
+			sentinelPort, err := freeport.GetFreePort()
+			Expect(err).NotTo(HaveOccurred())
+
+			monitors = append(monitors, startCLI(&CLI{
+				Port:                    uint(sentinelPort),
+				Workers:                 10,
+				Sentinel:                true,
+				SentinelMonitor:         []string{fmt.Sprintf("mymaster 127.0.0.1 %d 2", primaryPort)},
+				SentinelDownAfter:       200 * time.Millisecond,
+				SentinelFailoverTimeout: time.Second,
+			}))
+		}
+
+		for _, monitor := range monitors {
+			Eventually(func() int {
+				sentinels, _ := monitor.Do(context.TODO(), "SENTINEL", "SENTINELS", "mymaster").Slice()
+
+				return len(sentinels)
+			}).Should(Equal(2))
+
+			Eventually(func() int {
+				replicas, _ := monitor.Do(context.TODO(), "SENTINEL", "REPLICAS", "mymaster").Slice()
+
+				return len(replicas)
+			}).Should(Equal(1))
+		}
+
+		err = monitors[0].Do(context.TODO(), "SENTINEL", "CKQUORUM", "mymaster").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		// the primary stops answering, like it is down
+		err = primary.Do(context.TODO(), "CLIENT", "PAUSE", "5000", "ALL").Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, monitor := range monitors {
+			Eventually(func() []string {
+				addr, _ := monitor.Do(context.TODO(), "SENTINEL", "GET-MASTER-ADDR-BY-NAME", "mymaster").StringSlice()
+
+				return addr
+			}, 5*time.Second).Should(Equal([]string{"127.0.0.1", strconv.Itoa(replicaPort)}))
+		}
+	})
+})
+
+var _ = Describe("CLI importing and exporting RDB files", func() {
+	It("moves strings and lists through a RDB file", func() {
+		dir := GinkgoT().TempDir()
+		path := filepath.Join(dir, "dump.rdb")
+
+		file, err := os.Create(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		writer, err := rdb.NewWriter(file)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, entry := range []*rdb.Entry{
+			{Key: "string", Type: rdb.String, Value: "Hello"},
+			{Key: "list", Type: rdb.List, Elements: []string{"a", "b", "c"}},
+			{Key: "expiring", Type: rdb.String, Value: "World", ExpiresAt: time.Now().Add(time.Hour)},
+			{Key: "expired", Type: rdb.String, Value: "Gone", ExpiresAt: time.Now().Add(-time.Hour)},
+			{Key: "hash", Type: rdb.Hash, Fields: []rdb.Field{{Name: "field", Value: "value"}}},
+			{DB: 1, Key: "other", Type: rdb.String, Value: "Other"},
+		} {
+			Expect(writer.Write(entry)).To(Succeed())
+		}
+
+		Expect(writer.Close()).To(Succeed())
+		Expect(file.Close()).To(Succeed())
+
+		run := func(args ...string) {
+			parser, err := kong.New(&Commands{}, kong.Configuration(configResolver))
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, err := parser.Parse(args)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ctx.Run()).To(Succeed())
+		}
+
+		filename := "sqlite://" + filepath.Join(dir, "imported.db")
+		run("import", "--filename", filename, "--rdb", path)
+
+		client, err := db.NewClient(filename)
+		Expect(err).NotTo(HaveOccurred())
+
+		names, err := client.Keys(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(Equal([]string{"expiring", "list", "string"}))
+
+		values, err := client.MGet(context.TODO(), "string", "expiring")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(values).To(Equal([]string{"Hello", "World"}))
+
+		elements, err := client.ListRange(context.TODO(), "list", 0, -1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(elements).To(Equal([]string{"a", "b", "c"}))
+
+		Expect(client.Close()).To(Succeed())
+
+		exported := filepath.Join(dir, "exported.rdb")
+		run("export", "--filename", filename, "--rdb", exported)
+
+		data, err := os.ReadFile(exported)
+		Expect(err).NotTo(HaveOccurred())
+
+		entries := map[string]*rdb.Entry{}
+		err = rdb.Read(bytes.NewReader(data), func(entry *rdb.Entry) error {
+			entries[entry.Key] = entry
+
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(3))
+		Expect(entries["string"].Type).To(Equal(rdb.String))
+		Expect(entries["string"].Value).To(Equal("Hello"))
+		Expect(entries["list"].Type).To(Equal(rdb.List))
+		Expect(entries["list"].Elements).To(Equal([]string{"a", "b", "c"}))
+	})
+})
+
+var _ = Describe("CLI restoring keys as of a time", func() {
+	It("restores from a snapshot and the change journal", func() {
+		dir := GinkgoT().TempDir()
+
+		port, err := freeport.GetFreePort()
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(dir, "redis.conf")
+
+		err = os.WriteFile(path, []byte(fmt.Sprintf("dir %q\n", dir)), 0o600)
+		Expect(err).NotTo(HaveOccurred())
+
+		filename := "sqlite://" + filepath.Join(dir, "test.db")
+		cli := &CLI{
+			Config:              kong.ConfigFlag(path),
+			Port:                uint(port),
+			Filename:            filename,
+			Workers:             10,
+			SqliteChangeJournal: true,
+		}
+
+		client := startCLI(cli)
+
+		// the changes before and after a time are told apart by milliseconds
+		later := func() time.Time {
+			time.Sleep(10 * time.Millisecond)
+			now := time.Now()
+			time.Sleep(10 * time.Millisecond)
+
+			return now
+		}
+
+		set(client, "string", "Hello")
+		Expect(client.RPush(context.TODO(), "list", "a").Err()).To(Succeed())
+
+		beforeSave := later()
+
+		Expect(client.Save(context.TODO()).Err()).To(Succeed())
+		Expect(client.RPush(context.TODO(), "list", "b").Err()).To(Succeed())
+		Expect(client.Del(context.TODO(), "string").Err()).To(Succeed())
+
+		afterSave := later()
+
+		Expect(client.FlushAll(context.TODO()).Err()).To(Succeed())
+
+		info, err := client.Info(context.TODO(), "persistence").Result()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(ContainSubstring("sqlite_journal_enabled:1\r\n"))
+
+		restore := func(output string, to time.Time) error {
+			parser, err := kong.New(&Commands{}, kong.Configuration(configResolver))
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, err := parser.Parse([]string{
+				"restore",
+				"--filename", filename,
+				"--dir", dir,
+				"--to", to.Format(time.RFC3339Nano),
+				"--output", output,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			return ctx.Run()
+		}
+
+		keys := func(output string) map[string]string {
+			restored, err := db.NewClient("sqlite://" + output)
+			Expect(err).NotTo(HaveOccurred())
+			defer restored.Close()
+
+			names, err := restored.Keys(context.TODO())
+			Expect(err).NotTo(HaveOccurred())
+
+			values, err := restored.MGet(context.TODO(), names...)
+			Expect(err).NotTo(HaveOccurred())
+
+			keys := map[string]string{}
+			for index, name := range names {
+				keys[name] = values[index]
+			}
+
+			return keys
+		}
+
+		restored := filepath.Join(dir, "restored.db")
+		Expect(restore(restored, afterSave)).To(Succeed())
+		Expect(keys(restored)).To(Equal(map[string]string{"list": `["a","b"]`}))
+
+		Expect(restore(restored, afterSave)).To(MatchError(ErrOutputExists))
+
+		// the snapshot was taken after the time, the journal is applied from
+		// its start
+		restored = filepath.Join(dir, "before.db")
+		Expect(restore(restored, beforeSave)).To(Succeed())
+		Expect(keys(restored)).To(Equal(map[string]string{"string": "Hello", "list": `["a"]`}))
+
+		restored = filepath.Join(dir, "now.db")
+		Expect(restore(restored, time.Now())).To(Succeed())
+		Expect(keys(restored)).To(BeEmpty())
+
+		Expect(restore(filepath.Join(dir, "never.db"), beforeSave.Add(-time.Hour))).To(MatchError(ErrNotJournaled))
+	})
+})
+
+// startCLI runs the server of the CLI in the background, returning a client
+// once it listens.
+func startCLI(cli *CLI) *redis.Client {
+	go func() {
+		defer GinkgoRecover()
+
+		err := cli.Run()
+		Expect(err).NotTo(HaveOccurred())
+	}()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", cli.Port)
+
+	ok := wait.New().Do([]string{addr})
+	Expect(ok).To(BeTrue())
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	DeferCleanup(client.Close)
+
+	return client
+}
+
+func send(conn net.Conn, args ...string) {
+	command := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		command += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	_, err := conn.Write([]byte(command))
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func readLine(reader *bufio.Reader) string {
+	line, _, err := reader.ReadLine()
+	Expect(err).NotTo(HaveOccurred())
+
+	return string(line)
+}
+
+func set(client redis.Cmdable, key, value string) {
 	err := client.Set(context.Background(), key, value, time.Hour).Err()
 	Expect(err).NotTo(HaveOccurred())
 }
 
-func get(client *redis.Client, key, expected string) {
+func get(client redis.Cmdable, key, expected string) {
 	actual, err := client.Get(context.Background(), key).Result()
 	if expected == "" {
 		Expect(err).To(HaveOccurred())
@@ -0,0 +1,31 @@
+package tcp
+
+import (
+	"net"
+
+	"go.uber.org/atomic"
+)
+
+// countingConn records the bytes read and written on a connection.
+type countingConn struct {
+	net.Conn
+
+	read    *atomic.Uint64
+	written *atomic.Uint64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(uint64(n))
+
+	//nolint:wrapcheck
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(uint64(n))
+
+	//nolint:wrapcheck
+	return n, err
+}
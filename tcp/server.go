@@ -14,6 +14,11 @@ type Server struct {
 	listener net.Listener
 	poolSize uint
 	port     uint
+
+	activeConnections atomic.Int64
+	totalConnections  atomic.Uint64
+	bytesRead         atomic.Uint64
+	bytesWritten      atomic.Uint64
 }
 
 func NewServer(
@@ -28,6 +33,10 @@ func NewServer(
 		return nil, fmt.Errorf("could not listen for tcp: %w", err)
 	}
 
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+		port = uint(addr.Port)
+	}
+
 	return &Server{
 		port:     port,
 		poolSize: poolSize,
@@ -36,13 +45,14 @@ func NewServer(
 }
 
 func (s *Server) Listen(ctx context.Context, handler Handler) error {
-	var totalConnections atomic.Uint64
-
 	workerPool := worker.New(
 		int(s.poolSize),
 		int(s.poolSize),
 		func(worker int, conn net.Conn) {
-			currentConnection := totalConnections.Add(1)
+			currentConnection := s.totalConnections.Add(1)
+
+			s.activeConnections.Add(1)
+			defer s.activeConnections.Add(-1)
 
 			slog.Info("accepted new connection",
 				slog.Int("worker", worker),
@@ -90,10 +100,39 @@ func (s *Server) Listen(ctx context.Context, handler Handler) error {
 			return fmt.Errorf("could not accept connection for tcp: %w", err)
 		}
 
-		workerPool.Enqueue(conn)
+		workerPool.Enqueue(&countingConn{
+			Conn:    conn,
+			read:    &s.bytesRead,
+			written: &s.bytesWritten,
+		})
 	}
 }
 
+// Port is the port the server is listening on.
+func (s *Server) Port() uint {
+	return s.port
+}
+
+// ActiveConnections is the number of connections being handled.
+func (s *Server) ActiveConnections() int64 {
+	return s.activeConnections.Load()
+}
+
+// TotalConnections is the number of connections accepted since starting.
+func (s *Server) TotalConnections() uint64 {
+	return s.totalConnections.Load()
+}
+
+// BytesRead is the number of bytes read from all connections.
+func (s *Server) BytesRead() uint64 {
+	return s.bytesRead.Load()
+}
+
+// BytesWritten is the number of bytes written to all connections.
+func (s *Server) BytesWritten() uint64 {
+	return s.bytesWritten.Load()
+}
+
 func (s *Server) Close() error {
 	err := s.listener.Close()
 	if err != nil {
@@ -43,6 +43,21 @@ var _ = Describe("When starting a TCP Server", func() {
 		Expect(response).To(Equal("echo"))
 	})
 
+	It("tracks the connections", func() {
+		port, server := startServer(&handlers.Echo{})
+		defer server.Close()
+
+		Expect(server.Port()).To(BeEquivalentTo(port))
+
+		_, err := tcp.Write(port, "echo\r\n")
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(server.TotalConnections).Should(BeEquivalentTo(1))
+		Eventually(server.ActiveConnections).Should(BeEquivalentTo(0))
+		Expect(server.BytesRead()).To(BeEquivalentTo(6))
+		Expect(server.BytesWritten()).To(BeEquivalentTo(6))
+	})
+
 	When("the handler errors on the client", func() {
 		It("server continues accepting connections", func() {
 			port, server := startServer(&handlers.Error{})
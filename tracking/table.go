@@ -0,0 +1,275 @@
+package tracking
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrPrefixRequiresBCast = errors.New("PREFIX option requires BCAST mode to be enabled")
+	ErrOptInAndOptOut      = errors.New("you can't use both OPTIN and OPTOUT")
+	ErrOptWithBCast        = errors.New("OPTIN and OPTOUT are not compatible with BCAST")
+	ErrPrefixOverlap       = errors.New("prefixes overlap")
+	ErrCachingNotAllowed   = errors.New(
+		"CLIENT CACHING can be called only when the client is in tracking mode with OPTIN or OPTOUT mode enabled",
+	)
+	ErrCachingOptIn  = errors.New("CLIENT CACHING NO is only valid when tracking is enabled in OPTOUT mode")
+	ErrCachingOptOut = errors.New("CLIENT CACHING YES is only valid when tracking is enabled in OPTIN mode")
+)
+
+// Options are the modes of CLIENT TRACKING.
+type Options struct {
+	Redirect uint64
+	BCast    bool
+	Prefixes []string
+	OptIn    bool
+	OptOut   bool
+	NoLoop   bool
+}
+
+func (o Options) validate() error {
+	if len(o.Prefixes) > 0 && !o.BCast {
+		return ErrPrefixRequiresBCast
+	}
+
+	if o.OptIn && o.OptOut {
+		return ErrOptInAndOptOut
+	}
+
+	if o.BCast && (o.OptIn || o.OptOut) {
+		return ErrOptWithBCast
+	}
+
+	for i, prefix := range o.Prefixes {
+		for j, other := range o.Prefixes {
+			if i != j && strings.HasPrefix(prefix, other) {
+				return ErrPrefixOverlap
+			}
+		}
+	}
+
+	return nil
+}
+
+// Notifier delivers invalidated keys to a tracking client.
+// A nil slice of keys invalidates every key the client has cached.
+type Notifier func(client uint64, options Options, keys []string)
+
+type state struct {
+	options Options
+	caching *bool
+}
+
+// Table tracks the keys clients have read so that they can be invalidated
+// when the keys are written. Clients in BCAST mode are notified for every
+// key matching their prefixes instead.
+type Table struct {
+	mutex   sync.Mutex
+	notify  Notifier
+	clients map[uint64]*state
+	keys    map[string]map[uint64]struct{}
+}
+
+func NewTable(notify Notifier) *Table {
+	return &Table{
+		notify:  notify,
+		clients: map[uint64]*state{},
+		keys:    map[string]map[uint64]struct{}{},
+	}
+}
+
+func (t *Table) Enable(client uint64, options Options) error {
+	err := options.validate()
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.clients[client] = &state{options: options}
+
+	return nil
+}
+
+// Disable stops tracking for the client and forgets the keys it has read.
+func (t *Table) Disable(client uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.clients, client)
+
+	for key, clients := range t.keys {
+		delete(clients, client)
+
+		if len(clients) == 0 {
+			delete(t.keys, key)
+		}
+	}
+}
+
+// Keys is the number of keys read by tracking clients.
+func (t *Table) Keys() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return len(t.keys)
+}
+
+// Len is the number of clients with tracking enabled.
+func (t *Table) Len() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return len(t.clients)
+}
+
+func (t *Table) Options(client uint64) (Options, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state, ok := t.clients[client]
+	if !ok {
+		return Options{}, false
+	}
+
+	return state.options, true
+}
+
+// SetCaching applies CLIENT CACHING to the next command of the client.
+func (t *Table) SetCaching(client uint64, enabled bool) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state, ok := t.clients[client]
+	if !ok || !(state.options.OptIn || state.options.OptOut) {
+		return ErrCachingNotAllowed
+	}
+
+	if enabled && !state.options.OptIn {
+		return ErrCachingOptOut
+	}
+
+	if !enabled && !state.options.OptOut {
+		return ErrCachingOptIn
+	}
+
+	state.caching = &enabled
+
+	return nil
+}
+
+// Track remembers the keys read by the client, honoring OPTIN and OPTOUT.
+func (t *Table) Track(client uint64, keys ...string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state, ok := t.clients[client]
+	if !ok || state.options.BCast {
+		return
+	}
+
+	if state.options.OptIn && (state.caching == nil || !*state.caching) {
+		return
+	}
+
+	if state.options.OptOut && state.caching != nil && !*state.caching {
+		return
+	}
+
+	for _, key := range keys {
+		clients, ok := t.keys[key]
+		if !ok {
+			clients = map[uint64]struct{}{}
+			t.keys[key] = clients
+		}
+
+		clients[client] = struct{}{}
+	}
+}
+
+// EndCommand resets the CLIENT CACHING flag after the command it applied to.
+func (t *Table) EndCommand(client uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if state, ok := t.clients[client]; ok {
+		state.caching = nil
+	}
+}
+
+// Invalidate notifies clients that have read any of the keys or that
+// broadcast a matching prefix. The origin is the client that wrote the keys.
+func (t *Table) Invalidate(origin uint64, keys ...string) {
+	invalidations := map[uint64][]string{}
+
+	t.mutex.Lock()
+
+	for _, key := range keys {
+		for client := range t.keys[key] {
+			state, ok := t.clients[client]
+			if ok && !(state.options.NoLoop && client == origin) {
+				invalidations[client] = append(invalidations[client], key)
+			}
+		}
+
+		delete(t.keys, key)
+	}
+
+	for client, state := range t.clients {
+		if !state.options.BCast || (state.options.NoLoop && client == origin) {
+			continue
+		}
+
+		for _, key := range keys {
+			if matchesPrefix(key, state.options.Prefixes) {
+				invalidations[client] = append(invalidations[client], key)
+			}
+		}
+	}
+
+	notifications := make(map[uint64]Options, len(invalidations))
+	for client := range invalidations {
+		notifications[client] = t.clients[client].options
+	}
+
+	t.mutex.Unlock()
+
+	for client, keys := range invalidations {
+		t.notify(client, notifications[client], keys)
+	}
+}
+
+// InvalidateAll notifies every tracking client that all keys were removed,
+// as happens with FLUSHALL.
+func (t *Table) InvalidateAll() {
+	t.mutex.Lock()
+
+	t.keys = map[string]map[uint64]struct{}{}
+
+	notifications := make(map[uint64]Options, len(t.clients))
+	for client, state := range t.clients {
+		notifications[client] = state.options
+	}
+
+	t.mutex.Unlock()
+
+	for client, options := range notifications {
+		t.notify(client, options, nil)
+	}
+}
+
+func matchesPrefix(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package tracking_test
+
+import (
+	"github.com/jtarchie/sqlettuce/tracking"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Table", func() {
+	var (
+		table         *tracking.Table
+		invalidations map[uint64][][]string
+	)
+
+	BeforeEach(func() {
+		invalidations = map[uint64][][]string{}
+		table = tracking.NewTable(func(client uint64, _ tracking.Options, keys []string) {
+			invalidations[client] = append(invalidations[client], keys)
+		})
+	})
+
+	It("invalidates keys that were read once", func() {
+		Expect(table.Enable(1, tracking.Options{})).To(Succeed())
+
+		table.Track(1, "key1", "key2")
+		table.Track(2, "key1")
+
+		table.Invalidate(3, "key1", "key3")
+		Expect(invalidations).To(Equal(map[uint64][][]string{
+			1: {{"key1"}},
+		}))
+
+		table.Invalidate(3, "key1")
+		Expect(invalidations[1]).To(HaveLen(1))
+	})
+
+	It("skips the writer with NOLOOP", func() {
+		Expect(table.Enable(1, tracking.Options{NoLoop: true})).To(Succeed())
+
+		table.Track(1, "key")
+		table.Invalidate(1, "key")
+		Expect(invalidations).To(BeEmpty())
+	})
+
+	It("broadcasts keys matching prefixes", func() {
+		Expect(table.Enable(1, tracking.Options{BCast: true, Prefixes: []string{"user:", "order:"}})).To(Succeed())
+		Expect(table.Enable(2, tracking.Options{BCast: true})).To(Succeed())
+
+		table.Invalidate(3, "user:1", "session:1")
+		Expect(invalidations).To(Equal(map[uint64][][]string{
+			1: {{"user:1"}},
+			2: {{"user:1", "session:1"}},
+		}))
+	})
+
+	It("only tracks the next command with OPTIN", func() {
+		Expect(table.Enable(1, tracking.Options{OptIn: true})).To(Succeed())
+
+		table.Track(1, "ignored")
+		Expect(table.SetCaching(1, true)).To(Succeed())
+		table.Track(1, "cached")
+		table.EndCommand(1)
+		table.Track(1, "ignored")
+
+		table.Invalidate(3, "ignored", "cached")
+		Expect(invalidations).To(Equal(map[uint64][][]string{
+			1: {{"cached"}},
+		}))
+
+		Expect(table.SetCaching(1, false)).To(MatchError(tracking.ErrCachingOptIn))
+	})
+
+	It("skips the next command with OPTOUT", func() {
+		Expect(table.Enable(1, tracking.Options{OptOut: true})).To(Succeed())
+
+		Expect(table.SetCaching(1, false)).To(Succeed())
+		table.Track(1, "ignored")
+		table.EndCommand(1)
+		table.Track(1, "cached")
+
+		table.Invalidate(3, "ignored", "cached")
+		Expect(invalidations).To(Equal(map[uint64][][]string{
+			1: {{"cached"}},
+		}))
+	})
+
+	It("invalidates everything on a flush", func() {
+		Expect(table.Enable(1, tracking.Options{})).To(Succeed())
+		Expect(table.Enable(2, tracking.Options{BCast: true})).To(Succeed())
+
+		table.Track(1, "key")
+		table.InvalidateAll()
+		Expect(invalidations).To(Equal(map[uint64][][]string{
+			1: {nil},
+			2: {nil},
+		}))
+
+		table.Invalidate(3, "key")
+		Expect(invalidations[1]).To(HaveLen(1))
+	})
+
+	It("stops notifying disabled clients", func() {
+		Expect(table.Enable(1, tracking.Options{})).To(Succeed())
+		Expect(table.Len()).To(Equal(1))
+
+		table.Track(1, "key")
+		Expect(table.Keys()).To(Equal(1))
+
+		table.Disable(1)
+		Expect(table.Len()).To(Equal(0))
+		Expect(table.Keys()).To(Equal(0))
+		table.Invalidate(3, "key")
+		Expect(invalidations).To(BeEmpty())
+
+		_, enabled := table.Options(1)
+		Expect(enabled).To(BeFalse())
+	})
+
+	It("validates the options", func() {
+		Expect(table.Enable(1, tracking.Options{Prefixes: []string{"a"}})).To(MatchError(tracking.ErrPrefixRequiresBCast))
+		Expect(table.Enable(1, tracking.Options{OptIn: true, OptOut: true})).To(MatchError(tracking.ErrOptInAndOptOut))
+		Expect(table.Enable(1, tracking.Options{BCast: true, OptIn: true})).To(MatchError(tracking.ErrOptWithBCast))
+		Expect(table.Enable(1, tracking.Options{BCast: true, Prefixes: []string{"a", "ab"}})).To(MatchError(tracking.ErrPrefixOverlap))
+		Expect(table.SetCaching(1, true)).To(MatchError(tracking.ErrCachingNotAllowed))
+	})
+})
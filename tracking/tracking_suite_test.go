@@ -0,0 +1,13 @@
+package tracking_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTracking(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracking Suite")
+}